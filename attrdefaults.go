@@ -0,0 +1,197 @@
+package fastxml
+
+import (
+	"bytes"
+	"strings"
+)
+
+// attributeDefault is a default value of the attribute declared in ATTLIST.
+type attributeDefault struct {
+	name  string
+	value string
+}
+
+// WithAttributeDefaults makes parser add attributes that have default or #FIXED values declared
+// in ATTLIST declarations of the internal subset of DOCTYPE to start elements that do not specify them,
+// as validating parsers do.
+//
+// Default attributes are read by StartToken.NextAttribute after the specified ones, in order of declaration.
+// Their values are raw, same as values of specified attributes, so they may contain references.
+// Only the first declaration of the attribute is used, and external subset is not read.
+// Nothing is added if DOCTYPE is skipped, see WithSkipDTD.
+func WithAttributeDefaults() ParserOption {
+	return func(p *Parser) {
+		p.attrDefaults = map[string][]attributeDefault{}
+	}
+}
+
+// readAttributeDefaults records default values from ATTLIST declarations of the DOCTYPE in buf.
+//
+// Internal subset is walked the same way as it is scanned,
+// so declarations inside of literals, comments and processing instructions are not read.
+func (p *Parser) readAttributeDefaults(buf []byte) {
+	var inSubset bool
+
+	for i := len(docTypePrefix); i < len(buf); i++ {
+		switch buf[i] {
+		case '"', '\'':
+			i += 1 + bytes.IndexByte(buf[i+1:], buf[i])
+		case '[':
+			inSubset = true
+		case ']':
+			inSubset = false
+		case '<':
+			if !inSubset {
+				continue
+			}
+
+			if bytes.HasPrefix(buf[i:], attListPrefix) {
+				p.addAttributeDefaults(buf[i+len(attListPrefix):])
+
+				continue
+			}
+
+			// Declaration is already scanned, so it can not be incomplete.
+			end, _ := scanSubsetMarkup(buf[i:])
+			i += end - 1
+		}
+	}
+}
+
+// addAttributeDefaults records default values from the body of ATTLIST declaration.
+func (p *Parser) addAttributeDefaults(decl []byte) {
+	element, decl := nextDeclarationPart(decl)
+
+	for {
+		var attr, attrType, value []byte
+
+		attr, decl = nextDeclarationPart(decl)
+		attrType, decl = nextDeclarationPart(decl)
+
+		if len(attr) == 0 || len(attrType) == 0 {
+			return
+		}
+
+		if string(attrType) == "NOTATION" {
+			_, decl = nextDeclarationPart(decl)
+		}
+
+		value, decl = nextDeclarationPart(decl)
+		if string(value) == "#FIXED" {
+			value, decl = nextDeclarationPart(decl)
+		}
+
+		// #REQUIRED and #IMPLIED attributes have no default value.
+		if len(value) < 2 || value[0] != '"' && value[0] != '\'' {
+			continue
+		}
+
+		p.addAttributeDefault(string(element), string(attr), string(value[1:len(value)-1]))
+	}
+}
+
+// addAttributeDefault records default value of the attribute, unless it was already declared.
+func (p *Parser) addAttributeDefault(element, attr, value string) {
+	for _, def := range p.attrDefaults[element] {
+		if def.name == attr {
+			return
+		}
+	}
+
+	p.attrDefaults[element] = append(p.attrDefaults[element], attributeDefault{name: attr, value: value})
+}
+
+// nextDeclarationPart returns the next name, literal or parenthesized group of the declaration, and the rest of it.
+// Empty part is returned at the end of the declaration.
+func nextDeclarationPart(decl []byte) (part, rest []byte) {
+	decl = decl[NextNonSpaceIndex(decl):]
+
+	if len(decl) == 0 || decl[0] == '>' {
+		return nil, decl
+	}
+
+	var end int
+
+	switch decl[0] {
+	case '"', '\'':
+		end = 2 + bytes.IndexByte(decl[1:], decl[0])
+	case '(':
+		end = 1 + bytes.IndexByte(decl, ')')
+	default:
+		end = bytes.IndexFunc(decl, func(r rune) bool { return IsHTMLSpaceChar(r) || r == '>' || r == '(' })
+		if end == -1 {
+			end = len(decl)
+		}
+	}
+
+	// Unterminated literal or group takes the rest of the declaration.
+	if end <= 0 {
+		end = len(decl)
+	}
+
+	return decl[:end], decl[end:]
+}
+
+// applyAttributeDefaults adds declared default attributes, which are not specified, to the start element.
+//
+// Attributes are written into the parser's buffer, which is reused for the next start element.
+func (p *Parser) applyAttributeDefaults(start *StartToken) {
+	defaults := p.attrDefaults[start.Name]
+	if len(defaults) == 0 {
+		return
+	}
+
+	specified := bytes.TrimSuffix(start.attrBuf, []byte(">"))
+	specified = bytes.TrimSuffix(specified, []byte("/"))
+	specified = bytes.TrimRight(specified, " \t\r\n")
+
+	oldCap := cap(p.attrDefaultsBuf)
+	buf := append(p.attrDefaultsBuf[:0], specified...)
+
+	for _, def := range defaults {
+		if hasAttribute(start, def.name) {
+			continue
+		}
+
+		quote := byte('"')
+		if strings.IndexByte(def.value, quote) != -1 {
+			quote = '\''
+		}
+
+		if len(buf) != 0 {
+			buf = append(buf, ' ')
+		}
+
+		buf = append(buf, def.name...)
+		buf = append(buf, '=', quote)
+		buf = append(buf, def.value...)
+		buf = append(buf, quote)
+	}
+
+	added := len(buf) != len(specified)
+	buf = append(buf, '>')
+
+	p.attrDefaultsBuf = buf
+	p.retain(cap(buf) - oldCap)
+
+	if added {
+		start.attrBuf = buf
+	}
+}
+
+// hasAttribute reports if the start element specifies the attribute.
+func hasAttribute(start *StartToken, name string) bool {
+	// Attributes are read from the copy to not consume them from the caller's token.
+	attrs := *start
+
+	for {
+		attrName, _, err := attrs.NextAttribute()
+		if err != nil {
+			return false
+		}
+
+		if attrName == name {
+			return true
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package fastxml
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startAttributes returns attributes of start elements of the document, like "a: x=1 y=2".
+func startAttributes(t *testing.T, input string, opts ...ParserOption) []string {
+	t.Helper()
+
+	return parsedAttributes(t, NewParser([]byte(input), false, opts...))
+}
+
+// parsedAttributes returns attributes of start elements that are left in the parser.
+func parsedAttributes(t *testing.T, p *Parser) []string {
+	t.Helper()
+
+	var result []string
+
+	for {
+		token, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			return result
+		}
+
+		require.NoError(t, err)
+
+		start, ok := token.(*StartToken)
+		if !ok {
+			continue
+		}
+
+		attrs := start.Name + ":"
+
+		for {
+			name, val, err := start.NextAttribute()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			require.NoError(t, err)
+
+			attrs += " " + name + "=" + val
+		}
+
+		result = append(result, attrs)
+	}
+}
+
+func TestWithAttributeDefaults(t *testing.T) {
+	doctype := `<!DOCTYPE doc [
+		<!ELEMENT doc (item*)>
+		<!ATTLIST doc version CDATA #FIXED "1.0" xmlns CDATA "urn:doc">
+		<!ATTLIST item
+			kind (a|b) "a"
+			note NOTATION (n) 'n'
+			id ID #REQUIRED
+			title CDATA #IMPLIED
+			quote CDATA 'say "hi"'>
+		<!ATTLIST item kind CDATA "ignored" extra CDATA "&amp;">
+		<!-- <!ATTLIST item commented CDATA "x"> -->
+		<!ENTITY e "<!ATTLIST item literal CDATA 'x'>">
+	]>`
+
+	tests := []struct {
+		name   string
+		input  string
+		opts   []ParserOption
+		result []string
+	}{
+		{
+			name:  "defaults",
+			input: doctype + `<doc><item id="1"/><item kind="b" id="2" ></item><other/></doc>`,
+			opts:  []ParserOption{WithAttributeDefaults()},
+			result: []string{
+				"doc: version=1.0 xmlns=urn:doc",
+				`item: id=1 kind=a note=n quote=say "hi" extra=&amp;`,
+				`item: kind=b id=2 note=n quote=say "hi" extra=&amp;`,
+				"other:",
+			},
+		},
+		{
+			name:   "not enabled",
+			input:  doctype + `<doc><item id="1"/></doc>`,
+			result: []string{"doc:", "item: id=1"},
+		},
+		{
+			name:   "skipped doctype",
+			input:  doctype + `<doc><item id="1"/></doc>`,
+			opts:   []ParserOption{WithAttributeDefaults(), WithSkipDTD()},
+			result: []string{"doc:", "item: id=1"},
+		},
+		{
+			name:   "no doctype",
+			input:  `<doc version="2"/>`,
+			opts:   []ParserOption{WithAttributeDefaults()},
+			result: []string{"doc: version=2"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.result, startAttributes(t, test.input, test.opts...))
+		})
+	}
+}
+
+func TestWithAttributeDefaults_Namespace(t *testing.T) {
+	p := NewParser([]byte(`<!DOCTYPE a [<!ATTLIST a xmlns CDATA "urn:a">]><a/>`), false,
+		WithAttributeDefaults(), WithDefaultNamespace())
+
+	_, err := p.Next()
+	require.NoError(t, err)
+
+	token, err := p.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "urn:a", token.(*StartToken).Space)
+
+	token, err = p.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "urn:a", token.(*EndElement).Name.Space)
+}
+
+func TestWithAttributeDefaults_Clone(t *testing.T) {
+	p := NewStreamParser(WithAttributeDefaults())
+	p.Feed([]byte(`<?xml version="1.0"?>`))
+
+	_, err := p.Next()
+	require.NoError(t, err)
+
+	// Parsers are cloned before DOCTYPE, so each of them must keep its own defaults.
+	clone := p.Clone()
+
+	p.Feed([]byte(`<!DOCTYPE a [<!ATTLIST a x CDATA "1">]><a/>`))
+	clone.Feed([]byte(`<!DOCTYPE a [<!ATTLIST a y CDATA "2">]><a/>`))
+
+	p.streaming = false
+	clone.streaming = false
+
+	assert.Equal(t, []string{"a: x=1"}, parsedAttributes(t, p))
+	assert.Equal(t, []string{"a: y=2"}, parsedAttributes(t, clone))
+}
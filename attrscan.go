@@ -0,0 +1,162 @@
+package fastxml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ScanAttributes calls fn with value of attribute attr of each element elem in buf, in document order.
+//
+// Tokens are not built: start tags of the element are found with byte search,
+// skipping comments, CDATA sections, processing instructions and DOCTYPE, and only their attributes are read.
+// This allows to build indexes, like for `id` attributes, much faster than with Parser.Next,
+// but the rest of the document is not verified to be well-formed.
+//
+// Names are compared as written in the document, including namespace prefixes.
+// val is the raw value, as it is in the document, without unescaping (see Unescape),
+// and it is only valid until fn returns, as it points into buf.
+// Scanning stops on the first error returned by fn, which is returned as is.
+func ScanAttributes(buf []byte, elem, attr string, fn func(val []byte) error) error {
+	needle := append([]byte{'<'}, elem...)
+
+	// special is the index of the next construct that may contain text looking like a tag.
+	special := -1
+
+	for offset := 0; offset < len(buf); {
+		idx := bytes.Index(buf[offset:], needle)
+		if idx == -1 {
+			return nil
+		}
+
+		idx += offset
+
+		if special < offset {
+			special = nextSpecialIndex(buf, offset)
+		}
+
+		if special != -1 && special < idx {
+			end, err := scanSkipped(buf[special:])
+			if err != nil {
+				return fmt.Errorf("scan attributes: index position %d: %w", special, err)
+			}
+
+			offset = special + end
+
+			continue
+		}
+
+		offset = idx + len(needle)
+
+		// Needle may be a prefix of another element name.
+		if offset < len(buf) && !IsHTMLSpaceChar(rune(buf[offset])) && buf[offset] != '/' && buf[offset] != '>' {
+			continue
+		}
+
+		end, val, err := scanTagAttribute(buf[offset:], attr)
+		if err != nil {
+			return fmt.Errorf("scan attributes: index position %d: %w", idx, err)
+		}
+
+		offset += end
+
+		if val != nil {
+			if err := fn(val); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// nextSpecialIndex returns index of the next "<!" or "<?" starting from offset, or -1.
+func nextSpecialIndex(buf []byte, offset int) int {
+	for {
+		idx := bytes.IndexByte(buf[offset:], '<')
+		if idx == -1 {
+			return -1
+		}
+
+		idx += offset
+		if idx+1 < len(buf) && (buf[idx+1] == '!' || buf[idx+1] == '?') {
+			return idx
+		}
+
+		offset = idx + 1
+	}
+}
+
+// scanSkipped returns length of the comment, CDATA section, DOCTYPE or processing instruction.
+func scanSkipped(buf []byte) (int, error) {
+	if buf[1] == '?' {
+		idx := bytes.Index(buf, procInstSuffix)
+		if idx == -1 {
+			return 0, io.ErrUnexpectedEOF
+		}
+
+		return idx + len(procInstSuffix), nil
+	}
+
+	end, err := scanSpecial(buf)
+	if errors.Is(err, ErrNeedMoreData) {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	return end, err
+}
+
+// scanTagAttribute reads attributes of the start tag after its name.
+// It returns index after the end of the tag and value of attr, which is nil if tag does not have it.
+func scanTagAttribute(buf []byte, attr string) (end int, val []byte, err error) {
+	for i := 0; ; {
+		i += NextNonSpaceIndex(buf[i:])
+		if i >= len(buf) {
+			return 0, nil, io.ErrUnexpectedEOF
+		}
+
+		switch {
+		case buf[i] == '>':
+			return i + 1, val, nil
+		case buf[i] == '/':
+			if i+1 < len(buf) && buf[i+1] == '>' {
+				return i + 2, val, nil
+			}
+
+			return 0, nil, ErrNotAValidTag
+		}
+
+		nameEnd := bytes.IndexAny(buf[i:], "= \t\r\n")
+		if nameEnd <= 0 {
+			return 0, nil, ErrNotAValidTag
+		}
+
+		name := buf[i : i+nameEnd]
+
+		i += nameEnd
+		i += NextNonSpaceIndex(buf[i:])
+
+		if i >= len(buf) || buf[i] != '=' {
+			return 0, nil, ErrNotAValidTag
+		}
+
+		i++
+		i += NextNonSpaceIndex(buf[i:])
+
+		if i >= len(buf) || buf[i] != '"' && buf[i] != '\'' {
+			return 0, nil, ErrNotAValidTag
+		}
+
+		valEnd := bytes.IndexByte(buf[i+1:], buf[i])
+		if valEnd == -1 {
+			return 0, nil, io.ErrUnexpectedEOF
+		}
+
+		if val == nil && string(name) == attr {
+			val = buf[i+1 : i+1+valEnd]
+		}
+
+		i += valEnd + 2
+	}
+}
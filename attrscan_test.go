@@ -0,0 +1,94 @@
+package fastxml
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanAttributes(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		elem   string
+		attr   string
+		result []string
+	}{
+		{
+			name:   "simple",
+			input:  `<root><item id="1"/><item  x='2' id = '2' >t</item><item/><item id=""></item></root>`,
+			elem:   "item",
+			attr:   "id",
+			result: []string{"1", "2", ""},
+		},
+		{
+			name:   "name prefix",
+			input:  `<root><items id="0"><item id="1"/></items><x:item id="2"/></root>`,
+			elem:   "item",
+			attr:   "id",
+			result: []string{"1"},
+		},
+		{
+			name: "skipped constructs",
+			input: `<!DOCTYPE r [<!ELEMENT item ANY>]><?pi <item id="0"?><r><!-- <item id="1"/> -->` +
+				`<![CDATA[<item id="2"/>]]><item id="3" x="&lt;a&gt;"/></r>`,
+			elem:   "item",
+			attr:   "id",
+			result: []string{"3"},
+		},
+		{
+			name:   "raw value",
+			input:  `<a v="&lt;&amp;"/><a v="x>y"/>`,
+			elem:   "a",
+			attr:   "v",
+			result: []string{"&lt;&amp;", "x>y"},
+		},
+		{
+			name:   "prefixed names",
+			input:  `<p:a p:id="1" id="2"/>`,
+			elem:   "p:a",
+			attr:   "p:id",
+			result: []string{"1"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			var result []string
+
+			err := ScanAttributes([]byte(test.input), test.elem, test.attr, func(val []byte) error {
+				result = append(result, string(val))
+
+				return nil
+			})
+			require.NoError(t, err)
+
+			assert.Equal(t, test.result, result)
+		})
+	}
+}
+
+func TestScanAttributes_Error(t *testing.T) {
+	noop := func([]byte) error { return nil }
+
+	assert.ErrorIs(t, ScanAttributes([]byte(`<a id="1`), "a", "id", noop), io.ErrUnexpectedEOF)
+	assert.ErrorIs(t, ScanAttributes([]byte(`<r><!-- <a/>`), "a", "id", noop), io.ErrUnexpectedEOF)
+	assert.ErrorIs(t, ScanAttributes([]byte(`<a id=1/>`), "a", "id", noop), ErrNotAValidTag)
+
+	errStop := errors.New("stop")
+
+	var calls int
+
+	err := ScanAttributes([]byte(`<a id="1"/><a id="2"/>`), "a", "id", func([]byte) error {
+		calls++
+
+		return errStop
+	})
+	assert.Equal(t, errStop, err)
+	assert.Equal(t, 1, calls)
+}
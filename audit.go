@@ -0,0 +1,140 @@
+package fastxml
+
+import "bytes"
+
+// AuditKind is a kind of construct that the parser ignored or changed.
+type AuditKind uint8
+
+const (
+	// AuditProcInstIgnored is reported for processing instructions, which are not returned by the parser.
+	// Detail is the target of the processing instruction.
+	AuditProcInstIgnored AuditKind = iota + 1
+	// AuditDeclarationIgnored is reported for DOCTYPE, ELEMENT and ATTLIST declarations,
+	// which are not returned by the parser. Detail is the keyword of the declaration, like "DOCTYPE".
+	AuditDeclarationIgnored
+	// AuditEntityUnexpanded is reported for references to entities other than predefined ones
+	// in char data and attributes. Such references are left as is by the parser and by Unescape.
+	// Detail is the name of the entity.
+	AuditEntityUnexpanded
+	// AuditLineEndingsNormalized is reported when line endings of the token were replaced, see WithLineEndings.
+	// Detail is the kind of the token, like "char_data".
+	AuditLineEndingsNormalized
+)
+
+func (k AuditKind) String() string {
+	switch k {
+	case AuditProcInstIgnored:
+		return "proc_inst_ignored"
+	case AuditDeclarationIgnored:
+		return "declaration_ignored"
+	case AuditEntityUnexpanded:
+		return "entity_unexpanded"
+	case AuditLineEndingsNormalized:
+		return "line_endings_normalized"
+	default:
+		return "unknown"
+	}
+}
+
+// AuditEvent describes a single construct that the parser ignored or changed.
+type AuditEvent struct {
+	Kind AuditKind
+	// Offset is the offset of the token, counted from the start of the input.
+	Offset int
+	Detail string
+}
+
+// AuditHook is notified about constructs that the parser ignored or changed,
+// so that loss of data fidelity can be audited.
+//
+// Audit is called synchronously from Parser.Next, so it should be cheap.
+// Tokens skipped on request of the caller, like with WithSkipDTD, are not reported.
+// Tokens that were peeked with Parser.Peek are reported again when they are returned by Parser.Next.
+type AuditHook interface {
+	Audit(event AuditEvent)
+}
+
+// AuditHookFunc is an adapter to use function as AuditHook.
+type AuditHookFunc func(event AuditEvent)
+
+// Audit calls f(event).
+func (f AuditHookFunc) Audit(event AuditEvent) {
+	f(event)
+}
+
+// WithAuditHook sets hook that will be notified about constructs that the parser ignored or changed.
+func WithAuditHook(h AuditHook) ParserOption {
+	return func(p *Parser) {
+		p.auditHook = h
+	}
+}
+
+// auditToken reports just scanned token, if it is ignored or contains unexpanded entities.
+func (p *Parser) auditToken(buf []byte) {
+	if p.auditHook == nil {
+		return
+	}
+
+	offset := p.tokenOffset(buf)
+	p.auditOffset = offset
+
+	switch {
+	case buf[0] != '<':
+		p.auditEntities(buf, offset)
+	case bytes.HasPrefix(buf, procInstPrefix):
+		body := bytes.TrimSuffix(buf[len(procInstPrefix):], procInstSuffix)
+		target, _, _ := NextWord(body)
+
+		p.auditHook.Audit(AuditEvent{Kind: AuditProcInstIgnored, Offset: offset, Detail: target})
+	case bytes.HasPrefix(buf, docTypePrefix),
+		bytes.HasPrefix(buf, elementPrefix),
+		bytes.HasPrefix(buf, attListPrefix):
+		// All declaration prefixes have the same length.
+		keyword := string(buf[len("<!"):len(docTypePrefix)])
+
+		p.auditHook.Audit(AuditEvent{Kind: AuditDeclarationIgnored, Offset: offset, Detail: keyword})
+	case buf[1] != '!' && buf[1] != '/':
+		p.auditEntities(buf, offset)
+	}
+}
+
+// auditEntities reports references to entities that are not predefined in data,
+// which starts at offset of the input.
+func (p *Parser) auditEntities(data []byte, offset int) {
+	for idx := 0; idx < len(data); idx++ {
+		if data[idx] != '&' {
+			continue
+		}
+
+		nameEnd := idx + 1
+		for nameEnd < len(data) && !isReferenceNameEnd(data[nameEnd]) {
+			nameEnd++
+		}
+
+		if nameEnd == len(data) || data[nameEnd] != ';' {
+			// Not a reference.
+			continue
+		}
+
+		name := data[idx+1 : nameEnd]
+		if _, ok := predefinedEntities[string(name)]; !ok && len(name) != 0 && name[0] != '#' {
+			p.auditHook.Audit(AuditEvent{Kind: AuditEntityUnexpanded, Offset: offset + idx, Detail: string(name)})
+		}
+
+		idx = nameEnd
+	}
+}
+
+// isReferenceNameEnd reports if b can not be a part of the name of the entity reference.
+func isReferenceNameEnd(b byte) bool {
+	return b == ';' || b == '&' || IsHTMLSpaceChar(rune(b))
+}
+
+// auditLineEndings reports token which line endings were normalized.
+func (p *Parser) auditLineEndings(kind TokenKind) {
+	if p.auditHook == nil {
+		return
+	}
+
+	p.auditHook.Audit(AuditEvent{Kind: AuditLineEndingsNormalized, Offset: p.auditOffset, Detail: kind.String()})
+}
@@ -0,0 +1,93 @@
+package fastxml
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithAuditHook(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     []ParserOption
+		expected []AuditEvent
+	}{
+		{
+			name:  "processing instructions",
+			input: `<?xml version="1.0"?><a><?php echo 1 ?><?empty?></a>`,
+			expected: []AuditEvent{
+				{Kind: AuditProcInstIgnored, Offset: 0, Detail: "xml"},
+				{Kind: AuditProcInstIgnored, Offset: 24, Detail: "php"},
+				{Kind: AuditProcInstIgnored, Offset: 39, Detail: "empty"},
+			},
+		},
+		{
+			name:  "declarations",
+			input: `<!DOCTYPE a [<!ENTITY e "x">]><a/>`,
+			expected: []AuditEvent{
+				{Kind: AuditDeclarationIgnored, Offset: 0, Detail: "DOCTYPE"},
+			},
+		},
+		{
+			name:  "skipped declarations are not reported",
+			input: `<!DOCTYPE a><?pi?><a/>`,
+			opts:  []ParserOption{WithSkipDTD(), WithSkipProcInst()},
+		},
+		{
+			name:  "entities",
+			input: `<a title="&amp;&custom;">&lt;&#38;&#x26;&nbsp;<![CDATA[&cdata;]]><!-- &comment; -->& text;&;</a>`,
+			expected: []AuditEvent{
+				{Kind: AuditEntityUnexpanded, Offset: 15, Detail: "custom"},
+				{Kind: AuditEntityUnexpanded, Offset: 40, Detail: "nbsp"},
+			},
+		},
+		{
+			name:  "line endings",
+			input: "<a b='1\r\n2'>x\ry<!--\r-->\n</a>",
+			opts:  []ParserOption{WithLineEndings(LineEndingsXML10)},
+			expected: []AuditEvent{
+				{Kind: AuditLineEndingsNormalized, Offset: 0, Detail: "start_element"},
+				{Kind: AuditLineEndingsNormalized, Offset: 12, Detail: "char_data"},
+				{Kind: AuditLineEndingsNormalized, Offset: 15, Detail: "comment"},
+			},
+		},
+		{
+			name:  "raw line endings",
+			input: "<a>x\ry</a>",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			var events []AuditEvent
+
+			hook := AuditHookFunc(func(event AuditEvent) {
+				events = append(events, event)
+			})
+
+			p := NewParser([]byte(test.input), false, append(test.opts, WithAuditHook(hook))...)
+
+			for {
+				_, err := p.Next()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, test.expected, events)
+		})
+	}
+}
+
+func TestAuditKind_String(t *testing.T) {
+	assert.Equal(t, "entity_unexpanded", AuditEntityUnexpanded.String())
+	assert.Equal(t, "unknown", AuditKind(0).String())
+}
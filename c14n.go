@@ -0,0 +1,513 @@
+package fastxml
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"sort"
+	"strings"
+)
+
+var _ TokenWriter = (*Canonicalizer)(nil)
+
+// Canonicalizer writes tokens in canonical form, following Canonical XML 1.0
+// without comments (https://www.w3.org/TR/xml-c14n).
+//
+// Only a subset of the specification is implemented, as DTD is not processed:
+// default attributes are not added and all attributes are considered to be of CDATA type.
+// Within this subset, canonical form of documents is:
+//   - XML declaration, DOCTYPE and comments are removed;
+//   - references are replaced, CDATA sections are replaced with their content;
+//   - empty elements are written as start and end element pairs;
+//   - attributes are double quoted and sorted, namespace declarations come first;
+//   - namespace declarations that are already in scope of the parent element are removed;
+//   - whitespace outside of the root element is removed;
+//   - special characters are escaped as required by the specification.
+//
+// Tokens of this package must be raw, as returned by the parser.
+// encoding/xml tokens must have references replaced and names not translated,
+// as returned by Decoder.RawToken of this or encoding/xml package.
+// Parser does not distinguish CDATA sections in its tokens, so Digest should be used
+// for exact canonical form of documents.
+//
+// Output is buffered, Canonicalizer.Flush must be called after the last token was written.
+type Canonicalizer struct {
+	w *bufio.Writer
+	// scopes hold namespaces of open elements, last one is for the innermost element.
+	scopes []canonicalScope
+	// rootClosed is set when the root element was closed.
+	rootClosed bool
+	attrs      []canonicalAttr
+
+	comments          bool
+	exclusive         bool
+	inclusivePrefixes []string
+}
+
+// canonicalScope holds namespace declarations that are in scope of the element,
+// and ones that were written to the output by the element or its ancestors.
+type canonicalScope struct {
+	declared map[string]string
+	rendered map[string]string
+	// synced is set when all declared namespaces are rendered.
+	synced bool
+	// ownRendered is set when rendered map was copied from the parent, so it can be modified.
+	ownRendered bool
+}
+
+type canonicalAttr struct {
+	// space is the namespace URI of the attribute, used for sorting.
+	space string
+	name  string
+	value string
+	// isNS is set for namespace declarations.
+	isNS bool
+}
+
+// CanonicalizerOption configures Canonicalizer.
+type CanonicalizerOption func(c *Canonicalizer)
+
+// WithComments makes canonical form include comments,
+// as Canonical XML with comments does.
+func WithComments() CanonicalizerOption {
+	return func(c *Canonicalizer) {
+		c.comments = true
+	}
+}
+
+// WithExclusive makes Canonicalizer follow Exclusive XML Canonicalization
+// (https://www.w3.org/TR/xml-exc-c14n), in which element only declares namespaces it visibly uses.
+//
+// Namespaces with inclusivePrefixes are treated as in Canonical XML,
+// "#default" stands for the default namespace.
+func WithExclusive(inclusivePrefixes ...string) CanonicalizerOption {
+	return func(c *Canonicalizer) {
+		c.exclusive = true
+
+		for _, prefix := range inclusivePrefixes {
+			if prefix == "#default" {
+				prefix = ""
+			}
+
+			c.inclusivePrefixes = append(c.inclusivePrefixes, prefix)
+		}
+	}
+}
+
+// WithNamespaces sets namespace declarations that are in scope of the top level elements,
+// mapping prefix to namespace URI, with empty prefix for the default namespace.
+//
+// This is needed to canonicalize a subtree of the document,
+// in which case ns should hold namespaces declared by ancestors of the subtree.
+func WithNamespaces(ns map[string]string) CanonicalizerOption {
+	return func(c *Canonicalizer) {
+		scope := &c.scopes[0]
+		scope.declared = copyScope(scope.declared)
+
+		for prefix, uri := range ns {
+			scope.declared[prefix] = uri
+		}
+
+		scope.synced = false
+	}
+}
+
+// NewCanonicalizer will create a canonicalizer that writes to w.
+func NewCanonicalizer(w io.Writer, opts ...CanonicalizerOption) *Canonicalizer {
+	topScope := map[string]string{"": ""}
+
+	c := &Canonicalizer{
+		w:      bufio.NewWriter(w),
+		scopes: []canonicalScope{{declared: topScope, rendered: topScope, synced: true}},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// WriteToken writes token in canonical form.
+func (c *Canonicalizer) WriteToken(token xml.Token) error {
+	switch tkn := token.(type) {
+	case nil, *Directive, xml.Directive:
+		return nil
+	case *Comment:
+		c.writeComment(*tkn)
+	case xml.Comment:
+		c.writeComment(tkn)
+	case *StartToken:
+		if err := c.writeStartElement(rawStartElement(tkn)); err != nil {
+			return err
+		}
+	case xml.StartElement:
+		if err := c.writeStartElement(tkn); err != nil {
+			return err
+		}
+	case *EndElement:
+		c.writeEndElement(splitName(tkn.Name.Local))
+	case xml.EndElement:
+		c.writeEndElement(tkn.Name)
+	case *CharData:
+		c.writeCharData(Unescape(normalizeLineEndings(string(*tkn))))
+	case xml.CharData:
+		c.writeCharData(string(tkn))
+	case *ProcInst:
+		c.writeProcInst(xml.ProcInst(*tkn))
+	case xml.ProcInst:
+		c.writeProcInst(tkn)
+	default:
+		return fmt.Errorf("%w: %T", ErrUnsupportedToken, token)
+	}
+
+	_, err := c.w.Write(nil)
+
+	return err
+}
+
+// Flush will write buffered data to the underlying writer.
+func (c *Canonicalizer) Flush() error {
+	return c.w.Flush()
+}
+
+// Digest writes canonical form of the document into h, see Canonicalizer.
+//
+// Canonical form is streamed into the hash, so it is never fully built in memory.
+func Digest(buf []byte, h hash.Hash) error {
+	return Canonicalize(h, buf)
+}
+
+// Canonicalize writes canonical form of the document to w, see Canonicalizer.
+//
+// Unlike Canonicalizer that gets tokens from the parser, CDATA sections and processing instructions
+// are taken into account. buf can also hold a subtree of the document, see WithNamespaces.
+func Canonicalize(w io.Writer, buf []byte, opts ...CanonicalizerOption) error {
+	c := NewCanonicalizer(w, opts...)
+	p := NewParser(buf, false)
+
+	for {
+		start := p.InputOffset()
+
+		token, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			return c.Flush()
+		}
+
+		if err != nil {
+			return err
+		}
+
+		raw := buf[start:p.InputOffset()]
+
+		switch {
+		case bytes.HasPrefix(raw, cdataPrefix):
+			token = xml.CharData(normalizeLineEndings(string(*token.(*CharData))))
+		case token == nil && bytes.HasPrefix(raw, procInstPrefix) && !isDeclaration(raw) && len(raw) > 4:
+			token = procInst(raw[2 : len(raw)-2])
+		}
+
+		if err := c.WriteToken(token); err != nil {
+			return err
+		}
+	}
+}
+
+// rawStartElement converts start token to encoding/xml value, normalizing attribute values.
+func rawStartElement(tkn *StartToken) xml.StartElement {
+	start := xml.StartElement{Name: splitName(tkn.Name)}
+
+	attrs := *tkn
+
+	for {
+		name, val, err := attrs.NextAttribute()
+		if err != nil {
+			return start
+		}
+
+		// Attribute value normalization replaces literal whitespace before references are replaced.
+		val = strings.Map(func(r rune) rune {
+			if r == '\t' || r == '\n' || r == '\r' {
+				return ' '
+			}
+
+			return r
+		}, strings.ReplaceAll(val, "\r\n", "\n"))
+
+		start.Attr = append(start.Attr, xml.Attr{Name: splitName(name), Value: Unescape(val)})
+	}
+}
+
+func (c *Canonicalizer) writeStartElement(start xml.StartElement) error {
+	parent := c.scopes[len(c.scopes)-1]
+	scope := canonicalScope{declared: parent.declared, rendered: parent.rendered, synced: parent.synced}
+	declared := false
+
+	c.attrs = c.attrs[:0]
+
+	for _, attr := range start.Attr {
+		prefix, isNS := namespacePrefix(attr.Name)
+		if !isNS {
+			continue
+		}
+
+		if uri, ok := scope.declared[prefix]; ok && uri == attr.Value {
+			continue
+		}
+
+		if !declared {
+			scope.declared, declared = copyScope(parent.declared), true
+		}
+
+		scope.declared[prefix] = attr.Value
+	}
+
+	switch {
+	case c.exclusive:
+		c.renderNamespace(&scope, parent.rendered, start.Name.Space)
+
+		for _, attr := range start.Attr {
+			if _, isNS := namespacePrefix(attr.Name); !isNS && attr.Name.Space != "" && attr.Name.Space != xmlPrefix {
+				c.renderNamespace(&scope, parent.rendered, attr.Name.Space)
+			}
+		}
+
+		for _, prefix := range c.inclusivePrefixes {
+			c.renderNamespace(&scope, parent.rendered, prefix)
+		}
+	case parent.synced:
+		for _, attr := range start.Attr {
+			if prefix, isNS := namespacePrefix(attr.Name); isNS {
+				c.renderNamespace(&scope, parent.rendered, prefix)
+			}
+		}
+	default:
+		for prefix := range scope.declared {
+			c.renderNamespace(&scope, parent.rendered, prefix)
+		}
+
+		scope.synced = true
+	}
+
+	for _, attr := range start.Attr {
+		if _, isNS := namespacePrefix(attr.Name); isNS {
+			continue
+		}
+
+		var space string
+
+		switch attr.Name.Space {
+		case "":
+		case xmlPrefix:
+			space = xmlURL
+		default:
+			uri, ok := scope.declared[attr.Name.Space]
+			if !ok {
+				return fmt.Errorf("canonicalize: undeclared namespace prefix %q", attr.Name.Space)
+			}
+
+			space = uri
+		}
+
+		c.attrs = append(c.attrs, canonicalAttr{name: qualifiedName(attr.Name), space: space, value: attr.Value})
+	}
+
+	sort.SliceStable(c.attrs, func(i, j int) bool {
+		a, b := c.attrs[i], c.attrs[j]
+
+		switch {
+		case a.isNS != b.isNS:
+			return a.isNS
+		case a.isNS:
+			return a.space < b.space
+		case a.space != b.space:
+			return a.space < b.space
+		default:
+			return localName(a.name) < localName(b.name)
+		}
+	})
+
+	c.scopes = append(c.scopes, scope)
+
+	c.w.WriteByte('<')
+	c.w.WriteString(qualifiedName(start.Name))
+
+	for _, attr := range c.attrs {
+		c.w.WriteByte(' ')
+		c.w.WriteString(attr.name)
+		c.w.WriteString(`="`)
+		writeCanonicalText(c.w, attr.value, true)
+		c.w.WriteByte('"')
+	}
+
+	c.w.WriteByte('>')
+
+	return nil
+}
+
+// renderNamespace declares namespace with prefix on the element if it was not rendered by output ancestors.
+//
+// Empty default namespace is considered to be rendered if no default namespace was rendered.
+func (c *Canonicalizer) renderNamespace(scope *canonicalScope, parentRendered map[string]string, prefix string) {
+	uri, ok := scope.declared[prefix]
+	if !ok {
+		return
+	}
+
+	if rendered, ok := scope.rendered[prefix]; ok && rendered == uri {
+		return
+	}
+
+	if !scope.ownRendered {
+		scope.rendered, scope.ownRendered = copyScope(parentRendered), true
+	}
+
+	scope.rendered[prefix] = uri
+
+	name := xmlnsPrefix
+	if prefix != "" {
+		name += ":" + prefix
+	}
+
+	c.attrs = append(c.attrs, canonicalAttr{name: name, space: prefix, value: uri, isNS: true})
+}
+
+func (c *Canonicalizer) writeEndElement(name xml.Name) {
+	if len(c.scopes) > 1 {
+		c.scopes = c.scopes[:len(c.scopes)-1]
+	}
+
+	if len(c.scopes) == 1 {
+		c.rootClosed = true
+	}
+
+	c.w.WriteString("</")
+	c.w.WriteString(qualifiedName(name))
+	c.w.WriteByte('>')
+}
+
+func (c *Canonicalizer) writeCharData(text string) {
+	// Whitespace outside of the root element is removed.
+	if len(c.scopes) == 1 {
+		return
+	}
+
+	writeCanonicalText(c.w, text, false)
+}
+
+func (c *Canonicalizer) writeProcInst(pi xml.ProcInst) {
+	if pi.Target == xmlPrefix {
+		return // XML declaration.
+	}
+
+	c.writeOutside(func() {
+		c.w.WriteString("<?")
+		c.w.WriteString(pi.Target)
+
+		if len(pi.Inst) != 0 {
+			c.w.WriteByte(' ')
+			c.w.Write(pi.Inst)
+		}
+
+		c.w.WriteString("?>")
+	})
+}
+
+func (c *Canonicalizer) writeComment(comment []byte) {
+	if !c.comments {
+		return
+	}
+
+	c.writeOutside(func() {
+		c.w.WriteString("<!--")
+		c.w.WriteString(normalizeLineEndings(string(comment)))
+		c.w.WriteString("-->")
+	})
+}
+
+// writeOutside calls write, separating nodes outside of the root element with line feeds.
+func (c *Canonicalizer) writeOutside(write func()) {
+	outside := len(c.scopes) == 1
+	if outside && c.rootClosed {
+		c.w.WriteByte('\n')
+	}
+
+	write()
+
+	if outside && !c.rootClosed {
+		c.w.WriteByte('\n')
+	}
+}
+
+// writeCanonicalText escapes text of char data or attribute value as required by canonical form.
+func writeCanonicalText(w *bufio.Writer, text string, isAttr bool) {
+	for _, r := range text {
+		switch {
+		case r == '&':
+			w.WriteString("&amp;")
+		case r == '<':
+			w.WriteString("&lt;")
+		case r == '>' && !isAttr:
+			w.WriteString("&gt;")
+		case r == '"' && isAttr:
+			w.WriteString("&quot;")
+		case r == '\t' && isAttr:
+			w.WriteString("&#x9;")
+		case r == '\n' && isAttr:
+			w.WriteString("&#xA;")
+		case r == '\r':
+			w.WriteString("&#xD;")
+		default:
+			w.WriteRune(r)
+		}
+	}
+}
+
+// namespacePrefix returns declared prefix if attribute is a namespace declaration.
+func namespacePrefix(name xml.Name) (string, bool) {
+	switch {
+	case name.Space == xmlnsPrefix:
+		return name.Local, true
+	case name.Space == "" && name.Local == xmlnsPrefix:
+		return "", true
+	default:
+		return "", false
+	}
+}
+
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+
+	return name.Space + ":" + name.Local
+}
+
+func localName(name string) string {
+	if idx := strings.IndexByte(name, ':'); idx != -1 {
+		return name[idx+1:]
+	}
+
+	return name
+}
+
+func normalizeLineEndings(s string) string {
+	if strings.IndexByte(s, '\r') == -1 {
+		return s
+	}
+
+	return strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\r", "\n")
+}
+
+func copyScope(scope map[string]string) map[string]string {
+	scopeCopy := make(map[string]string, len(scope)+1)
+	for prefix, uri := range scope {
+		scopeCopy[prefix] = uri
+	}
+
+	return scopeCopy
+}
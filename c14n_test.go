@@ -0,0 +1,156 @@
+package fastxml
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/xml"
+	"errors"
+	"hash"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Examples are from https://www.w3.org/TR/xml-c14n#Examples.
+func TestDigest(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name: "pis, comments, and outside of document element",
+			input: "<?xml version=\"1.0\"?>\n\n<?xml-stylesheet   href=\"doc.xsl\"\n   type=\"text/xsl\"   ?>\n\n" +
+				"<!DOCTYPE doc SYSTEM \"doc.dtd\">\n\n<doc>Hello, world!<!-- Comment 1 --></doc>\n\n<?pi-without-data     ?>\n\n" +
+				"<!-- Comment 2 -->\n\n<!-- Comment 3 -->",
+			want: "<?xml-stylesheet href=\"doc.xsl\"\n   type=\"text/xsl\"   ?>\n<doc>Hello, world!</doc>\n<?pi-without-data?>",
+		},
+		{
+			name: "start and end tags",
+			input: "<doc>\n   <e1   />\n   <e2   ></e2>\n   <e3   name = \"elem3\"   id=\"elem3\"   />\n" +
+				"   <e5 a:attr=\"out\" b:attr=\"sorted\" attr2=\"all\" attr=\"I'm\"\n      xmlns:b=\"http://www.ietf.org\"\n" +
+				"      xmlns:a=\"http://www.w3.org\"\n      xmlns=\"http://example.org\"/>\n" +
+				"   <e6 xmlns=\"\" xmlns:a=\"http://www.w3.org\">\n       <e7 xmlns=\"http://www.ietf.org\">\n" +
+				"           <e8 xmlns=\"\" xmlns:a=\"http://www.w3.org\">\n               <e9 xmlns=\"\" xmlns:a=\"http://www.ietf.org\"/>\n" +
+				"           </e8>\n       </e7>\n   </e6>\n</doc>",
+			want: "<doc>\n   <e1></e1>\n   <e2></e2>\n   <e3 id=\"elem3\" name=\"elem3\"></e3>\n" +
+				"   <e5 xmlns=\"http://example.org\" xmlns:a=\"http://www.w3.org\" xmlns:b=\"http://www.ietf.org\" attr=\"I'm\" attr2=\"all\" b:attr=\"sorted\" a:attr=\"out\"></e5>\n" +
+				"   <e6 xmlns:a=\"http://www.w3.org\">\n       <e7 xmlns=\"http://www.ietf.org\">\n" +
+				"           <e8 xmlns=\"\">\n               <e9 xmlns:a=\"http://www.ietf.org\"></e9>\n" +
+				"           </e8>\n       </e7>\n   </e6>\n</doc>",
+		},
+		{
+			name: "character modifications and character references",
+			input: "<doc>\n   <text>First line&#x0d;&#10;Second line</text>\n   <value>&#x32;</value>\n" +
+				"   <compute><![CDATA[value>\"0\" && value<\"10\" ?\"valid\":\"error\"]]></compute>\n" +
+				"   <compute expr='value>\"0\" &amp;&amp; value&lt;\"10\" ?\"valid\":\"error\"'>valid</compute>\n" +
+				"   <norm attr=' &apos;   &#x20;&#13;&#xa;&#9;   &apos; '/>\n</doc>",
+			want: "<doc>\n   <text>First line&#xD;\nSecond line</text>\n   <value>2</value>\n" +
+				"   <compute>value&gt;\"0\" &amp;&amp; value&lt;\"10\" ?\"valid\":\"error\"</compute>\n" +
+				"   <compute expr=\"value>&quot;0&quot; &amp;&amp; value&lt;&quot;10&quot; ?&quot;valid&quot;:&quot;error&quot;\">valid</compute>\n" +
+				"   <norm attr=\" '    &#xD;&#xA;&#x9;   ' \"></norm>\n</doc>",
+		},
+		{
+			name:  "line endings and attribute whitespace",
+			input: "<doc a=\"1\r\n2\t3\">x\r\ny<![CDATA[\r\n]]></doc>",
+			want:  "<doc a=\"1 2 3\">x\ny\n</doc>",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			h := &recordingHash{Hash: sha256.New()}
+			require.NoError(t, Digest([]byte(test.input), h))
+
+			mustSum := sha256.Sum256([]byte(test.want))
+
+			assert.Equal(t, test.want, h.buf.String())
+			assert.Equal(t, mustSum[:], h.Sum(nil))
+		})
+	}
+}
+
+func TestCanonicalizer_StdTokens(t *testing.T) {
+	input := `<?xml version="1.0"?><a:root xmlns:a="urn:a" xmlns:b="urn:b" b:y="2" a:x="1"><a:e xmlns:a="urn:a">&lt;text&gt;<![CDATA[&]]></a:e><!--c--></a:root>`
+
+	var buf bytes.Buffer
+
+	c := NewCanonicalizer(&buf)
+	dec := xml.NewDecoder(bytes.NewReader([]byte(input)))
+
+	for {
+		token, err := dec.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+		require.NoError(t, c.WriteToken(token))
+	}
+
+	require.NoError(t, c.Flush())
+
+	assert.Equal(t, `<a:root xmlns:a="urn:a" xmlns:b="urn:b" a:x="1" b:y="2"><a:e>&lt;text&gt;&amp;</a:e></a:root>`, buf.String())
+
+	assert.ErrorIs(t, c.WriteToken(xml.Attr{}), ErrUnsupportedToken)
+}
+
+func TestCanonicalizer_UndeclaredPrefix(t *testing.T) {
+	err := Digest([]byte(`<a x:b="1"/>`), sha256.New())
+	assert.Error(t, err)
+}
+
+// recordingHash records everything written to the hash.
+type recordingHash struct {
+	hash.Hash
+	buf bytes.Buffer
+}
+
+func (h *recordingHash) Write(p []byte) (int, error) {
+	h.buf.Write(p)
+
+	return h.Hash.Write(p)
+}
+
+// Example is from https://www.w3.org/TR/xml-exc-c14n#sec-Enveloping.
+func TestCanonicalize_Subtree(t *testing.T) {
+	subtree := []byte(`<n1:elem2 xmlns:n1="http://example.net" xml:lang="en"><n3:stuff xmlns:n3="ftp://example.org"/><!--c--></n1:elem2>`)
+	ns := map[string]string{"n0": "foo:bar", "n3": "ftp://example.org"}
+
+	tests := []struct {
+		name string
+		opts []CanonicalizerOption
+		want string
+	}{
+		{
+			name: "inclusive",
+			opts: []CanonicalizerOption{WithNamespaces(ns)},
+			want: `<n1:elem2 xmlns:n0="foo:bar" xmlns:n1="http://example.net" xmlns:n3="ftp://example.org" xml:lang="en"><n3:stuff></n3:stuff></n1:elem2>`,
+		},
+		{
+			name: "exclusive",
+			opts: []CanonicalizerOption{WithNamespaces(ns), WithExclusive()},
+			want: `<n1:elem2 xmlns:n1="http://example.net" xml:lang="en"><n3:stuff xmlns:n3="ftp://example.org"></n3:stuff></n1:elem2>`,
+		},
+		{
+			name: "exclusive with inclusive prefixes and comments",
+			opts: []CanonicalizerOption{WithNamespaces(ns), WithExclusive("n0", "#default"), WithComments()},
+			want: `<n1:elem2 xmlns:n0="foo:bar" xmlns:n1="http://example.net" xml:lang="en"><n3:stuff xmlns:n3="ftp://example.org"></n3:stuff><!--c--></n1:elem2>`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			require.NoError(t, Canonicalize(&buf, subtree, test.opts...))
+			assert.Equal(t, test.want, buf.String())
+		})
+	}
+}
@@ -0,0 +1,111 @@
+// Command xml2json converts XML document to JSON.
+//
+// Usage:
+//
+//	xml2json [flags] [file]
+//
+// If file is not provided - document is read from stdin. Document is converted
+// as it is read, see package xmljson for conversion rules.
+// With -records flag, output is newline-delimited JSON with a line per record element.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"fastxml/xmljson"
+	"fastxml/xmlpath"
+)
+
+// pathsFlag collects paths from repeated or comma separated flag values.
+type pathsFlag []string
+
+func (f *pathsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *pathsFlag) Set(val string) error {
+	for _, path := range strings.Split(val, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			*f = append(*f, path)
+		}
+	}
+
+	return nil
+}
+
+func main() {
+	var (
+		opts   xmljson.Options
+		arrays pathsFlag
+		rules  pathsFlag
+		record string
+		pretty bool
+	)
+
+	flag.StringVar(&opts.AttrPrefix, "attr-prefix", "@", "prefix for attribute keys")
+	flag.StringVar(&opts.TextKey, "text-key", xmljson.DefaultTextKey, "key for text of elements with attributes or children")
+	flag.Var(&arrays, "array", "slash separated path of element that is always an array, like `root/item` (repeatable)")
+	flag.Var(&rules, "array-rule", "path expression of elements that are always arrays, like `//item` (repeatable)")
+	flag.StringVar(&record, "records", "", "path expression of records, each written on a separate line (NDJSON)")
+	flag.BoolVar(&pretty, "pretty", false, "pretty print output")
+	flag.BoolVar(&opts.PreserveOrder, "ordered", false, "keep document order, writing content as name-value objects")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [file]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	opts.ArrayPaths = arrays
+
+	if pretty {
+		opts.Indent = "  "
+	}
+
+	if err := run(flag.Args(), opts, rules, record); err != nil {
+		fmt.Fprintln(os.Stderr, "xml2json:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, opts xmljson.Options, rules []string, record string) error {
+	if record != "" {
+		path, err := xmlpath.Compile(record)
+		if err != nil {
+			return err
+		}
+
+		opts.Records = path
+	}
+
+	for _, rule := range rules {
+		path, err := xmlpath.Compile(rule)
+		if err != nil {
+			return err
+		}
+
+		opts.ArrayRules = append(opts.ArrayRules, path)
+	}
+
+	var r io.Reader = os.Stdin
+
+	switch len(args) {
+	case 0:
+	case 1:
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+
+		defer f.Close()
+
+		r = f
+	default:
+		return fmt.Errorf("expected at most one file, got %d", len(args))
+	}
+
+	return xmljson.Convert(os.Stdout, r, opts)
+}
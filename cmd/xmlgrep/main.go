@@ -0,0 +1,155 @@
+// Command xmlgrep prints elements matching the path expression.
+//
+// Usage:
+//
+//	xmlgrep [flags] path [file]
+//
+// If file is not provided - document is read from stdin.
+// See package xmlpath for the path syntax.
+//
+// By default raw XML of each matched element is printed on a separate line.
+// Elements nested into an already matched element are not matched again.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"fastxml"
+	"fastxml/xmlpath"
+)
+
+type options struct {
+	text bool
+	attr string
+}
+
+func main() {
+	var opts options
+
+	flag.BoolVar(&opts.text, "text", false, "print text content instead of raw XML")
+	flag.StringVar(&opts.attr, "attr", "", "print value of the attribute instead of raw XML")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] path [file]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if err := run(flag.Args(), opts); err != nil {
+		fmt.Fprintln(os.Stderr, "xmlgrep:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, opts options) error {
+	if len(args) == 0 || len(args) > 2 {
+		flag.Usage()
+
+		return errors.New("expected path and at most one file")
+	}
+
+	path, err := xmlpath.Compile(args[0])
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+
+	if len(args) == 2 {
+		data, err = os.ReadFile(args[1])
+	} else {
+		data, err = io.ReadAll(os.Stdin)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+
+	if err := grep(w, data, path, opts); err != nil {
+		_ = w.Flush()
+
+		return err
+	}
+
+	return w.Flush()
+}
+
+func grep(w *bufio.Writer, data []byte, path *xmlpath.Path, opts options) error {
+	p := fastxml.NewParser(data, false)
+
+	var (
+		matchDepth int // Depth of currently matched element, 0 if there is no match.
+		matchStart int
+		text       strings.Builder
+	)
+
+	for {
+		offset := p.InputOffset()
+
+		token, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		switch tkn := token.(type) {
+		case *fastxml.StartToken:
+			if matchDepth != 0 || !path.Match(p.Stack()) {
+				continue
+			}
+
+			if opts.attr != "" {
+				if val, ok := attribute(tkn, opts.attr); ok {
+					w.WriteString(fastxml.Unescape(val))
+					w.WriteByte('\n')
+				}
+
+				continue
+			}
+
+			matchDepth, matchStart = p.Depth(), offset
+			text.Reset()
+		case *fastxml.CharData:
+			if matchDepth != 0 && opts.text {
+				text.Write(*tkn)
+			}
+		case *fastxml.EndElement:
+			if matchDepth == 0 || p.Depth() >= matchDepth {
+				continue
+			}
+
+			matchDepth = 0
+
+			if opts.text {
+				w.WriteString(fastxml.Unescape(text.String()))
+			} else {
+				w.Write(data[matchStart:p.InputOffset()])
+			}
+
+			w.WriteByte('\n')
+		}
+	}
+}
+
+func attribute(start *fastxml.StartToken, name string) (string, bool) {
+	for {
+		attrName, attrVal, err := start.NextAttribute()
+		if err != nil {
+			return "", false
+		}
+
+		if attrName == name {
+			return attrVal, true
+		}
+	}
+}
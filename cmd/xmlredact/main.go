@@ -0,0 +1,91 @@
+// Command xmlredact masks sensitive values of the document, to share production payloads for debugging.
+//
+// Usage:
+//
+//	xmlredact [flags] [file]
+//
+// If file is not provided - document is read from stdin. Redacted document is written to stdout.
+//
+// Each `-select` flag adds comma separated selectors, see fastxml.NewRedactor for the syntax:
+// `user/password` masks text of the element, `user@token` and `@token` mask attributes.
+// Values are masked byte by byte, so offsets of all tokens are the same as in the original document.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"fastxml"
+)
+
+// selectorsFlag collects selectors from repeated or comma separated flag values.
+type selectorsFlag []string
+
+func (f *selectorsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *selectorsFlag) Set(val string) error {
+	for _, selector := range strings.Split(val, ",") {
+		if selector = strings.TrimSpace(selector); selector != "" {
+			*f = append(*f, selector)
+		}
+	}
+
+	return nil
+}
+
+func main() {
+	var selected selectorsFlag
+
+	flag.Var(&selected, "select", "selector of values to mask, like `user/password` or `@token` (repeatable)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [file]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if err := run(flag.Args(), selected); err != nil {
+		fmt.Fprintln(os.Stderr, "xmlredact:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, selected selectorsFlag) error {
+	if len(args) > 1 {
+		flag.Usage()
+
+		return errors.New("expected at most one file")
+	}
+
+	if len(selected) == 0 {
+		return errors.New("no values are selected")
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+
+	if len(args) == 1 {
+		data, err = os.ReadFile(args[0])
+	} else {
+		data, err = io.ReadAll(os.Stdin)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := fastxml.NewRedactor(selected...).Redact(data); err != nil {
+		return err
+	}
+
+	_, err = os.Stdout.Write(data)
+
+	return err
+}
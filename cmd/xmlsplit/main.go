@@ -0,0 +1,181 @@
+// Command xmlsplit splits huge document into smaller ones by children of the root element.
+//
+// Usage:
+//
+//	xmlsplit [flags] file
+//
+// Each output document holds up to `-n` records - children of the root element.
+// Everything before the root element(XML declaration, comments, DOCTYPE)
+// and the root start element itself are copied to each output document as is,
+// so output documents are valid on their own.
+//
+// If `-element` is set - only children with this name are records,
+// other children of the root element are dropped.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"fastxml"
+)
+
+type options struct {
+	perFile int
+	element string
+	output  string
+}
+
+func main() {
+	var opts options
+
+	flag.IntVar(&opts.perFile, "n", 100000, "number of records per output file")
+	flag.StringVar(&opts.element, "element", "", "name of the record element, any child of the root if empty")
+	flag.StringVar(&opts.output, "o", "part-%04d.xml", "output file name pattern, formatted with the file number")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] file\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if err := run(flag.Args(), opts); err != nil {
+		fmt.Fprintln(os.Stderr, "xmlsplit:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, opts options) error {
+	if len(args) != 1 {
+		flag.Usage()
+
+		return errors.New("expected exactly one file")
+	}
+
+	if opts.perFile <= 0 {
+		return fmt.Errorf("number of records per file must be positive, got %d", opts.perFile)
+	}
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	s := splitter{opts: opts, data: data}
+
+	return s.split()
+}
+
+// splitter writes records of the document to output files.
+type splitter struct {
+	opts options
+	data []byte
+	// header is the part of the document up to and including the root start element.
+	header []byte
+	// footer is the root end element.
+	footer string
+
+	file    *os.File
+	w       *bufio.Writer
+	files   int
+	records int
+}
+
+func (s *splitter) split() error {
+	p := fastxml.NewParser(s.data, false)
+
+	var recordStart int
+
+	for {
+		offset := p.InputOffset()
+
+		token, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			return s.closeFile()
+		}
+
+		if err != nil {
+			_ = s.closeFile()
+
+			return err
+		}
+
+		switch tkn := token.(type) {
+		case *fastxml.StartToken:
+			switch p.Depth() {
+			case 1:
+				s.header = s.data[:p.InputOffset()]
+				s.footer = "</" + tkn.Name + ">\n"
+			case 2:
+				recordStart = offset
+			}
+		case *fastxml.EndElement:
+			if p.Depth() != 1 || (s.opts.element != "" && tkn.Name.Local != s.opts.element) {
+				continue
+			}
+
+			if err := s.writeRecord(s.data[recordStart:p.InputOffset()]); err != nil {
+				_ = s.closeFile()
+
+				return err
+			}
+		}
+	}
+}
+
+func (s *splitter) writeRecord(record []byte) error {
+	if s.file != nil && s.records == s.opts.perFile {
+		if err := s.closeFile(); err != nil {
+			return err
+		}
+	}
+
+	if s.file == nil {
+		if err := s.openFile(); err != nil {
+			return err
+		}
+	}
+
+	s.records++
+
+	s.w.WriteByte('\n')
+	_, err := s.w.Write(record)
+
+	return err
+}
+
+func (s *splitter) openFile() error {
+	s.files++
+
+	file, err := os.Create(fmt.Sprintf(s.opts.output, s.files))
+	if err != nil {
+		return err
+	}
+
+	s.file, s.w, s.records = file, bufio.NewWriter(file), 0
+
+	_, err = s.w.Write(s.header)
+
+	return err
+}
+
+func (s *splitter) closeFile() error {
+	if s.file == nil {
+		return nil
+	}
+
+	s.w.WriteByte('\n')
+	s.w.WriteString(s.footer)
+
+	err := s.w.Flush()
+	if closeErr := s.file.Close(); err == nil {
+		err = closeErr
+	}
+
+	s.file, s.w = nil, nil
+
+	return err
+}
@@ -0,0 +1,91 @@
+// Command xmltogo generates Go struct types from sample XML documents.
+//
+// Usage:
+//
+//	xmltogo [flags] [file...]
+//
+// If no files are provided - single sample is read from stdin.
+// All samples must have the same root element, see package xmlinfer for inference rules.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"fastxml/xmlinfer"
+)
+
+func main() {
+	var pkg, output string
+
+	flag.StringVar(&pkg, "pkg", "main", "package name of the generated file")
+	flag.StringVar(&output, "o", "", "output file, stdout if empty")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [file...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if err := run(flag.Args(), pkg, output); err != nil {
+		fmt.Fprintln(os.Stderr, "xmltogo:", err)
+		os.Exit(1)
+	}
+}
+
+func run(files []string, pkg, output string) error {
+	root, err := inferFiles(files)
+	if err != nil {
+		return err
+	}
+
+	w := os.Stdout
+
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+
+		defer f.Close()
+
+		w = f
+	}
+
+	return xmlinfer.GenerateGo(w, root, pkg)
+}
+
+// inferFiles infers structure from files, or from stdin if there are no files.
+func inferFiles(files []string) (*xmlinfer.Element, error) {
+	var in xmlinfer.Inferrer
+
+	if len(files) == 0 {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := in.Add(data); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := in.Add(data); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+	}
+
+	root := in.Root()
+	if root == nil {
+		return nil, fmt.Errorf("no elements found")
+	}
+
+	return root, nil
+}
@@ -0,0 +1,220 @@
+/*
+Package conformance compares results of fastxml parser with encoding/xml.
+
+It is intended to vet documents before switching from encoding/xml to fastxml:
+any token-level difference between the two parsers is reported.
+*/
+package conformance
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"fastxml"
+)
+
+// Difference is a single token that differs between parsers.
+type Difference struct {
+	// Index is the index of the token in the stream.
+	Index int
+	// Offset is the offset of the token end as reported by encoding/xml,
+	// or -1 if encoding/xml has no token at this index.
+	Offset int64
+	// FastXML is the representation of the token produced by fastxml.
+	FastXML string
+	// Std is the representation of the token produced by encoding/xml.
+	Std string
+}
+
+func (d Difference) String() string {
+	return fmt.Sprintf("token %d(offset %d): fastxml %s, encoding/xml %s", d.Index, d.Offset, d.FastXML, d.Std)
+}
+
+// unsupported represents token that fastxml recognizes but does not decode(processing instructions, directives).
+const unsupported = "<unsupported>"
+
+// Compare parses buf with both parsers and returns differences between produced tokens.
+//
+// Tokens are compared in their raw form: names are not resolved to namespaces
+// (encoding/xml Decoder.RawToken is used), and char data is compared as decoded by each parser,
+// so differences in entity expansion are reported.
+//
+// Processing instructions and directives are not decoded by fastxml,
+// so they are considered equal to any processing instruction or directive from encoding/xml.
+//
+// Parsing errors are reported as differences at the index they happened at.
+func Compare(buf []byte) []Difference {
+	fastTokens := fastXMLTokens(buf)
+	stdTokens, offsets := stdTokens(buf)
+
+	var diffs []Difference
+
+	for i := 0; i < len(fastTokens) || i < len(stdTokens); i++ {
+		diff := Difference{Index: i, Offset: -1, FastXML: "<none>", Std: "<none>"}
+
+		if i < len(fastTokens) {
+			diff.FastXML = fastTokens[i]
+		}
+
+		if i < len(stdTokens) {
+			diff.Std = stdTokens[i]
+			diff.Offset = offsets[i]
+		}
+
+		if !equalTokens(diff.FastXML, diff.Std) {
+			diffs = append(diffs, diff)
+		}
+	}
+
+	return diffs
+}
+
+func equalTokens(fast, std string) bool {
+	if fast == unsupported {
+		return strings.HasPrefix(std, "ProcInst ") || strings.HasPrefix(std, "Directive ")
+	}
+
+	return fast == std
+}
+
+func fastXMLTokens(buf []byte) []string {
+	p := fastxml.NewParser(buf, false)
+
+	var tokens []string
+
+	for {
+		token, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			return tokens
+		}
+
+		if err != nil {
+			return append(tokens, "error: "+err.Error())
+		}
+
+		switch tkn := token.(type) {
+		case nil:
+			tokens = append(tokens, unsupported)
+		case *fastxml.StartToken:
+			attrs, err := fastXMLAttributes(tkn)
+			if err != nil {
+				return append(tokens, "error: "+err.Error())
+			}
+
+			tokens = append(tokens, startString(splitName(tkn.Name), attrs))
+		case *fastxml.EndElement:
+			tokens = append(tokens, endString(splitName(tkn.Name.Local)))
+		case *fastxml.CharData:
+			tokens = append(tokens, charDataString(*tkn))
+		case *fastxml.Comment:
+			tokens = append(tokens, commentString(*tkn))
+		default:
+			tokens = append(tokens, fmt.Sprintf("%T", token))
+		}
+	}
+}
+
+func fastXMLAttributes(start *fastxml.StartToken) ([]xml.Attr, error) {
+	var attrs []xml.Attr
+
+	for {
+		name, value, err := start.NextAttribute()
+		if errors.Is(err, io.EOF) {
+			return attrs, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		attrs = append(attrs, xml.Attr{Name: splitName(name), Value: value})
+	}
+}
+
+func stdTokens(buf []byte) ([]string, []int64) {
+	dec := xml.NewDecoder(bytes.NewReader(buf))
+	dec.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	var (
+		tokens  []string
+		offsets []int64
+	)
+
+	for {
+		token, err := dec.RawToken()
+		if errors.Is(err, io.EOF) {
+			return tokens, offsets
+		}
+
+		if err != nil {
+			return append(tokens, "error: "+err.Error()), append(offsets, dec.InputOffset())
+		}
+
+		var str string
+
+		switch tkn := token.(type) {
+		case xml.StartElement:
+			str = startString(tkn.Name, tkn.Attr)
+		case xml.EndElement:
+			str = endString(tkn.Name)
+		case xml.CharData:
+			str = charDataString(tkn)
+		case xml.Comment:
+			str = commentString(tkn)
+		case xml.ProcInst:
+			str = fmt.Sprintf("ProcInst %s", tkn.Target)
+		case xml.Directive:
+			str = fmt.Sprintf("Directive %q", tkn)
+		}
+
+		tokens = append(tokens, str)
+		offsets = append(offsets, dec.InputOffset())
+	}
+}
+
+func splitName(name string) xml.Name {
+	if idx := strings.IndexByte(name, ':'); idx != -1 {
+		return xml.Name{Space: name[:idx], Local: name[idx+1:]}
+	}
+
+	return xml.Name{Local: name}
+}
+
+func nameString(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+
+	return name.Space + ":" + name.Local
+}
+
+func startString(name xml.Name, attrs []xml.Attr) string {
+	var sb strings.Builder
+
+	sb.WriteString("StartElement ")
+	sb.WriteString(nameString(name))
+
+	for _, attr := range attrs {
+		fmt.Fprintf(&sb, " %s=%q", nameString(attr.Name), attr.Value)
+	}
+
+	return sb.String()
+}
+
+func endString(name xml.Name) string {
+	return "EndElement " + nameString(name)
+}
+
+func charDataString(data []byte) string {
+	return fmt.Sprintf("CharData %q", data)
+}
+
+func commentString(data []byte) string {
+	return fmt.Sprintf("Comment %q", data)
+}
@@ -0,0 +1,40 @@
+package conformance
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompare_Equal(t *testing.T) {
+	input := `<?xml version="1.0"?>
+<!DOCTYPE root>
+<root xmlns:x="urn:x"><x:item id="1" x:attr='2'>text</x:item><!-- comment --><![CDATA[<cdata>]]><empty/></root>`
+
+	assert.Empty(t, Compare([]byte(input)))
+}
+
+func TestCompare_Differences(t *testing.T) {
+	input := `<root a="1 &amp; 2">x &lt; y</root>`
+
+	diffs := Compare([]byte(input))
+	require.Len(t, diffs, 2)
+
+	assert.Equal(t, Difference{
+		Index:   0,
+		Offset:  20,
+		FastXML: `StartElement root a="1 &amp; 2"`,
+		Std:     `StartElement root a="1 & 2"`,
+	}, diffs[0])
+	assert.Equal(t, `token 1(offset 28): fastxml CharData "x &lt; y", encoding/xml CharData "x < y"`, diffs[1].String())
+}
+
+func TestCompare_Errors(t *testing.T) {
+	diffs := Compare([]byte(`<root><!-- comment`))
+	require.NotEmpty(t, diffs)
+
+	last := diffs[len(diffs)-1]
+	assert.Contains(t, last.FastXML, "error: ")
+	assert.Contains(t, last.Std, "error: ")
+}
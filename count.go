@@ -0,0 +1,79 @@
+package fastxml
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// TokenCounts holds number of tokens of each kind in the document.
+type TokenCounts struct {
+	StartElements int
+	// EndElements includes end elements of self-closing elements, same as they are returned by the parser.
+	EndElements int
+	// SelfClosingElements is the number of start elements that were self-closing.
+	SelfClosingElements int
+	// CharData includes CDATA sections.
+	CharData   int
+	Comments   int
+	ProcInsts  int
+	Directives int
+}
+
+// Total returns total number of tokens.
+func (c TokenCounts) Total() int {
+	return c.StartElements + c.EndElements + c.CharData + c.Comments + c.ProcInsts + c.Directives
+}
+
+// Count returns number of tokens of each kind in buf.
+//
+// Only the scanner is used: tokens are not decoded and no strings are created,
+// so this is much cheaper than going through the document with Parser.Next.
+// Because of this, documents that scanner accepts, but parser would not decode, are still counted.
+func Count(buf []byte) (TokenCounts, error) {
+	var counts TokenCounts
+
+	for offset := 0; offset < len(buf); {
+		tokenBytes, err := FetchNextToken(buf[offset:])
+		if errors.Is(err, ErrNeedMoreData) {
+			if tokenBytes[0] != '<' {
+				// Char data at the end of the document.
+				err = nil
+			} else {
+				err = io.ErrUnexpectedEOF
+			}
+		}
+
+		if err != nil {
+			return counts, fmt.Errorf("count tokens: index position %d: %w", offset, err)
+		}
+
+		if len(tokenBytes) == 0 {
+			return counts, fmt.Errorf("count tokens: index position %d: %w", offset, ErrNotAValidTag)
+		}
+
+		offset += len(tokenBytes)
+
+		switch scannedTokenKind(tokenBytes) {
+		case TokenKindStartElement:
+			counts.StartElements++
+
+			if len(tokenBytes) > 1 && tokenBytes[len(tokenBytes)-2] == '/' {
+				counts.SelfClosingElements++
+				counts.EndElements++
+			}
+		case TokenKindEndElement:
+			counts.EndElements++
+		case TokenKindCharData:
+			counts.CharData++
+		case TokenKindComment:
+			counts.Comments++
+		case TokenKindProcInst:
+			counts.ProcInsts++
+		case TokenKindDirective:
+			counts.Directives++
+		}
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,49 @@
+package fastxml
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCount(t *testing.T) {
+	input := `<?xml version="1.0"?>
+<!DOCTYPE root>
+<root a="1"><!-- comment --><item/><item>text<![CDATA[<x>]]></item></root>
+trailing`
+
+	counts, err := Count([]byte(input))
+	require.NoError(t, err)
+
+	assert.Equal(t, TokenCounts{
+		StartElements:       3,
+		EndElements:         3,
+		SelfClosingElements: 1,
+		CharData:            5,
+		Comments:            1,
+		ProcInsts:           1,
+		Directives:          1,
+	}, counts)
+	assert.Equal(t, 14, counts.Total())
+}
+
+func TestCount_Truncated(t *testing.T) {
+	_, err := Count([]byte(`<root><item`))
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func BenchmarkCount(b *testing.B) {
+	buf := []byte(`<doc>` + strings.Repeat(`<p a="1">`+strings.Repeat("Lorem ipsum dolor sit amet. ", 20)+`<br/></p>`, 100) + `</doc>`)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(buf)))
+
+	for i := 0; i < b.N; i++ {
+		if _, err := Count(buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package fastxml
+
+import "encoding/xml"
+
+// Cursor iterates tokens of the parser's input independently of the parser and other cursors.
+//
+// Each cursor has its own position and its own tokens, while the input and configuration
+// are shared, so several goroutines can iterate the same buffer concurrently,
+// each with its own cursor. Single cursor is not safe for concurrent use.
+type Cursor struct {
+	p Parser
+}
+
+// NewCursor will create a cursor at the start of the parser's input, configured the same way as the parser.
+//
+// Input MUST NOT be modified while cursors are used, so cursors can not be created from stream parsers.
+// As Interner is not safe for concurrent use, cursors do not intern names even if parser does.
+// Metrics of the parser are shared with cursors, so they must be safe for concurrent use.
+func (p *Parser) NewCursor() *Cursor {
+	return &Cursor{p: p.configured()}
+}
+
+// Clone returns a new parser at the same position as p, that continues independently of it.
+//
+// Input buffer is shared between parsers, same as for Parser.NewCursor, except for stream parsers:
+// their not yet parsed data is copied, so both parsers can be fed separately.
+// Elements with tracked IDs are copied as well.
+func (p *Parser) Clone() *Parser {
+	clone := p.configured()
+
+	clone.currentPointer = p.currentPointer
+	clone.discarded = p.discarded
+	clone.lastTagName = p.lastTagName
+	clone.stack = append([]string(nil), p.stack...)
+	clone.namespaces = append([]string(nil), p.namespaces...)
+	clone.ownedStackNames = p.ownedStackNames
+	clone.rawTextElement = p.rawTextElement
+	clone.skipDepth = p.skipDepth
+	clone.stopped = p.stopped
+	clone.standalone = p.standalone
+	clone.standaloneDeclared = p.standaloneDeclared
+
+	for id, offset := range p.ids {
+		clone.ids[id] = offset
+	}
+
+	if p.declaredEntities != nil {
+		clone.declaredEntities = make(map[string]struct{}, len(p.declaredEntities))
+
+		for name := range p.declaredEntities {
+			clone.declaredEntities[name] = struct{}{}
+		}
+	}
+
+	for element, defaults := range p.attrDefaults {
+		clone.attrDefaults[element] = append([]attributeDefault(nil), defaults...)
+	}
+
+	if p.streaming {
+		clone.streaming = true
+		clone.buf = append([]byte(nil), p.buf...)
+		clone.retain(cap(clone.buf))
+
+		// Names must not point into the buffer of p, as it is overwritten by Parser.Feed.
+		clone.lastTagName = CopyString(clone.lastTagName)
+		clone.copyStackNames()
+	}
+
+	return &clone
+}
+
+// configured returns a parser at the start of the input, configured the same way as p.
+func (p *Parser) configured() Parser {
+	c := Parser{
+		buf:               p.buf,
+		memoryBudget:      p.memoryBudget,
+		deadline:          p.deadline,
+		ownedTokens:       p.ownedTokens,
+		lineEndings:       p.lineEndings,
+		metrics:           p.metrics,
+		matchEndElements:  p.matchEndElements,
+		defaultNamespace:  p.defaultNamespace,
+		html:              p.html,
+		skipDTD:           p.skipDTD,
+		skipKinds:         p.skipKinds,
+		descendFunc:       p.descendFunc,
+		strict:            p.strict,
+		typographicQuotes: p.typographicQuotes,
+		tolerantAttrs:     p.tolerantAttrs,
+		tokenDecoders:     p.tokenDecoders,
+		auditHook:         p.auditHook,
+	}
+
+	if p.ids != nil {
+		c.ids = map[string]int{}
+	}
+
+	if p.attrDefaults != nil {
+		c.attrDefaults = map[string][]attributeDefault{}
+	}
+
+	return c
+}
+
+// Next returns next token of the cursor, same as Parser.Next.
+func (c *Cursor) Next() (xml.Token, error) {
+	return c.p.Next()
+}
+
+// Peek returns next token without advancing the cursor, same as Parser.Peek.
+func (c *Cursor) Peek() (xml.Token, error) {
+	return c.p.Peek()
+}
+
+// InputOffset returns offset of the next byte that will be parsed by the cursor, same as Parser.InputOffset.
+func (c *Cursor) InputOffset() int {
+	return c.p.InputOffset()
+}
@@ -0,0 +1,159 @@
+package fastxml
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParser_NewCursor(t *testing.T) {
+	input := []byte(`<root>` + strings.Repeat(`<item id="1">text</item><!--c-->`, 100) + `</root>`)
+
+	p := NewParser(input, false, WithEndElementMatching(), WithSkipComments())
+
+	mustTokens, err := Tokens(input, WithSkipComments())
+	require.NoError(t, err)
+
+	// Parser position does not affect cursors.
+	_, err = p.Next()
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+
+	results := make([][]xml.Token, 4)
+
+	for i := range results {
+		wg.Add(1)
+
+		go func(i int, c *Cursor) {
+			defer wg.Done()
+
+			for {
+				token, err := c.Next()
+				if errors.Is(err, io.EOF) {
+					return
+				}
+
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				results[i] = append(results[i], CopyToken(token))
+			}
+		}(i, p.NewCursor())
+	}
+
+	wg.Wait()
+
+	for _, tokens := range results {
+		assert.Equal(t, mustTokens, tokens)
+	}
+
+	// Parser continues from its own position.
+	token, err := p.Next()
+	require.NoError(t, err)
+	assert.Equal(t, &StartToken{Name: "item", attrBuf: []byte(`id="1">`)}, token)
+}
+
+func TestCursor_Peek(t *testing.T) {
+	c := NewParser([]byte(`<a/>`), false).NewCursor()
+
+	peeked, err := c.Peek()
+	require.NoError(t, err)
+	assert.Equal(t, &StartToken{Name: "a"}, peeked)
+	assert.Equal(t, 0, c.InputOffset())
+
+	_, err = c.Next()
+	require.NoError(t, err)
+	assert.Equal(t, 4, c.InputOffset())
+}
+
+func TestParser_Clone(t *testing.T) {
+	input := []byte(`<root><a><b/>text</a><c/></root>`)
+
+	p := NewParser(input, false, WithEndElementMatching())
+
+	// Stop right after self-closing <b/>, before its end element.
+	for i := 0; i < 3; i++ {
+		_, err := p.Next()
+		require.NoError(t, err)
+	}
+
+	clone := p.Clone()
+
+	mustTokens := parseRemaining(t, p)
+	assert.Equal(t, mustTokens, parseRemaining(t, clone))
+	assert.Len(t, mustTokens, 6)
+}
+
+func TestParser_Clone_DeclaredEntities(t *testing.T) {
+	input := []byte(`<?xml version="1.0" standalone="yes"?><!DOCTYPE a [<!ENTITY e "x">]><a>&e;&u;</a>`)
+
+	for _, clonedAt := range []int{1, 2} {
+		p := NewParser(input, false, WithStrict())
+
+		for i := 0; i < clonedAt; i++ {
+			_, err := p.Next()
+			require.NoError(t, err)
+		}
+
+		parsers := []*Parser{p, p.Clone()}
+		errs := make([]error, len(parsers))
+
+		var wg sync.WaitGroup
+
+		for i, parser := range parsers {
+			wg.Add(1)
+
+			go func(i int, parser *Parser) {
+				defer wg.Done()
+
+				for errs[i] == nil {
+					_, errs[i] = parser.Next()
+				}
+			}(i, parser)
+		}
+
+		wg.Wait()
+
+		for _, err := range errs {
+			require.ErrorIs(t, err, ErrUndeclaredEntity)
+			assert.Contains(t, err.Error(), "&u;")
+		}
+	}
+}
+
+func TestParser_Clone_Stream(t *testing.T) {
+	p := NewStreamParser(WithEndElementMatching())
+	p.Feed([]byte(`<root><a>te`))
+
+	_, err := p.Next()
+	require.NoError(t, err)
+
+	clone := p.Clone()
+
+	p.Feed([]byte(`xt</a></root>`))
+	clone.Feed([]byte(`st</a></root>`))
+
+	p.streaming = false
+	clone.streaming = false
+
+	assert.Equal(t, []xml.Token{
+		xml.StartElement{Name: xml.Name{Local: "a"}},
+		xml.CharData("text"),
+		xml.EndElement{Name: xml.Name{Local: "a"}},
+		xml.EndElement{Name: xml.Name{Local: "root"}},
+	}, parseRemaining(t, p))
+	assert.Equal(t, []xml.Token{
+		xml.StartElement{Name: xml.Name{Local: "a"}},
+		xml.CharData("test"),
+		xml.EndElement{Name: xml.Name{Local: "a"}},
+		xml.EndElement{Name: xml.Name{Local: "root"}},
+	}, parseRemaining(t, clone))
+}
@@ -0,0 +1,36 @@
+package fastxml
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrDeadlineExceeded is returned by Parser.Next when parsing did not finish before the deadline.
+var ErrDeadlineExceeded = errors.New("parse deadline exceeded")
+
+// WithDeadline makes parser return ErrDeadlineExceeded from Parser.Next after the deadline,
+// so pathological inputs can not stall a worker indefinitely, even if there is no context to cancel it.
+//
+// Deadline is checked before each token, so a single token is never interrupted,
+// and parsing can not be continued once the deadline is exceeded.
+func WithDeadline(deadline time.Time) ParserOption {
+	return func(p *Parser) {
+		p.deadline = deadline
+	}
+}
+
+// WithTimeout is like WithDeadline, with the deadline after timeout from the creation of the parser.
+func WithTimeout(timeout time.Duration) ParserOption {
+	return func(p *Parser) {
+		p.deadline = time.Now().Add(timeout)
+	}
+}
+
+// checkDeadline returns error if the deadline of the parser is exceeded.
+func (p *Parser) checkDeadline() error {
+	if !p.deadline.IsZero() && time.Now().After(p.deadline) {
+		return ErrDeadlineExceeded
+	}
+
+	return nil
+}
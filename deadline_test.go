@@ -0,0 +1,40 @@
+package fastxml
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithDeadline(t *testing.T) {
+	input := `<a><b/></a>`
+
+	assert.Len(t, collectTokens(t, input, WithDeadline(time.Now().Add(time.Hour))), 4)
+
+	p := NewParser([]byte(input), false, WithDeadline(time.Now().Add(-time.Second)))
+
+	_, err := p.Next()
+	require.ErrorIs(t, err, ErrDeadlineExceeded)
+
+	_, err = p.Next()
+	require.ErrorIs(t, err, ErrDeadlineExceeded, "parsing can not be continued")
+
+	_, err = p.NewCursor().Next()
+	assert.ErrorIs(t, err, ErrDeadlineExceeded, "cursor has the same deadline")
+}
+
+func TestWithTimeout(t *testing.T) {
+	input := "<root>" + strings.Repeat("<item>text</item>", 1000) + "</root>"
+
+	p := NewParser([]byte(input), false, WithTimeout(time.Millisecond))
+
+	time.Sleep(2 * time.Millisecond)
+
+	_, err := p.Next()
+	require.ErrorIs(t, err, ErrDeadlineExceeded)
+
+	assert.Len(t, collectTokens(t, input, WithTimeout(time.Hour)), 3002)
+}
@@ -0,0 +1,552 @@
+package fastxml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	xmlnsPrefix = "xmlns"
+	xmlPrefix   = "xml"
+	xmlURL      = "http://www.w3.org/XML/1998/namespace"
+)
+
+var procInstPrefix = []byte("<?")
+
+// Decoder is a replacement for encoding/xml Decoder, built on top of the Parser.
+//
+// It has the same method set and returns the same token values as encoding/xml Decoder,
+// so migration is a change of the constructor:
+//   - names are split into prefix and local part, and prefixes are translated to namespace URLs;
+//   - char data and attribute values have entities replaced and line endings normalized;
+//   - processing instructions and directives are returned;
+//   - start and end elements are verified to match.
+//
+// Tokens are only valid until the next call to Decoder.Token, same as with encoding/xml.
+//
+// Unlike encoding/xml, all input is read into memory on the first call.
+type Decoder struct {
+	// Strict is true by default. When false, decoder is lenient the same way as encoding/xml:
+	//   - unknown entities and '&' without a reference are kept as is;
+	//   - attributes without value get their name as a value;
+	//   - end element closes any open elements until the matching start element.
+	Strict bool
+	// AutoClose holds names of elements that are closed right after they were opened,
+	// if they are not followed by their end element. It is only used when Strict is false.
+	AutoClose []string
+	// Entity maps names of custom entities to their replacement text.
+	Entity map[string]string
+	// DefaultSpace sets the namespace of elements without namespace, same as in encoding/xml.
+	DefaultSpace string
+
+	r   io.Reader
+	p   *Parser
+	buf []byte
+	err error
+	// ns maps prefixes to namespace URLs, empty prefix is the default namespace.
+	ns map[string]string
+	// nsUndo holds previous bindings of prefixes that were redeclared by open elements.
+	nsUndo []nsBinding
+	// open holds names of open elements, before translation.
+	open []openElement
+	// nextToken is the token that must be processed before reading next token from the input.
+	nextToken xml.Token
+	// toClose is the name of the end element that must be returned before reading from the input.
+	toClose *xml.Name
+}
+
+type nsBinding struct {
+	prefix string
+	url    string
+	ok     bool
+}
+
+type openElement struct {
+	name xml.Name
+	// bindings is the number of namespace bindings declared by the element.
+	bindings int
+}
+
+// NewDecoder will create a decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{
+		Strict: true,
+		r:      r,
+		ns:     make(map[string]string),
+	}
+}
+
+// NewBytesDecoder will create a decoder over buf, without copying it.
+func NewBytesDecoder(buf []byte) *Decoder {
+	return &Decoder{
+		Strict: true,
+		p:      NewParser(buf, false),
+		buf:    buf,
+		ns:     make(map[string]string),
+	}
+}
+
+// init reads input into memory, if it was not read yet.
+func (d *Decoder) init() error {
+	if d.p != nil || d.err != nil {
+		return d.err
+	}
+
+	d.buf, d.err = io.ReadAll(d.r)
+	d.r = nil
+	d.p = NewParser(d.buf, false)
+
+	return d.err
+}
+
+// Token returns the next token, same as encoding/xml Decoder.Token.
+//
+// io.EOF is returned at the end of the input, if all elements were closed.
+func (d *Decoder) Token() (xml.Token, error) {
+	token := d.nextToken
+	d.nextToken = nil
+
+	if token == nil {
+		var err error
+
+		token, err = d.RawToken()
+		if errors.Is(err, io.EOF) && len(d.open) != 0 {
+			return nil, d.syntaxError("unexpected EOF")
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if end, ok := d.autoClose(token); ok {
+		d.nextToken = token
+		token = end
+	}
+
+	switch tkn := token.(type) {
+	case xml.StartElement:
+		d.pushElement(tkn)
+		d.translate(&tkn.Name, true)
+
+		for i := range tkn.Attr {
+			d.translate(&tkn.Attr[i].Name, false)
+		}
+
+		return tkn, nil
+	case xml.EndElement:
+		if err := d.popElement(&tkn); err != nil {
+			return nil, err
+		}
+
+		return tkn, nil
+	default:
+		return token, nil
+	}
+}
+
+// RawToken is like Decoder.Token, but does not verify that start and end elements match
+// and does not translate namespace prefixes.
+func (d *Decoder) RawToken() (xml.Token, error) {
+	if err := d.init(); err != nil {
+		return nil, err
+	}
+
+	if d.toClose != nil {
+		name := *d.toClose
+		d.toClose = nil
+
+		return xml.EndElement{Name: name}, nil
+	}
+
+	for {
+		start := d.p.InputOffset()
+
+		token, err := d.p.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		raw := d.buf[start:d.p.InputOffset()]
+
+		switch tkn := token.(type) {
+		case *StartToken:
+			return d.startElement(tkn)
+		case *EndElement:
+			return xml.EndElement{Name: splitName(tkn.Name.Local)}, nil
+		case *CharData:
+			if bytes.HasPrefix(raw, cdataPrefix) {
+				return xml.CharData(*tkn), nil
+			}
+
+			// Same as encoding/xml, "]]>" is only allowed to end CDATA section.
+			if idx := bytes.Index(raw, cdataSuffix); idx != -1 {
+				return nil, d.syntaxErrorAt("unescaped ]]> not in CDATA section", start+idx+len(cdataSuffix))
+			}
+
+			text, err := d.unescape(*tkn)
+			if err != nil {
+				return nil, err
+			}
+
+			return xml.CharData(text), nil
+		case *Comment:
+			return xml.Comment(*tkn), nil
+		case nil:
+			switch {
+			case bytes.HasPrefix(raw, procInstPrefix) && bytes.HasSuffix(raw, procInstSuffix):
+				// "<?>" has both the prefix and the suffix, but no body between them.
+				if len(raw) < len("<??>") {
+					return nil, d.syntaxError("expected target name after <?")
+				}
+
+				inst := procInst(raw[2 : len(raw)-2])
+				if inst.Target == "" {
+					return nil, d.syntaxError("expected target name after <?")
+				}
+
+				return inst, nil
+			case bytes.HasPrefix(raw, []byte("<!")) && len(raw) > 2:
+				return xml.Directive(raw[2 : len(raw)-1]), nil
+			}
+		}
+	}
+}
+
+// Skip reads tokens until it has consumed the end element matching the most recent start element.
+func (d *Decoder) Skip() error {
+	var depth int
+
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch token.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			if depth == 0 {
+				return nil
+			}
+
+			depth--
+		}
+	}
+}
+
+// Decode works like encoding/xml Decoder.Decode.
+func (d *Decoder) Decode(v interface{}) error {
+	return d.DecodeElement(v, nil)
+}
+
+// DecodeElement works like encoding/xml Decoder.DecodeElement.
+//
+// Decoding of values is done by encoding/xml, from tokens provided by this decoder.
+func (d *Decoder) DecodeElement(v interface{}, start *xml.StartElement) error {
+	for start == nil {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		if tkn, ok := token.(xml.StartElement); ok {
+			start = &tkn
+		}
+	}
+
+	return xml.NewTokenDecoder(&elementReader{d: d, start: start}).Decode(v)
+}
+
+// InputOffset returns offset of the current position in the input, same as encoding/xml Decoder.InputOffset.
+func (d *Decoder) InputOffset() int64 {
+	if d.p == nil {
+		return 0
+	}
+
+	return int64(d.p.InputOffset())
+}
+
+// elementReader provides tokens of a single element to encoding/xml Decoder.
+type elementReader struct {
+	d     *Decoder
+	start *xml.StartElement
+}
+
+func (r *elementReader) Token() (xml.Token, error) {
+	if r.start != nil {
+		start := r.start.Copy()
+		r.start = nil
+
+		return start, nil
+	}
+
+	return r.d.Token()
+}
+
+func (d *Decoder) startElement(tkn *StartToken) (xml.Token, error) {
+	start := xml.StartElement{Name: splitName(tkn.Name)}
+
+	for {
+		name, val, hasValue, err := d.nextAttribute(tkn)
+		if errors.Is(err, io.EOF) {
+			return start, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !hasValue {
+			val = name
+		}
+
+		val, err = d.unescapeString(val)
+		if err != nil {
+			return nil, err
+		}
+
+		start.Attr = append(start.Attr, xml.Attr{Name: splitName(name), Value: val})
+	}
+}
+
+// nextAttribute returns next attribute of the start element, allowing attributes without value if not strict.
+func (d *Decoder) nextAttribute(tkn *StartToken) (name, val string, hasValue bool, err error) {
+	if d.Strict {
+		name, val, err = tkn.NextAttribute()
+
+		return name, val, true, err
+	}
+
+	return tkn.nextAttributeWithFlag()
+}
+
+// pushElement registers namespace declarations of the element.
+func (d *Decoder) pushElement(start xml.StartElement) {
+	var bindings int
+
+	for _, attr := range start.Attr {
+		var prefix string
+
+		switch {
+		case attr.Name.Space == xmlnsPrefix:
+			prefix = attr.Name.Local
+		case attr.Name.Space == "" && attr.Name.Local == xmlnsPrefix:
+			prefix = ""
+		default:
+			continue
+		}
+
+		url, ok := d.ns[prefix]
+		d.nsUndo = append(d.nsUndo, nsBinding{prefix: prefix, url: url, ok: ok})
+		d.ns[prefix] = attr.Value
+		bindings++
+	}
+
+	d.open = append(d.open, openElement{name: start.Name, bindings: bindings})
+}
+
+// popElement verifies that end element matches the most recent start element,
+// translates its name and removes namespace declarations of the element.
+func (d *Decoder) popElement(end *xml.EndElement) error {
+	if len(d.open) == 0 {
+		return d.syntaxError("unexpected end element </" + end.Name.Local + ">")
+	}
+
+	top := d.open[len(d.open)-1]
+
+	switch {
+	case top.name.Local != end.Name.Local && !d.Strict:
+		// Close the open element, end element will be matched against the next open element.
+		toClose := end.Name
+		d.toClose = &toClose
+		end.Name = top.name
+	case top.name.Local != end.Name.Local:
+		return d.syntaxError("element <" + top.name.Local + "> closed by </" + end.Name.Local + ">")
+	case top.name.Space != end.Name.Space:
+		return d.syntaxError("element <" + top.name.Local + "> in space " + top.name.Space +
+			" closed by </" + end.Name.Local + "> in space " + end.Name.Space)
+	}
+
+	d.translate(&end.Name, true)
+	d.open = d.open[:len(d.open)-1]
+
+	for i := 0; i < top.bindings; i++ {
+		binding := d.nsUndo[len(d.nsUndo)-1]
+		d.nsUndo = d.nsUndo[:len(d.nsUndo)-1]
+
+		if binding.ok {
+			d.ns[binding.prefix] = binding.url
+		} else {
+			delete(d.ns, binding.prefix)
+		}
+	}
+
+	return nil
+}
+
+// autoClose returns end element for the open element that must be closed before the token.
+func (d *Decoder) autoClose(token xml.Token) (xml.EndElement, bool) {
+	if d.Strict || len(d.open) == 0 {
+		return xml.EndElement{}, false
+	}
+
+	top := d.open[len(d.open)-1].name
+
+	for _, name := range d.AutoClose {
+		if !strings.EqualFold(name, top.Local) {
+			continue
+		}
+
+		if end, ok := token.(xml.EndElement); ok && strings.EqualFold(end.Name.Local, top.Local) {
+			break
+		}
+
+		return xml.EndElement{Name: top}, true
+	}
+
+	return xml.EndElement{}, false
+}
+
+// translate replaces namespace prefix of the name with namespace URL.
+func (d *Decoder) translate(n *xml.Name, isElementName bool) {
+	switch {
+	case n.Space == xmlnsPrefix:
+		return
+	case n.Space == "" && !isElementName:
+		return
+	case n.Space == xmlPrefix:
+		n.Space = xmlURL
+	case n.Space == "" && n.Local == xmlnsPrefix:
+		return
+	}
+
+	if url, ok := d.ns[n.Space]; ok {
+		n.Space = url
+	} else if n.Space == "" {
+		n.Space = d.DefaultSpace
+	}
+}
+
+func (d *Decoder) syntaxError(msg string) error {
+	return d.syntaxErrorAt(msg, d.p.InputOffset())
+}
+
+// syntaxErrorAt returns syntax error with the line of the offset in the input.
+func (d *Decoder) syntaxErrorAt(msg string, offset int) error {
+	return &xml.SyntaxError{
+		Msg:  msg,
+		Line: 1 + bytes.Count(d.buf[:offset], []byte{'\n'}),
+	}
+}
+
+// splitName splits name into prefix and local part, same as encoding/xml does.
+func splitName(name string) xml.Name {
+	idx := strings.IndexByte(name, ':')
+	if idx < 1 || idx > len(name)-2 || strings.Count(name, ":") > 1 {
+		return xml.Name{Local: name}
+	}
+
+	return xml.Name{Space: name[:idx], Local: name[idx+1:]}
+}
+
+// procInst decodes body of the processing instruction, without `<?` and `?>`.
+func procInst(body []byte) xml.ProcInst {
+	targetEnd := bytes.IndexFunc(body, func(r rune) bool { return IsHTMLSpaceChar(r) })
+	if targetEnd == -1 {
+		return xml.ProcInst{Target: string(body)}
+	}
+
+	inst := body[targetEnd:]
+
+	return xml.ProcInst{Target: string(body[:targetEnd]), Inst: inst[NextNonSpaceIndex(inst):]}
+}
+
+// unescape normalizes line endings and replaces references in text, same as encoding/xml does.
+//
+// Input is returned as is if there is nothing to replace.
+func (d *Decoder) unescape(text []byte) ([]byte, error) {
+	if bytes.IndexByte(text, '&') == -1 && bytes.IndexByte(text, '\r') == -1 {
+		return text, nil
+	}
+
+	s, err := d.unescapeString(string(text))
+
+	return []byte(s), err
+}
+
+func (d *Decoder) unescapeString(s string) (string, error) {
+	if strings.IndexByte(s, '\r') != -1 {
+		s = strings.ReplaceAll(s, "\r\n", "\n")
+		s = strings.ReplaceAll(s, "\r", "\n")
+	}
+
+	ampIdx := strings.IndexByte(s, '&')
+	if ampIdx == -1 {
+		return s, nil
+	}
+
+	var sb strings.Builder
+
+	sb.Grow(len(s))
+
+	for ampIdx != -1 {
+		sb.WriteString(s[:ampIdx])
+		s = s[ampIdx:]
+
+		nameEnd := 1
+		for nameEnd < len(s) && (isNameChar(rune(s[nameEnd])) || s[nameEnd] == '#' || s[nameEnd] >= utf8.RuneSelf) {
+			nameEnd++
+		}
+
+		ref := s[:nameEnd]
+		if nameEnd < len(s) && s[nameEnd] == ';' {
+			ref = s[:nameEnd+1]
+
+			if text, ok := d.entity(s[1:nameEnd]); ok {
+				sb.WriteString(text)
+				s = s[nameEnd+1:]
+				ampIdx = strings.IndexByte(s, '&')
+
+				continue
+			}
+		}
+
+		if d.Strict {
+			if !strings.HasSuffix(ref, ";") {
+				ref += " (no semicolon)"
+			}
+
+			return "", d.syntaxError("invalid character entity " + ref)
+		}
+
+		// Not strict decoder keeps invalid references as is.
+		sb.WriteByte('&')
+		s = s[1:]
+		ampIdx = strings.IndexByte(s, '&')
+	}
+
+	sb.WriteString(s)
+
+	return sb.String(), nil
+}
+
+// entity returns replacement text of the predefined or custom entity, or character reference.
+func (d *Decoder) entity(name string) (string, bool) {
+	if name == "" {
+		return "", false
+	}
+
+	if text, ok := decodeReference(name); ok {
+		return text, true
+	}
+
+	text, ok := d.Entity[name]
+
+	return text, ok
+}
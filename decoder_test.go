@@ -0,0 +1,191 @@
+package fastxml
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const decoderInput = "<?xml version=\"1.0\"?>\n<!DOCTYPE root>\n" +
+	`<root xmlns="urn:default" xmlns:x="urn:x" x:a="1 &amp; 2" xml:lang="en">` +
+	"<x:item id='1'>a &lt; b\r\nc</x:item><!-- comment --><![CDATA[<cdata> &amp;]]>" +
+	`<empty/><?pi some data?><inner xmlns:x="urn:other"><x:deep/></inner><x:after/></root>`
+
+func TestDecoder_Token(t *testing.T) {
+	std := xml.NewDecoder(strings.NewReader(decoderInput))
+	dec := NewDecoder(strings.NewReader(decoderInput))
+
+	for {
+		mustToken, mustErr := std.Token()
+		token, err := dec.Token()
+
+		if errors.Is(mustErr, io.EOF) {
+			assert.ErrorIs(t, err, io.EOF)
+
+			break
+		}
+
+		require.NoError(t, mustErr)
+		require.NoError(t, err)
+
+		assert.Equal(t, xml.CopyToken(mustToken), xml.CopyToken(token))
+	}
+}
+
+func TestDecoder_RawToken(t *testing.T) {
+	dec := NewBytesDecoder([]byte(`<x:a x:b="1"></x:a>`))
+
+	token, err := dec.RawToken()
+	require.NoError(t, err)
+	assert.Equal(t, xml.StartElement{
+		Name: xml.Name{Space: "x", Local: "a"},
+		Attr: []xml.Attr{{Name: xml.Name{Space: "x", Local: "b"}, Value: "1"}},
+	}, token)
+}
+
+func TestDecoder_Decode(t *testing.T) {
+	type item struct {
+		ID    int    `xml:"id,attr"`
+		Value string `xml:",chardata"`
+	}
+
+	type doc struct {
+		XMLName xml.Name `xml:"urn:default root"`
+		Lang    string   `xml:"lang,attr"`
+		Items   []item   `xml:"urn:x item"`
+		Deep    struct{} `xml:"inner>deep"`
+	}
+
+	var v doc
+
+	dec := NewDecoder(strings.NewReader(decoderInput))
+	require.NoError(t, dec.Decode(&v))
+
+	assert.Equal(t, "en", v.Lang)
+	assert.Equal(t, []item{{ID: 1, Value: "a < b\nc"}}, v.Items)
+
+	_, err := dec.Token()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestDecoder_DecodeElementAndSkip(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`<list><skip><a/><b>x</b></skip><n>1</n><n>2</n></list>`))
+
+	var values []int
+
+	for {
+		token, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "skip":
+			require.NoError(t, dec.Skip())
+		case "n":
+			var n int
+
+			require.NoError(t, dec.DecodeElement(&n, &start))
+
+			values = append(values, n)
+		}
+	}
+
+	assert.Equal(t, []int{1, 2}, values)
+	assert.Equal(t, int64(len(`<list><skip><a/><b>x</b></skip><n>1</n><n>2</n></list>`)), dec.InputOffset())
+}
+
+func TestDecoder_SyntaxErrors(t *testing.T) {
+	tests := map[string]string{
+		"<a>\n</b>":        "element <a> closed by </b>",
+		"<a><b></b>":       "unexpected EOF",
+		"</a>":             "unexpected end element </a>",
+		"<x:a></y:a>":      "element <a> in space x closed by </a> in space y",
+		"<a>\n<b>\n</a>\n": "element <b> closed by </a>",
+		"<a>]]></a>":       "unescaped ]]> not in CDATA section",
+		"<?>":              "expected target name after <?",
+		"<a><??></a>":      "expected target name after <?",
+	}
+
+	for input, msg := range tests {
+		dec := NewBytesDecoder([]byte(input))
+
+		var err error
+		for err == nil {
+			_, err = dec.Token()
+		}
+
+		var syntaxErr *xml.SyntaxError
+
+		require.ErrorAs(t, err, &syntaxErr, input)
+		assert.Equal(t, msg, syntaxErr.Msg, input)
+	}
+}
+
+func TestDecoder_Parity(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		strict    bool
+		autoClose []string
+		entity    map[string]string
+	}{
+		{name: "custom entity", input: `<a x="&e;">&e; &amp;</a>`, strict: true, entity: map[string]string{"e": "E"}},
+		{name: "unknown entity strict", input: `<a>&unknown;</a>`, strict: true},
+		{name: "bare ampersand strict", input: `<a>a & b</a>`, strict: true},
+		{name: "unknown entity lenient", input: `<a>&unknown; a & b &#xZZ;</a>`},
+		{name: "attribute without value", input: `<input disabled x="1">`, autoClose: []string{"input"}},
+		{name: "auto close", input: `<p>a<br>b<BR></BR><hr/></p>`, autoClose: xml.HTMLAutoClose},
+		{name: "mismatched end elements", input: `<a><b><c>text</a>`},
+		{name: "unexpected end element", input: `<a></b></a>`},
+		{name: "unescaped cdata end", input: `<a>]]></a>`},
+		{name: "unescaped cdata end line", input: "<a>\nx\n]]>\n\n</a>"},
+		{name: "unescaped cdata end after section", input: `<a><![CDATA[x]]>]]></a>`},
+		{name: "cdata end in attribute", input: `<a x="]]>">]]</a>`},
+		{name: "empty processing instruction", input: `<a><?></a>`},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			std := xml.NewDecoder(strings.NewReader(test.input))
+			std.Strict, std.AutoClose, std.Entity = test.strict, test.autoClose, test.entity
+
+			dec := NewDecoder(strings.NewReader(test.input))
+			dec.Strict, dec.AutoClose, dec.Entity = test.strict, test.autoClose, test.entity
+
+			for {
+				mustToken, mustErr := std.Token()
+				token, err := dec.Token()
+
+				if mustErr != nil {
+					var syntaxErr *xml.SyntaxError
+					if errors.As(mustErr, &syntaxErr) {
+						require.ErrorAs(t, err, &syntaxErr)
+						assert.Equal(t, mustErr.Error(), err.Error())
+					} else {
+						assert.ErrorIs(t, err, mustErr)
+					}
+
+					break
+				}
+
+				require.NoError(t, err)
+				assert.Equal(t, xml.CopyToken(mustToken), xml.CopyToken(token))
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package fastxml
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Decision tells parser what to do with the element.
+type Decision uint8
+
+const (
+	// Descend returns the element and its content as usual.
+	Descend Decision = iota
+	// SkipSubtree skips the element with all its content, nothing is returned for it.
+	SkipSubtree
+	// StopParsing stops parsing, all following calls to Parser.Next will return io.EOF.
+	StopParsing
+)
+
+// DescendFunc decides what parser should do with the element.
+//
+// start is a copy of the start element, so its attributes can be read
+// without affecting the token that will be returned to the caller.
+// parents holds names of the open parent elements, same as Parser.Stack.
+//
+// Neither start nor parents can be held onto after the function returns.
+type DescendFunc func(start *StartToken, parents []string) Decision
+
+// WithDescendFunc sets function that is called for each start element to decide
+// whether it should be returned, skipped with all its content, or parsing should be stopped.
+//
+// Skipped elements are not decoded at all, parser only scans for the end of the element,
+// which allows to quickly pass over huge irrelevant parts of the document.
+func WithDescendFunc(fn DescendFunc) ParserOption {
+	return func(p *Parser) {
+		p.descendFunc = fn
+	}
+}
+
+// decideDescend calls descend function for the start element.
+//
+// errSkipToken is returned if element must not be returned to the caller.
+func (p *Parser) decideDescend(start *StartToken) error {
+	if p.descendFunc == nil {
+		return nil
+	}
+
+	startCopy := *start
+
+	switch p.descendFunc(&startCopy, p.stack) {
+	case SkipSubtree:
+		if p.lastTagName != "" {
+			// Self-closing element does not have any content to skip.
+			p.lastTagName = ""
+		} else {
+			p.skipDepth = 1
+		}
+
+		return errSkipToken
+	case StopParsing:
+		p.stopped = true
+
+		return io.EOF
+	default:
+		return nil
+	}
+}
+
+// skipSubtree scans tokens without decoding them until skipped element is closed.
+func (p *Parser) skipSubtree() error {
+	for p.skipDepth > 0 {
+		if p.currentPointer >= len(p.buf) {
+			if p.streaming {
+				return ErrNeedMoreData
+			}
+
+			return fmt.Errorf("skip subtree: %w", io.ErrUnexpectedEOF)
+		}
+
+		tokenBytes, err := FetchNextToken(p.buf[p.currentPointer:])
+		if errors.Is(err, ErrNeedMoreData) && !p.streaming && tokenBytes[0] != '<' {
+			// Char data at the end of the document.
+			err = nil
+		}
+
+		if err != nil {
+			if errors.Is(err, ErrNeedMoreData) && p.streaming {
+				return err
+			}
+
+			return fmt.Errorf("skip subtree: %w", err)
+		}
+
+		p.currentPointer += len(tokenBytes)
+
+		switch scannedTokenKind(tokenBytes) {
+		case TokenKindStartElement:
+			if tokenBytes[len(tokenBytes)-2] != '/' {
+				p.skipDepth++
+			}
+		case TokenKindEndElement:
+			p.skipDepth--
+		}
+	}
+
+	return nil
+}
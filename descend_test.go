@@ -0,0 +1,70 @@
+package fastxml
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithDescendFunc(t *testing.T) {
+	input := `<root><skip a="1"><x><y/></x>text<!-- <z> --></skip><keep id="2"><inner/></keep><empty/><stop/><never/></root>`
+
+	var paths []string
+
+	descend := func(start *StartToken, parents []string) Decision {
+		paths = append(paths, strings.Join(append(parents, start.Name), "/"))
+
+		switch start.Name {
+		case "skip", "empty":
+			return SkipSubtree
+		case "stop":
+			return StopParsing
+		default:
+			return Descend
+		}
+	}
+
+	assert.Equal(t, []string{
+		`*fastxml.StartToken: &{"root" ""}`,
+		`*fastxml.StartToken: &{"keep" "id=\"2\">"}`,
+		`*fastxml.StartToken: &{"inner" ""}`,
+		`*fastxml.EndElement: &{{"" "inner"}}`,
+		`*fastxml.EndElement: &{{"" "keep"}}`,
+	}, collectTokens(t, input, WithDescendFunc(descend)))
+
+	assert.Equal(t, []string{"root", "root/skip", "root/keep", "root/keep/inner", "root/empty", "root/stop"}, paths)
+}
+
+func TestWithDescendFunc_Stream(t *testing.T) {
+	p := NewStreamParser(WithDescendFunc(func(start *StartToken, parents []string) Decision {
+		if start.Name == "skip" {
+			return SkipSubtree
+		}
+
+		return Descend
+	}))
+
+	p.Feed([]byte(`<skip><a><b>`))
+
+	_, err := p.Next()
+	require.ErrorIs(t, err, ErrNeedMoreData)
+
+	p.Feed([]byte(`</b></a></skip><keep/>`))
+
+	token, err := p.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "keep", token.(*StartToken).Name)
+}
+
+func TestWithDescendFunc_Truncated(t *testing.T) {
+	p := NewParser([]byte(`<skip><a>`), false, WithDescendFunc(func(start *StartToken, parents []string) Decision {
+		return SkipSubtree
+	}))
+
+	_, err := p.Next()
+	require.True(t, errors.Is(err, io.ErrUnexpectedEOF), err)
+}
@@ -0,0 +1,620 @@
+package fastxml
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var ErrUnsupportedToken = errors.New("unsupported token")
+
+// TokenWriter consumes tokens, same as io.Writer consumes bytes.
+//
+// Implementations MUST NOT hold onto received tokens, as they are usually owned by the parser.
+// This allows to compose processing stages, see Copy.
+type TokenWriter interface {
+	WriteToken(token xml.Token) error
+}
+
+var (
+	_ TokenWriter = TokenHandlerFunc(nil)
+	_ TokenWriter = (*Encoder)(nil)
+)
+
+// WriteToken calls f with the token.
+func (f TokenHandlerFunc) WriteToken(token xml.Token) error {
+	return f(token)
+}
+
+// Copy writes all tokens from src to dst until src is exhausted or error occurs.
+//
+// It returns number of written tokens. Reaching io.EOF is not reported as an error.
+// Nil tokens (which are returned for not decoded constructs, like processing instructions) are not written.
+func Copy(dst TokenWriter, src *Parser) (int, error) {
+	var written int
+
+	for {
+		token, err := src.Next()
+		if errors.Is(err, io.EOF) {
+			return written, nil
+		}
+
+		if err != nil {
+			return written, err
+		}
+
+		if token == nil {
+			continue
+		}
+
+		if err := dst.WriteToken(token); err != nil {
+			return written, err
+		}
+
+		written++
+	}
+}
+
+// Encoder writes tokens as XML.
+//
+// Both tokens of this package(as returned by the parser) and tokens of encoding/xml are accepted.
+// Values of tokens from this package are raw, as they are in the source document,
+// so they are written as is, only escaping characters that are not allowed in their position
+// (for example text of CDATA section is written as char data).
+// Values of encoding/xml tokens are escaped.
+//
+// Name space of encoding/xml tokens is written as a prefix if it is a valid prefix, like "x" in `<x:a>`.
+// Otherwise, like in tokens returned by xml.Decoder.Token, it is a namespace URI:
+// it is written with the prefix declared for it in the enclosing elements or in the token itself,
+// and if there is no such prefix - element gets a default namespace declaration and attribute gets
+// a generated prefix, same as encoding/xml does.
+//
+// Empty elements, including self-closing elements of the parser, are written as start and end elements,
+// like `<a></a>`, unless other style is set with WithEmptyElementStyle.
+//
+// Go values are written with Encoder.Encode.
+//
+// Output is buffered, Encoder.Flush must be called after the last token was written.
+type Encoder struct {
+	w *bufio.Writer
+	// buf holds text of encoded values.
+	buf []byte
+	// cdataElements holds local names of elements, which text is written as CDATA.
+	cdataElements map[string]bool
+	// emptyStyle is the style of empty elements, which are not in emptyStyles.
+	emptyStyle  EmptyElementStyle
+	emptyStyles map[string]EmptyElementStyle
+	// startOpen is set when start element was written without closing '>',
+	// as it may turn out to be empty and be written as self-closing.
+	startOpen bool
+
+	// Indentation, see WithIndent.
+	indenting      bool
+	prefix, indent string
+	// preformatted holds local names of elements, which content is written as is.
+	preformatted map[string]bool
+	// mixed holds for each open element if text was written in it, so its content is not indented.
+	mixed []bool
+	// preserve is the depth of the element, which content is written as is, or 0.
+	preserve int
+	// indentedIn is set if nothing was written into the last started element.
+	indentedIn bool
+	// indented is set after the first indentation, so the next ones start with a new line.
+	indented bool
+	// space holds whitespace-only text, which may be replaced by indentation.
+	space []byte
+
+	// depth is the number of open elements.
+	depth int
+	// namespaces hold namespaces declared in open elements, innermost last.
+	namespaces []encoderNamespace
+	// prefixSeq is used to generate unique prefixes for namespaces of attributes.
+	prefixSeq int
+}
+
+// encoderNamespace is a namespace declared in the open element.
+type encoderNamespace struct {
+	prefix, uri string
+	depth       int
+	// generated is set if the declaration was added by the encoder, and not written from the token.
+	generated bool
+}
+
+// EmptyElementStyle is the form empty elements are written in.
+type EmptyElementStyle uint8
+
+const (
+	// EmptyElementExpanded writes empty elements as start and end elements, like `<a></a>`.
+	EmptyElementExpanded EmptyElementStyle = iota
+	// EmptyElementSelfClosing writes empty elements as self-closing elements, like `<a/>`.
+	EmptyElementSelfClosing
+)
+
+// EncoderOption configures optional behavior of the encoder.
+type EncoderOption func(e *Encoder)
+
+// WithCDATAElements makes encoder write text of elements with given local names as CDATA sections,
+// when Go values are encoded, like for `script` or `html` elements with embedded markup.
+//
+// Text of struct fields with `cdata` tag option, like `xml:",cdata"`, is always written as CDATA.
+func WithCDATAElements(names ...string) EncoderOption {
+	return func(e *Encoder) {
+		if e.cdataElements == nil {
+			e.cdataElements = make(map[string]bool, len(names))
+		}
+
+		for _, name := range names {
+			e.cdataElements[name] = true
+		}
+	}
+}
+
+// WithEmptyElementStyle sets the form empty elements are written in.
+//
+// If names are given - style is set only for elements with these local names,
+// otherwise it is set for all other elements.
+// Element is empty if nothing, including empty text, was written between its start and end.
+func WithEmptyElementStyle(style EmptyElementStyle, names ...string) EncoderOption {
+	return func(e *Encoder) {
+		if len(names) == 0 {
+			e.emptyStyle = style
+
+			return
+		}
+
+		if e.emptyStyles == nil {
+			e.emptyStyles = make(map[string]EmptyElementStyle, len(names))
+		}
+
+		for _, name := range names {
+			e.emptyStyles[name] = style
+		}
+	}
+}
+
+// NewEncoder will create an encoder that writes to w.
+func NewEncoder(w io.Writer, opts ...EncoderOption) *Encoder {
+	e := &Encoder{
+		w: bufio.NewWriter(w),
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e
+}
+
+// WriteToken will write token to the underlying writer.
+//
+// Nil token is ignored. Well-formedness of the output is not verified.
+func (e *Encoder) WriteToken(token xml.Token) error { //nolint:gocyclo,cyclop // Flat switch over token types.
+	switch token.(type) {
+	case nil, *EndElement, xml.EndElement, *CharData, xml.CharData:
+	default:
+		e.closeStart()
+	}
+
+	switch tkn := token.(type) {
+	case nil:
+		return nil
+	case *StartToken:
+		e.writeStartToken(tkn)
+	case *EndElement:
+		e.writeEndElement(xml.EndElement(*tkn))
+	case *CharData:
+		if e.indentText(*tkn, false) {
+			e.closeStart()
+			writeEscapedRaw(e.w, *tkn, false)
+		}
+	case *Comment:
+		e.writeWrapped("<!--", *tkn, "-->")
+	case *Directive:
+		e.writeWrapped("<!", *tkn, ">")
+	case *ProcInst:
+		e.writeProcInst(xml.ProcInst(*tkn))
+	case xml.StartElement:
+		e.writeStartElement(tkn)
+	case xml.EndElement:
+		e.writeEndElement(tkn)
+	case xml.CharData:
+		if e.indentText(tkn, false) {
+			e.closeStart()
+			_ = xml.EscapeText(e.w, tkn) // Error is saved in the buffered writer.
+		}
+	case xml.Comment:
+		e.writeWrapped("<!--", tkn, "-->")
+	case xml.Directive:
+		e.writeWrapped("<!", tkn, ">")
+	case xml.ProcInst:
+		e.writeProcInst(tkn)
+	default:
+		return fmt.Errorf("%w: %T", ErrUnsupportedToken, token)
+	}
+
+	// Write errors of bufio.Writer are sticky, so there is no need to check every write.
+	_, err := e.w.Write(nil)
+
+	return err
+}
+
+// WriteCDATA will write data as CDATA section.
+//
+// Data containing "]]>", which can not appear inside of the section, is split into several sections.
+// Empty data is not written.
+func (e *Encoder) WriteCDATA(data []byte) error {
+	if len(data) != 0 {
+		e.indentText(data, true)
+		e.closeStart()
+	}
+
+	writeCDATA(e.w, data)
+
+	_, err := e.w.Write(nil)
+
+	return err
+}
+
+// Flush will write buffered data to the underlying writer.
+//
+// Element which start was written last is not written as self-closing after flush.
+func (e *Encoder) Flush() error {
+	e.closeStart()
+
+	return e.w.Flush()
+}
+
+// closeStart writes '>' of the start element, if it was not written yet.
+func (e *Encoder) closeStart() {
+	if e.startOpen {
+		e.startOpen = false
+		e.w.WriteByte('>')
+	}
+}
+
+// openStart finishes writing of start element, keeping it open if empty elements may be self-closing.
+func (e *Encoder) openStart() {
+	if e.emptyStyle == EmptyElementSelfClosing || len(e.emptyStyles) != 0 {
+		e.startOpen = true
+	} else {
+		e.w.WriteByte('>')
+	}
+}
+
+// emptyElementStyle returns style of empty element with the name.
+func (e *Encoder) emptyElementStyle(name string) EmptyElementStyle {
+	if style, ok := e.emptyStyles[localName(name)]; ok {
+		return style
+	}
+
+	return e.emptyStyle
+}
+
+func (e *Encoder) writeStartToken(tkn *StartToken) {
+	e.closeStart()
+	e.indentStart(tkn)
+	e.depth++
+	e.w.WriteByte('<')
+	e.w.WriteString(tkn.Name)
+
+	// Attributes are read from the copy to not consume them from the caller's token.
+	attrs := *tkn
+
+	for {
+		name, val, err := attrs.NextAttribute()
+		if err != nil {
+			break
+		}
+
+		e.w.WriteByte(' ')
+		e.w.WriteString(name)
+		e.w.WriteString(`="`)
+		writeEscapedRaw(e.w, []byte(val), true)
+		e.w.WriteByte('"')
+	}
+
+	e.openStart()
+}
+
+func (e *Encoder) writeStartElement(tkn xml.StartElement) {
+	e.closeStart()
+	e.indentStart(tkn)
+	e.depth++
+
+	for _, attr := range tkn.Attr {
+		if prefix, ok := namespacePrefix(attr.Name); ok {
+			e.namespaces = append(e.namespaces, encoderNamespace{prefix: prefix, uri: attr.Value, depth: e.depth})
+		}
+	}
+
+	e.w.WriteByte('<')
+	e.writeElementName(tkn.Name)
+
+	if isNamespaceURI(tkn.Name.Space) && e.elementPrefix(tkn.Name.Space) == "" {
+		if uri, _ := e.defaultNamespace(); uri != tkn.Name.Space {
+			e.declareNamespace("", tkn.Name.Space)
+		}
+	} else if uri, generated := e.defaultNamespace(); tkn.Name.Space == "" && uri != "" && generated {
+		// Default namespace was declared only for the parent, it must not apply to elements without namespace.
+		e.declareNamespace("", "")
+	}
+
+	for _, attr := range tkn.Attr {
+		// Prefix is resolved first, as it may need to be declared before the attribute.
+		prefix := e.attributePrefix(attr.Name.Space)
+
+		e.w.WriteByte(' ')
+
+		if prefix != "" {
+			e.w.WriteString(prefix)
+			e.w.WriteByte(':')
+		}
+
+		e.w.WriteString(attr.Name.Local)
+		e.w.WriteString(`="`)
+		_ = xml.EscapeText(e.w, []byte(attr.Value))
+		e.w.WriteByte('"')
+	}
+
+	e.openStart()
+}
+
+func (e *Encoder) writeEndElement(tkn xml.EndElement) {
+	e.indentEnd()
+
+	if e.startOpen {
+		e.startOpen = false
+
+		if e.emptyElementStyle(tkn.Name.Local) == EmptyElementSelfClosing {
+			e.w.WriteString("/>")
+			e.popNamespaces()
+
+			return
+		}
+
+		e.w.WriteByte('>')
+	}
+
+	e.w.WriteString("</")
+	e.writeElementName(tkn.Name)
+	e.w.WriteByte('>')
+	e.popNamespaces()
+}
+
+// isNamespaceURI reports if name space is a namespace URI, and not a prefix.
+func isNamespaceURI(space string) bool {
+	return strings.ContainsAny(space, ":/")
+}
+
+func (e *Encoder) writeElementName(name xml.Name) {
+	switch {
+	case isNamespaceURI(name.Space):
+		if prefix := e.elementPrefix(name.Space); prefix != "" {
+			e.w.WriteString(prefix)
+			e.w.WriteByte(':')
+		}
+	case name.Space != "":
+		e.w.WriteString(name.Space)
+		e.w.WriteByte(':')
+	}
+
+	e.w.WriteString(name.Local)
+}
+
+// attributePrefix returns prefix of the attribute with the name space, declaring it if needed.
+func (e *Encoder) attributePrefix(space string) string {
+	switch {
+	case space == xmlURL:
+		return xmlPrefix
+	case isNamespaceURI(space):
+		prefix := e.elementPrefix(space)
+		if prefix == "" {
+			prefix = e.generatePrefix(space)
+			e.declareNamespace(prefix, space)
+		}
+
+		return prefix
+	default:
+		return space
+	}
+}
+
+// elementPrefix returns non-empty prefix that is bound to uri in the current element, if any.
+func (e *Encoder) elementPrefix(uri string) string {
+	for i := len(e.namespaces) - 1; i >= 0; i-- {
+		ns := e.namespaces[i]
+		if ns.uri == uri && ns.prefix != "" && e.boundPrefix(ns.prefix, i) {
+			return ns.prefix
+		}
+	}
+
+	return ""
+}
+
+// boundPrefix reports if prefix declared by the namespace at idx is not redeclared by inner elements.
+func (e *Encoder) boundPrefix(prefix string, idx int) bool {
+	for _, ns := range e.namespaces[idx+1:] {
+		if ns.prefix == prefix {
+			return false
+		}
+	}
+
+	return true
+}
+
+// defaultNamespace returns default namespace of the current element.
+func (e *Encoder) defaultNamespace() (uri string, generated bool) {
+	for i := len(e.namespaces) - 1; i >= 0; i-- {
+		if ns := e.namespaces[i]; ns.prefix == "" {
+			return ns.uri, ns.generated
+		}
+	}
+
+	return "", false
+}
+
+// generatePrefix returns unused prefix for uri, which is derived from its last path segment, like encoding/xml does.
+func (e *Encoder) generatePrefix(uri string) string {
+	prefix := strings.TrimRight(uri, "/")
+	if idx := strings.LastIndexByte(prefix, '/'); idx != -1 {
+		prefix = prefix[idx+1:]
+	}
+
+	if !isName(prefix) || strings.Contains(prefix, ":") {
+		prefix = "_"
+	}
+
+	if strings.HasPrefix(strings.ToLower(prefix), xmlPrefix) {
+		prefix = "_" + prefix
+	}
+
+	for candidate := prefix; ; candidate = prefix + strconv.Itoa(e.prefixSeq) {
+		if !e.prefixDeclared(candidate) {
+			return candidate
+		}
+
+		e.prefixSeq++
+	}
+}
+
+// prefixDeclared reports if prefix is declared in any of the open elements.
+func (e *Encoder) prefixDeclared(prefix string) bool {
+	for _, ns := range e.namespaces {
+		if ns.prefix == prefix {
+			return true
+		}
+	}
+
+	return false
+}
+
+// declareNamespace writes declaration of the namespace for the current element.
+func (e *Encoder) declareNamespace(prefix, uri string) {
+	e.namespaces = append(e.namespaces, encoderNamespace{prefix: prefix, uri: uri, depth: e.depth, generated: true})
+
+	e.w.WriteString(" xmlns")
+
+	if prefix != "" {
+		e.w.WriteByte(':')
+		e.w.WriteString(prefix)
+	}
+
+	e.w.WriteString(`="`)
+	_ = xml.EscapeText(e.w, []byte(uri))
+	e.w.WriteByte('"')
+}
+
+// popNamespaces removes namespaces declared by the element that was just closed.
+func (e *Encoder) popNamespaces() {
+	for len(e.namespaces) != 0 && e.namespaces[len(e.namespaces)-1].depth >= e.depth {
+		e.namespaces = e.namespaces[:len(e.namespaces)-1]
+	}
+
+	if e.depth > 0 {
+		e.depth--
+	}
+}
+
+func (e *Encoder) writeProcInst(tkn xml.ProcInst) {
+	e.indentItem()
+	e.w.WriteString("<?")
+	e.w.WriteString(tkn.Target)
+
+	if len(tkn.Inst) != 0 {
+		e.w.WriteByte(' ')
+		e.w.Write(tkn.Inst)
+	}
+
+	e.w.WriteString("?>")
+}
+
+func (e *Encoder) writeWrapped(prefix string, data []byte, suffix string) {
+	e.closeStart()
+	e.indentItem()
+	e.w.WriteString(prefix)
+	e.w.Write(data)
+	e.w.WriteString(suffix)
+}
+
+// writeCDATA writes data as CDATA sections, splitting it around "]]>".
+func writeCDATA(w *bufio.Writer, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+
+	w.Write(cdataPrefix)
+
+	for {
+		idx := bytes.Index(data, cdataSuffix)
+		if idx == -1 {
+			break
+		}
+
+		// "]]" ends the current section, and ">" starts the next one.
+		w.Write(data[:idx+2])
+		w.WriteString("]]><![CDATA[")
+
+		data = data[idx+2:]
+	}
+
+	w.Write(data)
+	w.Write(cdataSuffix)
+}
+
+// writeEscapedRaw writes raw value, escaping only characters that cannot appear in it as is.
+//
+// References in the value are kept, while '&' that does not start a reference is escaped.
+// If quoted is set - value is a part of double quoted attribute, so quotes are escaped too.
+func writeEscapedRaw(w *bufio.Writer, data []byte, quoted bool) {
+	for len(data) != 0 {
+		idx := bytes.IndexAny(data, `<&"`)
+		if idx == -1 {
+			w.Write(data)
+
+			return
+		}
+
+		w.Write(data[:idx])
+
+		switch data[idx] {
+		case '<':
+			w.WriteString("&lt;")
+		case '&':
+			if isReference(data[idx:]) {
+				w.WriteByte('&')
+			} else {
+				w.WriteString("&amp;")
+			}
+		case '"':
+			if quoted {
+				w.WriteString("&quot;")
+			} else {
+				w.WriteByte('"')
+			}
+		}
+
+		data = data[idx+1:]
+	}
+}
+
+// isReference reports if data starts with entity or character reference.
+func isReference(data []byte) bool {
+	semicolonIdx := bytes.IndexByte(data, ';')
+	if semicolonIdx < 2 {
+		return false
+	}
+
+	for _, b := range data[1:semicolonIdx] {
+		if !isNameChar(rune(b)) && b != '#' && b < utf8.RuneSelf {
+			return false
+		}
+	}
+
+	return true
+}
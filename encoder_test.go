@@ -0,0 +1,282 @@
+package fastxml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopy_Encoder(t *testing.T) {
+	tests := []struct {
+		input  string
+		result string
+	}{
+		{`<a x="1" y='"2"'>text &amp; <b/></a>`, `<a x="1" y="&quot;2&quot;">text &amp; <b></b></a>`},
+		{`<a><!-- comment --><![CDATA[<x> & y]]></a>`, `<a><!-- comment -->&lt;x> &amp; y</a>`},
+		{`<?xml version="1.0"?><a>&#65;&custom;</a>`, `<a>&#65;&custom;</a>`},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.input, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			enc := NewEncoder(&buf)
+
+			_, err := Copy(enc, NewParser([]byte(test.input), false))
+			require.NoError(t, err)
+			require.NoError(t, enc.Flush())
+
+			assert.Equal(t, test.result, buf.String())
+		})
+	}
+}
+
+func TestEncoder_StdTokens(t *testing.T) {
+	var buf bytes.Buffer
+
+	enc := NewEncoder(&buf)
+
+	tokens := []xml.Token{
+		xml.ProcInst{Target: "xml", Inst: []byte(`version="1.0"`)},
+		xml.StartElement{Name: xml.Name{Space: "x", Local: "a"}, Attr: []xml.Attr{{Name: xml.Name{Local: "v"}, Value: `"1" & 2`}}},
+		xml.CharData("a < b"),
+		xml.Comment(" c "),
+		xml.EndElement{Name: xml.Name{Space: "x", Local: "a"}},
+	}
+
+	for _, token := range tokens {
+		require.NoError(t, enc.WriteToken(token))
+	}
+
+	require.NoError(t, enc.Flush())
+
+	assert.Equal(t, `<?xml version="1.0"?><x:a v="&#34;1&#34; &amp; 2">a &lt; b<!-- c --></x:a>`, buf.String())
+	assert.ErrorIs(t, enc.WriteToken(42), ErrUnsupportedToken)
+}
+
+func TestEncoder_Namespaces(t *testing.T) {
+	tests := []struct {
+		input  string
+		result string
+	}{
+		{
+			`<a xmlns="urn:a" xmlns:b="urn:b" b:x="1"><b:c/><d/></a>`,
+			`<a xmlns="urn:a" xmlns:b="urn:b" b:x="1"><b:c></b:c><d></d></a>`,
+		},
+		{
+			`<x:a xmlns:x="urn:x" x:y="2" xml:lang="en"><b/></x:a>`,
+			`<x:a xmlns:x="urn:x" x:y="2" xml:lang="en"><b></b></x:a>`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.input, func(t *testing.T) {
+			for name, next := range map[string]func() (xml.Token, error){
+				"encoding/xml": xml.NewDecoder(strings.NewReader(test.input)).Token,
+				"fastxml":      NewBytesDecoder([]byte(test.input)).Token,
+			} {
+				var buf bytes.Buffer
+
+				enc := NewEncoder(&buf)
+
+				for {
+					token, err := next()
+					if errors.Is(err, io.EOF) {
+						break
+					}
+
+					require.NoError(t, err)
+					require.NoError(t, enc.WriteToken(token))
+				}
+
+				require.NoError(t, enc.Flush())
+				assert.Equal(t, test.result, buf.String(), name)
+			}
+		})
+	}
+}
+
+func TestEncoder_GeneratedNamespaces(t *testing.T) {
+	var buf bytes.Buffer
+
+	enc := NewEncoder(&buf)
+
+	tokens := []xml.Token{
+		xml.StartElement{Name: xml.Name{Space: "urn:a:b", Local: "a"}, Attr: []xml.Attr{
+			{Name: xml.Name{Space: "http://example.com/ns/c", Local: "v"}, Value: "1"},
+			{Name: xml.Name{Space: "urn:q", Local: "w"}, Value: "2"},
+		}},
+		xml.StartElement{Name: xml.Name{Local: "b"}, Attr: []xml.Attr{{Name: xml.Name{Space: "urn:q", Local: "w"}, Value: "3"}}},
+		xml.EndElement{Name: xml.Name{Local: "b"}},
+		xml.StartElement{Name: xml.Name{Space: "urn:a:b", Local: "c"}},
+		xml.EndElement{Name: xml.Name{Space: "urn:a:b", Local: "c"}},
+		xml.EndElement{Name: xml.Name{Space: "urn:a:b", Local: "a"}},
+		xml.StartElement{Name: xml.Name{Space: "urn:a:b", Local: "d"}},
+		xml.EndElement{Name: xml.Name{Space: "urn:a:b", Local: "d"}},
+	}
+
+	for _, token := range tokens {
+		require.NoError(t, enc.WriteToken(token))
+	}
+
+	require.NoError(t, enc.Flush())
+
+	assert.Equal(t, `<a xmlns="urn:a:b" xmlns:c="http://example.com/ns/c" c:v="1" xmlns:_="urn:q" _:w="2">`+
+		`<b xmlns="" _:w="3"></b><c></c></a><d xmlns="urn:a:b"></d>`, buf.String())
+}
+
+func TestCopy_Error(t *testing.T) {
+	errStop := errors.New("stop")
+
+	var names []string
+
+	written, err := Copy(TokenHandlerFunc(func(token xml.Token) error {
+		if start, ok := token.(*StartToken); ok {
+			if start.Name == "c" {
+				return errStop
+			}
+
+			names = append(names, start.Name)
+		}
+
+		return nil
+	}), NewParser([]byte(`<a><b/><c/></a>`), false))
+
+	assert.ErrorIs(t, err, errStop)
+	assert.Equal(t, 3, written)
+	assert.Equal(t, []string{"a", "b"}, names)
+}
+
+func TestEncoder_WriteCDATA(t *testing.T) {
+	tests := []struct {
+		input  string
+		result string
+	}{
+		{"", ""},
+		{"<b>x & y</b>", "<![CDATA[<b>x & y</b>]]>"},
+		{"a]]>b", "<![CDATA[a]]]]><![CDATA[>b]]>"},
+		{"]]>]]>", "<![CDATA[]]]]><![CDATA[>]]]]><![CDATA[>]]>"},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.input, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			enc := NewEncoder(&buf)
+
+			require.NoError(t, enc.WriteCDATA([]byte(test.input)))
+			require.NoError(t, enc.Flush())
+			assert.Equal(t, test.result, buf.String())
+
+			if test.input == "" {
+				return
+			}
+
+			// Sections must be read back as the original text.
+			var text []byte
+
+			p := NewParser([]byte("<a>"+buf.String()+"</a>"), false)
+
+			for {
+				token, err := p.Next()
+				if err != nil {
+					break
+				}
+
+				if charData, ok := token.(*CharData); ok {
+					text = append(text, *charData...)
+				}
+			}
+
+			assert.Equal(t, test.input, string(text))
+		})
+	}
+}
+
+func TestWithEmptyElementStyle(t *testing.T) {
+	const input = `<a><b/><c></c><d>x</d><e><!--c--></e><f x="1"/><p:g/></a>`
+
+	tests := []struct {
+		name   string
+		opts   []EncoderOption
+		result string
+	}{
+		{
+			name:   "default",
+			result: `<a><b></b><c></c><d>x</d><e><!--c--></e><f x="1"></f><p:g></p:g></a>`,
+		},
+		{
+			name:   "self-closing",
+			opts:   []EncoderOption{WithEmptyElementStyle(EmptyElementSelfClosing)},
+			result: `<a><b/><c/><d>x</d><e><!--c--></e><f x="1"/><p:g/></a>`,
+		},
+		{
+			name:   "self-closing for some elements",
+			opts:   []EncoderOption{WithEmptyElementStyle(EmptyElementSelfClosing, "b", "g")},
+			result: `<a><b/><c></c><d>x</d><e><!--c--></e><f x="1"></f><p:g/></a>`,
+		},
+		{
+			name: "expanded for some elements",
+			opts: []EncoderOption{
+				WithEmptyElementStyle(EmptyElementSelfClosing),
+				WithEmptyElementStyle(EmptyElementExpanded, "c"),
+			},
+			result: `<a><b/><c></c><d>x</d><e><!--c--></e><f x="1"/><p:g/></a>`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			enc := NewEncoder(&buf, test.opts...)
+
+			_, err := Copy(enc, NewParser([]byte(input), false))
+			require.NoError(t, err)
+			require.NoError(t, enc.Flush())
+
+			assert.Equal(t, test.result, buf.String())
+		})
+	}
+
+	t.Run("values", func(t *testing.T) {
+		type doc struct {
+			XMLName xml.Name `xml:"doc"`
+			Empty   string   `xml:"empty"`
+			Text    string   `xml:"text"`
+			Inner   struct {
+				ID   string `xml:"id,attr"`
+				Text string `xml:",chardata"`
+			} `xml:"inner"`
+		}
+
+		var buf bytes.Buffer
+
+		enc := NewEncoder(&buf, WithEmptyElementStyle(EmptyElementSelfClosing))
+
+		value := doc{Text: "t"}
+		value.Inner.ID = "1"
+
+		require.NoError(t, enc.Encode(value))
+		require.NoError(t, enc.WriteToken(xml.StartElement{Name: xml.Name{Local: "flushed"}}))
+		require.NoError(t, enc.Flush())
+		require.NoError(t, enc.WriteToken(xml.EndElement{Name: xml.Name{Local: "flushed"}}))
+		require.NoError(t, enc.Flush())
+
+		assert.Equal(t, `<doc><empty/><text>t</text><inner id="1"/></doc><flushed></flushed>`, buf.String())
+	})
+}
@@ -0,0 +1,140 @@
+package fastxml
+
+import (
+	"io"
+	"strconv"
+	"strings"
+)
+
+var predefinedEntities = map[string]string{
+	"amp":  "&",
+	"lt":   "<",
+	"gt":   ">",
+	"quot": `"`,
+	"apos": "'",
+}
+
+// Unescape will replace predefined entities and character references in the value.
+//
+// Parser does not expand entities, so this can be used on attribute values
+// and char data when expanded values are needed.
+// Unknown or malformed references are left as is.
+func Unescape(val string) string {
+	ampIdx := strings.IndexByte(val, '&')
+	if ampIdx == -1 {
+		return val
+	}
+
+	var sb strings.Builder
+
+	sb.Grow(len(val))
+
+	for ampIdx != -1 {
+		sb.WriteString(val[:ampIdx])
+		val = val[ampIdx:]
+
+		semicolonIdx := strings.IndexByte(val, ';')
+		if semicolonIdx == -1 {
+			break
+		}
+
+		if replacement, ok := decodeReference(val[1:semicolonIdx]); ok {
+			sb.WriteString(replacement)
+		} else {
+			sb.WriteString(val[:semicolonIdx+1])
+		}
+
+		val = val[semicolonIdx+1:]
+		ampIdx = strings.IndexByte(val, '&')
+	}
+
+	sb.WriteString(val)
+
+	return sb.String()
+}
+
+// UnescapeReader returns reader of the value with references replaced as they are read, same as with Unescape.
+//
+// This allows to process huge values, like base64 encoded data in attributes,
+// without creating their unescaped copies. Reader points to the memory of val.
+func UnescapeReader(val string) io.Reader {
+	return &unescapeReader{val: val}
+}
+
+type unescapeReader struct {
+	val string
+	// pending holds the rest of the replacement, which did not fit into the previous read.
+	pending string
+}
+
+func (r *unescapeReader) Read(p []byte) (int, error) {
+	var n int
+
+	for n < len(p) {
+		if r.pending != "" {
+			copied := copy(p[n:], r.pending)
+			r.pending = r.pending[copied:]
+			n += copied
+
+			continue
+		}
+
+		if r.val == "" {
+			break
+		}
+
+		ampIdx := strings.IndexByte(r.val, '&')
+
+		switch ampIdx {
+		case -1:
+			ampIdx = len(r.val)
+		case 0:
+			semicolonIdx := strings.IndexByte(r.val, ';')
+			if semicolonIdx == -1 {
+				r.pending, r.val = r.val, ""
+
+				continue
+			}
+
+			if replacement, ok := decodeReference(r.val[1:semicolonIdx]); ok {
+				r.pending = replacement
+			} else {
+				r.pending = r.val[:semicolonIdx+1]
+			}
+
+			r.val = r.val[semicolonIdx+1:]
+
+			continue
+		}
+
+		copied := copy(p[n:], r.val[:ampIdx])
+		r.val = r.val[copied:]
+		n += copied
+	}
+
+	if n == 0 && len(p) != 0 {
+		return 0, io.EOF
+	}
+
+	return n, nil
+}
+
+func decodeReference(ref string) (string, bool) {
+	if !strings.HasPrefix(ref, "#") {
+		replacement, ok := predefinedEntities[ref]
+
+		return replacement, ok
+	}
+
+	base, digits := 10, ref[1:]
+	if strings.HasPrefix(digits, "x") {
+		base, digits = 16, digits[1:]
+	}
+
+	code, err := strconv.ParseUint(digits, base, 32)
+	if err != nil {
+		return "", false
+	}
+
+	return string(rune(code)), true
+}
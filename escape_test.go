@@ -0,0 +1,41 @@
+package fastxml
+
+import (
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnescape(t *testing.T) {
+	tests := []struct {
+		input  string
+		result string
+	}{
+		{"no entities", "no entities"},
+		{"a&amp;b&lt;&gt;&quot;&apos;", `a&b<>"'`},
+		{"&#65;&#x42;", "AB"},
+		{"&unknown; &amp", "&unknown; &amp"},
+		{"&#x1F600;&#128512;", "\U0001F600\U0001F600"},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.input, func(t *testing.T) {
+			assert.Equal(t, test.result, Unescape(test.input))
+
+			// Reader must give the same result, even if replacements are read partially.
+			data, err := io.ReadAll(iotest.OneByteReader(UnescapeReader(test.input)))
+			require.NoError(t, err)
+			assert.Equal(t, test.result, string(data))
+
+			data, err = io.ReadAll(UnescapeReader(test.input))
+			require.NoError(t, err)
+			assert.Equal(t, test.result, string(data))
+		})
+	}
+}
@@ -0,0 +1,749 @@
+/*
+Package fastinfoset decodes Fast Infoset (ITU-T X.891) documents into tokens of fastxml package.
+
+Fast Infoset is a binary encoding of XML infoset, in which names and repeated values
+are replaced with indexes into vocabulary tables, that are built while document is decoded.
+
+Only documents that are self-contained are supported: external vocabularies, restricted alphabets,
+encoding algorithms, notations, unparsed entities and entity references are reported with ErrUnsupported.
+Document type declarations are skipped, as they do not record the name of the root element.
+
+Returned tokens are the same as the ones returned by fastxml.Parser: values are raw
+(escaped as they would be in XML document), so they can be passed to any fastxml machinery.
+*/
+package fastinfoset
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf16"
+
+	"fastxml"
+)
+
+var (
+	ErrInvalidDocument = errors.New("invalid document")
+	ErrUnsupported     = errors.New("unsupported feature")
+)
+
+// header identifies Fast Infoset document of version 1.
+var header = []byte{0xE0, 0x00, 0x00, 0x01}
+
+// Optional components of the document.
+const (
+	documentAdditionalData     = 0x40
+	documentInitialVocabulary  = 0x20
+	documentNotations          = 0x10
+	documentUnparsedEntities   = 0x08
+	documentCharEncodingScheme = 0x04
+	documentStandalone         = 0x02
+	documentVersion            = 0x01
+)
+
+// Octets that start items.
+const (
+	elementAttributesFlag = 0x40
+	elementNamespacesFlag = 0x38
+	literalQNameFlag      = 0x3C
+	attrLiteralQNameFlag  = 0x78
+	namespaceAttribute    = 0xCC
+	prefixFlag            = 0x02
+	namespaceNameFlag     = 0x01
+	charChunk             = 0x80
+	charChunkIndexFlag    = 0x20
+	charChunkAddFlag      = 0x10
+	entityReference       = 0xC8
+	docTypeDeclaration    = 0xC4
+	docTypeSystemIDFlag   = 0x02
+	docTypePublicIDFlag   = 0x01
+	processingInstruction = 0xE1
+	comment               = 0xE2
+	terminator            = 0xF0
+	doubleTerminator      = 0xFF
+)
+
+// Encoding formats of literal strings, for strings starting on the first bit.
+// For character chunks they are shifted right by 2 bits.
+const (
+	formatUTF8  = 0x00
+	formatUTF16 = 0x10
+)
+
+const (
+	xmlPrefix    = "xml"
+	xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+)
+
+type qname struct {
+	prefix, local string
+}
+
+func (q qname) String() string {
+	if q.prefix == "" {
+		return q.local
+	}
+
+	return q.prefix + ":" + q.local
+}
+
+// Decoder returns tokens of Fast Infoset document.
+//
+// Same as for fastxml.Parser, returned tokens are reused between calls to Decoder.Next,
+// see fastxml.CopyToken.
+type Decoder struct {
+	buf    []byte
+	offset int
+
+	// Vocabulary tables, indexed from 0.
+	prefixes, namespaces, localNames      []string
+	otherNCNames, otherURIs, otherStrings []string
+	attrValues, charChunks                []string
+	elementNames, attrNames               []qname
+
+	// Standalone is set if document declared to be standalone.
+	Standalone bool
+	// Version is the XML version declared by the document.
+	Version string
+
+	// stack holds names of open elements.
+	stack []string
+	// pendingEnds is the number of terminations that were read, but end elements were not returned yet.
+	pendingEnds int
+	ended       bool
+
+	charData   fastxml.CharData
+	comment    fastxml.Comment
+	endElement fastxml.EndElement
+	procInst   fastxml.ProcInst
+}
+
+// NewDecoder will create a decoder of the document in buf, reading its header.
+//
+// Document can start with XML declaration, as allowed by the specification.
+func NewDecoder(buf []byte) (*Decoder, error) {
+	d := &Decoder{
+		buf:        buf,
+		prefixes:   []string{xmlPrefix},
+		namespaces: []string{xmlNamespace},
+	}
+
+	if err := d.readHeader(); err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+
+	return d, nil
+}
+
+func (d *Decoder) readHeader() error {
+	if bytes.HasPrefix(d.buf, []byte("<?xml")) {
+		end := bytes.Index(d.buf, []byte("?>"))
+		if end == -1 {
+			return fmt.Errorf("%w: XML declaration is not closed", ErrInvalidDocument)
+		}
+
+		d.offset = end + 2
+	}
+
+	if !bytes.HasPrefix(d.buf[d.offset:], header) {
+		return fmt.Errorf("%w: unknown header", ErrInvalidDocument)
+	}
+
+	d.offset += len(header)
+
+	flags, err := d.readByte()
+	if err != nil {
+		return err
+	}
+
+	const unsupported = documentAdditionalData | documentInitialVocabulary | documentNotations |
+		documentUnparsedEntities | documentCharEncodingScheme
+	if flags&unsupported != 0 || flags&0x80 != 0 {
+		return fmt.Errorf("%w: optional document components 0x%02X", ErrUnsupported, flags)
+	}
+
+	if flags&documentStandalone != 0 {
+		standalone, err := d.readByte()
+		if err != nil {
+			return err
+		}
+
+		d.Standalone = standalone == 0x01
+	}
+
+	if flags&documentVersion != 0 {
+		if d.Version, err = d.readNonIdentifyingString(&d.otherStrings); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Next returns next token of the document, or io.EOF when the document was terminated.
+func (d *Decoder) Next() (xml.Token, error) {
+	for {
+		if d.pendingEnds > 0 {
+			d.pendingEnds--
+
+			if len(d.stack) != 0 {
+				return d.popElement(), nil
+			}
+
+			d.ended = true
+		}
+
+		if d.ended {
+			return nil, io.EOF
+		}
+
+		b, err := d.readByte()
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case b&0x80 == 0:
+			return d.readElement(b)
+		case b&0xC0 == charChunk:
+			if len(d.stack) == 0 {
+				return nil, fmt.Errorf("%w: character data outside of root element", ErrInvalidDocument)
+			}
+
+			return d.readCharChunk(b)
+		case b == processingInstruction:
+			return d.readProcInst()
+		case b == comment:
+			text, err := d.readNonIdentifyingString(&d.otherStrings)
+			if err != nil {
+				return nil, err
+			}
+
+			d.comment = append(d.comment[:0], text...)
+
+			return &d.comment, nil
+		case b&0xFC == docTypeDeclaration:
+			if err := d.skipDocTypeDeclaration(b); err != nil {
+				return nil, err
+			}
+		case b == entityReference:
+			return nil, fmt.Errorf("%w: unexpanded entity reference", ErrUnsupported)
+		case b == terminator:
+			d.pendingEnds = 1
+		case b == doubleTerminator:
+			d.pendingEnds = 2
+		default:
+			return nil, fmt.Errorf("%w: unexpected octet 0x%02X", ErrInvalidDocument, b)
+		}
+	}
+}
+
+func (d *Decoder) readElement(b byte) (xml.Token, error) {
+	hasAttributes := b&elementAttributesFlag != 0
+
+	var (
+		attrs []xml.Attr
+		err   error
+	)
+
+	if b&0x3F == elementNamespacesFlag {
+		if attrs, err = d.readNamespaceAttributes(); err != nil {
+			return nil, err
+		}
+
+		// Qualified name starts on the third bit of the next octet.
+		if b, err = d.readByte(); err != nil {
+			return nil, err
+		}
+	}
+
+	name, err := d.readQName(b, false, &d.elementNames)
+	if err != nil {
+		return nil, err
+	}
+
+	if hasAttributes {
+		var endsElement bool
+
+		if attrs, endsElement, err = d.readAttributes(attrs); err != nil {
+			return nil, err
+		}
+
+		if endsElement {
+			d.pendingEnds = 1
+		}
+	}
+
+	d.stack = append(d.stack, name.String())
+
+	return fastxml.NewStartToken(name.String(), attrs...), nil
+}
+
+func (d *Decoder) readNamespaceAttributes() ([]xml.Attr, error) {
+	var attrs []xml.Attr
+
+	for {
+		b, err := d.readByte()
+		if err != nil {
+			return nil, err
+		}
+
+		if b == terminator {
+			return attrs, nil
+		}
+
+		if b&0xFC != namespaceAttribute {
+			return nil, fmt.Errorf("%w: unexpected octet 0x%02X in namespace attributes", ErrInvalidDocument, b)
+		}
+
+		attr := xml.Attr{Name: xml.Name{Local: "xmlns"}}
+
+		if b&prefixFlag != 0 {
+			prefix, err := d.readIdentifyingString(&d.prefixes)
+			if err != nil {
+				return nil, err
+			}
+
+			attr.Name = xml.Name{Space: "xmlns", Local: prefix}
+		}
+
+		if b&namespaceNameFlag != 0 {
+			if attr.Value, err = d.readIdentifyingString(&d.namespaces); err != nil {
+				return nil, err
+			}
+		}
+
+		attrs = append(attrs, attr)
+	}
+}
+
+// readAttributes reads attributes till the terminator, reporting if element was terminated as well.
+func (d *Decoder) readAttributes(attrs []xml.Attr) ([]xml.Attr, bool, error) {
+	for {
+		b, err := d.readByte()
+		if err != nil {
+			return nil, false, err
+		}
+
+		switch {
+		case b == terminator:
+			return attrs, false, nil
+		case b == doubleTerminator:
+			return attrs, true, nil
+		case b&0x80 != 0:
+			return nil, false, fmt.Errorf("%w: unexpected octet 0x%02X in attributes", ErrInvalidDocument, b)
+		}
+
+		name, err := d.readQName(b, true, &d.attrNames)
+		if err != nil {
+			return nil, false, err
+		}
+
+		value, err := d.readNonIdentifyingString(&d.attrValues)
+		if err != nil {
+			return nil, false, err
+		}
+
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Space: name.prefix, Local: name.local}, Value: value})
+	}
+}
+
+// readQName reads qualified name of the element (starting on the third bit)
+// or of the attribute (starting on the second bit).
+func (d *Decoder) readQName(b byte, isAttr bool, table *[]qname) (qname, error) {
+	literalMask, literalFlag := byte(0x3C), byte(literalQNameFlag)
+	if isAttr {
+		literalMask, literalFlag = 0x7C, attrLiteralQNameFlag
+	}
+
+	if b&literalMask != literalFlag {
+		var (
+			idx int
+			err error
+		)
+
+		if isAttr {
+			idx, err = d.readIntegerOnSecondBit(b)
+		} else {
+			idx, err = d.readIntegerOnThirdBit(b)
+		}
+
+		if err != nil {
+			return qname{}, err
+		}
+
+		if idx >= len(*table) {
+			return qname{}, fmt.Errorf("%w: name index %d is out of range", ErrInvalidDocument, idx)
+		}
+
+		return (*table)[idx], nil
+	}
+
+	var (
+		name qname
+		err  error
+	)
+
+	if b&prefixFlag != 0 {
+		if name.prefix, err = d.readIdentifyingString(&d.prefixes); err != nil {
+			return qname{}, err
+		}
+	}
+
+	if b&namespaceNameFlag != 0 {
+		// Namespace is declared with namespace attributes, so name is only checked here.
+		if _, err = d.readIdentifyingString(&d.namespaces); err != nil {
+			return qname{}, err
+		}
+	}
+
+	if name.local, err = d.readIdentifyingString(&d.localNames); err != nil {
+		return qname{}, err
+	}
+
+	*table = append(*table, name)
+
+	return name, nil
+}
+
+func (d *Decoder) readCharChunk(b byte) (xml.Token, error) {
+	var text string
+
+	if b&charChunkIndexFlag != 0 {
+		idx, err := d.readIntegerOnFourthBit(b)
+		if err != nil {
+			return nil, err
+		}
+
+		if text, err = tableString(d.charChunks, idx); err != nil {
+			return nil, err
+		}
+	} else {
+		length, err := d.readLengthOnSeventhBit(b)
+		if err != nil {
+			return nil, err
+		}
+
+		if text, err = d.readEncodedString((b&0x0C)<<2, length); err != nil {
+			return nil, err
+		}
+
+		if b&charChunkAddFlag != 0 {
+			d.charChunks = append(d.charChunks, text)
+		}
+	}
+
+	d.charData = appendEscaped(d.charData[:0], text)
+
+	return &d.charData, nil
+}
+
+func (d *Decoder) readProcInst() (xml.Token, error) {
+	target, err := d.readIdentifyingString(&d.otherNCNames)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := d.readNonIdentifyingString(&d.otherStrings)
+	if err != nil {
+		return nil, err
+	}
+
+	d.procInst = fastxml.ProcInst{Target: target, Inst: []byte(content)}
+
+	return &d.procInst, nil
+}
+
+func (d *Decoder) skipDocTypeDeclaration(b byte) error {
+	if b&docTypeSystemIDFlag != 0 {
+		if _, err := d.readIdentifyingString(&d.otherURIs); err != nil {
+			return err
+		}
+	}
+
+	if b&docTypePublicIDFlag != 0 {
+		if _, err := d.readIdentifyingString(&d.otherURIs); err != nil {
+			return err
+		}
+	}
+
+	// Declaration can contain processing instructions.
+	for {
+		b, err := d.readByte()
+		if err != nil {
+			return err
+		}
+
+		switch b {
+		case terminator:
+			return nil
+		case processingInstruction:
+			if _, err := d.readProcInst(); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%w: unexpected octet 0x%02X in document type declaration", ErrInvalidDocument, b)
+		}
+	}
+}
+
+// readIdentifyingString reads literal string, that is added to the table, or index into the table.
+func (d *Decoder) readIdentifyingString(table *[]string) (string, error) {
+	b, err := d.readByte()
+	if err != nil {
+		return "", err
+	}
+
+	if b&0x80 != 0 {
+		idx, err := d.readIntegerOnSecondBit(b)
+		if err != nil {
+			return "", err
+		}
+
+		return tableString(*table, idx)
+	}
+
+	length, err := d.readLengthOnSecondBit(b)
+	if err != nil {
+		return "", err
+	}
+
+	text, err := d.readN(length)
+	if err != nil {
+		return "", err
+	}
+
+	*table = append(*table, string(text))
+
+	return string(text), nil
+}
+
+// readNonIdentifyingString reads literal string, that is optionally added to the table, or index into the table.
+func (d *Decoder) readNonIdentifyingString(table *[]string) (string, error) {
+	b, err := d.readByte()
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case b == 0xFF:
+		return "", nil
+	case b&0x80 != 0:
+		idx, err := d.readIntegerOnSecondBit(b)
+		if err != nil {
+			return "", err
+		}
+
+		return tableString(*table, idx)
+	}
+
+	length, err := d.readLengthOnFifthBit(b)
+	if err != nil {
+		return "", err
+	}
+
+	text, err := d.readEncodedString(b&0x30, length)
+	if err != nil {
+		return "", err
+	}
+
+	if b&0x40 != 0 {
+		*table = append(*table, text)
+	}
+
+	return text, nil
+}
+
+func (d *Decoder) readEncodedString(format byte, length int) (string, error) {
+	data, err := d.readN(length)
+	if err != nil {
+		return "", err
+	}
+
+	switch format {
+	case formatUTF8:
+		return string(data), nil
+	case formatUTF16:
+		if len(data)%2 != 0 {
+			return "", fmt.Errorf("%w: odd length of UTF-16 string", ErrInvalidDocument)
+		}
+
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			units[i] = binary.BigEndian.Uint16(data[2*i:])
+		}
+
+		return string(utf16.Decode(units)), nil
+	default:
+		return "", fmt.Errorf("%w: restricted alphabets and encoding algorithms", ErrUnsupported)
+	}
+}
+
+func (d *Decoder) readIntegerOnSecondBit(b byte) (int, error) {
+	switch {
+	case b&0x40 == 0:
+		return int(b & 0x3F), nil
+	case b&0x60 == 0x40:
+		n, err := d.readUint(1)
+
+		return int(b&0x1F)<<8 | n + 64, err
+	case b&0x70 == 0x60:
+		n, err := d.readUint(2)
+
+		return int(b&0x0F)<<16 | n + 8256, err
+	default:
+		return 0, fmt.Errorf("%w: malformed integer 0x%02X", ErrInvalidDocument, b)
+	}
+}
+
+func (d *Decoder) readIntegerOnThirdBit(b byte) (int, error) {
+	switch {
+	case b&0x20 == 0:
+		return int(b & 0x1F), nil
+	case b&0x38 == 0x20:
+		n, err := d.readUint(1)
+
+		return int(b&0x07)<<8 | n + 32, err
+	case b&0x38 == 0x28:
+		n, err := d.readUint(2)
+
+		return int(b&0x07)<<16 | n + 2080, err
+	default:
+		return 0, fmt.Errorf("%w: integer 0x%02X", ErrUnsupported, b)
+	}
+}
+
+func (d *Decoder) readIntegerOnFourthBit(b byte) (int, error) {
+	switch {
+	case b&0x10 == 0:
+		return int(b & 0x0F), nil
+	case b&0x1C == 0x10:
+		n, err := d.readUint(1)
+
+		return int(b&0x03)<<8 | n + 16, err
+	case b&0x1C == 0x14:
+		n, err := d.readUint(2)
+
+		return int(b&0x03)<<16 | n + 1040, err
+	default:
+		return 0, fmt.Errorf("%w: integer 0x%02X", ErrUnsupported, b)
+	}
+}
+
+func (d *Decoder) readLengthOnSecondBit(b byte) (int, error) {
+	switch {
+	case b&0x40 == 0:
+		return int(b&0x3F) + 1, nil
+	case b&0x60 == 0x40:
+		n, err := d.readUint(1)
+
+		return n + 65, err
+	default:
+		n, err := d.readUint(4)
+
+		return n + 321, err
+	}
+}
+
+func (d *Decoder) readLengthOnFifthBit(b byte) (int, error) {
+	switch {
+	case b&0x08 == 0:
+		return int(b&0x07) + 1, nil
+	case b&0x0C == 0x08:
+		n, err := d.readUint(1)
+
+		return n + 9, err
+	default:
+		n, err := d.readUint(4)
+
+		return n + 265, err
+	}
+}
+
+func (d *Decoder) readLengthOnSeventhBit(b byte) (int, error) {
+	switch {
+	case b&0x02 == 0:
+		return int(b&0x01) + 1, nil
+	case b&0x03 == 0x02:
+		n, err := d.readUint(1)
+
+		return n + 3, err
+	default:
+		n, err := d.readUint(4)
+
+		return n + 259, err
+	}
+}
+
+func (d *Decoder) popElement() xml.Token {
+	d.endElement.Name.Local = d.stack[len(d.stack)-1]
+	d.stack = d.stack[:len(d.stack)-1]
+
+	return &d.endElement
+}
+
+func (d *Decoder) readByte() (byte, error) {
+	if d.offset >= len(d.buf) {
+		return 0, fmt.Errorf("%w: unexpected end of data", ErrInvalidDocument)
+	}
+
+	d.offset++
+
+	return d.buf[d.offset-1], nil
+}
+
+// readUint reads big-endian unsigned integer of n octets.
+func (d *Decoder) readUint(n int) (int, error) {
+	data, err := d.readN(n)
+	if err != nil {
+		return 0, err
+	}
+
+	var v int
+	for _, b := range data {
+		v = v<<8 | int(b)
+	}
+
+	return v, nil
+}
+
+func (d *Decoder) readN(n int) ([]byte, error) {
+	if n < 0 || n > len(d.buf)-d.offset {
+		return nil, fmt.Errorf("%w: unexpected end of data", ErrInvalidDocument)
+	}
+
+	d.offset += n
+
+	return d.buf[d.offset-n : d.offset], nil
+}
+
+func tableString(table []string, idx int) (string, error) {
+	if idx >= len(table) {
+		return "", fmt.Errorf("%w: table index %d is out of range", ErrInvalidDocument, idx)
+	}
+
+	return table[idx], nil
+}
+
+// appendEscaped appends text escaped as XML char data.
+func appendEscaped(dst []byte, text string) []byte {
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '&':
+			dst = append(dst, "&amp;"...)
+		case '<':
+			dst = append(dst, "&lt;"...)
+		case '>':
+			dst = append(dst, "&gt;"...)
+		case '\r':
+			dst = append(dst, "&#xD;"...)
+		default:
+			dst = append(dst, text[i])
+		}
+	}
+
+	return dst
+}
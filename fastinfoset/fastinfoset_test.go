@@ -0,0 +1,144 @@
+package fastinfoset
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"fastxml"
+)
+
+func decodeAll(t *testing.T, doc []byte) ([]xml.Token, *Decoder) {
+	t.Helper()
+
+	d, err := NewDecoder(doc)
+	require.NoError(t, err)
+
+	var tokens []xml.Token
+
+	for {
+		token, err := d.Next()
+		if errors.Is(err, io.EOF) {
+			return tokens, d
+		}
+
+		require.NoError(t, err)
+
+		tokens = append(tokens, fastxml.ToStd(token))
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	doc := []byte("<?xml version='1.0' encoding='finf'?>")
+	doc = append(doc, 0xE0, 0x00, 0x00, 0x01, // Header.
+		0x02, 0x01, // Standalone.
+		0x38,                                               // Element with namespace attributes.
+		0xCD, 0x07, 'u', 'r', 'n', ':', 't', 'e', 's', 't', // xmlns="urn:test"
+		0xF0,                                 // End of namespace attributes.
+		0x3D, 0x81, 0x03, 'r', 'o', 'o', 't', // Literal name "root" in namespace with index 1.
+		0x7C, 0x03, 'i', 't', 'e', 'm', // <item with attributes.
+		0x78, 0x01, 'i', 'd', 0x40, '1', // id="1", value added to the table.
+		0x7B, 0x80, 0x80, 0x03, 'l', 'a', 'n', 'g', 0x01, 'e', 'n', // xml:lang="en"
+		0xF0,                      // End of attributes.
+		0x92, 0x00, 'a', '<', 'b', // Character chunk added to the table.
+		0xF0,       // </item>
+		0x41,       // <item with attributes, name with index 1.
+		0x00, 0x80, // id="1" by indexes.
+		0xFF,                      // End of attributes and element.
+		0xE2, 0x02, ' ', 'c', ' ', // Comment.
+		0xE1, 0x01, 'p', 'i', 0x03, 'd', 'a', 't', 'a', // Processing instruction.
+		0xA0, // Character chunk with index 0.
+		0xFF, // End of root element and document.
+	)
+
+	tokens, d := decodeAll(t, doc)
+	assert.True(t, d.Standalone)
+	assert.Equal(t, []xml.Token{
+		xml.StartElement{Name: xml.Name{Local: "root"}, Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: "urn:test"}}},
+		xml.StartElement{Name: xml.Name{Local: "item"}, Attr: []xml.Attr{
+			{Name: xml.Name{Local: "id"}, Value: "1"},
+			{Name: xml.Name{Space: "xml", Local: "lang"}, Value: "en"},
+		}},
+		xml.CharData("a<b"),
+		xml.EndElement{Name: xml.Name{Local: "item"}},
+		xml.StartElement{Name: xml.Name{Local: "item"}, Attr: []xml.Attr{{Name: xml.Name{Local: "id"}, Value: "1"}}},
+		xml.EndElement{Name: xml.Name{Local: "item"}},
+		xml.Comment(" c "),
+		xml.ProcInst{Target: "pi", Inst: []byte("data")},
+		xml.CharData("a<b"),
+		xml.EndElement{Name: xml.Name{Local: "root"}},
+	}, tokens)
+}
+
+func TestDecoder_UTF16AndLongStrings(t *testing.T) {
+	long := make([]byte, 70)
+	for i := range long {
+		long[i] = 'a'
+	}
+
+	doc := []byte{0xE0, 0x00, 0x00, 0x01, 0x00,
+		0x3C, 0x40, 70 - 65} // Element with literal local name of 70 bytes.
+	doc = append(doc, long...)
+	doc = append(doc,
+		0x86, 0x01, 0x00, 0xE9, 0x00, '!', // Character chunk "é!" encoded as UTF-16.
+		0xFF, // End of element and document.
+	)
+
+	tokens, _ := decodeAll(t, doc)
+	assert.Equal(t, []xml.Token{
+		xml.StartElement{Name: xml.Name{Local: string(long)}},
+		xml.CharData("é!"),
+		xml.EndElement{Name: xml.Name{Local: string(long)}},
+	}, tokens)
+}
+
+func TestDecoder_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     []byte
+		wantErr error
+	}{
+		{
+			name:    "unknown header",
+			doc:     []byte{0xE0, 0x00, 0x00, 0x02, 0x00},
+			wantErr: ErrInvalidDocument,
+		},
+		{
+			name:    "initial vocabulary",
+			doc:     []byte{0xE0, 0x00, 0x00, 0x01, 0x20},
+			wantErr: ErrUnsupported,
+		},
+		{
+			name:    "unknown name index",
+			doc:     []byte{0xE0, 0x00, 0x00, 0x01, 0x00, 0x05},
+			wantErr: ErrInvalidDocument,
+		},
+		{
+			name:    "encoding algorithm",
+			doc:     []byte{0xE0, 0x00, 0x00, 0x01, 0x00, 0x3C, 0x00, 'a', 0x8C, 0x00},
+			wantErr: ErrUnsupported,
+		},
+		{
+			name:    "truncated",
+			doc:     []byte{0xE0, 0x00, 0x00, 0x01, 0x00, 0x3C, 0x03, 'a'},
+			wantErr: ErrInvalidDocument,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			d, err := NewDecoder(test.doc)
+			for err == nil {
+				_, err = d.Next()
+			}
+
+			assert.ErrorIs(t, err, test.wantErr)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+//go:build go1.18
+// +build go1.18
+
+package fastxml
+
+import (
+	"testing"
+)
+
+func FuzzParser_Next(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		parseAll(data)
+	})
+}
+
+func FuzzParser_Feed(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add([]byte(seed), uint8(3))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte, chunkSize uint8) {
+		feedAll(data, int(chunkSize)+1)
+	})
+}
+
+func FuzzHelpers(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		callHelpers(data)
+	})
+}
+
+func FuzzDecoder_Token(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		decodeAll(data)
+	})
+}
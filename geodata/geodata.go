@@ -0,0 +1,214 @@
+/*
+Package geodata provides streaming parsing of GPX (https://www.topografix.com/gpx.asp)
+and KML (https://developers.google.com/kml/documentation/kmlreference) documents.
+
+GPX points and KML placemarks are returned one by one, so even huge documents can be processed
+without building the full document in memory. Tracks, routes and folders enclosing
+returned values are shared between values of the same container.
+*/
+package geodata
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"fastxml"
+)
+
+var (
+	ErrUnknownRoot = errors.New("unknown root element")
+	ErrInvalidData = errors.New("invalid data")
+)
+
+var cdataPrefix = []byte("<![CDATA[")
+
+// timeLayouts are layouts of XML Schema dateTime, date, gYearMonth and gYear values.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02Z07:00",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+// ParseTime parses timestamp of GPX `time` and KML `when`, `begin` and `end` elements.
+//
+// Timestamps without time zone are returned in UTC.
+func ParseTime(val string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, val); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%w: invalid time value %q", ErrInvalidData, val)
+}
+
+// decoder holds parser along with its input, which is needed to recognize CDATA sections.
+type decoder struct {
+	buf []byte
+	p   *fastxml.Parser
+}
+
+// root returns local name of the root element of the document.
+func (d *decoder) root() (string, error) {
+	for {
+		token, err := d.p.Next()
+		if err != nil {
+			return "", fmt.Errorf("find root element: %w", err)
+		}
+
+		if start, ok := token.(*fastxml.StartToken); ok {
+			return localName(start.Name), nil
+		}
+	}
+}
+
+// nextChild returns next child element of current element,
+// or nil if current element was closed.
+//
+// Char data between elements is ignored.
+func (d *decoder) nextChild() (*fastxml.StartToken, error) {
+	for {
+		token, err := d.p.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		switch tkn := token.(type) {
+		case *fastxml.StartToken:
+			return tkn, nil
+		case *fastxml.EndElement:
+			return nil, nil
+		}
+	}
+}
+
+// readText will return trimmed text content of current element and advance parser past its end.
+//
+// Nested elements are skipped, CDATA sections are returned as is.
+func (d *decoder) readText() (string, error) {
+	var text strings.Builder
+
+	for {
+		start := d.p.InputOffset()
+
+		token, err := d.p.Next()
+		if err != nil {
+			return "", err
+		}
+
+		switch tkn := token.(type) {
+		case *fastxml.CharData:
+			if bytes.HasPrefix(d.buf[start:], cdataPrefix) {
+				text.Write(*tkn)
+			} else {
+				text.WriteString(fastxml.Unescape(string(*tkn)))
+			}
+		case *fastxml.StartToken:
+			if err := d.skipElement(); err != nil {
+				return "", err
+			}
+		case *fastxml.EndElement:
+			return strings.TrimSpace(text.String()), nil
+		}
+	}
+}
+
+// readLeaves calls set with local name and text of each descendant element
+// of current element, that has no child elements, and advances parser past the end of current element.
+func (d *decoder) readLeaves(set func(name, text string)) error {
+	var (
+		names []string
+		text  strings.Builder
+		leaf  bool
+	)
+
+	for {
+		start := d.p.InputOffset()
+
+		token, err := d.p.Next()
+		if err != nil {
+			return err
+		}
+
+		switch tkn := token.(type) {
+		case *fastxml.StartToken:
+			names = append(names, localName(tkn.Name))
+			leaf = true
+
+			text.Reset()
+		case *fastxml.CharData:
+			if !leaf {
+				continue
+			}
+
+			if bytes.HasPrefix(d.buf[start:], cdataPrefix) {
+				text.Write(*tkn)
+			} else {
+				text.WriteString(fastxml.Unescape(string(*tkn)))
+			}
+		case *fastxml.EndElement:
+			if len(names) == 0 {
+				return nil
+			}
+
+			if leaf {
+				set(names[len(names)-1], strings.TrimSpace(text.String()))
+			}
+
+			leaf = false
+			names = names[:len(names)-1]
+		}
+	}
+}
+
+// skipElement will advance parser past the end of current element.
+func (d *decoder) skipElement() error {
+	for depth := 1; depth > 0; {
+		token, err := d.p.Next()
+		if err != nil {
+			return err
+		}
+
+		switch token.(type) {
+		case *fastxml.StartToken:
+			depth++
+		case *fastxml.EndElement:
+			depth--
+		}
+	}
+
+	return nil
+}
+
+// eachAttribute calls fn with each attribute of the element, with references replaced in values.
+func eachAttribute(start *fastxml.StartToken, fn func(name, value string) error) error {
+	for {
+		name, value, err := start.NextAttribute()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := fn(localName(name), fastxml.Unescape(value)); err != nil {
+			return err
+		}
+	}
+}
+
+func localName(name string) string {
+	if idx := strings.IndexByte(name, ':'); idx != -1 {
+		return name[idx+1:]
+	}
+
+	return name
+}
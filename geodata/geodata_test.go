@@ -0,0 +1,35 @@
+package geodata
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Time
+	}{
+		{input: "2009-10-17T18:37:26Z", expected: time.Date(2009, 10, 17, 18, 37, 26, 0, time.UTC)},
+		{input: "2009-10-17T18:37:26.25", expected: time.Date(2009, 10, 17, 18, 37, 26, 25e7, time.UTC)},
+		{input: "2009-10-17", expected: time.Date(2009, 10, 17, 0, 0, 0, 0, time.UTC)},
+		{input: "2009-10", expected: time.Date(2009, 10, 1, 0, 0, 0, 0, time.UTC)},
+		{input: "2009", expected: time.Date(2009, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.input, func(t *testing.T) {
+			parsed, err := ParseTime(test.input)
+			require.NoError(t, err)
+			assert.True(t, test.expected.Equal(parsed), parsed)
+		})
+	}
+
+	_, err := ParseTime("yesterday")
+	require.ErrorIs(t, err, ErrInvalidData)
+}
@@ -0,0 +1,263 @@
+package geodata
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"fastxml"
+)
+
+// PointKind is the kind of GPX point.
+type PointKind uint8
+
+const (
+	// PointWaypoint is a `wpt` element.
+	PointWaypoint PointKind = iota + 1
+	// PointRoute is a `rtept` element of the route.
+	PointRoute
+	// PointTrack is a `trkpt` element of the track segment.
+	PointTrack
+)
+
+// Path holds properties of GPX route or track.
+type Path struct {
+	Name        string
+	Description string
+	Comment     string
+	Type        string
+	Number      int
+}
+
+// Point is a single GPX waypoint, route point or track point.
+type Point struct {
+	Kind         PointKind
+	Lat          float64
+	Lon          float64
+	Elevation    float64
+	HasElevation bool
+	Time         time.Time
+	Name         string
+	Description  string
+	Comment      string
+	Symbol       string
+	Type         string
+	// Extensions hold text of leaf elements of `extensions` element by their local names,
+	// like "hr" and "cad" of Garmin track point extensions.
+	Extensions map[string]string
+	// Path is the enclosing route or track, nil for waypoints.
+	Path *Path
+	// Segment is the index of the enclosing segment of the track.
+	Segment int
+}
+
+// GPXReader iterates over points of GPX document.
+type GPXReader struct {
+	d decoder
+	// open are local names of opened routes, tracks and track segments.
+	open    []string
+	path    *Path
+	segment int
+}
+
+// NewGPXReader will create a reader over GPX document.
+func NewGPXReader(buf []byte) (*GPXReader, error) {
+	r := &GPXReader{
+		d: decoder{buf: buf, p: fastxml.NewParser(buf, false)},
+	}
+
+	root, err := r.d.root()
+	if err != nil {
+		return nil, err
+	}
+
+	if root != "gpx" {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownRoot, root)
+	}
+
+	return r, nil
+}
+
+// Next will return next point of the document, in document order.
+// io.EOF is returned when no more points are available.
+//
+// Properties of the route or track are set as they are read, so properties
+// that follow points in the document are only visible after they were read.
+func (r *GPXReader) Next() (Point, error) {
+	for {
+		token, err := r.d.p.Next()
+		if err != nil {
+			return Point{}, err
+		}
+
+		switch tkn := token.(type) {
+		case *fastxml.StartToken:
+			name := localName(tkn.Name)
+
+			switch name {
+			case "wpt":
+				return r.readPoint(tkn, PointWaypoint, nil)
+			case "rtept":
+				return r.readPoint(tkn, PointRoute, r.path)
+			case "trkpt":
+				return r.readPoint(tkn, PointTrack, r.path)
+			case "rte", "trk":
+				r.path, r.segment = &Path{}, -1
+				r.open = append(r.open, name)
+			case "trkseg":
+				r.segment++
+				r.open = append(r.open, name)
+			default:
+				if err := r.readPathProperty(name); err != nil {
+					return Point{}, err
+				}
+			}
+		case *fastxml.EndElement:
+			if len(r.open) == 0 {
+				// Only root element can be closed here.
+				return Point{}, io.EOF
+			}
+
+			if closed := r.open[len(r.open)-1]; closed == "rte" || closed == "trk" {
+				r.path = nil
+			}
+
+			r.open = r.open[:len(r.open)-1]
+		}
+	}
+}
+
+// readPathProperty sets property of the route or track, if element is its direct child,
+// or skips the element otherwise.
+func (r *GPXReader) readPathProperty(name string) error {
+	if len(r.open) == 0 || r.open[len(r.open)-1] == "trkseg" {
+		return r.d.skipElement()
+	}
+
+	var (
+		text string
+		err  error
+	)
+
+	switch name {
+	case "name", "desc", "cmt", "type", "number":
+		if text, err = r.d.readText(); err != nil {
+			return fmt.Errorf("read %s: %w", name, err)
+		}
+	default:
+		return r.d.skipElement()
+	}
+
+	switch name {
+	case "name":
+		r.path.Name = text
+	case "desc":
+		r.path.Description = text
+	case "cmt":
+		r.path.Comment = text
+	case "type":
+		r.path.Type = text
+	case "number":
+		if r.path.Number, err = strconv.Atoi(text); err != nil {
+			return fmt.Errorf("%w: invalid number %q", ErrInvalidData, text)
+		}
+	}
+
+	return nil
+}
+
+func (r *GPXReader) readPoint(start *fastxml.StartToken, kind PointKind, path *Path) (Point, error) {
+	point := Point{Kind: kind, Path: path}
+
+	if kind == PointTrack {
+		point.Segment = r.segment
+	}
+
+	var hasLat, hasLon bool
+
+	err := eachAttribute(start, func(name, value string) (err error) {
+		switch name {
+		case "lat":
+			point.Lat, err = parseDegrees(value, 90)
+			hasLat = true
+		case "lon":
+			point.Lon, err = parseDegrees(value, 180)
+			hasLon = true
+		}
+
+		return err
+	})
+	if err != nil {
+		return Point{}, fmt.Errorf("read %s: %w", start.Name, err)
+	}
+
+	if !hasLat || !hasLon {
+		return Point{}, fmt.Errorf("read %s: %w: lat and lon attributes are required", start.Name, ErrInvalidData)
+	}
+
+	for {
+		child, err := r.d.nextChild()
+		if err != nil {
+			return Point{}, fmt.Errorf("read %s: %w", start.Name, err)
+		}
+
+		if child == nil {
+			return point, nil
+		}
+
+		name := localName(child.Name)
+		if name == "extensions" {
+			if point.Extensions == nil {
+				point.Extensions = make(map[string]string)
+			}
+
+			if err := r.d.readLeaves(func(name, text string) { point.Extensions[name] = text }); err != nil {
+				return Point{}, fmt.Errorf("read extensions: %w", err)
+			}
+
+			continue
+		}
+
+		text, err := r.d.readText()
+		if err != nil {
+			return Point{}, fmt.Errorf("read %s: %w", name, err)
+		}
+
+		switch name {
+		case "ele":
+			point.HasElevation = true
+
+			if point.Elevation, err = strconv.ParseFloat(text, 64); err != nil {
+				err = fmt.Errorf("%w: invalid elevation %q", ErrInvalidData, text)
+			}
+		case "time":
+			point.Time, err = ParseTime(text)
+		case "name":
+			point.Name = text
+		case "desc":
+			point.Description = text
+		case "cmt":
+			point.Comment = text
+		case "sym":
+			point.Symbol = text
+		case "type":
+			point.Type = text
+		}
+
+		if err != nil {
+			return Point{}, fmt.Errorf("read %s: %w", name, err)
+		}
+	}
+}
+
+// parseDegrees parses latitude or longitude, which absolute value must not exceed limit.
+func parseDegrees(val string, limit float64) (float64, error) {
+	degrees, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+	if err != nil || degrees < -limit || degrees > limit {
+		return 0, fmt.Errorf("%w: invalid coordinate %q", ErrInvalidData, val)
+	}
+
+	return degrees, nil
+}
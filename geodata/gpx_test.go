@@ -0,0 +1,133 @@
+package geodata
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const gpxDocument = `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="test" xmlns="http://www.topografix.com/GPX/1/1"
+  xmlns:gpxtpx="http://www.garmin.com/xmlschemas/TrackPointExtension/v1">
+  <metadata><name>Document name</name></metadata>
+  <wpt lat="47.644548" lon="-122.326897">
+    <ele>4.46</ele>
+    <time>2009-10-17T18:37:26Z</time>
+    <name>Start &amp; finish</name>
+    <sym>Flag</sym>
+  </wpt>
+  <rte>
+    <name>Route</name>
+    <rtept lat="1" lon="2"/>
+  </rte>
+  <trk>
+    <name>Morning run</name>
+    <type>running</type>
+    <number>3</number>
+    <extensions><color>red</color></extensions>
+    <trkseg>
+      <trkpt lat="47.644548" lon="-122.326897">
+        <time>2009-10-17T18:37:26.5+02:00</time>
+        <extensions>
+          <gpxtpx:TrackPointExtension>
+            <gpxtpx:hr>151</gpxtpx:hr>
+            <gpxtpx:cad>88</gpxtpx:cad>
+          </gpxtpx:TrackPointExtension>
+        </extensions>
+      </trkpt>
+      <trkpt lat="47.644549" lon="-122.326898"><ele>-1</ele></trkpt>
+    </trkseg>
+    <trkseg>
+      <trkpt lat="0" lon="0"><desc><![CDATA[<b>paused</b>]]></desc></trkpt>
+    </trkseg>
+  </trk>
+</gpx>`
+
+func TestGPXReader(t *testing.T) {
+	r, err := NewGPXReader([]byte(gpxDocument))
+	require.NoError(t, err)
+
+	points := readAllPoints(t, r)
+	require.Len(t, points, 5)
+
+	assert.Equal(t, Point{
+		Kind:         PointWaypoint,
+		Lat:          47.644548,
+		Lon:          -122.326897,
+		Elevation:    4.46,
+		HasElevation: true,
+		Time:         time.Date(2009, 10, 17, 18, 37, 26, 0, time.UTC),
+		Name:         "Start & finish",
+		Symbol:       "Flag",
+	}, points[0])
+
+	route := &Path{Name: "Route"}
+	assert.Equal(t, Point{Kind: PointRoute, Lat: 1, Lon: 2, Path: route}, points[1])
+
+	track := &Path{Name: "Morning run", Type: "running", Number: 3}
+
+	assert.Equal(t, PointTrack, points[2].Kind)
+	assert.Equal(t, track, points[2].Path)
+	assert.Equal(t, 0, points[2].Segment)
+	assert.True(t, points[2].Time.Equal(time.Date(2009, 10, 17, 16, 37, 26, 5e8, time.UTC)))
+	assert.Equal(t, map[string]string{"hr": "151", "cad": "88"}, points[2].Extensions)
+
+	assert.Equal(t, -1.0, points[3].Elevation)
+	assert.True(t, points[3].HasElevation)
+	assert.Same(t, points[2].Path, points[3].Path)
+
+	assert.Equal(t, 1, points[4].Segment)
+	assert.Equal(t, "<b>paused</b>", points[4].Description)
+	assert.False(t, points[4].HasElevation)
+}
+
+func TestNewGPXReader_UnknownRoot(t *testing.T) {
+	_, err := NewGPXReader([]byte(`<kml></kml>`))
+	require.ErrorIs(t, err, ErrUnknownRoot)
+}
+
+func TestGPXReader_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "missing lon", input: `<gpx><wpt lat="1"/></gpx>`},
+		{name: "invalid lat", input: `<gpx><wpt lat="91" lon="0"/></gpx>`},
+		{name: "invalid elevation", input: `<gpx><wpt lat="1" lon="1"><ele>high</ele></wpt></gpx>`},
+		{name: "invalid time", input: `<gpx><wpt lat="1" lon="1"><time>noon</time></wpt></gpx>`},
+		{name: "invalid number", input: `<gpx><trk><number>one</number></trk></gpx>`},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			r, err := NewGPXReader([]byte(test.input))
+			require.NoError(t, err)
+
+			_, err = r.Next()
+			require.ErrorIs(t, err, ErrInvalidData)
+		})
+	}
+}
+
+func readAllPoints(t *testing.T, r *GPXReader) []Point {
+	t.Helper()
+
+	var points []Point
+
+	for {
+		point, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return points
+		}
+
+		require.NoError(t, err)
+
+		points = append(points, point)
+	}
+}
@@ -0,0 +1,467 @@
+package geodata
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"fastxml"
+)
+
+// GeometryKind is the kind of KML geometry.
+type GeometryKind uint8
+
+const (
+	GeometryPoint GeometryKind = iota + 1
+	GeometryLineString
+	GeometryLinearRing
+	GeometryPolygon
+)
+
+// Coordinate is a single KML coordinate tuple.
+type Coordinate struct {
+	Lon float64
+	Lat float64
+	// Alt is the altitude in meters, zero if it was not set.
+	Alt float64
+}
+
+// Geometry is a single KML geometry.
+// Geometries of `MultiGeometry` are returned as separate geometries.
+type Geometry struct {
+	Kind GeometryKind
+	// Coordinates are the coordinates of the geometry, or outer boundary of the polygon.
+	Coordinates []Coordinate
+	// InnerBoundaries are the holes of the polygon.
+	InnerBoundaries [][]Coordinate
+}
+
+// Container holds properties of KML `Document` or `Folder`.
+type Container struct {
+	ID   string
+	Name string
+	// Folder is false for `Document` containers.
+	Folder bool
+}
+
+// Placemark is a single KML `Placemark` element.
+type Placemark struct {
+	ID          string
+	Name        string
+	Description string
+	StyleURL    string
+	// When is set from `TimeStamp` element.
+	When time.Time
+	// Begin and End are set from `TimeSpan` element.
+	Begin time.Time
+	End   time.Time
+	// ExtendedData holds values of `Data` and `SimpleData` elements by their names.
+	ExtendedData map[string]string
+	Geometries   []Geometry
+	// Containers are the enclosing documents and folders, from the outermost one.
+	Containers []*Container
+}
+
+// KMLReader iterates over placemarks of KML document.
+type KMLReader struct {
+	d          decoder
+	containers []*Container
+}
+
+// NewKMLReader will create a reader over KML document.
+func NewKMLReader(buf []byte) (*KMLReader, error) {
+	r := &KMLReader{
+		d: decoder{buf: buf, p: fastxml.NewParser(buf, false)},
+	}
+
+	root, err := r.d.root()
+	if err != nil {
+		return nil, err
+	}
+
+	if root != "kml" {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownRoot, root)
+	}
+
+	return r, nil
+}
+
+// Next will return next placemark of the document.
+// io.EOF is returned when no more placemarks are available.
+//
+// Properties of the containers are set as they are read, so properties
+// that follow placemarks in the document are only visible after they were read.
+func (r *KMLReader) Next() (Placemark, error) {
+	for {
+		token, err := r.d.p.Next()
+		if err != nil {
+			return Placemark{}, err
+		}
+
+		switch tkn := token.(type) {
+		case *fastxml.StartToken:
+			switch name := localName(tkn.Name); name {
+			case "Placemark":
+				return r.readPlacemark(tkn)
+			case "Document", "Folder":
+				container := &Container{Folder: name == "Folder"}
+
+				err := eachAttribute(tkn, func(name, value string) error {
+					if name == "id" {
+						container.ID = value
+					}
+
+					return nil
+				})
+				if err != nil {
+					return Placemark{}, fmt.Errorf("read %s: %w", name, err)
+				}
+
+				r.containers = append(r.containers, container)
+			case "name":
+				if len(r.containers) == 0 {
+					if err := r.d.skipElement(); err != nil {
+						return Placemark{}, err
+					}
+
+					continue
+				}
+
+				if r.containers[len(r.containers)-1].Name, err = r.d.readText(); err != nil {
+					return Placemark{}, fmt.Errorf("read name: %w", err)
+				}
+			default:
+				if err := r.d.skipElement(); err != nil {
+					return Placemark{}, err
+				}
+			}
+		case *fastxml.EndElement:
+			if len(r.containers) == 0 {
+				// Only root element can be closed here.
+				return Placemark{}, io.EOF
+			}
+
+			r.containers = r.containers[:len(r.containers)-1]
+		}
+	}
+}
+
+func (r *KMLReader) readPlacemark(start *fastxml.StartToken) (Placemark, error) {
+	placemark := Placemark{Containers: append([]*Container(nil), r.containers...)}
+
+	err := eachAttribute(start, func(name, value string) error {
+		if name == "id" {
+			placemark.ID = value
+		}
+
+		return nil
+	})
+	if err != nil {
+		return Placemark{}, fmt.Errorf("read Placemark: %w", err)
+	}
+
+	for {
+		child, err := r.d.nextChild()
+		if err != nil {
+			return Placemark{}, fmt.Errorf("read Placemark: %w", err)
+		}
+
+		if child == nil {
+			return placemark, nil
+		}
+
+		name := localName(child.Name)
+
+		switch name {
+		case "name":
+			placemark.Name, err = r.d.readText()
+		case "description":
+			placemark.Description, err = r.d.readText()
+		case "styleUrl":
+			placemark.StyleURL, err = r.d.readText()
+		case "TimeStamp", "TimeSpan":
+			err = r.readTimes(&placemark)
+		case "ExtendedData":
+			err = r.readExtendedData(&placemark)
+		case "Point", "LineString", "LinearRing", "Polygon", "MultiGeometry":
+			err = r.readGeometry(name, &placemark.Geometries)
+		default:
+			err = r.d.skipElement()
+		}
+
+		if err != nil {
+			return Placemark{}, fmt.Errorf("read %s: %w", name, err)
+		}
+	}
+}
+
+// readTimes sets times of the placemark from `TimeStamp` or `TimeSpan` element.
+func (r *KMLReader) readTimes(placemark *Placemark) error {
+	for {
+		child, err := r.d.nextChild()
+		if err != nil || child == nil {
+			return err
+		}
+
+		name := localName(child.Name)
+
+		text, err := r.d.readText()
+		if err != nil {
+			return err
+		}
+
+		switch name {
+		case "when":
+			placemark.When, err = ParseTime(text)
+		case "begin":
+			placemark.Begin, err = ParseTime(text)
+		case "end":
+			placemark.End, err = ParseTime(text)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// readExtendedData reads untyped `Data` and typed `SimpleData` values.
+func (r *KMLReader) readExtendedData(placemark *Placemark) error {
+	if placemark.ExtendedData == nil {
+		placemark.ExtendedData = make(map[string]string)
+	}
+
+	for {
+		child, err := r.d.nextChild()
+		if err != nil || child == nil {
+			return err
+		}
+
+		switch localName(child.Name) {
+		case "Data":
+			err = r.readData(child, placemark.ExtendedData)
+		case "SchemaData":
+			err = r.readSchemaData(placemark.ExtendedData)
+		default:
+			err = r.d.skipElement()
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (r *KMLReader) readData(start *fastxml.StartToken, data map[string]string) error {
+	name, err := nameAttribute(start)
+	if err != nil {
+		return err
+	}
+
+	for {
+		child, err := r.d.nextChild()
+		if err != nil || child == nil {
+			return err
+		}
+
+		if localName(child.Name) != "value" {
+			if err := r.d.skipElement(); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if data[name], err = r.d.readText(); err != nil {
+			return err
+		}
+	}
+}
+
+func (r *KMLReader) readSchemaData(data map[string]string) error {
+	for {
+		child, err := r.d.nextChild()
+		if err != nil || child == nil {
+			return err
+		}
+
+		if localName(child.Name) != "SimpleData" {
+			if err := r.d.skipElement(); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		name, err := nameAttribute(child)
+		if err != nil {
+			return err
+		}
+
+		if data[name], err = r.d.readText(); err != nil {
+			return err
+		}
+	}
+}
+
+// readGeometry appends geometries of the element with given name to geometries.
+func (r *KMLReader) readGeometry(name string, geometries *[]Geometry) error {
+	var geometry Geometry
+
+	switch name {
+	case "MultiGeometry":
+		for {
+			child, err := r.d.nextChild()
+			if err != nil || child == nil {
+				return err
+			}
+
+			switch childName := localName(child.Name); childName {
+			case "Point", "LineString", "LinearRing", "Polygon", "MultiGeometry":
+				err = r.readGeometry(childName, geometries)
+			default:
+				err = r.d.skipElement()
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+	case "Polygon":
+		geometry.Kind = GeometryPolygon
+	case "Point":
+		geometry.Kind = GeometryPoint
+	case "LineString":
+		geometry.Kind = GeometryLineString
+	case "LinearRing":
+		geometry.Kind = GeometryLinearRing
+	}
+
+	for {
+		child, err := r.d.nextChild()
+		if err != nil {
+			return err
+		}
+
+		if child == nil {
+			*geometries = append(*geometries, geometry)
+
+			return nil
+		}
+
+		switch childName := localName(child.Name); {
+		case childName == "coordinates" && geometry.Kind != GeometryPolygon:
+			geometry.Coordinates, err = r.readCoordinates()
+		case childName == "outerBoundaryIs" && geometry.Kind == GeometryPolygon:
+			geometry.Coordinates, err = r.readBoundary()
+		case childName == "innerBoundaryIs" && geometry.Kind == GeometryPolygon:
+			var boundary []Coordinate
+
+			boundary, err = r.readBoundary()
+			geometry.InnerBoundaries = append(geometry.InnerBoundaries, boundary)
+		default:
+			err = r.d.skipElement()
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// readBoundary returns coordinates of the `LinearRing` of polygon boundary.
+func (r *KMLReader) readBoundary() ([]Coordinate, error) {
+	var rings []Geometry
+
+	for {
+		child, err := r.d.nextChild()
+		if err != nil {
+			return nil, err
+		}
+
+		if child == nil {
+			break
+		}
+
+		if localName(child.Name) != "LinearRing" {
+			if err := r.d.skipElement(); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		if err := r.readGeometry("LinearRing", &rings); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rings) != 1 {
+		return nil, fmt.Errorf("%w: boundary must have exactly one LinearRing, got %d", ErrInvalidData, len(rings))
+	}
+
+	return rings[0].Coordinates, nil
+}
+
+func (r *KMLReader) readCoordinates() ([]Coordinate, error) {
+	text, err := r.d.readText()
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseCoordinates(text)
+}
+
+// ParseCoordinates parses content of KML `coordinates` element:
+// whitespace separated tuples of longitude, latitude and optional altitude, separated by commas.
+func ParseCoordinates(val string) ([]Coordinate, error) {
+	tuples := strings.Fields(val)
+	coordinates := make([]Coordinate, 0, len(tuples))
+
+	for _, tuple := range tuples {
+		parts := strings.Split(tuple, ",")
+		if len(parts) != 2 && len(parts) != 3 {
+			return nil, fmt.Errorf("%w: invalid coordinate tuple %q", ErrInvalidData, tuple)
+		}
+
+		var (
+			coordinate Coordinate
+			err        error
+		)
+
+		if coordinate.Lon, err = parseDegrees(parts[0], 180); err != nil {
+			return nil, err
+		}
+
+		if coordinate.Lat, err = parseDegrees(parts[1], 90); err != nil {
+			return nil, err
+		}
+
+		if len(parts) == 3 {
+			if coordinate.Alt, err = strconv.ParseFloat(parts[2], 64); err != nil {
+				return nil, fmt.Errorf("%w: invalid altitude %q", ErrInvalidData, parts[2])
+			}
+		}
+
+		coordinates = append(coordinates, coordinate)
+	}
+
+	return coordinates, nil
+}
+
+// nameAttribute returns value of the `name` attribute of extended data element.
+func nameAttribute(start *fastxml.StartToken) (string, error) {
+	var name string
+
+	err := eachAttribute(start, func(attrName, value string) error {
+		if attrName == "name" {
+			name = value
+		}
+
+		return nil
+	})
+
+	return name, err
+}
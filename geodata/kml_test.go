@@ -0,0 +1,149 @@
+package geodata
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const kmlDocument = `<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2">
+  <Document id="doc">
+    <name>Places</name>
+    <Style id="red"><LineStyle><color>ff0000ff</color></LineStyle></Style>
+    <Placemark id="p1">
+      <name>Office</name>
+      <description><![CDATA[<p>Main & only</p>]]></description>
+      <styleUrl>#red</styleUrl>
+      <TimeStamp><when>2020-05-06</when></TimeStamp>
+      <ExtendedData>
+        <Data name="floor"><displayName>Floor</displayName><value>3</value></Data>
+        <SchemaData schemaUrl="#s"><SimpleData name="owner">A &amp; B</SimpleData></SchemaData>
+      </ExtendedData>
+      <Point><extrude>1</extrude><coordinates>-122.0822035425683,37.42228990140251,0</coordinates></Point>
+    </Placemark>
+    <Folder>
+      <name>Areas</name>
+      <Placemark>
+        <TimeSpan><begin>2019-01</begin><end>2019-12-31T23:59:59Z</end></TimeSpan>
+        <MultiGeometry>
+          <LineString><coordinates>
+            1,2 3,4,5
+          </coordinates></LineString>
+          <Polygon>
+            <outerBoundaryIs><LinearRing><coordinates>0,0 0,1 1,1 0,0</coordinates></LinearRing></outerBoundaryIs>
+            <innerBoundaryIs><LinearRing><coordinates>0.1,0.1 0.1,0.2 0.2,0.2 0.1,0.1</coordinates></LinearRing></innerBoundaryIs>
+          </Polygon>
+        </MultiGeometry>
+      </Placemark>
+    </Folder>
+    <Placemark><name>After folder</name></Placemark>
+  </Document>
+</kml>`
+
+func TestKMLReader(t *testing.T) {
+	r, err := NewKMLReader([]byte(kmlDocument))
+	require.NoError(t, err)
+
+	placemarks := readAllPlacemarks(t, r)
+	require.Len(t, placemarks, 3)
+
+	document := &Container{ID: "doc", Name: "Places"}
+	folder := &Container{Name: "Areas", Folder: true}
+
+	assert.Equal(t, Placemark{
+		ID:           "p1",
+		Name:         "Office",
+		Description:  "<p>Main & only</p>",
+		StyleURL:     "#red",
+		When:         time.Date(2020, 5, 6, 0, 0, 0, 0, time.UTC),
+		ExtendedData: map[string]string{"floor": "3", "owner": "A & B"},
+		Geometries: []Geometry{{
+			Kind:        GeometryPoint,
+			Coordinates: []Coordinate{{Lon: -122.0822035425683, Lat: 37.42228990140251}},
+		}},
+		Containers: []*Container{document},
+	}, placemarks[0])
+
+	assert.Equal(t, Placemark{
+		Begin: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2019, 12, 31, 23, 59, 59, 0, time.UTC),
+		Geometries: []Geometry{
+			{
+				Kind:        GeometryLineString,
+				Coordinates: []Coordinate{{Lon: 1, Lat: 2}, {Lon: 3, Lat: 4, Alt: 5}},
+			},
+			{
+				Kind:            GeometryPolygon,
+				Coordinates:     []Coordinate{{Lon: 0, Lat: 0}, {Lon: 0, Lat: 1}, {Lon: 1, Lat: 1}, {Lon: 0, Lat: 0}},
+				InnerBoundaries: [][]Coordinate{{{Lon: 0.1, Lat: 0.1}, {Lon: 0.1, Lat: 0.2}, {Lon: 0.2, Lat: 0.2}, {Lon: 0.1, Lat: 0.1}}},
+			},
+		},
+		Containers: []*Container{document, folder},
+	}, placemarks[1])
+
+	assert.Equal(t, "After folder", placemarks[2].Name)
+	assert.Equal(t, []*Container{document}, placemarks[2].Containers)
+	assert.Same(t, placemarks[0].Containers[0], placemarks[2].Containers[0])
+}
+
+func TestNewKMLReader_UnknownRoot(t *testing.T) {
+	_, err := NewKMLReader([]byte(`<gpx></gpx>`))
+	require.ErrorIs(t, err, ErrUnknownRoot)
+}
+
+func TestKMLReader_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "invalid tuple", input: `<kml><Placemark><Point><coordinates>1</coordinates></Point></Placemark></kml>`},
+		{name: "invalid longitude", input: `<kml><Placemark><Point><coordinates>181,0</coordinates></Point></Placemark></kml>`},
+		{name: "invalid altitude", input: `<kml><Placemark><Point><coordinates>1,1,x</coordinates></Point></Placemark></kml>`},
+		{name: "invalid when", input: `<kml><Placemark><TimeStamp><when>today</when></TimeStamp></Placemark></kml>`},
+		{name: "boundary without ring", input: `<kml><Placemark><Polygon><outerBoundaryIs/></Polygon></Placemark></kml>`},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			r, err := NewKMLReader([]byte(test.input))
+			require.NoError(t, err)
+
+			_, err = r.Next()
+			require.ErrorIs(t, err, ErrInvalidData)
+		})
+	}
+}
+
+func TestParseCoordinates(t *testing.T) {
+	coordinates, err := ParseCoordinates(" 1,2,3\n\t4,5 ")
+	require.NoError(t, err)
+	assert.Equal(t, []Coordinate{{Lon: 1, Lat: 2, Alt: 3}, {Lon: 4, Lat: 5}}, coordinates)
+
+	coordinates, err = ParseCoordinates("")
+	require.NoError(t, err)
+	assert.Empty(t, coordinates)
+}
+
+func readAllPlacemarks(t *testing.T, r *KMLReader) []Placemark {
+	t.Helper()
+
+	var placemarks []Placemark
+
+	for {
+		placemark, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return placemarks
+		}
+
+		require.NoError(t, err)
+
+		placemarks = append(placemarks, placemark)
+	}
+}
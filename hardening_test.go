@@ -0,0 +1,125 @@
+package fastxml
+
+import (
+	"errors"
+	"testing"
+)
+
+// fuzzSeeds are documents which prefixes and mutations are used to check that parser never panics.
+var fuzzSeeds = []string{
+	`<?xml version="1.0" encoding="UTF-8"?><!DOCTYPE a [<!ELEMENT a ANY>]><a>`,
+	`<a b='1' c="2"><b/><c /></a>`,
+	`<!-- comment --><![CDATA[data]]>text</a>`,
+	`</a></ ></>`,
+	`<a b= c=' d="`,
+	`<a =''`,
+	`<!DOCTYPE`,
+	`<!-->`,
+	`<?>`,
+}
+
+// parseAll parses all tokens and attributes of data, stopping on the first error.
+func parseAll(data []byte) {
+	p := NewParser(data, false)
+
+	for i := 0; i <= len(data); i++ {
+		token, err := p.Next()
+		if err != nil {
+			return
+		}
+
+		readAttributes(token, len(data))
+	}
+}
+
+// decodeAll reads all tokens of data with Decoder, stopping on the first error.
+func decodeAll(data []byte) {
+	d := NewBytesDecoder(data)
+
+	for i := 0; i <= len(data); i++ {
+		if _, err := d.Token(); err != nil {
+			return
+		}
+	}
+}
+
+// feedAll feeds data to the stream parser in chunks, parsing all available tokens after each chunk.
+func feedAll(data []byte, chunkSize int) {
+	p := NewStreamParser()
+
+	for start := 0; start < len(data); start += chunkSize {
+		end := start + chunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+
+		p.Feed(data[start:end])
+
+		for i := 0; i <= len(data); i++ {
+			token, err := p.Next()
+			if errors.Is(err, ErrNeedMoreData) {
+				break
+			}
+
+			if err != nil {
+				return
+			}
+
+			readAttributes(token, len(data))
+		}
+	}
+}
+
+func readAttributes(token interface{}, limit int) {
+	start, ok := token.(*StartToken)
+	if !ok {
+		return
+	}
+
+	for i := 0; i <= limit; i++ {
+		if _, _, err := start.NextAttribute(); err != nil {
+			return
+		}
+	}
+}
+
+// callHelpers calls exported and internal helpers that receive raw buffer.
+func callHelpers(data []byte) {
+	_, _ = FetchNextToken(data)
+	_, _, _ = NextWord(data)
+	_, _, _ = NextQuotedWord(data)
+	_ = NextNonSpaceIndex(data)
+	_ = NextSpaceIndex(data)
+	_, _, _ = NextWordIndex(data)
+	_, _, _ = NextQuotedWordIndex(data)
+	_, _, _, _ = decodeTagAttribute(data, false)
+	_, _, _ = decodeBoolTagAttribute(data)
+
+	p := NewParser(nil, false)
+	_, _ = p.decodeToken(data)
+	_, _ = p.decodeClosingTag(data)
+	_, _ = p.decodeComment(data)
+	_, _ = p.decodeCdata(data)
+	_, _ = p.decodeSimpleTag(data)
+}
+
+func TestParser_NoPanicOnTruncatedInput(t *testing.T) {
+	for _, seed := range fuzzSeeds {
+		for end := 0; end <= len(seed); end++ {
+			data := []byte(seed[:end])
+
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						t.Errorf("panic on input %q: %v", data, r)
+					}
+				}()
+
+				parseAll(data)
+				decodeAll(data)
+				feedAll(data, 1)
+				callHelpers(data)
+			}()
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package fastxml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+)
+
+// rawTextElements are HTML elements which content is not parsed as markup.
+var rawTextElements = []string{"script", "style"}
+
+// WithHTMLMode makes parser to handle HTML specifics:
+//   - content of `<script>` and `<style>` elements is returned as char data
+//     without looking for tags inside, up to the matching end element;
+//   - attributes without value(like `<input disabled>`) are returned with empty value.
+//   - '<' that is not followed by a name, '/', '!' or '?', like in `a < b`, is returned as part of char data.
+func WithHTMLMode() ParserOption {
+	return func(p *Parser) {
+		p.html = true
+	}
+}
+
+// startRawText remembers that content of the element must be treated as raw text,
+// if the element is one of raw text elements.
+func (p *Parser) startRawText(name string) {
+	if !p.html || p.lastTagName != "" {
+		return
+	}
+
+	for _, rawTextName := range rawTextElements {
+		if strings.EqualFold(name, rawTextName) {
+			p.rawTextElement = rawTextName
+
+			return
+		}
+	}
+}
+
+// scanRawText returns length of raw text up to the end of current raw text element.
+func (p *Parser) scanRawText(buf []byte) (int, error) {
+	for idx := 0; ; {
+		closeIdx := bytes.Index(buf[idx:], []byte("</"))
+		if closeIdx == -1 {
+			return len(buf), ErrNeedMoreData
+		}
+
+		idx += closeIdx
+
+		nameEnd := idx + 2 + len(p.rawTextElement)
+		if nameEnd >= len(buf) {
+			return len(buf), ErrNeedMoreData
+		}
+
+		if bytes.EqualFold(buf[idx+2:nameEnd], []byte(p.rawTextElement)) &&
+			(buf[nameEnd] == '>' || buf[nameEnd] == '/' || IsHTMLSpaceChar(rune(buf[nameEnd]))) {
+			return idx, nil
+		}
+
+		idx += 2
+	}
+}
+
+// nextRawText returns content of the current raw text element as char data.
+func (p *Parser) nextRawText() (xml.Token, error) {
+	buf := p.buf[p.currentPointer:]
+
+	textEnd, err := p.scanRawText(buf)
+	if err != nil && p.streaming {
+		return nil, err
+	}
+
+	// Without end element all the rest of the document is raw text.
+	p.rawTextElement = ""
+
+	if textEnd == 0 {
+		return nil, errSkipToken
+	}
+
+	return p.textToken(buf[:textEnd])
+}
+
+// scanHTMLText returns length of char data at the start of buf up to the next tag,
+// including '<' that does not start a tag.
+//
+// ErrNeedMoreData is returned if buffer ends before the tag.
+func scanHTMLText(buf []byte) (int, error) {
+	for idx := 0; ; idx++ {
+		openIdx := bytes.IndexByte(buf[idx:], '<')
+		if openIdx == -1 {
+			return len(buf), ErrNeedMoreData
+		}
+
+		idx += openIdx
+
+		if idx+1 == len(buf) {
+			return len(buf), ErrNeedMoreData
+		}
+
+		if next := buf[idx+1]; next == '/' || next == '!' || next == '?' || isNameStartChar(rune(next)) {
+			return idx, nil
+		}
+	}
+}
+
+// nextHTMLText returns char data with '<' that does not start a tag, if there is such at the current position.
+func (p *Parser) nextHTMLText() (xml.Token, bool, error) {
+	buf := p.buf[p.currentPointer:]
+
+	textEnd, err := scanHTMLText(buf)
+	if bytes.IndexByte(buf[:textEnd], '<') == -1 {
+		return nil, false, nil
+	}
+
+	if err != nil && p.streaming {
+		return nil, true, err
+	}
+
+	token, err := p.textToken(buf[:textEnd])
+
+	return token, true, err
+}
+
+// textToken returns text at the current position as char data.
+func (p *Parser) textToken(text []byte) (xml.Token, error) {
+	p.currentPointer += len(text)
+	p.innerData.charData = text
+
+	if p.metrics != nil {
+		p.metrics.TokenScanned(TokenKindCharData, len(text))
+	}
+
+	if p.skipKinds&(1<<TokenKindCharData) != 0 {
+		return nil, errSkipToken
+	}
+
+	return &p.innerData.charData, nil
+}
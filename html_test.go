@@ -0,0 +1,151 @@
+package fastxml
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithHTMLMode_RawText(t *testing.T) {
+	input := `<html><SCRIPT type="text/javascript">if (a < b && c > d) { s = "</p>"; }</script >` +
+		`<style></style><script/><p>text</p></html>`
+
+	mustResult := []string{
+		`*fastxml.StartToken: &{"html" ""}`,
+		`*fastxml.StartToken: &{"SCRIPT" "type=\"text/javascript\">"}`,
+		`*fastxml.CharData: &"if (a < b && c > d) { s = \"</p>\"; }"`,
+		`*fastxml.EndElement: &{{"" "script"}}`,
+		`*fastxml.StartToken: &{"style" ""}`,
+		`*fastxml.EndElement: &{{"" "style"}}`,
+		`*fastxml.StartToken: &{"script" ""}`,
+		`*fastxml.EndElement: &{{"" "script"}}`,
+		`*fastxml.StartToken: &{"p" ""}`,
+		`*fastxml.CharData: &"text"`,
+		`*fastxml.EndElement: &{{"" "p"}}`,
+		`*fastxml.EndElement: &{{"" "html"}}`,
+	}
+
+	p := NewParser([]byte(input), false, WithHTMLMode())
+
+	var results []string
+
+	for {
+		token, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+
+		results = append(results, tokenString(token))
+	}
+
+	assert.Equal(t, mustResult, results)
+}
+
+func TestWithHTMLMode_RawTextStream(t *testing.T) {
+	p := NewStreamParser(WithHTMLMode())
+	p.Feed([]byte(`<script>a < b </scr`))
+
+	_, err := p.Next()
+	require.NoError(t, err)
+
+	_, err = p.Next()
+	require.ErrorIs(t, err, ErrNeedMoreData)
+
+	p.Feed([]byte(`ipt>`))
+
+	token, err := p.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "a < b ", string(*token.(*CharData)))
+
+	token, err = p.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "script", token.(*EndElement).Name.Local)
+}
+
+func TestWithHTMLMode_LessThanInText(t *testing.T) {
+	input := `<p>a < b, 1 <2, x<= y <b>c</b> <</p>`
+
+	mustResult := []string{
+		`*fastxml.StartToken: &{"p" ""}`,
+		`*fastxml.CharData: &"a < b, 1 <2, x<= y "`,
+		`*fastxml.StartToken: &{"b" ""}`,
+		`*fastxml.CharData: &"c"`,
+		`*fastxml.EndElement: &{{"" "b"}}`,
+		`*fastxml.CharData: &" <"`,
+		`*fastxml.EndElement: &{{"" "p"}}`,
+	}
+
+	p := NewParser([]byte(input), false, WithHTMLMode())
+
+	var results []string
+
+	for {
+		token, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+
+		results = append(results, tokenString(token))
+	}
+
+	assert.Equal(t, mustResult, results)
+
+	stream := NewStreamParser(WithHTMLMode())
+	stream.Feed([]byte(`a <`))
+
+	_, err := stream.Next()
+	require.ErrorIs(t, err, ErrNeedMoreData)
+
+	stream.Feed([]byte(` b<p>`))
+
+	token, err := stream.Next()
+	require.NoError(t, err)
+	assert.Equal(t, `*fastxml.CharData: &"a < b"`, tokenString(token))
+}
+
+func TestWithHTMLMode_BoolAttributes(t *testing.T) {
+	tests := []struct {
+		input string
+		attrs [][2]string
+	}{
+		{`<input disabled>`, [][2]string{{"disabled", ""}}},
+		{`<input disabled/>`, [][2]string{{"disabled", ""}}},
+		{`<input type="checkbox" checked disabled >`, [][2]string{{"type", "checkbox"}, {"checked", ""}, {"disabled", ""}}},
+		{`<input checked type = 'checkbox'>`, [][2]string{{"checked", ""}, {"type", "checkbox"}}},
+		{`<option a='1' b="2" x=''>`, [][2]string{{"a", "1"}, {"b", "2"}, {"x", ""}}},
+		{`<br>`, nil},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.input, func(t *testing.T) {
+			token, err := NewParser([]byte(test.input), false, WithHTMLMode()).Next()
+			require.NoError(t, err)
+
+			start := token.(*StartToken)
+
+			var attrs [][2]string
+
+			for {
+				name, value, err := start.NextAttribute()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+
+				require.NoError(t, err)
+
+				attrs = append(attrs, [2]string{name, value})
+			}
+
+			assert.Equal(t, test.attrs, attrs)
+		})
+	}
+}
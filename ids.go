@@ -0,0 +1,210 @@
+package fastxml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// IDViolationKind is the kind of ID/IDREF integrity violation.
+type IDViolationKind uint8
+
+const (
+	// IDViolationDuplicate is reported when the same ID value is used more than once.
+	IDViolationDuplicate IDViolationKind = iota + 1
+	// IDViolationDangling is reported when IDREF points to an ID that does not exist.
+	IDViolationDangling
+)
+
+func (k IDViolationKind) String() string {
+	switch k {
+	case IDViolationDuplicate:
+		return "duplicate id"
+	case IDViolationDangling:
+		return "dangling idref"
+	default:
+		return "unknown"
+	}
+}
+
+// IDAttributes describes which attributes hold IDs and references to them.
+//
+// As DTDs are not parsed, types of attributes must be provided by the caller.
+// Attributes are matched by their full name, like `id` or `xml:id`.
+type IDAttributes struct {
+	// ID attributes hold unique identifiers of elements.
+	ID []string
+	// IDRef attributes hold single reference to the ID.
+	IDRef []string
+	// IDRefs attributes hold whitespace separated list of references to IDs.
+	IDRefs []string
+}
+
+// IDViolation describes single violation of ID/IDREF integrity.
+type IDViolation struct {
+	Kind IDViolationKind
+	// Offset is the offset of the start of the element that holds the attribute.
+	Offset int
+	Attr   string
+	Value  string
+}
+
+func (v IDViolation) String() string {
+	return fmt.Sprintf("%s: offset %d: %s=%q", v.Kind, v.Offset, v.Attr, v.Value)
+}
+
+// ValidateIDs checks that values of ID attributes are unique and
+// that IDREF/IDREFS attributes point to existing IDs.
+//
+// Returned error is only set if document cannot be parsed,
+// integrity violations are returned as a list, ordered by offset.
+func ValidateIDs(buf []byte, attrs IDAttributes) ([]IDViolation, error) {
+	p := NewParser(buf, false)
+
+	type reference struct {
+		offset      int
+		attr, value string
+	}
+
+	var (
+		violations []IDViolation
+		references []reference
+		ids        = map[string]struct{}{}
+	)
+
+	for {
+		offset := p.currentPointer
+
+		token, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := token.(*StartToken)
+		if !ok || !start.HasAttributes() {
+			continue
+		}
+
+		for {
+			name, value, err := start.NextAttribute()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			if err != nil {
+				return nil, fmt.Errorf("offset %d: %w", offset, err)
+			}
+
+			switch {
+			case containsString(attrs.ID, name):
+				if _, ok := ids[value]; ok {
+					violations = append(violations, IDViolation{Kind: IDViolationDuplicate, Offset: offset, Attr: name, Value: value})
+				}
+
+				ids[value] = struct{}{}
+			case containsString(attrs.IDRef, name):
+				references = append(references, reference{offset: offset, attr: name, value: value})
+			case containsString(attrs.IDRefs, name):
+				for _, ref := range strings.Fields(value) {
+					references = append(references, reference{offset: offset, attr: name, value: ref})
+				}
+			}
+		}
+	}
+
+	// References are checked after all IDs are known, as they can point forward.
+	for _, ref := range references {
+		if _, ok := ids[ref.value]; !ok {
+			violations = append(violations, IDViolation{Kind: IDViolationDangling, Offset: ref.offset, Attr: ref.attr, Value: ref.value})
+		}
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Offset < violations[j].Offset
+	})
+
+	return violations, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ErrDuplicateID is returned by the parser with enabled ID tracking
+// when the same `xml:id` value is found more than once.
+var ErrDuplicateID = errors.New("duplicate xml:id")
+
+var xmlIDAttr = []byte("xml:id")
+
+// WithIDTracking makes parser remember offsets of elements with `xml:id` attribute,
+// which then can be found with Parser.ByID.
+//
+// If the same id is found more than once - Parser.Next will return ErrDuplicateID.
+func WithIDTracking() ParserOption {
+	return func(p *Parser) {
+		p.ids = map[string]int{}
+	}
+}
+
+// ByID returns offset of the start of the element with provided `xml:id`.
+//
+// Only elements that were already parsed can be found.
+// Parser must be created with WithIDTracking option.
+func (p *Parser) ByID(id string) (offset int, ok bool) {
+	offset, ok = p.ids[id]
+
+	return offset, ok
+}
+
+// trackID remembers offset of the element if it has `xml:id` attribute.
+func (p *Parser) trackID(start *StartToken, offset int) error {
+	if !bytes.Contains(start.attrBuf, xmlIDAttr) {
+		return nil
+	}
+
+	// Attributes are read from the copy of the token, so caller can still iterate over them.
+	attrs := *start
+
+	for {
+		name, value, err := attrs.NextAttribute()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+		}
+
+		if name != "xml:id" {
+			continue
+		}
+
+		if knownOffset, ok := p.ids[value]; ok {
+			// Same element could be already seen with Parser.Peek.
+			if knownOffset == offset {
+				return nil
+			}
+
+			return fmt.Errorf("%w: %q", ErrDuplicateID, value)
+		}
+
+		value = CopyString(value)
+		p.ids[value] = offset
+		p.retain(len(value))
+
+		return nil
+	}
+}
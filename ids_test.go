@@ -0,0 +1,108 @@
+package fastxml
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateIDs(t *testing.T) {
+	input := `<doc>
+	<item id="a" ref="b"/>
+	<item id="b" refs="a  c"/>
+	<item id="a"/>
+	<link ref="missing"/>
+</doc>`
+
+	violations, err := ValidateIDs([]byte(input), IDAttributes{
+		ID:     []string{"id"},
+		IDRef:  []string{"ref"},
+		IDRefs: []string{"refs"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []IDViolation{
+		{Kind: IDViolationDangling, Offset: 31, Attr: "refs", Value: "c"},
+		{Kind: IDViolationDuplicate, Offset: 59, Attr: "id", Value: "a"},
+		{Kind: IDViolationDangling, Offset: 75, Attr: "ref", Value: "missing"},
+	}, violations)
+	assert.Equal(t, `dangling idref: offset 75: ref="missing"`, violations[2].String())
+}
+
+func TestValidateIDs_Valid(t *testing.T) {
+	input := `<doc><a xml:id="first" next="second"/><b xml:id="second" next="first"/></doc>`
+
+	violations, err := ValidateIDs([]byte(input), IDAttributes{
+		ID:    []string{"xml:id"},
+		IDRef: []string{"next"},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+}
+
+func TestParser_ByID(t *testing.T) {
+	input := `<svg><g xml:id="group"><rect xml:id='r1' width="1"/></g><use href="#r1"/></svg>`
+
+	p := NewParser([]byte(input), false, WithIDTracking())
+
+	for {
+		token, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+
+		// Tracking must not consume attributes of the token.
+		if start, ok := token.(*StartToken); ok && start.Name == "rect" {
+			name, _, err := start.NextAttribute()
+			require.NoError(t, err)
+			require.Equal(t, "xml:id", name)
+		}
+	}
+
+	offset, ok := p.ByID("group")
+	require.True(t, ok)
+	assert.Equal(t, 5, offset)
+
+	offset, ok = p.ByID("r1")
+	require.True(t, ok)
+	assert.Equal(t, 23, offset)
+
+	_, ok = p.ByID("missing")
+	assert.False(t, ok)
+}
+
+func TestParser_ByIDDuplicate(t *testing.T) {
+	p := NewParser([]byte(`<a xml:id="x"><b xml:id="x"/></a>`), false, WithIDTracking())
+
+	_, err := p.Next()
+	require.NoError(t, err)
+
+	_, err = p.Next()
+	require.ErrorIs(t, err, ErrDuplicateID)
+}
+
+func TestParser_ByIDStream(t *testing.T) {
+	p := NewStreamParser(WithIDTracking())
+
+	for _, chunk := range []string{`<a xml:id="first">`, `text<b xml:id="second"/>`} {
+		p.Feed([]byte(chunk))
+
+		for {
+			_, err := p.Next()
+			if errors.Is(err, ErrNeedMoreData) {
+				break
+			}
+
+			require.NoError(t, err)
+		}
+	}
+
+	offset, ok := p.ByID("second")
+	require.True(t, ok)
+	assert.Equal(t, 22, offset)
+}
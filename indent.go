@@ -0,0 +1,198 @@
+package fastxml
+
+import (
+	"encoding/xml"
+)
+
+// xmlNamespace is the namespace bound to the "xml" prefix.
+const xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+// WithIndent makes encoder reformat the document, writing each element, comment,
+// processing instruction and directive on a new line, which starts with prefix
+// and then one copy of indent for each level of nesting.
+//
+// Whitespace-only text of tokens between elements is replaced by the indentation,
+// while other text is written as is.
+// Content of elements with text (mixed content, like `<p>Hello <b>world</b>!</p>`)
+// is not indented, as added whitespace would change the text.
+// Content of elements with `xml:space="preserve"` attribute and of elements set with WithPreformattedElements,
+// including their nested elements, is written as is.
+func WithIndent(prefix, indent string) EncoderOption {
+	return func(e *Encoder) {
+		e.indenting = true
+		e.prefix = prefix
+		e.indent = indent
+	}
+}
+
+// WithPreformattedElements sets local names of elements, content of which is not reformatted
+// when WithIndent is used, like `pre` or `code`, as if they have `xml:space="preserve"` attribute.
+func WithPreformattedElements(names ...string) EncoderOption {
+	return func(e *Encoder) {
+		if e.preformatted == nil {
+			e.preformatted = make(map[string]bool, len(names))
+		}
+
+		for _, name := range names {
+			e.preformatted[name] = true
+		}
+	}
+}
+
+// indentStart prepares indentation for start element, which is *StartToken or xml.StartElement.
+func (e *Encoder) indentStart(start xml.Token) {
+	if !e.indenting {
+		return
+	}
+
+	if e.preserve == 0 {
+		e.indentItem()
+
+		if name, space := startSpace(start); space == "preserve" || e.preformatted[localName(name)] {
+			e.preserve = len(e.mixed) + 1
+		}
+	}
+
+	e.mixed = append(e.mixed, false)
+	e.indentedIn = true
+}
+
+// indentEnd writes indentation before end element, unless element is empty or has mixed content.
+func (e *Encoder) indentEnd() {
+	depth := len(e.mixed)
+	if !e.indenting || depth == 0 {
+		return
+	}
+
+	mixed, indentedIn := e.mixed[depth-1], e.indentedIn
+	e.mixed, e.indentedIn = e.mixed[:depth-1], false
+
+	switch {
+	case e.preserve != 0:
+		if depth == e.preserve {
+			e.preserve = 0
+		}
+	case mixed:
+		e.flushSpace()
+	case indentedIn:
+		e.space = e.space[:0]
+	default:
+		e.space = e.space[:0]
+		e.writeIndent(depth - 1)
+	}
+}
+
+// indentItem writes indentation before markup, which is not an end element.
+func (e *Encoder) indentItem() {
+	if !e.indenting || e.preserve != 0 {
+		return
+	}
+
+	e.indentedIn = false
+
+	if depth := len(e.mixed); depth != 0 && e.mixed[depth-1] {
+		e.flushSpace()
+
+		return
+	}
+
+	e.space = e.space[:0]
+	e.writeIndent(len(e.mixed))
+}
+
+// indentText reports if text can be written now.
+//
+// Whitespace-only text of tokens is held until the next token,
+// as it is replaced by indentation, unless it is a part of mixed content.
+// If value is set - text is a part of the encoded value and is always written.
+func (e *Encoder) indentText(data []byte, value bool) bool {
+	if !e.indenting || e.preserve != 0 {
+		return true
+	}
+
+	if !value && isSpaceOnly(data) {
+		e.space = append(e.space, data...)
+
+		return false
+	}
+
+	if len(data) == 0 {
+		return true
+	}
+
+	e.flushSpace()
+	e.indentedIn = false
+
+	if depth := len(e.mixed); depth != 0 {
+		e.mixed[depth-1] = true
+	}
+
+	return true
+}
+
+// flushSpace writes held whitespace as is.
+func (e *Encoder) flushSpace() {
+	if len(e.space) == 0 {
+		return
+	}
+
+	e.closeStart()
+	e.w.Write(e.space)
+	e.space = e.space[:0]
+}
+
+// writeIndent writes new line with indentation for the depth.
+func (e *Encoder) writeIndent(depth int) {
+	if e.indented {
+		e.w.WriteByte('\n')
+	}
+
+	e.indented = true
+	e.w.WriteString(e.prefix)
+
+	for i := 0; i < depth; i++ {
+		e.w.WriteString(e.indent)
+	}
+}
+
+// startSpace returns name of the start element and value of its xml:space attribute.
+func startSpace(start xml.Token) (string, string) {
+	switch tkn := start.(type) {
+	case *StartToken:
+		// Attributes are read from the copy to not consume them from the caller's token.
+		attrs := *tkn
+
+		for {
+			name, val, err := attrs.NextAttribute()
+			if err != nil {
+				return tkn.Name, ""
+			}
+
+			if name == "xml:space" {
+				return tkn.Name, val
+			}
+		}
+	case xml.StartElement:
+		for _, attr := range tkn.Attr {
+			// Prefix is resolved to the namespace by encoding/xml decoder.
+			if (attr.Name.Space == "xml" || attr.Name.Space == xmlNamespace) && attr.Name.Local == "space" {
+				return tkn.Name.Local, attr.Value
+			}
+		}
+
+		return tkn.Name.Local, ""
+	default:
+		return "", ""
+	}
+}
+
+// isSpaceOnly reports if data consists only of whitespace.
+func isSpaceOnly(data []byte) bool {
+	for _, c := range data {
+		if !IsHTMLSpaceChar(rune(c)) {
+			return false
+		}
+	}
+
+	return true
+}
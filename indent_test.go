@@ -0,0 +1,137 @@
+package fastxml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithIndent(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		prefix string
+		opts   []EncoderOption
+		result string
+	}{
+		{
+			name:   "nested",
+			input:  "<!--top-->\n<a>\n<b>x</b>   <c><d/></c><!--c--></a>",
+			result: "<!--top-->\n<a>\n  <b>x</b>\n  <c>\n    <d></d>\n  </c>\n  <!--c-->\n</a>",
+		},
+		{
+			name:   "reindent",
+			input:  "<a>\n\t\t<b>\n\t\t\t<c/>\n\t\t</b>\n</a>",
+			result: "<a>\n  <b>\n    <c></c>\n  </b>\n</a>",
+		},
+		{
+			name:   "prefix and self-closing",
+			input:  "<a><b> </b></a>",
+			prefix: "> ",
+			opts:   []EncoderOption{WithEmptyElementStyle(EmptyElementSelfClosing)},
+			result: "> <a>\n>   <b/>\n> </a>",
+		},
+		{
+			name:   "mixed content",
+			input:  "<a><p>Hello <b>big</b> <i>world</i>!</p><p> <b>x</b> </p></a>",
+			result: "<a>\n  <p>Hello <b>big</b> <i>world</i>!</p>\n  <p>\n    <b>x</b>\n  </p>\n</a>",
+		},
+		{
+			name:   "xml:space",
+			input:  "<a><b xml:space=\"preserve\">\n  <c> x </c>\n\n</b><d xml:space=\"default\"> <e/></d></a>",
+			result: "<a>\n  <b xml:space=\"preserve\">\n  <c> x </c>\n\n</b>\n  <d xml:space=\"default\">\n    <e></e>\n  </d>\n</a>",
+		},
+		{
+			name:  "preformatted",
+			input: "<a><pre>\n  if x {\n    <b>y</b>\n  }\n</pre> <code> </code><p:pre> <c/></p:pre></a>",
+			opts:  []EncoderOption{WithPreformattedElements("pre", "code")},
+			result: "<a>\n  <pre>\n  if x {\n    <b>y</b>\n  }\n</pre>\n  <code> </code>\n" +
+				"  <p:pre> <c></c></p:pre>\n</a>",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			enc := NewEncoder(&buf, append(test.opts, WithIndent(test.prefix, "  "))...)
+
+			_, err := Copy(enc, NewParser([]byte(test.input), false))
+			require.NoError(t, err)
+			require.NoError(t, enc.Flush())
+
+			assert.Equal(t, test.result, buf.String())
+		})
+	}
+}
+
+func TestWithIndent_StdTokens(t *testing.T) {
+	var buf bytes.Buffer
+
+	enc := NewEncoder(&buf, WithIndent("", "\t"))
+
+	tokens := []xml.Token{
+		xml.StartElement{Name: xml.Name{Local: "a"}},
+		xml.StartElement{
+			Name: xml.Name{Local: "b"},
+			Attr: []xml.Attr{{Name: xml.Name{Space: "xml", Local: "space"}, Value: "preserve"}},
+		},
+		xml.CharData(" "),
+		xml.StartElement{Name: xml.Name{Local: "c"}},
+		xml.EndElement{Name: xml.Name{Local: "c"}},
+		xml.EndElement{Name: xml.Name{Local: "b"}},
+		xml.CharData("\n "),
+		xml.StartElement{Name: xml.Name{Local: "d"}},
+		xml.CharData(" "),
+		xml.EndElement{Name: xml.Name{Local: "d"}},
+		xml.EndElement{Name: xml.Name{Local: "a"}},
+	}
+
+	require.NoError(t, enc.WriteDeclaration(Declaration{}))
+
+	for _, token := range tokens {
+		require.NoError(t, enc.WriteToken(token))
+	}
+
+	require.NoError(t, enc.Flush())
+
+	assert.Equal(t, "<?xml version=\"1.0\"?>\n<a>\n\t<b xml:space=\"preserve\"> <c></c></b>\n\t<d></d>\n</a>", buf.String())
+}
+
+func TestWithIndent_Values(t *testing.T) {
+	type doc struct {
+		XMLName xml.Name `xml:"doc"`
+		Items   struct {
+			Item []string `xml:"item"`
+		} `xml:"items"`
+		Space string `xml:"space"`
+		Note  struct {
+			Text string `xml:",chardata"`
+			Code string `xml:"code"`
+		} `xml:"note"`
+	}
+
+	var v doc
+
+	v.Items.Item = []string{"a", "b"}
+	v.Space = " "
+	v.Note.Text = "see"
+	v.Note.Code = "x"
+
+	var buf bytes.Buffer
+
+	enc := NewEncoder(&buf, WithIndent("", "  "))
+	require.NoError(t, enc.Encode(v))
+	require.NoError(t, enc.Flush())
+
+	// Unlike encoding/xml, text of the mixed content is not changed.
+	expected := "<doc>\n  <items>\n    <item>a</item>\n    <item>b</item>\n  </items>\n" +
+		"  <space> </space>\n  <note>see<code>x</code></note>\n</doc>"
+
+	assert.Equal(t, expected, buf.String())
+}
@@ -0,0 +1,86 @@
+package fastxml
+
+// Interner deduplicates strings by their content.
+//
+// It is useful when the same values(like element names or enumerated attribute values)
+// are repeated many times in the document and must outlive parsed buffer:
+// instead of copying each of them with CopyString, only one copy per unique value is made.
+//
+// Interner is not safe for concurrent use.
+type Interner struct {
+	strings map[string]string
+	size    int
+}
+
+// NewInterner will create an empty interner.
+func NewInterner() *Interner {
+	return &Interner{
+		strings: map[string]string{},
+	}
+}
+
+// Intern returns string with the same content as b.
+//
+// String is allocated only the first time the content is seen.
+func (in *Interner) Intern(b []byte) string {
+	s, _ := in.intern(b)
+
+	return s
+}
+
+// InternString returns deduplicated copy of s.
+//
+// Returned string never points to the memory of s, so it can be used
+// to get long-living copies of strings returned in tokens.
+func (in *Interner) InternString(s string) string {
+	return in.Intern([]byte(s))
+}
+
+// Len returns number of unique strings in the interner.
+func (in *Interner) Len() int {
+	return len(in.strings)
+}
+
+// Size returns total number of bytes in unique strings of the interner.
+func (in *Interner) Size() int {
+	return in.size
+}
+
+// intern returns interned string and reports if it was added to the interner.
+func (in *Interner) intern(b []byte) (string, bool) {
+	// Compiler does not allocate for string conversion in map lookup.
+	if s, ok := in.strings[string(b)]; ok {
+		return s, false
+	}
+
+	s := string(b)
+	in.strings[s] = s
+	in.size += len(s)
+
+	return s, true
+}
+
+// WithInternedNames makes parser to intern names of elements with provided interner.
+//
+// This way names in returned tokens do not point to the parsed buffer
+// and can be held onto after parsing is done.
+// Size of newly interned names is accounted in Parser.RetainedBytes.
+func WithInternedNames(in *Interner) ParserOption {
+	return func(p *Parser) {
+		p.interner = in
+	}
+}
+
+// name converts element name to string, interning it if needed.
+func (p *Parser) name(b []byte) string {
+	if p.interner == nil {
+		return unsafeByteToString(b)
+	}
+
+	s, added := p.interner.intern(b)
+	if added {
+		p.retain(len(s))
+	}
+
+	return s
+}
@@ -0,0 +1,64 @@
+package fastxml
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterner(t *testing.T) {
+	in := NewInterner()
+
+	first := in.Intern([]byte("value"))
+	second := in.InternString("value")
+	other := in.Intern([]byte("other"))
+
+	assert.Equal(t, "value", first)
+	assert.Equal(t, "other", other)
+	assert.Equal(t, unsafeStringData(first), unsafeStringData(second), "same content must be deduplicated")
+	assert.Equal(t, 2, in.Len())
+	assert.Equal(t, len("value")+len("other"), in.Size())
+}
+
+func TestWithInternedNames(t *testing.T) {
+	buf := []byte(`<a><b/><b></b></a>`)
+
+	in := NewInterner()
+	p := NewParser(buf, false, WithInternedNames(in))
+
+	var names []string
+
+	for {
+		token, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+
+		switch tkn := token.(type) {
+		case *StartToken:
+			names = append(names, tkn.Name)
+		case *EndElement:
+			names = append(names, tkn.Name.Local)
+		}
+	}
+
+	// Interned names must not be affected by changes of the parsed buffer.
+	for i := range buf {
+		buf[i] = 'x'
+	}
+
+	assert.Equal(t, []string{"a", "b", "b", "b", "b", "a"}, names)
+	assert.Equal(t, 2, in.Len())
+	assert.Equal(t, 2, p.RetainedBytes())
+}
+
+func unsafeStringData(s string) uintptr {
+	return (*reflect.StringHeader)(unsafe.Pointer(&s)).Data
+}
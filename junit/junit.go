@@ -0,0 +1,434 @@
+/*
+Package junit provides streaming parsing of JUnit-style test reports.
+
+Reports with `testsuites` or `testsuite` root elements are supported, including nested suites.
+Test cases are returned one by one, so even huge reports can be processed without building
+the full document in memory. Attributes of the enclosing suites are available along with each test case.
+*/
+package junit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"fastxml"
+)
+
+// Status is the outcome of the test case.
+type Status uint8
+
+const (
+	StatusPassed Status = iota
+	StatusFailed
+	StatusError
+	StatusSkipped
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusPassed:
+		return "passed"
+	case StatusFailed:
+		return "failed"
+	case StatusError:
+		return "error"
+	case StatusSkipped:
+		return "skipped"
+	default:
+		return "unknown"
+	}
+}
+
+var ErrUnknownRoot = errors.New("unknown report root element")
+
+var cdataPrefix = []byte("<![CDATA[")
+
+// Suite holds attributes and properties of `testsuite` element.
+type Suite struct {
+	Name      string
+	Package   string
+	Hostname  string
+	Timestamp string
+	Tests     int
+	Failures  int
+	Errors    int
+	Skipped   int
+	Time      time.Duration
+	// Properties are set from `properties` element, if it precedes test cases of the suite.
+	Properties map[string]string
+}
+
+// Result is the `failure`, `error` or `skipped` element of the test case.
+type Result struct {
+	Message string
+	Type    string
+	Text    string
+}
+
+// TestCase is a single `testcase` element.
+type TestCase struct {
+	Name      string
+	ClassName string
+	File      string
+	Line      int
+	Time      time.Duration
+	Status    Status
+	// Result is set for test cases that did not pass.
+	Result    *Result
+	SystemOut string
+	SystemErr string
+	// Suites are the enclosing suites, from the outermost one.
+	Suites []*Suite
+}
+
+// Suite returns the innermost suite of the test case, or nil if test case is not inside of the suite.
+func (tc *TestCase) Suite() *Suite {
+	if len(tc.Suites) == 0 {
+		return nil
+	}
+
+	return tc.Suites[len(tc.Suites)-1]
+}
+
+// Reader iterates over test cases of the report.
+type Reader struct {
+	buf    []byte
+	p      *fastxml.Parser
+	suites []*Suite
+}
+
+// NewReader will create a reader over the report.
+func NewReader(buf []byte) (*Reader, error) {
+	r := &Reader{
+		buf: buf,
+		p:   fastxml.NewParser(buf, false),
+	}
+
+	for {
+		token, err := r.p.Next()
+		if err != nil {
+			return nil, fmt.Errorf("find root element: %w", err)
+		}
+
+		start, ok := token.(*fastxml.StartToken)
+		if !ok {
+			continue
+		}
+
+		switch localName(start.Name) {
+		case "testsuites":
+		case "testsuite":
+			if err := r.pushSuite(start); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("%w: %q", ErrUnknownRoot, start.Name)
+		}
+
+		return r, nil
+	}
+}
+
+// Next will return next test case of the report.
+// io.EOF is returned when no more test cases are available.
+//
+// Suites of returned test cases are shared between test cases of the same suite.
+func (r *Reader) Next() (TestCase, error) {
+	for {
+		token, err := r.p.Next()
+		if err != nil {
+			return TestCase{}, err
+		}
+
+		switch tkn := token.(type) {
+		case *fastxml.StartToken:
+			switch localName(tkn.Name) {
+			case "testsuite":
+				if err := r.pushSuite(tkn); err != nil {
+					return TestCase{}, err
+				}
+			case "testcase":
+				return r.readTestCase(tkn)
+			case "properties":
+				if err := r.readProperties(); err != nil {
+					return TestCase{}, err
+				}
+			default:
+				if err := skipElement(r.p); err != nil {
+					return TestCase{}, err
+				}
+			}
+		case *fastxml.EndElement:
+			if len(r.suites) != 0 && localName(tkn.Name.Local) == "testsuite" {
+				r.suites = r.suites[:len(r.suites)-1]
+			}
+		}
+	}
+}
+
+func (r *Reader) pushSuite(start *fastxml.StartToken) error {
+	suite := &Suite{}
+
+	err := eachAttribute(start, func(name, value string) (err error) {
+		switch name {
+		case "name":
+			suite.Name = value
+		case "package":
+			suite.Package = value
+		case "hostname":
+			suite.Hostname = value
+		case "timestamp":
+			suite.Timestamp = value
+		case "tests":
+			suite.Tests, err = strconv.Atoi(value)
+		case "failures":
+			suite.Failures, err = strconv.Atoi(value)
+		case "errors":
+			suite.Errors, err = strconv.Atoi(value)
+		case "skipped", "disabled":
+			suite.Skipped, err = strconv.Atoi(value)
+		case "time":
+			suite.Time, err = ParseTime(value)
+		}
+
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("read testsuite: %w", err)
+	}
+
+	r.suites = append(r.suites, suite)
+
+	return nil
+}
+
+func (r *Reader) readProperties() error {
+	var suite *Suite
+	if len(r.suites) != 0 {
+		suite = r.suites[len(r.suites)-1]
+	}
+
+	for {
+		token, err := r.p.Next()
+		if err != nil {
+			return fmt.Errorf("read properties: %w", err)
+		}
+
+		switch tkn := token.(type) {
+		case *fastxml.StartToken:
+			var name, value string
+
+			err := eachAttribute(tkn, func(attrName, attrValue string) error {
+				switch attrName {
+				case "name":
+					name = attrValue
+				case "value":
+					value = attrValue
+				}
+
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("read property: %w", err)
+			}
+
+			if err := skipElement(r.p); err != nil {
+				return err
+			}
+
+			if suite == nil || localName(tkn.Name) != "property" {
+				continue
+			}
+
+			if suite.Properties == nil {
+				suite.Properties = make(map[string]string)
+			}
+
+			suite.Properties[name] = value
+		case *fastxml.EndElement:
+			return nil
+		}
+	}
+}
+
+func (r *Reader) readTestCase(start *fastxml.StartToken) (TestCase, error) {
+	tc := TestCase{Suites: append([]*Suite(nil), r.suites...)}
+
+	err := eachAttribute(start, func(name, value string) (err error) {
+		switch name {
+		case "name":
+			tc.Name = value
+		case "classname":
+			tc.ClassName = value
+		case "file":
+			tc.File = value
+		case "line":
+			tc.Line, err = strconv.Atoi(value)
+		case "time":
+			tc.Time, err = ParseTime(value)
+		}
+
+		return err
+	})
+	if err != nil {
+		return TestCase{}, fmt.Errorf("read testcase: %w", err)
+	}
+
+	for {
+		token, err := r.p.Next()
+		if err != nil {
+			return TestCase{}, fmt.Errorf("read testcase: %w", err)
+		}
+
+		var child *fastxml.StartToken
+
+		switch tkn := token.(type) {
+		case *fastxml.StartToken:
+			child = tkn
+		case *fastxml.EndElement:
+			return tc, nil
+		default:
+			continue
+		}
+
+		name := localName(child.Name)
+
+		var result *Result
+
+		switch name {
+		case "failure":
+			tc.Status = StatusFailed
+		case "error":
+			tc.Status = StatusError
+		case "skipped":
+			tc.Status = StatusSkipped
+		}
+
+		if tc.Status != StatusPassed && tc.Result == nil {
+			result = &Result{}
+
+			err = eachAttribute(child, func(attrName, attrValue string) error {
+				switch attrName {
+				case "message":
+					result.Message = attrValue
+				case "type":
+					result.Type = attrValue
+				}
+
+				return nil
+			})
+			if err != nil {
+				return TestCase{}, fmt.Errorf("read %s: %w", name, err)
+			}
+
+			tc.Result = result
+		}
+
+		text, err := r.readText()
+		if err != nil {
+			return TestCase{}, fmt.Errorf("read %s: %w", name, err)
+		}
+
+		switch {
+		case result != nil:
+			result.Text = text
+		case name == "system-out":
+			tc.SystemOut = text
+		case name == "system-err":
+			tc.SystemErr = text
+		}
+	}
+}
+
+// readText will return text content of current element and advance parser past its end.
+//
+// Nested elements are skipped, CDATA sections are returned as is.
+func (r *Reader) readText() (string, error) {
+	var text strings.Builder
+
+	for {
+		start := r.p.InputOffset()
+
+		token, err := r.p.Next()
+		if err != nil {
+			return "", err
+		}
+
+		switch tkn := token.(type) {
+		case *fastxml.CharData:
+			if bytes.HasPrefix(r.buf[start:], cdataPrefix) {
+				text.Write(*tkn)
+			} else {
+				text.WriteString(fastxml.Unescape(string(*tkn)))
+			}
+		case *fastxml.StartToken:
+			if err := skipElement(r.p); err != nil {
+				return "", err
+			}
+		case *fastxml.EndElement:
+			return text.String(), nil
+		}
+	}
+}
+
+// ParseTime parses duration in seconds, which is used by `time` attributes.
+//
+// Thousands separators, that are written by some tools, are allowed.
+func ParseTime(val string) (time.Duration, error) {
+	seconds, err := strconv.ParseFloat(strings.ReplaceAll(val, ",", ""), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time value: %q", val)
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// eachAttribute calls fn with each attribute of the element, with references replaced in values.
+func eachAttribute(start *fastxml.StartToken, fn func(name, value string) error) error {
+	for {
+		name, value, err := start.NextAttribute()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := fn(localName(name), fastxml.Unescape(value)); err != nil {
+			return err
+		}
+	}
+}
+
+// skipElement will advance parser past the end of current element.
+func skipElement(p *fastxml.Parser) error {
+	for depth := 1; depth > 0; {
+		token, err := p.Next()
+		if err != nil {
+			return err
+		}
+
+		switch token.(type) {
+		case *fastxml.StartToken:
+			depth++
+		case *fastxml.EndElement:
+			depth--
+		}
+	}
+
+	return nil
+}
+
+func localName(name string) string {
+	if idx := strings.IndexByte(name, ':'); idx != -1 {
+		return name[idx+1:]
+	}
+
+	return name
+}
@@ -0,0 +1,111 @@
+package junit
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const report = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="all" tests="4" failures="1" errors="1">
+  <testsuite name="pkg/a" tests="3" failures="1" errors="1" skipped="1" time="1,234.5" hostname="ci">
+    <properties>
+      <property name="go.version" value="go1.21"/>
+    </properties>
+    <testcase name="TestOK" classname="pkg/a" time="0.010" file="a_test.go" line="12"/>
+    <testcase name="TestFail" classname="pkg/a" time="0.5">
+      <failure message="expected &lt;1&gt;" type="assert">a_test.go:20: mismatch &amp; more</failure>
+      <system-out><![CDATA[output <raw> &amp;]]></system-out>
+    </testcase>
+    <testcase name="TestErr"><error message="panic"/><system-err>stack</system-err></testcase>
+    <testsuite name="pkg/a/nested">
+      <testcase name="TestSkip"><skipped message="short mode"/></testcase>
+    </testsuite>
+    <system-out>suite output</system-out>
+  </testsuite>
+  <testsuite name="pkg/b">
+    <testcase name="TestB"/>
+  </testsuite>
+</testsuites>`
+
+func readAll(t *testing.T, r *Reader) []TestCase {
+	t.Helper()
+
+	var cases []TestCase
+
+	for {
+		tc, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return cases
+		}
+
+		require.NoError(t, err)
+
+		cases = append(cases, tc)
+	}
+}
+
+func TestReader(t *testing.T) {
+	r, err := NewReader([]byte(report))
+	require.NoError(t, err)
+
+	cases := readAll(t, r)
+	require.Len(t, cases, 5)
+
+	suiteA := &Suite{
+		Name: "pkg/a", Hostname: "ci", Tests: 3, Failures: 1, Errors: 1, Skipped: 1,
+		Time:       1234*time.Second + 500*time.Millisecond,
+		Properties: map[string]string{"go.version": "go1.21"},
+	}
+
+	assert.Equal(t, TestCase{
+		Name: "TestOK", ClassName: "pkg/a", File: "a_test.go", Line: 12, Time: 10 * time.Millisecond,
+		Suites: []*Suite{suiteA},
+	}, cases[0])
+	assert.Equal(t, TestCase{
+		Name: "TestFail", ClassName: "pkg/a", Time: 500 * time.Millisecond,
+		Status:    StatusFailed,
+		Result:    &Result{Message: "expected <1>", Type: "assert", Text: "a_test.go:20: mismatch & more"},
+		SystemOut: "output <raw> &amp;",
+		Suites:    []*Suite{suiteA},
+	}, cases[1])
+	assert.Equal(t, TestCase{
+		Name: "TestErr", Status: StatusError, Result: &Result{Message: "panic"}, SystemErr: "stack",
+		Suites: []*Suite{suiteA},
+	}, cases[2])
+
+	assert.Equal(t, "TestSkip", cases[3].Name)
+	assert.Equal(t, StatusSkipped, cases[3].Status)
+	assert.Equal(t, "short mode", cases[3].Result.Message)
+	require.Len(t, cases[3].Suites, 2)
+	assert.Equal(t, "pkg/a/nested", cases[3].Suite().Name)
+
+	assert.Equal(t, "TestB", cases[4].Name)
+	assert.Equal(t, StatusPassed, cases[4].Status)
+	assert.Equal(t, "pkg/b", cases[4].Suite().Name)
+}
+
+func TestReader_SuiteRoot(t *testing.T) {
+	r, err := NewReader([]byte(`<testsuite name="s"><testcase name="a"/><testcase name="b"/></testsuite>`))
+	require.NoError(t, err)
+
+	cases := readAll(t, r)
+	require.Len(t, cases, 2)
+	assert.Equal(t, "s", cases[1].Suite().Name)
+
+	_, err = NewReader([]byte(`<report/>`))
+	assert.ErrorIs(t, err, ErrUnknownRoot)
+}
+
+func TestParseTime(t *testing.T) {
+	d, err := ParseTime("0.25")
+	require.NoError(t, err)
+	assert.Equal(t, 250*time.Millisecond, d)
+
+	_, err = ParseTime("fast")
+	assert.Error(t, err)
+}
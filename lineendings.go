@@ -0,0 +1,94 @@
+package fastxml
+
+import (
+	"bytes"
+	"encoding/xml"
+)
+
+// LineEndings selects how parser handles line endings of the input.
+type LineEndings int
+
+const (
+	// LineEndingsRaw returns line endings as they are in the input, without any allocations.
+	LineEndingsRaw LineEndings = iota
+	// LineEndingsXML10 replaces "\r\n" and "\r" with "\n", as required by XML 1.0 specification.
+	LineEndingsXML10
+	// LineEndingsXML11 additionally replaces NEL(U+0085), "\r" followed by NEL and LS(U+2028) with "\n",
+	// as required by XML 1.1 specification.
+	LineEndingsXML11
+)
+
+var (
+	nelBytes = []byte("\u0085")
+	lsBytes  = []byte("\u2028")
+)
+
+// WithLineEndings sets the handling of line endings in returned tokens, LineEndingsRaw is the default.
+//
+// Line endings are normalized in char data, attributes, comments, directives and processing instructions.
+// Normalized data is written to the buffer of the parser, so tokens that had line endings replaced
+// do not point into the input, but are still valid only until the next call to Parser.Next.
+func WithLineEndings(mode LineEndings) ParserOption {
+	return func(p *Parser) {
+		p.lineEndings = mode
+	}
+}
+
+// normalizeLineEndings replaces line endings of the token in place, according to the mode of the parser.
+func (p *Parser) normalizeLineEndings(token xml.Token) {
+	switch tkn := token.(type) {
+	case *CharData:
+		*tkn = p.normalizedLineEndings(*tkn, TokenKindCharData)
+	case *StartToken:
+		tkn.attrBuf = p.normalizedLineEndings(tkn.attrBuf, TokenKindStartElement)
+	case *Comment:
+		*tkn = p.normalizedLineEndings(*tkn, TokenKindComment)
+	case *Directive:
+		*tkn = p.normalizedLineEndings(*tkn, TokenKindDirective)
+	case *ProcInst:
+		tkn.Inst = p.normalizedLineEndings(tkn.Inst, TokenKindProcInst)
+	}
+}
+
+// normalizedLineEndings returns data with replaced line endings,
+// or data itself if there is nothing to replace.
+//
+// kind is the kind of the token which data is normalized.
+func (p *Parser) normalizedLineEndings(data []byte, kind TokenKind) []byte {
+	xml11 := p.lineEndings == LineEndingsXML11
+
+	if bytes.IndexByte(data, '\r') == -1 &&
+		(!xml11 || !bytes.Contains(data, nelBytes) && !bytes.Contains(data, lsBytes)) {
+		return data
+	}
+
+	oldCap := cap(p.lineEndingsBuf)
+	p.lineEndingsBuf = p.lineEndingsBuf[:0]
+
+	for i := 0; i < len(data); i++ {
+		switch {
+		case data[i] == '\r':
+			p.lineEndingsBuf = append(p.lineEndingsBuf, '\n')
+
+			switch {
+			case i+1 < len(data) && data[i+1] == '\n':
+				i++
+			case xml11 && bytes.HasPrefix(data[i+1:], nelBytes):
+				i += len(nelBytes)
+			}
+		case xml11 && bytes.HasPrefix(data[i:], nelBytes):
+			p.lineEndingsBuf = append(p.lineEndingsBuf, '\n')
+			i += len(nelBytes) - 1
+		case xml11 && bytes.HasPrefix(data[i:], lsBytes):
+			p.lineEndingsBuf = append(p.lineEndingsBuf, '\n')
+			i += len(lsBytes) - 1
+		default:
+			p.lineEndingsBuf = append(p.lineEndingsBuf, data[i])
+		}
+	}
+
+	p.retain(cap(p.lineEndingsBuf) - oldCap)
+	p.auditLineEndings(kind)
+
+	return p.lineEndingsBuf
+}
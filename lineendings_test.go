@@ -0,0 +1,76 @@
+package fastxml
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithLineEndings(t *testing.T) {
+	input := "<a x=\"1\r\n2\">a\r\nb\rc\u0085d\r\u0085e\u2028f<!--\r\n--><?pi \r\n?></a>"
+
+	tests := []struct {
+		name       string
+		mode       LineEndings
+		mustTokens []xml.Token
+	}{
+		{
+			name: "raw",
+			mode: LineEndingsRaw,
+			mustTokens: []xml.Token{
+				xml.StartElement{Name: xml.Name{Local: "a"}, Attr: []xml.Attr{{Name: xml.Name{Local: "x"}, Value: "1\r\n2"}}},
+				xml.CharData("a\r\nb\rc\u0085d\r\u0085e\u2028f"),
+				xml.Comment("\r\n"),
+				xml.EndElement{Name: xml.Name{Local: "a"}},
+			},
+		},
+		{
+			name: "xml 1.0",
+			mode: LineEndingsXML10,
+			mustTokens: []xml.Token{
+				xml.StartElement{Name: xml.Name{Local: "a"}, Attr: []xml.Attr{{Name: xml.Name{Local: "x"}, Value: "1\n2"}}},
+				xml.CharData("a\nb\nc\u0085d\n\u0085e\u2028f"),
+				xml.Comment("\n"),
+				xml.EndElement{Name: xml.Name{Local: "a"}},
+			},
+		},
+		{
+			name: "xml 1.1",
+			mode: LineEndingsXML11,
+			mustTokens: []xml.Token{
+				xml.StartElement{Name: xml.Name{Local: "a"}, Attr: []xml.Attr{{Name: xml.Name{Local: "x"}, Value: "1\n2"}}},
+				xml.CharData("a\nb\nc\nd\ne\nf"),
+				xml.Comment("\n"),
+				xml.EndElement{Name: xml.Name{Local: "a"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			tokens, err := Tokens([]byte(input), WithLineEndings(test.mode))
+			require.NoError(t, err)
+
+			var stdTokens []xml.Token
+			for _, token := range tokens {
+				stdTokens = append(stdTokens, ToStd(token))
+			}
+
+			assert.Equal(t, test.mustTokens, stdTokens)
+		})
+	}
+}
+
+func TestWithLineEndings_ProcInst(t *testing.T) {
+	// Parser does not return processing instructions, but they are normalized when decoded.
+	p := NewParser(nil, false, WithLineEndings(LineEndingsXML10))
+
+	token := &ProcInst{Target: "pi", Inst: []byte("a\r\nb")}
+	p.normalizeLineEndings(token)
+
+	assert.Equal(t, []byte("a\nb"), token.Inst)
+}
@@ -0,0 +1,673 @@
+package fastxml
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	xmlAttrsType      = reflect.TypeOf([]xml.Attr(nil))
+	marshalerType     = reflect.TypeOf((*xml.Marshaler)(nil)).Elem()
+	marshalerAttrType = reflect.TypeOf((*xml.MarshalerAttr)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// encodeInfoCache holds *encodeInfo by reflect.Type of the struct.
+var encodeInfoCache sync.Map
+
+// Marshal returns XML encoding of v, see Encoder.Encode.
+func Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+
+	enc := NewEncoder(&buf)
+
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Encode will write XML encoding of v.
+//
+// Struct fields are mapped with the same struct tags as for ElementDecoder, and written in their order.
+// Fields of embedded structs are written in place of the embedded struct,
+// and are hidden by fields of the outer struct with the same name.
+//
+// Name of the element is taken from XMLName field, or from the name of the type.
+// Nil pointers and interfaces are not written, slices and arrays are written as repeated elements.
+// Elements and attributes with `omitempty` tag option are not written if their value is empty:
+// false, 0, nil pointer or interface, empty string, slice, array or map.
+// Structs, including time.Time, are never empty. These rules are the same as in encoding/xml,
+// so documents produced by both encoders are the same for types that both of them support,
+// unless options of this package, like `layout` tag, `base64` option or WithCDATAElements, are used.
+// Nested paths, like `xml:"a>b"`, are not supported, and ErrUnsupportedType is returned for them.
+// Maps with string keys are written as child elements, ordered by keys.
+// Text of char data fields with `cdata` tag option, like `xml:",cdata"`,
+// and of elements set by WithCDATAElements, is written as CDATA sections.
+//
+// Values implementing xml.Marshaler and xml.MarshalerAttr produce their own elements and attributes,
+// and values implementing encoding.TextMarshaler are written as text.
+// Methods with pointer receivers are only used for addressable values, like fields of the struct
+// passed by pointer. time.Time is written with layout from `layout` tag, or as RFC 3339 by default.
+// time.Duration is written as integer number of nanoseconds, as in encoding/xml.
+func (e *Encoder) Encode(v interface{}) error {
+	return e.EncodeElement(v, xml.StartElement{})
+}
+
+// EncodeElement will write XML encoding of v, using start as the element.
+//
+// If start has no name - name is chosen as with Encoder.Encode.
+func (e *Encoder) EncodeElement(v interface{}, start xml.StartElement) error {
+	if err := e.marshal(reflect.ValueOf(v), nil, start); err != nil {
+		return err
+	}
+
+	_, err := e.w.Write(nil)
+
+	return err
+}
+
+// RecordSource returns next record to encode, or io.EOF when there are no more records.
+type RecordSource func() (interface{}, error)
+
+// ChannelSource returns RecordSource over values received from channel ch, until it is closed.
+//
+// ch must be a channel of any type that can be received from.
+func ChannelSource(ch interface{}) RecordSource {
+	rv := reflect.ValueOf(ch)
+	if rv.Kind() != reflect.Chan || rv.Type().ChanDir()&reflect.RecvDir == 0 {
+		return func() (interface{}, error) {
+			return nil, fmt.Errorf("%w: %T is not a receivable channel", ErrUnsupportedType, ch)
+		}
+	}
+
+	return func() (interface{}, error) {
+		record, ok := rv.Recv()
+		if !ok {
+			return nil, io.EOF
+		}
+
+		return record.Interface(), nil
+	}
+}
+
+// EncodeStream will write start element, each record returned by next as its child element, and end element.
+//
+// Records are encoded as with Encoder.Encode, and output is flushed after each record,
+// so exports of any size are produced with constant memory, as long as records are not retained by the source.
+// If next or encoding of the record fails - end element is not written.
+func (e *Encoder) EncodeStream(start xml.StartElement, next RecordSource) error {
+	if err := e.WriteToken(start); err != nil {
+		return err
+	}
+
+	for {
+		record, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := e.Encode(record); err != nil {
+			return err
+		}
+
+		if err := e.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if err := e.WriteToken(start.End()); err != nil {
+		return err
+	}
+
+	return e.Flush()
+}
+
+// marshal writes v as element named after start, field or type of v.
+// field is the struct field v belongs to, or nil if v is not a field.
+func (e *Encoder) marshal(v reflect.Value, field *fieldInfo, start xml.StartElement) error {
+	// Same as in encoding/xml, emptiness is also checked for each element of slices.
+	if field != nil && field.omitEmpty && v.IsValid() && isEmptyValue(v) {
+		return nil
+	}
+
+	v = derefValue(v)
+	if !v.IsValid() {
+		return nil
+	}
+
+	if v.Type() != timeType {
+		if m, ok := asInterface(v, marshalerType); ok {
+			return e.marshalXML(m.(xml.Marshaler), marshalerStart(v.Type(), field, start))
+		}
+	}
+
+	_, isTextMarshaler := asInterface(v, textMarshalerType)
+
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() != reflect.Uint8 &&
+		!isTextMarshaler {
+		for i := 0; i < v.Len(); i++ {
+			if err := e.marshal(v.Index(i), field, start); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	switch {
+	case v.Kind() == reflect.Struct && !isTextMarshaler:
+		return e.marshalStruct(v, field, start)
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String && !isTextMarshaler:
+		return e.marshalMap(v, field, start)
+	}
+
+	start, err := elementStart(v, field, nil, start)
+	if err != nil {
+		return err
+	}
+
+	if e.buf, err = appendValueText(e.buf[:0], v, field); err != nil {
+		return err
+	}
+
+	e.writeStartElement(start)
+	e.writeText(e.buf, e.cdataElements[start.Name.Local])
+	e.writeEndElement(start.End())
+
+	return nil
+}
+
+// marshalXML writes value with its own MarshalXML method.
+//
+// Output of the method is not verified, so it must write balanced elements, as with encoding/xml.
+func (e *Encoder) marshalXML(m xml.Marshaler, start xml.StartElement) error {
+	e.closeStart()
+	e.indentItem()
+
+	// xml.Encoder buffers output, so it is flushed into the buffer of this encoder.
+	enc := xml.NewEncoder(e.w)
+
+	if err := m.MarshalXML(enc, start); err != nil {
+		return err
+	}
+
+	return enc.Flush()
+}
+
+func (e *Encoder) marshalStruct(v reflect.Value, field *fieldInfo, start xml.StartElement) error {
+	info, err := getEncodeInfo(v.Type())
+	if err != nil {
+		return err
+	}
+
+	start, err = elementStart(v, field, info.xmlName, start)
+	if err != nil {
+		return err
+	}
+
+	start.Attr = append([]xml.Attr(nil), start.Attr...)
+
+	for _, f := range info.fields {
+		if f.kind != fieldAttr && f.kind != fieldAnyAttr {
+			continue
+		}
+
+		fv, ok := existingFieldByIndex(v, f.index)
+		if !ok || f.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+
+		if start.Attr, err = e.appendAttr(start.Attr, fv, f); err != nil {
+			return fmt.Errorf("attribute %s of %s: %w", f.name, v.Type(), err)
+		}
+	}
+
+	e.writeStartElement(start)
+
+	cdata := e.cdataElements[start.Name.Local]
+
+	for _, f := range info.fields {
+		fv, ok := existingFieldByIndex(v, f.index)
+		if !ok {
+			continue
+		}
+
+		if err := e.marshalField(fv, f, cdata); err != nil {
+			return err
+		}
+	}
+
+	e.writeEndElement(start.End())
+
+	return nil
+}
+
+// marshalField writes content of the struct field, except of attributes.
+// If cdata is set - text of the struct is written as CDATA.
+func (e *Encoder) marshalField(v reflect.Value, field *fieldInfo, cdata bool) error {
+	switch field.kind {
+	case fieldElement, fieldAny:
+		return e.marshal(v, field, xml.StartElement{})
+	case fieldCharData, fieldInnerXML, fieldComment:
+	default:
+		return nil
+	}
+
+	v = derefValue(v)
+	if !v.IsValid() {
+		return nil
+	}
+
+	var err error
+
+	if e.buf, err = appendValueText(e.buf[:0], v, field); err != nil {
+		return fmt.Errorf("field %s: %w", field.name, err)
+	}
+
+	switch field.kind {
+	case fieldCharData:
+		e.writeText(e.buf, cdata || field.cdata)
+	case fieldInnerXML:
+		if len(e.buf) != 0 {
+			e.indentText(e.buf, true)
+			e.closeStart()
+		}
+
+		e.w.Write(e.buf)
+	case fieldComment:
+		if len(e.buf) == 0 {
+			return nil
+		}
+
+		if bytes.Contains(e.buf, []byte("--")) {
+			return fmt.Errorf("%w: comment of field %s contains \"--\"", ErrInvalidValue, field.name)
+		}
+
+		e.writeWrapped("<!--", e.buf, "-->")
+	}
+
+	return nil
+}
+
+// writeText writes text of the value, escaped or as CDATA.
+func (e *Encoder) writeText(text []byte, cdata bool) {
+	if len(text) != 0 {
+		e.indentText(text, true)
+		e.closeStart()
+	}
+
+	if cdata {
+		writeCDATA(e.w, text)
+	} else {
+		_ = xml.EscapeText(e.w, text) // Error is saved in the buffered writer.
+	}
+}
+
+func (e *Encoder) marshalMap(v reflect.Value, field *fieldInfo, start xml.StartElement) error {
+	start, err := elementStart(v, field, nil, start)
+	if err != nil {
+		return err
+	}
+
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+	e.writeStartElement(start)
+
+	for _, key := range keys {
+		if err := e.marshal(v.MapIndex(key), nil, xml.StartElement{Name: xml.Name{Local: key.String()}}); err != nil {
+			return err
+		}
+	}
+
+	e.writeEndElement(start.End())
+
+	return nil
+}
+
+// appendAttr appends attribute, or unmapped attributes, of the field to attrs.
+// Nil pointers are not written.
+func (e *Encoder) appendAttr(attrs []xml.Attr, v reflect.Value, field *fieldInfo) ([]xml.Attr, error) {
+	v = derefValue(v)
+	if !v.IsValid() {
+		return attrs, nil
+	}
+
+	if field.kind == fieldAnyAttr {
+		if v.Kind() == reflect.Slice {
+			return append(attrs, v.Convert(xmlAttrsType).Interface().([]xml.Attr)...), nil
+		}
+
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+		for _, key := range keys {
+			attrs = append(attrs, xml.Attr{Name: xml.Name{Local: key.String()}, Value: v.MapIndex(key).String()})
+		}
+
+		return attrs, nil
+	}
+
+	if m, ok := asInterface(v, marshalerAttrType); ok && v.Type() != timeType {
+		attr, err := m.(xml.MarshalerAttr).MarshalXMLAttr(xml.Name{Space: field.space, Local: field.name})
+		if err != nil {
+			return nil, err
+		}
+
+		// Same as in encoding/xml, attribute without name is not written.
+		if attr.Name.Local != "" {
+			attrs = append(attrs, attr)
+		}
+
+		return attrs, nil
+	}
+
+	var err error
+
+	if e.buf, err = appendValueText(e.buf[:0], v, field); err != nil {
+		return nil, err
+	}
+
+	return append(attrs, xml.Attr{Name: xml.Name{Local: field.name}, Value: string(e.buf)}), nil
+}
+
+// marshalerStart returns start element passed to xml.Marshaler, named the same way as by encoding/xml.
+func marshalerStart(t reflect.Type, field *fieldInfo, start xml.StartElement) xml.StartElement {
+	switch {
+	case start.Name.Local != "":
+	case field != nil && field.name != "":
+		start.Name = xml.Name{Space: field.space, Local: field.name}
+	default:
+		start.Name.Local = t.Name()
+	}
+
+	return start
+}
+
+// asInterface returns v, or pointer to v if v is addressable, as value of interface type t,
+// if it implements t.
+func asInterface(v reflect.Value, t reflect.Type) (interface{}, bool) {
+	if v.CanInterface() && v.Type().Implements(t) {
+		return v.Interface(), true
+	}
+
+	if v.CanAddr() {
+		if pv := v.Addr(); pv.CanInterface() && pv.Type().Implements(t) {
+			return pv.Interface(), true
+		}
+	}
+
+	return nil, false
+}
+
+// elementStart returns start element for value v.
+//
+// Name is taken from the start, if it is set, then from XMLName field of the struct,
+// then from the field v belongs to, and at last from the name of the type.
+func elementStart(v reflect.Value, field, xmlName *fieldInfo, start xml.StartElement) (xml.StartElement, error) {
+	if start.Name.Local != "" {
+		return start, nil
+	}
+
+	var space string
+
+	switch {
+	case xmlName != nil && xmlName.name != "":
+		start.Name.Local, space = xmlName.name, xmlName.space
+	case xmlName != nil:
+		if name, ok := existingFieldByIndex(v, xmlName.index); ok {
+			start.Name = name.Interface().(xml.Name)
+		}
+	}
+
+	if start.Name.Local == "" && field != nil {
+		start.Name.Local, space = field.name, field.space
+	}
+
+	if start.Name.Local == "" {
+		start.Name.Local = v.Type().Name()
+	}
+
+	if start.Name.Local == "" {
+		return start, fmt.Errorf("%w: no element name for %s", ErrUnsupportedType, v.Type())
+	}
+
+	if space != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "xmlns"}, Value: space})
+	}
+
+	return start, nil
+}
+
+// appendValueText appends text of the value of simple type to dst.
+func appendValueText(dst []byte, v reflect.Value, field *fieldInfo) ([]byte, error) {
+	switch v.Type() {
+	case timeType:
+		layout := time.RFC3339Nano
+		if field != nil && field.layout != "" {
+			layout = field.layout
+		}
+
+		return v.Interface().(time.Time).AppendFormat(dst, layout), nil
+	}
+
+	if m, ok := asInterface(v, textMarshalerType); ok {
+		text, err := m.(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return dst, err
+		}
+
+		return append(dst, text...), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return append(dst, v.String()...), nil
+	case reflect.Bool:
+		return strconv.AppendBool(dst, v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.AppendInt(dst, v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.AppendUint(dst, v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.AppendFloat(dst, v.Float(), 'g', -1, v.Type().Bits()), nil
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return appendBinary(dst, bytesOfValue(v), field), nil
+		}
+	}
+
+	return dst, fmt.Errorf("%w: %s", ErrUnsupportedType, v.Type())
+}
+
+// appendBinary appends data in encoding of the field.
+func appendBinary(dst, data []byte, field *fieldInfo) []byte {
+	if field == nil || field.encoding == binaryRaw {
+		return append(dst, data...)
+	}
+
+	n := len(dst)
+
+	if field.encoding == binaryBase64 {
+		dst = append(dst, make([]byte, base64.StdEncoding.EncodedLen(len(data)))...)
+		base64.StdEncoding.Encode(dst[n:], data)
+	} else {
+		dst = append(dst, make([]byte, hex.EncodedLen(len(data)))...)
+		hex.Encode(dst[n:], data)
+	}
+
+	return dst
+}
+
+// bytesOfValue returns bytes of byte slice or array.
+func bytesOfValue(v reflect.Value) []byte {
+	if v.Kind() == reflect.Slice {
+		return v.Bytes()
+	}
+
+	b := make([]byte, v.Len())
+	reflect.Copy(reflect.ValueOf(b), v)
+
+	return b
+}
+
+// isEmptyValue reports if value is omitted by `omitempty` option.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
+
+// derefValue returns value v points to, or invalid value if pointer or interface is nil.
+func derefValue(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		v = v.Elem()
+	}
+
+	return v
+}
+
+// encodeInfo describes fields of the struct in the order they are written.
+type encodeInfo struct {
+	fields  []*fieldInfo
+	xmlName *fieldInfo
+}
+
+func getEncodeInfo(t reflect.Type) (*encodeInfo, error) {
+	if info, ok := encodeInfoCache.Load(t); ok {
+		return info.(*encodeInfo), nil
+	}
+
+	info := &encodeInfo{}
+
+	if err := info.addFields(t, nil); err != nil {
+		return nil, err
+	}
+
+	info.hideEmbedded()
+
+	encodeInfoCache.Store(t, info)
+
+	return info, nil
+}
+
+func (info *encodeInfo) addFields(t reflect.Type, index []int) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("xml")
+
+		if tag == "-" {
+			continue
+		}
+
+		fieldIndex := append(append([]int(nil), index...), i)
+
+		if field.Anonymous && tag == "" && isStructType(field.Type) {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+
+			if err := info.addFields(ft, fieldIndex); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		fi, err := newFieldInfo(field, tag)
+		if err != nil {
+			return fmt.Errorf("field %s of %s: %w", field.Name, t, err)
+		}
+
+		fi.index = fieldIndex
+
+		if fi.kind == fieldXMLName {
+			if info.xmlName == nil {
+				info.xmlName = fi
+			}
+
+			continue
+		}
+
+		info.fields = append(info.fields, fi)
+	}
+
+	return nil
+}
+
+// hideEmbedded removes elements and attributes of embedded structs,
+// that have the same name as the ones of less embedded structs.
+func (info *encodeInfo) hideEmbedded() {
+	depths := map[string]int{}
+
+	for _, fi := range info.fields {
+		if key, ok := fi.nameKey(); ok {
+			if depth, ok := depths[key]; !ok || len(fi.index) < depth {
+				depths[key] = len(fi.index)
+			}
+		}
+	}
+
+	fields := info.fields[:0]
+
+	for _, fi := range info.fields {
+		if key, ok := fi.nameKey(); ok && len(fi.index) > depths[key] {
+			continue
+		}
+
+		fields = append(fields, fi)
+	}
+
+	info.fields = fields
+}
+
+// nameKey returns key of the field name, unique between elements and attributes.
+func (fi *fieldInfo) nameKey() (string, bool) {
+	switch fi.kind {
+	case fieldElement:
+		return fi.name, true
+	case fieldAttr:
+		return "@" + fi.name, true
+	default:
+		return "", false
+	}
+}
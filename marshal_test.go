@@ -0,0 +1,571 @@
+package fastxml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testMarshalBase struct {
+	ID   int    `xml:"id,attr"`
+	Note string `xml:"note"`
+}
+
+type testMarshalItem struct {
+	SKU   string  `xml:"sku,attr"`
+	Price float64 `xml:"price"`
+	Name  string  `xml:",chardata"`
+}
+
+type testMarshalOrder struct {
+	XMLName xml.Name `xml:"order"`
+	testMarshalBase
+	Note     string            `xml:"comment"`
+	Items    []testMarshalItem `xml:"item"`
+	Created  time.Time         `xml:"created"`
+	Day      time.Time         `xml:"day,attr" layout:"2006-01-02"`
+	Data     []byte            `xml:"data,base64"`
+	Tags     map[string]string `xml:"tags"`
+	Missing  *testMarshalItem  `xml:"missing"`
+	Extra    string            `xml:",innerxml"`
+	Remark   string            `xml:",comment"`
+	Attrs    []xml.Attr        `xml:",anyattr"`
+	Ignored  string            `xml:"-"`
+	internal string
+}
+
+func TestMarshal(t *testing.T) {
+	order := testMarshalOrder{
+		testMarshalBase: testMarshalBase{ID: 7, Note: "hidden by outer field"},
+		Note:            "a < b",
+		Items: []testMarshalItem{
+			{SKU: "a", Price: 1.5, Name: "A & B"},
+			{SKU: "b", Price: 2},
+		},
+		Created: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Day:     time.Date(2021, 2, 3, 0, 0, 0, 0, time.UTC),
+		Data:    []byte("abc"),
+		Tags:    map[string]string{"z": "1", "a": "2"},
+		Extra:   "<raw/>",
+		Remark:  " remark ",
+		Attrs:   []xml.Attr{{Name: xml.Name{Local: "lang"}, Value: "en"}},
+		Ignored: "ignored",
+	}
+
+	result, err := Marshal(&order)
+	require.NoError(t, err)
+
+	assert.Equal(t, `<order id="7" day="2021-02-03" lang="en">`+
+		`<note>hidden by outer field</note>`+
+		`<comment>a &lt; b</comment>`+
+		`<item sku="a"><price>1.5</price>A &amp; B</item>`+
+		`<item sku="b"><price>2</price></item>`+
+		`<created>2020-01-02T03:04:05Z</created>`+
+		`<data>YWJj</data>`+
+		`<tags><a>2</a><z>1</z></tags>`+
+		`<raw/><!-- remark --></order>`, string(result))
+}
+
+func TestMarshal_Names(t *testing.T) {
+	type named struct {
+		XMLName xml.Name
+		Value   int `xml:"urn:x value"`
+	}
+
+	type plain struct {
+		Value string
+	}
+
+	tests := []struct {
+		name   string
+		value  interface{}
+		result string
+	}{
+		{"type name", plain{Value: "v"}, `<plain><Value>v</Value></plain>`},
+		{"XMLName value", named{XMLName: xml.Name{Local: "custom"}, Value: 1}, `<custom><value xmlns="urn:x">1</value></custom>`},
+		{"XMLName empty", &named{Value: 1}, `<named><value xmlns="urn:x">1</value></named>`},
+		{"slice", []int{1, 2}, `<int>1</int><int>2</int>`},
+		{"nil pointer", (*plain)(nil), ``},
+		{"duration", 90 * time.Second, `<Duration>90000000000</Duration>`},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			result, err := Marshal(test.value)
+			require.NoError(t, err)
+			assert.Equal(t, test.result, string(result))
+		})
+	}
+}
+
+func TestMarshal_Errors(t *testing.T) {
+	_, err := Marshal(struct{ Value string }{})
+	require.ErrorIs(t, err, ErrUnsupportedType)
+
+	_, err = Marshal(struct {
+		XMLName xml.Name `xml:"a"`
+		C       chan int `xml:"c"`
+	}{C: make(chan int)})
+	require.ErrorIs(t, err, ErrUnsupportedType)
+
+	_, err = Marshal(struct {
+		XMLName xml.Name `xml:"a"`
+		Comment string   `xml:",comment"`
+	}{Comment: "a--b"})
+	require.ErrorIs(t, err, ErrInvalidValue)
+}
+
+func TestMarshal_RoundTrip(t *testing.T) {
+	order := testMarshalOrder{
+		testMarshalBase: testMarshalBase{ID: 7},
+		Note:            "a < b",
+		Items:           []testMarshalItem{{SKU: "a", Price: 1.5, Name: "A & B"}},
+		Created:         time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC),
+		Day:             time.Date(2021, 2, 3, 0, 0, 0, 0, time.UTC),
+		Data:            []byte{0, 1, 2, 255},
+		Tags:            map[string]string{"a": "1"},
+		Attrs:           []xml.Attr{{Name: xml.Name{Local: "lang"}, Value: "en"}},
+	}
+
+	result, err := Marshal(order)
+	require.NoError(t, err)
+
+	var decoded testMarshalOrder
+
+	require.NoError(t, Unmarshal(result, &decoded))
+	assert.Contains(t, decoded.Extra, `<item sku="a">`)
+
+	decoded.Extra = ""
+	order.XMLName = xml.Name{Local: "order"}
+	assert.Equal(t, order, decoded)
+}
+
+// countingWriter counts writes to the underlying writer.
+type countingWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes++
+
+	return w.Buffer.Write(p)
+}
+
+func TestEncoder_EncodeStream(t *testing.T) {
+	var w countingWriter
+
+	enc := NewEncoder(&w)
+
+	i := 0
+	next := func() (interface{}, error) {
+		if i == 3 {
+			return nil, io.EOF
+		}
+
+		i++
+
+		return testMarshalItem{SKU: string(rune('a' + i - 1)), Price: float64(i)}, nil
+	}
+
+	require.NoError(t, enc.EncodeStream(xml.StartElement{Name: xml.Name{Local: "items"}}, next))
+
+	assert.Equal(t, `<items>`+
+		`<testMarshalItem sku="a"><price>1</price></testMarshalItem>`+
+		`<testMarshalItem sku="b"><price>2</price></testMarshalItem>`+
+		`<testMarshalItem sku="c"><price>3</price></testMarshalItem>`+
+		`</items>`, w.String())
+	assert.Equal(t, 4, w.writes, "output is flushed after each record")
+
+	t.Run("error", func(t *testing.T) {
+		errSource := errors.New("source")
+
+		var buf bytes.Buffer
+
+		enc := NewEncoder(&buf)
+
+		err := enc.EncodeStream(xml.StartElement{Name: xml.Name{Local: "items"}}, func() (interface{}, error) {
+			return nil, errSource
+		})
+		require.ErrorIs(t, err, errSource)
+	})
+}
+
+func TestChannelSource(t *testing.T) {
+	ch := make(chan *testMarshalItem, 2)
+	ch <- &testMarshalItem{SKU: "a"}
+	ch <- nil
+
+	close(ch)
+
+	var buf bytes.Buffer
+
+	enc := NewEncoder(&buf)
+
+	require.NoError(t, enc.EncodeStream(xml.StartElement{Name: xml.Name{Local: "items"}}, ChannelSource(ch)))
+	assert.Equal(t, `<items><testMarshalItem sku="a"><price>0</price></testMarshalItem></items>`, buf.String())
+
+	_, err := ChannelSource(42)()
+	require.ErrorIs(t, err, ErrUnsupportedType)
+
+	_, err = ChannelSource(make(chan<- int))()
+	require.ErrorIs(t, err, ErrUnsupportedType)
+}
+
+type testCompatInner struct {
+	A string `xml:"a,attr,omitempty"`
+	B int    `xml:"b"`
+}
+
+type testCompatEmbedded struct {
+	E string `xml:"e,omitempty"`
+}
+
+type testCompat struct {
+	XMLName xml.Name `xml:"compat"`
+	*testCompatEmbedded
+
+	AttrString    string  `xml:"as,attr"`
+	AttrOmitted   string  `xml:"ao,attr,omitempty"`
+	AttrPtr       *int    `xml:"ap,attr"`
+	AttrPtrOmit   *int    `xml:"apo,attr,omitempty"`
+	AttrBool      bool    `xml:"ab,attr,omitempty"`
+	AttrFloat     float32 `xml:"af,attr"`
+	AttrUint      uint8   `xml:"au,attr,omitempty"`
+	String        string  `xml:"string"`
+	StringOmit    string  `xml:"string-omit,omitempty"`
+	Int           int     `xml:"int"`
+	IntOmit       int64   `xml:"int-omit,omitempty"`
+	Bool          bool    `xml:"bool"`
+	BoolOmit      bool    `xml:"bool-omit,omitempty"`
+	Float         float64 `xml:"float,omitempty"`
+	Ptr           *string `xml:"ptr"`
+	PtrOmit       *string `xml:"ptr-omit,omitempty"`
+	PtrPtr        **int   `xml:"ptr-ptr"`
+	Bytes         []byte  `xml:"bytes"`
+	BytesOmit     []byte  `xml:"bytes-omit,omitempty"`
+	Slice         []int   `xml:"slice"`
+	SliceOmit     []int   `xml:"slice-omit,omitempty"`
+	Array         [2]bool `xml:"array"`
+	EmptyArray    [0]int  `xml:"empty-array,omitempty"`
+	Struct        testCompatInner
+	StructOmit    testCompatInner             `xml:"struct-omit,omitempty"`
+	StructPtr     *testCompatInner            `xml:"struct-ptr"`
+	StructPtrOmit *testCompatInner            `xml:"struct-ptr-omit,omitempty"`
+	StructSlice   []*testCompatInner          `xml:"struct-slice"`
+	Iface         interface{}                 `xml:"iface"`
+	IfaceOmit     interface{}                 `xml:"iface-omit,omitempty"`
+	Time          time.Time                   `xml:"time"`
+	TimeOmit      time.Time                   `xml:"time-omit,omitempty"`
+	TimePtr       *time.Time                  `xml:"time-ptr,omitempty"`
+	Duration      time.Duration               `xml:"duration"`
+	DurationOmit  time.Duration               `xml:"duration-omit,omitempty"`
+	DurationAttr  time.Duration               `xml:"duration-attr,attr,omitempty"`
+	CharData      string                      `xml:",chardata"`
+	Comment       string                      `xml:",comment"`
+	Any           *testCompatInner            `xml:",any"`
+	Namespaced    string                      `xml:"urn:x namespaced,omitempty"`
+	Unexported    string                      `xml:"-"`
+	Nested        struct{ Inner []string }    `xml:"nested"`
+	NilSlicePtrs  []*string                   `xml:"nil-slice-ptrs"`
+	AnonymousPtrs *struct{ Value *int }       `xml:"anonymous-ptrs"`
+	Interfaces    []interface{}               `xml:"interfaces"`
+	StringPtrOmit *string                     `xml:"string-ptr-omit,omitempty"`
+	IntPtrs       [2]*int                     `xml:"int-ptrs"`
+	Bools         []bool                      `xml:"bools,omitempty"`
+	Floats        []float32                   `xml:"floats"`
+	Strings       []string                    `xml:"strings,omitempty"`
+	InnerPtr      *testCompatInner            `xml:"inner-ptr,omitempty"`
+	Named         testMarshalItem             `xml:"named"`
+	NamedPtrs     []*testMarshalItem          `xml:"named-ptrs,omitempty"`
+	Typed         testCompatTyped             `xml:"typed,omitempty"`
+	TypedAttr     testCompatTyped             `xml:"typed-attr,attr,omitempty"`
+	Nil           *struct{ XMLName xml.Name } `xml:"nil"`
+}
+
+type testCompatTyped string
+
+// TestMarshal_EncodingXMLCompatibility verifies that documents produced by Marshal are the same
+// as produced by encoding/xml, for zero, empty and nil values with and without `omitempty` option.
+func TestMarshal_EncodingXMLCompatibility(t *testing.T) {
+	zero, one := 0, 1
+	empty, text := "", "text"
+	zeroPtr := &zero
+
+	tests := []struct {
+		name  string
+		value interface{}
+	}{
+		{"zero", &testCompat{}},
+		{"zero value", testCompat{}},
+		{"nil", (*testCompat)(nil)},
+		{"empty values", &testCompat{
+			testCompatEmbedded: &testCompatEmbedded{},
+			AttrPtr:            &zero,
+			AttrPtrOmit:        &zero,
+			Ptr:                &empty,
+			PtrOmit:            &empty,
+			PtrPtr:             &zeroPtr,
+			Bytes:              []byte{},
+			BytesOmit:          []byte{},
+			Slice:              []int{},
+			SliceOmit:          []int{},
+			StructPtr:          &testCompatInner{},
+			StructPtrOmit:      &testCompatInner{},
+			StructSlice:        []*testCompatInner{nil, {}},
+			Iface:              (*testCompatInner)(nil),
+			IfaceOmit:          (*testCompatInner)(nil),
+			TimePtr:            &time.Time{},
+			Any:                &testCompatInner{},
+			NilSlicePtrs:       []*string{nil, &empty},
+			AnonymousPtrs:      &struct{ Value *int }{},
+			Interfaces:         []interface{}{nil, (*int)(nil), 0},
+			StringPtrOmit:      &empty,
+			IntPtrs:            [2]*int{nil, &zero},
+			Bools:              []bool{},
+			Strings:            []string{""},
+			NamedPtrs:          []*testMarshalItem{},
+		}},
+		{"values", &testCompat{
+			testCompatEmbedded: &testCompatEmbedded{E: "e"},
+			AttrString:         `"quoted" & <tagged>`,
+			AttrOmitted:        "omitted",
+			AttrPtr:            &one,
+			AttrPtrOmit:        &one,
+			AttrBool:           true,
+			AttrFloat:          1.25,
+			AttrUint:           255,
+			String:             "line\nbreak",
+			StringOmit:         "s",
+			Int:                -1,
+			IntOmit:            1 << 40,
+			Bool:               true,
+			BoolOmit:           true,
+			Float:              1e21,
+			Ptr:                &text,
+			PtrOmit:            &text,
+			Bytes:              []byte("bytes"),
+			BytesOmit:          []byte("<b>"),
+			Slice:              []int{1, 2},
+			SliceOmit:          []int{3},
+			Array:              [2]bool{true, false},
+			Struct:             testCompatInner{A: "a", B: 1},
+			StructOmit:         testCompatInner{B: 2},
+			StructSlice:        []*testCompatInner{{A: "x"}},
+			Iface:              testCompatInner{B: 3},
+			IfaceOmit:          "iface",
+			Time:               time.Date(2020, 1, 2, 3, 4, 5, 6, time.FixedZone("", 3600)),
+			Duration:           -90 * time.Second,
+			DurationOmit:       time.Nanosecond,
+			DurationAttr:       time.Hour,
+			CharData:           "char & data",
+			Comment:            "comment",
+			Namespaced:         "ns",
+			Nested:             struct{ Inner []string }{Inner: []string{"a", "b"}},
+			Interfaces:         []interface{}{"a", 1, true},
+			Floats:             []float32{0.1, 3},
+			Strings:            []string{"s"},
+			InnerPtr:           &testCompatInner{A: "inner"},
+			Named:              testMarshalItem{SKU: "sku", Price: 9.99, Name: "name"},
+			NamedPtrs:          []*testMarshalItem{nil, {SKU: "p"}},
+			Typed:              "typed",
+			TypedAttr:          "typed",
+		}},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			expected, err := xml.Marshal(test.value)
+			require.NoError(t, err)
+
+			result, err := Marshal(test.value)
+			require.NoError(t, err)
+
+			assert.Equal(t, string(expected), string(result))
+		})
+	}
+}
+
+// TestMarshal_NestedPath verifies that nested paths, which encoding/xml supports, are rejected
+// instead of producing a document that is different from the one of encoding/xml.
+func TestMarshal_NestedPath(t *testing.T) {
+	value := struct {
+		XMLName xml.Name `xml:"root"`
+		Value   string   `xml:"a>b"`
+	}{Value: "v"}
+
+	expected, err := xml.Marshal(value)
+	require.NoError(t, err)
+	assert.Equal(t, `<root><a><b>v</b></a></root>`, string(expected))
+
+	_, err = Marshal(value)
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+}
+
+// testPoint implements xml.Marshaler and xml.MarshalerAttr with value receiver.
+type testPoint struct {
+	X, Y int
+}
+
+func (p testPoint) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "x"}, Value: strconv.Itoa(p.X)})
+
+	return e.EncodeElement(p.Y, start)
+}
+
+func (p testPoint) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	if p.X == 0 && p.Y == 0 {
+		return xml.Attr{}, nil
+	}
+
+	return xml.Attr{Name: name, Value: fmt.Sprintf("%d,%d", p.X, p.Y)}, nil
+}
+
+// testLevel implements encoding.TextMarshaler with pointer receiver.
+type testLevel int
+
+func (l *testLevel) MarshalText() ([]byte, error) {
+	if *l < 0 {
+		return nil, errors.New("negative level")
+	}
+
+	return []byte(strings.Repeat("*", int(*l))), nil
+}
+
+// testCSV implements encoding.TextMarshaler with value receiver.
+type testCSV []string
+
+func (c testCSV) MarshalText() ([]byte, error) {
+	return []byte(strings.Join(c, ",")), nil
+}
+
+type testMarshalers struct {
+	XMLName   xml.Name    `xml:"marshalers"`
+	PointAttr testPoint   `xml:"point,attr"`
+	ZeroAttr  testPoint   `xml:"zero,attr"`
+	LevelAttr testLevel   `xml:"level,attr"`
+	Point     testPoint   `xml:"point"`
+	Points    []testPoint `xml:"points"`
+	PointPtr  *testPoint  `xml:"urn:p point-ptr"`
+	Level     testLevel   `xml:"level"`
+	CSV       testCSV     `xml:"csv"`
+	CSVs      []testCSV   `xml:"csvs"`
+	Text      testCSV     `xml:",chardata"`
+	Any       testPoint   `xml:",any"`
+}
+
+func TestMarshal_Marshalers(t *testing.T) {
+	value := &testMarshalers{
+		PointAttr: testPoint{X: 1, Y: 2},
+		LevelAttr: 2,
+		Point:     testPoint{X: 3, Y: 4},
+		Points:    []testPoint{{X: 5}, {Y: 6}},
+		PointPtr:  &testPoint{X: 7, Y: 8},
+		Level:     3,
+		CSV:       testCSV{"a", "b"},
+		CSVs:      []testCSV{{"c"}, {"d", "e"}},
+		Text:      testCSV{"<", ">"},
+		Any:       testPoint{X: 9, Y: 10},
+	}
+
+	expected, err := xml.Marshal(value)
+	require.NoError(t, err)
+
+	result, err := Marshal(value)
+	require.NoError(t, err)
+
+	assert.Equal(t, string(expected), string(result))
+	assert.Equal(t, `<marshalers point="1,2" level="**">`+
+		`<point x="3">4</point>`+
+		`<points x="5">0</points><points x="0">6</points>`+
+		`<point-ptr xmlns="urn:p" x="7">8</point-ptr>`+
+		`<level>***</level><csv>a,b</csv><csvs>c</csvs><csvs>d,e</csvs>&lt;,&gt;<Any x="9">10</Any></marshalers>`,
+		string(result))
+
+	t.Run("not addressable", func(t *testing.T) {
+		value := testMarshalers{Level: 1}
+
+		expected, err := xml.Marshal(value)
+		require.NoError(t, err)
+
+		result, err := Marshal(value)
+		require.NoError(t, err)
+
+		assert.Equal(t, string(expected), string(result))
+		assert.Contains(t, string(result), `level="0"`)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := Marshal(&testMarshalers{Level: -1})
+		require.EqualError(t, err, "negative level")
+
+		_, err = Marshal(&testMarshalers{LevelAttr: -1})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "negative level")
+	})
+}
+
+func TestMarshal_CDATA(t *testing.T) {
+	type page struct {
+		XMLName xml.Name `xml:"page"`
+		Script  string   `xml:"script"`
+		Title   string   `xml:"title"`
+		Body    struct {
+			Text string `xml:",chardata"`
+		} `xml:"body"`
+	}
+
+	type snippet struct {
+		XMLName xml.Name `xml:"snippet"`
+		Lang    string   `xml:"lang,attr"`
+		Code    string   `xml:",cdata"`
+	}
+
+	t.Run("cdata tag option", func(t *testing.T) {
+		for _, code := range []string{"if (a < b && c]]>d) {}", ""} {
+			value := snippet{Lang: "js", Code: code}
+
+			expected, err := xml.Marshal(value)
+			require.NoError(t, err)
+
+			result, err := Marshal(value)
+			require.NoError(t, err)
+
+			assert.Equal(t, string(expected), string(result))
+		}
+	})
+
+	t.Run("WithCDATAElements", func(t *testing.T) {
+		value := page{Script: "a < b", Title: "a < b"}
+		value.Body.Text = "<p>x</p>"
+
+		var buf bytes.Buffer
+
+		enc := NewEncoder(&buf, WithCDATAElements("script", "body"))
+
+		require.NoError(t, enc.Encode(value))
+		require.NoError(t, enc.Flush())
+
+		assert.Equal(t, `<page><script><![CDATA[a < b]]></script><title>a &lt; b</title>`+
+			`<body><![CDATA[<p>x</p>]]></body></page>`, buf.String())
+
+		var decoded page
+
+		require.NoError(t, Unmarshal(buf.Bytes(), &decoded))
+		assert.Equal(t, value.Script, decoded.Script)
+		assert.Equal(t, value.Body.Text, decoded.Body.Text)
+	})
+}
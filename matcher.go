@@ -0,0 +1,110 @@
+package fastxml
+
+import (
+	"strings"
+)
+
+// ElementMatcher checks elements against a set of names and paths compiled in advance.
+//
+// Candidates are found by the first byte of the element name, and then only the names
+// of the same length are compared, so elements are not compared with each name of the set.
+// This makes matcher cheap enough to be called for each element, like from DescendFunc.
+//
+// Names are compared as written in the document, including namespace prefixes.
+type ElementMatcher struct {
+	// buckets hold entries by the first byte of their names.
+	buckets [256][]matchEntry
+	empty   bool
+}
+
+// matchEntry is the compiled name or path.
+type matchEntry struct {
+	name string
+	// parents are names of the parents in the path, from the outermost one.
+	parents []string
+	// anchored is set if the first parent must be the root element.
+	anchored bool
+}
+
+// NewElementMatcher will compile matcher from element names and paths.
+//
+// Path is a slash separated list of names, like `book/title`,
+// which matches `title` element that is a child of `book` element.
+// Path starting with '/' is anchored at the root element, like `/catalog/book`.
+// Empty patterns are ignored.
+func NewElementMatcher(patterns ...string) *ElementMatcher {
+	m := &ElementMatcher{empty: true}
+
+	for _, pattern := range patterns {
+		entry := matchEntry{anchored: strings.HasPrefix(pattern, "/")}
+
+		names := strings.Split(strings.Trim(pattern, "/"), "/")
+		entry.name = names[len(names)-1]
+
+		if entry.name == "" {
+			continue
+		}
+
+		if len(names) > 1 {
+			entry.parents = names[:len(names)-1]
+		}
+
+		first := entry.name[0]
+		m.buckets[first] = append(m.buckets[first], entry)
+		m.empty = false
+	}
+
+	return m
+}
+
+// Match reports if element with the name and open parent elements (as returned by Parser.Stack) matches.
+func (m *ElementMatcher) Match(name string, parents []string) bool {
+	if name == "" || m.empty {
+		return false
+	}
+
+	for i := range m.buckets[name[0]] {
+		entry := &m.buckets[name[0]][i]
+
+		if len(entry.name) != len(name) || entry.name != name {
+			continue
+		}
+
+		if entry.parents == nil || entry.matchParents(parents) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchParents reports if innermost parents are the same as parents of the path.
+func (e *matchEntry) matchParents(parents []string) bool {
+	if len(parents) < len(e.parents) || e.anchored && len(parents) != len(e.parents) {
+		return false
+	}
+
+	parents = parents[len(parents)-len(e.parents):]
+
+	for i, name := range e.parents {
+		if parents[i] != name {
+			return false
+		}
+	}
+
+	return true
+}
+
+// DescendFunc returns function, which decides matched elements with match decision
+// and all other elements with other decision.
+//
+// For example, `m.DescendFunc(SkipSubtree, Descend)` skips all matched elements with their content.
+func (m *ElementMatcher) DescendFunc(match, other Decision) DescendFunc {
+	return func(start *StartToken, parents []string) Decision {
+		if m.Match(start.Name, parents) {
+			return match
+		}
+
+		return other
+	}
+}
@@ -0,0 +1,60 @@
+package fastxml
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestElementMatcher_Match(t *testing.T) {
+	m := NewElementMatcher("title", "book/author", "/catalog/book", "x:item", "", "/")
+
+	tests := []struct {
+		path  string
+		match bool
+	}{
+		{"title", true},
+		{"a/b/title", true},
+		{"titles", false},
+		{"tit", false},
+		{"book/author", true},
+		{"catalog/book/author", true},
+		{"author", false},
+		{"magazine/author", false},
+		{"catalog/book", true},
+		{"catalog/book/book", false},
+		{"shop/catalog/book", false},
+		{"book", false},
+		{"root/x:item", true},
+		{"root/item", false},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.path, func(t *testing.T) {
+			names := strings.Split(test.path, "/")
+
+			assert.Equal(t, test.match, m.Match(names[len(names)-1], names[:len(names)-1]))
+		})
+	}
+
+	assert.False(t, NewElementMatcher().Match("a", nil))
+	assert.False(t, m.Match("", nil))
+}
+
+func TestElementMatcher_DescendFunc(t *testing.T) {
+	input := `<root><skip><a/></skip><keep><skip/><b/></keep><c/></root>`
+
+	m := NewElementMatcher("skip", "c")
+
+	assert.Equal(t, []string{
+		`*fastxml.StartToken: &{"root" ""}`,
+		`*fastxml.StartToken: &{"keep" ""}`,
+		`*fastxml.StartToken: &{"b" ""}`,
+		`*fastxml.EndElement: &{{"" "b"}}`,
+		`*fastxml.EndElement: &{{"" "keep"}}`,
+		`*fastxml.EndElement: &{{"" "root"}}`,
+	}, collectTokens(t, input, WithDescendFunc(m.DescendFunc(SkipSubtree, Descend))))
+}
@@ -0,0 +1,51 @@
+package fastxml
+
+import "fmt"
+
+// MemoryBudgetError is returned when memory retained by the parser exceeds its budget.
+type MemoryBudgetError struct {
+	Budget   int
+	Retained int
+}
+
+func (e *MemoryBudgetError) Error() string {
+	return fmt.Sprintf("memory budget exceeded: retained %d bytes, budget is %d bytes", e.Retained, e.Budget)
+}
+
+// WithMemoryBudget sets maximum number of bytes that parser is allowed to retain.
+//
+// When budget is exceeded Parser.Next will return *MemoryBudgetError
+// and parsing cannot be continued.
+//
+// Only memory allocated by the parser itself is accounted,
+// buffer that is provided to NewParser without copying is owned by the caller.
+func WithMemoryBudget(budget int) ParserOption {
+	return func(p *Parser) {
+		p.memoryBudget = budget
+	}
+}
+
+// RetainedBytes returns number of bytes that are currently retained by the parser.
+//
+// This includes copy of the input buffer(if it was requested),
+// buffer for the data provided with Parser.Feed and names interned by the parser.
+func (p *Parser) RetainedBytes() int {
+	return p.retainedBytes
+}
+
+// retain will account n bytes that were allocated by the parser.
+func (p *Parser) retain(n int) {
+	p.retainedBytes += n
+}
+
+// checkMemoryBudget returns error if parser retains more memory than allowed.
+func (p *Parser) checkMemoryBudget() error {
+	if p.memoryBudget > 0 && p.retainedBytes > p.memoryBudget {
+		return &MemoryBudgetError{
+			Budget:   p.memoryBudget,
+			Retained: p.retainedBytes,
+		}
+	}
+
+	return nil
+}
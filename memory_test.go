@@ -0,0 +1,46 @@
+package fastxml
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParser_RetainedBytes(t *testing.T) {
+	input := []byte("<a>text</a>")
+
+	assert.Equal(t, 0, NewParser(input, false).RetainedBytes())
+	assert.GreaterOrEqual(t, NewParser(input, true).RetainedBytes(), len(input))
+
+	p := NewStreamParser()
+	p.Feed(input)
+	assert.GreaterOrEqual(t, p.RetainedBytes(), len(input))
+}
+
+func TestWithMemoryBudget(t *testing.T) {
+	input := []byte("<a>text</a>")
+
+	_, err := NewParser(input, false, WithMemoryBudget(4)).Next()
+	require.NoError(t, err, "buffer that is not copied is not accounted")
+
+	_, err = NewParser(input, true, WithMemoryBudget(4)).Next()
+
+	var budgetErr *MemoryBudgetError
+
+	require.True(t, errors.As(err, &budgetErr), err)
+	assert.Equal(t, 4, budgetErr.Budget)
+	assert.GreaterOrEqual(t, budgetErr.Retained, len(input))
+
+	p := NewStreamParser(WithMemoryBudget(64))
+	p.Feed(input)
+
+	_, err = p.Next()
+	require.NoError(t, err)
+
+	p.Feed(make([]byte, 128))
+
+	_, err = p.Next()
+	require.True(t, errors.As(err, &budgetErr), err)
+}
@@ -0,0 +1,119 @@
+package fastxml
+
+import (
+	"bytes"
+	"time"
+)
+
+// TokenKind is a kind of the scanned token.
+type TokenKind uint8
+
+const (
+	TokenKindUnknown TokenKind = iota
+	TokenKindStartElement
+	TokenKindEndElement
+	TokenKindCharData
+	TokenKindComment
+	TokenKindProcInst
+	TokenKindDirective
+)
+
+var tokenKindNames = [...]string{
+	TokenKindUnknown:      "unknown",
+	TokenKindStartElement: "start_element",
+	TokenKindEndElement:   "end_element",
+	TokenKindCharData:     "char_data",
+	TokenKindComment:      "comment",
+	TokenKindProcInst:     "proc_inst",
+	TokenKindDirective:    "directive",
+}
+
+func (k TokenKind) String() string {
+	if int(k) < len(tokenKindNames) {
+		return tokenKindNames[k]
+	}
+
+	return tokenKindNames[TokenKindUnknown]
+}
+
+// ParsePhase is a phase of parsing a single token.
+type ParsePhase uint8
+
+const (
+	// ParsePhaseScan is the phase of finding token boundaries.
+	ParsePhaseScan ParsePhase = iota + 1
+	// ParsePhaseDecode is the phase of decoding found token.
+	ParsePhaseDecode
+)
+
+func (p ParsePhase) String() string {
+	switch p {
+	case ParsePhaseScan:
+		return "scan"
+	case ParsePhaseDecode:
+		return "decode"
+	default:
+		return "unknown"
+	}
+}
+
+// Metrics receives instrumentation events from the parser.
+//
+// Implementation can forward these events to Prometheus, expvar or any other metrics system.
+// Methods are called synchronously from Parser.Next, so they should be cheap.
+type Metrics interface {
+	// TokenScanned is called for each scanned token with its kind and size in bytes.
+	//
+	// End elements of self-closing tags are reported with size of 0,
+	// as they do not consume any additional data.
+	TokenScanned(kind TokenKind, size int)
+	// PhaseDuration is called with time spent in parsing phase of a single token.
+	PhaseDuration(phase ParsePhase, d time.Duration)
+}
+
+// WithMetrics sets metrics that will receive instrumentation events from the parser.
+func WithMetrics(m Metrics) ParserOption {
+	return func(p *Parser) {
+		p.metrics = m
+	}
+}
+
+// scannedTokenKind returns kind of the raw token returned from FetchNextToken.
+func scannedTokenKind(buf []byte) TokenKind {
+	switch {
+	case len(buf) == 0:
+		return TokenKindUnknown
+	case buf[0] != '<', bytes.HasPrefix(buf, cdataPrefix):
+		return TokenKindCharData
+	case bytes.HasPrefix(buf, commentPrefix):
+		return TokenKindComment
+	case len(buf) < 2:
+		return TokenKindUnknown
+	case buf[1] == '/':
+		return TokenKindEndElement
+	case buf[1] == '?':
+		return TokenKindProcInst
+	case buf[1] == '!':
+		return TokenKindDirective
+	default:
+		return TokenKindStartElement
+	}
+}
+
+// phaseStart returns start time of the parsing phase, if metrics are enabled.
+func (p *Parser) phaseStart() time.Time {
+	if p.metrics == nil {
+		return time.Time{}
+	}
+
+	return time.Now()
+}
+
+// observePhase reports duration of the phase that was started at `start`, if metrics are enabled.
+func (p *Parser) observePhase(phase ParsePhase, start time.Time) {
+	if p.metrics == nil {
+		return
+	}
+
+	p.metrics.PhaseDuration(phase, time.Since(start))
+}
@@ -0,0 +1,65 @@
+package fastxml
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testMetrics struct {
+	tokens map[TokenKind]int
+	bytes  int
+	phases map[ParsePhase]int
+}
+
+func (m *testMetrics) TokenScanned(kind TokenKind, size int) {
+	m.tokens[kind]++
+	m.bytes += size
+}
+
+func (m *testMetrics) PhaseDuration(phase ParsePhase, d time.Duration) {
+	m.phases[phase]++
+}
+
+func TestWithMetrics(t *testing.T) {
+	input := `<?xml version="1.0"?><!DOCTYPE a><a><!-- c --><b/>text<![CDATA[data]]></a>`
+
+	m := &testMetrics{
+		tokens: map[TokenKind]int{},
+		phases: map[ParsePhase]int{},
+	}
+
+	p := NewParser([]byte(input), false, WithMetrics(m))
+
+	for {
+		_, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, map[TokenKind]int{
+		TokenKindProcInst:     1,
+		TokenKindDirective:    1,
+		TokenKindStartElement: 2,
+		TokenKindEndElement:   2,
+		TokenKindComment:      1,
+		TokenKindCharData:     2,
+	}, m.tokens)
+	assert.Equal(t, len(input), m.bytes)
+	assert.Equal(t, map[ParsePhase]int{
+		ParsePhaseScan:   8,
+		ParsePhaseDecode: 8,
+	}, m.phases)
+}
+
+func TestTokenKind_String(t *testing.T) {
+	assert.Equal(t, "start_element", TokenKindStartElement.String())
+	assert.Equal(t, "unknown", TokenKind(100).String())
+}
@@ -0,0 +1,73 @@
+package fastxml
+
+import (
+	"strings"
+)
+
+// WithDefaultNamespace makes parser report unprefixed element names as qualified by the default namespace
+// in scope, which is declared with `xmlns` attribute, same as encoding/xml Decoder does:
+// StartToken.Space and EndElement.Name.Space are set to the namespace URL.
+//
+// By default names are reported as they are in the document, and namespace declarations
+// are only available as attributes. Prefixed names are not translated in both cases, see Decoder for that.
+func WithDefaultNamespace() ParserOption {
+	return func(p *Parser) {
+		p.defaultNamespace = true
+	}
+}
+
+// pushNamespace sets default namespace of the start element, which may be declared by the element itself.
+func (p *Parser) pushNamespace(start *StartToken) {
+	if !p.defaultNamespace {
+		return
+	}
+
+	var space string
+	if len(p.namespaces) != 0 {
+		space = p.namespaces[len(p.namespaces)-1]
+	}
+
+	// Attributes are read from the copy to not consume them from the caller's token.
+	attrs := *start
+
+	for {
+		name, val, err := attrs.NextAttribute()
+		if err != nil {
+			break
+		}
+
+		if name == xmlnsPrefix {
+			// Namespace must outlive the buffer, which is overwritten by Parser.Feed.
+			space = CopyString(Unescape(val))
+
+			break
+		}
+	}
+
+	p.namespaces = append(p.namespaces, space)
+
+	if strings.IndexByte(start.Name, ':') == -1 {
+		start.Space = space
+	} else {
+		start.Space = ""
+	}
+}
+
+// popNamespace sets default namespace of the end element and removes it from the scope.
+func (p *Parser) popNamespace(end *EndElement) {
+	if !p.defaultNamespace {
+		return
+	}
+
+	end.Name.Space = ""
+
+	if len(p.namespaces) == 0 {
+		return
+	}
+
+	if strings.IndexByte(end.Name.Local, ':') == -1 {
+		end.Name.Space = p.namespaces[len(p.namespaces)-1]
+	}
+
+	p.namespaces = p.namespaces[:len(p.namespaces)-1]
+}
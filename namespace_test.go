@@ -0,0 +1,104 @@
+package fastxml
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithDefaultNamespace(t *testing.T) {
+	const input = `<root xmlns="urn:a"><x:item xmlns:x="urn:x"/><item xmlns="urn:&amp;b"><inner/></item>` +
+		`<plain xmlns=""/><last/></root>`
+
+	collect := func(opts ...ParserOption) []string {
+		p := NewParser([]byte(input), false, opts...)
+
+		var names []string
+
+		for {
+			token, err := p.Next()
+			if errors.Is(err, io.EOF) {
+				return names
+			}
+
+			require.NoError(t, err)
+
+			switch tkn := token.(type) {
+			case *StartToken:
+				names = append(names, "<{"+tkn.Space+"}"+tkn.Name)
+			case *EndElement:
+				names = append(names, "</{"+tkn.Name.Space+"}"+tkn.Name.Local)
+			}
+		}
+	}
+
+	assert.Equal(t, []string{
+		"<{urn:a}root",
+		"<{}x:item", "</{}x:item",
+		"<{urn:&b}item", "<{urn:&b}inner", "</{urn:&b}inner", "</{urn:&b}item",
+		"<{}plain", "</{}plain",
+		"<{urn:a}last", "</{urn:a}last",
+		"</{urn:a}root",
+	}, collect(WithDefaultNamespace()))
+
+	raw := collect()
+	assert.Equal(t, "<{}root", raw[0])
+	assert.Equal(t, "</{}root", raw[len(raw)-1])
+}
+
+func TestWithDefaultNamespace_MatchesEncodingXML(t *testing.T) {
+	const input = `<root xmlns="urn:a"><item xmlns="urn:b"/><other/></root>`
+
+	p := NewParser([]byte(input), false, WithDefaultNamespace())
+	d := xml.NewDecoder(strings.NewReader(input))
+
+	for {
+		token, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+
+		expected, err := d.Token()
+		require.NoError(t, err)
+
+		switch tkn := token.(type) {
+		case *StartToken:
+			assert.Equal(t, expected.(xml.StartElement).Name, tkn.Std().Name)
+		case *EndElement:
+			assert.Equal(t, expected.(xml.EndElement).Name, tkn.Std().Name)
+		}
+	}
+}
+
+func TestWithDefaultNamespace_Peek(t *testing.T) {
+	p := NewParser([]byte(`<a xmlns="urn:a"><b/></a>`), false, WithDefaultNamespace())
+
+	_, err := p.Next()
+	require.NoError(t, err)
+
+	token, err := p.Peek()
+	require.NoError(t, err)
+	assert.Equal(t, "urn:a", token.(*StartToken).Space)
+
+	clone := p.Clone()
+
+	for _, parser := range []*Parser{p, clone} {
+		token, err = parser.Next()
+		require.NoError(t, err)
+		assert.Equal(t, "urn:a", token.(*StartToken).Space)
+
+		_, err = parser.Next()
+		require.NoError(t, err)
+
+		token, err = parser.Next()
+		require.NoError(t, err)
+		assert.Equal(t, xml.Name{Space: "urn:a", Local: "a"}, token.(*EndElement).Name)
+	}
+}
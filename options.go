@@ -0,0 +1,10 @@
+package fastxml
+
+// ParserOption configures optional behavior of the parser.
+type ParserOption func(p *Parser)
+
+func (p *Parser) applyOptions(opts []ParserOption) {
+	for _, opt := range opts {
+		opt(p)
+	}
+}
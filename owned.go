@@ -0,0 +1,47 @@
+package fastxml
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+)
+
+// WithOwnedTokens makes Parser.Next return newly allocated tokens,
+// that do not share memory with the parser or the input buffer.
+//
+// By default parser reuses the same token values for each call to Parser.Next,
+// and their data points into the input buffer, so tokens are valid only until the next call.
+// With this option tokens can be held indefinitely, for example to buffer them,
+// at the cost of allocation and copying for each token, see CopyToken.
+//
+// Parser.Retain can be used instead to keep only some of the tokens.
+func WithOwnedTokens() ParserOption {
+	return func(p *Parser) {
+		p.ownedTokens = true
+	}
+}
+
+// Tokens parses the whole document and returns all its tokens.
+//
+// Tokens are copied, so they can be held and modified freely, see WithOwnedTokens.
+// Declarations that parser does not return tokens for are omitted.
+func Tokens(buf []byte, opts ...ParserOption) ([]xml.Token, error) {
+	p := NewParser(buf, false, append(opts, WithOwnedTokens())...)
+
+	var tokens []xml.Token
+
+	for {
+		token, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			return tokens, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if token != nil {
+			tokens = append(tokens, token)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package fastxml
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithOwnedTokens(t *testing.T) {
+	p := NewStreamParser(WithOwnedTokens())
+
+	var tokens []xml.Token
+
+	for _, part := range []string{`<a x="1">first`, `</a><b>second</b>`} {
+		p.Feed([]byte(part))
+
+		for {
+			token, err := p.Next()
+			if errors.Is(err, ErrNeedMoreData) || errors.Is(err, io.EOF) {
+				break
+			}
+
+			require.NoError(t, err)
+
+			tokens = append(tokens, token)
+		}
+	}
+
+	p.Feed([]byte(`<c>third</c>`))
+
+	for {
+		_, err := p.Next()
+		if err != nil {
+			break
+		}
+	}
+
+	require.Len(t, tokens, 6)
+	assert.Equal(t, "a", tokens[0].(*StartToken).Name)
+	assert.Equal(t, CharData("first"), *tokens[1].(*CharData))
+	assert.Equal(t, "a", tokens[2].(*EndElement).Name.Local)
+	assert.Equal(t, "b", tokens[3].(*StartToken).Name)
+	assert.Equal(t, CharData("second"), *tokens[4].(*CharData))
+	assert.Equal(t, "b", tokens[5].(*EndElement).Name.Local)
+	assert.NotSame(t, tokens[2], tokens[5])
+}
+
+func TestTokens(t *testing.T) {
+	buf := []byte(`<?xml version="1.0"?><a><!--c-->text<b/></a>`)
+
+	tokens, err := Tokens(buf, WithSkipComments())
+	require.NoError(t, err)
+
+	copy(buf, make([]byte, len(buf)))
+
+	text := CharData("text")
+
+	assert.Equal(t, []xml.Token{
+		&StartToken{Name: "a"},
+		&text,
+		&StartToken{Name: "b"},
+		&EndElement{Name: xml.Name{Local: "b"}},
+		&EndElement{Name: xml.Name{Local: "a"}},
+	}, tokens)
+
+	_, err = Tokens([]byte(`<a></b>`), WithEndElementMatching())
+	assert.Error(t, err)
+}
@@ -0,0 +1,134 @@
+package fastxml
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// NewValueFunc returns a pointer to the value into which child element with provided name will be decoded.
+//
+// If nil is returned - element is skipped and will not be present in the results.
+type NewValueFunc func(name string) interface{}
+
+// childElement is a raw child element of the root that needs to be decoded.
+type childElement struct {
+	idx  int
+	name string
+	data []byte
+}
+
+// DecodeChildren will decode child elements of the root element concurrently with `workers` goroutines.
+//
+// This is intended for documents with common shape of "root with a million records".
+// Parser is used only to find boundaries of child elements, while
+// elements themselves are decoded with xml.Unmarshal, so decoded values may use standard struct tags.
+//
+// Returned values are in the same order as elements in the document.
+//
+// Note that each element is decoded separately from the rest of the document,
+// so namespace prefixes declared on the root element are not available for children.
+func DecodeChildren(buf []byte, workers int, newValue NewValueFunc) ([]interface{}, error) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	children, err := splitChildren(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]interface{}, len(children))
+	errs := make([]error, len(children))
+	jobs := make(chan childElement)
+
+	var wg sync.WaitGroup
+
+	wg.Add(workers)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+
+			for child := range jobs {
+				val := newValue(child.name)
+				if val == nil {
+					continue
+				}
+
+				if err := xml.Unmarshal(child.data, val); err != nil {
+					errs[child.idx] = fmt.Errorf("decode child %d %q: %w", child.idx, child.name, err)
+
+					continue
+				}
+
+				results[child.idx] = val
+			}
+		}()
+	}
+
+	for _, child := range children {
+		jobs <- child
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	decoded := results[:0]
+
+	for i, val := range results {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+
+		if val != nil {
+			decoded = append(decoded, val)
+		}
+	}
+
+	return decoded, nil
+}
+
+// splitChildren will find raw data for each child element of the root element.
+func splitChildren(buf []byte) ([]childElement, error) {
+	p := NewParser(buf, false)
+
+	var (
+		children []childElement
+		depth    int
+		child    childElement
+		childPos int
+	)
+
+	for {
+		startPos := p.currentPointer
+
+		token, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			return children, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch tkn := token.(type) {
+		case *StartToken:
+			depth++
+
+			if depth == 2 {
+				child = childElement{idx: len(children), name: tkn.Name}
+				childPos = startPos
+			}
+		case *EndElement:
+			depth--
+
+			if depth == 1 {
+				child.data = buf[childPos:p.currentPointer]
+				children = append(children, child)
+			}
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package fastxml
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testRecord struct {
+	ID   int    `xml:"id,attr"`
+	Name string `xml:"name"`
+}
+
+func TestDecodeChildren(t *testing.T) {
+	var sb strings.Builder
+
+	sb.WriteString("<?xml version='1.0'?>\n<records>\n")
+
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&sb, "\t<record id='%d'><name>record %d</name></record>\n", i, i)
+
+		if i%10 == 0 {
+			sb.WriteString("\t<!-- comment --><skipped/>\n")
+		}
+	}
+
+	sb.WriteString("</records>")
+
+	results, err := DecodeChildren([]byte(sb.String()), 4, func(name string) interface{} {
+		if name != "record" {
+			return nil
+		}
+
+		return &testRecord{}
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 100)
+
+	for i, result := range results {
+		assert.Equal(t, &testRecord{ID: i, Name: fmt.Sprintf("record %d", i)}, result)
+	}
+}
+
+func TestDecodeChildren_Error(t *testing.T) {
+	input := `<records><record id='1'/><record id='not a number'/></records>`
+
+	_, err := DecodeChildren([]byte(input), 2, func(name string) interface{} {
+		return &testRecord{}
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `decode child 1 "record"`)
+}
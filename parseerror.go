@@ -0,0 +1,99 @@
+package fastxml
+
+import (
+	"errors"
+	"io"
+	"unicode/utf8"
+)
+
+// errorSnippetContext is the maximum number of bytes of the input
+// before and after the offending byte that are included into ParseError.Snippet.
+const errorSnippetContext = 32
+
+// ErrorSnippetMark is inserted into ParseError.Snippet right before the offending byte.
+const ErrorSnippetMark = "‸"
+
+// ParseError is returned by Parser.Next for errors in the document, wrapping the original error.
+//
+// Its message is the message of the original error, so snippet does not clutter messages
+// that are compared or shown to users, but can be added to logs as a separate field.
+type ParseError struct {
+	// Offset is the offset of the offending byte, like '<' in attribute value, if it is known,
+	// or of the token, in which the error was found, otherwise. It is counted from the start of the input.
+	Offset int
+	// Snippet is the excerpt of the input around Offset, with ErrorSnippetMark inserted before the byte at Offset,
+	// like `<a><b>‸</c></a>`. It holds up to 32 bytes before and after Offset, not splitting UTF-8 characters.
+	Snippet string
+	Err     error
+}
+
+func (e *ParseError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// offsetError is an error at the known offset of the input, which is used by ParseError
+// instead of the offset of the token.
+type offsetError struct {
+	err    error
+	offset int
+}
+
+// errorAt marks err as found at offset, counted from the start of the input.
+func errorAt(err error, offset int) error {
+	return &offsetError{err: err, offset: offset}
+}
+
+func (e *offsetError) Error() string {
+	return e.err.Error()
+}
+
+func (e *offsetError) Unwrap() error {
+	return e.err
+}
+
+// parseError wraps err into ParseError with the snippet of the buffer around offset,
+// or around the offset of err, if it was marked with errorAt.
+//
+// io.EOF and ErrNeedMoreData are not errors in the document, and are returned as is.
+func (p *Parser) parseError(err error, offset int) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, ErrNeedMoreData) {
+		return err
+	}
+
+	var atErr *offsetError
+	if errors.As(err, &atErr) && atErr.offset >= p.discarded {
+		offset = atErr.offset - p.discarded
+	}
+
+	if offset > len(p.buf) {
+		offset = len(p.buf)
+	}
+
+	start := offset - errorSnippetContext
+	if start < 0 {
+		start = 0
+	}
+
+	for start < offset && !utf8.RuneStart(p.buf[start]) {
+		start++
+	}
+
+	end := offset + errorSnippetContext
+	if end > len(p.buf) {
+		end = len(p.buf)
+	}
+
+	for end > offset && end < len(p.buf) && !utf8.RuneStart(p.buf[end]) {
+		end--
+	}
+
+	return &ParseError{
+		Offset:  p.discarded + offset,
+		Snippet: string(p.buf[start:offset]) + ErrorSnippetMark + string(p.buf[offset:end]),
+		Err:     err,
+	}
+}
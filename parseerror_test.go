@@ -0,0 +1,128 @@
+package fastxml
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseError(t *testing.T) {
+	long := strings.Repeat("x", 40)
+
+	tests := []struct {
+		name    string
+		input   string
+		opts    []ParserOption
+		offset  int
+		snippet string
+		// stream makes the input fed in two parts, so parsed prolog is discarded.
+		stream bool
+	}{
+		{
+			name:    "mismatched end element",
+			input:   `<a><b></c></a>`,
+			opts:    []ParserOption{WithEndElementMatching()},
+			offset:  6,
+			snippet: `<a><b>‸</c></a>`,
+		},
+		{
+			name:    "unclosed tag",
+			input:   `<a>text<b`,
+			offset:  7,
+			snippet: `<a>text‸<b`,
+		},
+		{
+			name:    "long input",
+			input:   "<a>" + long + "</c>" + long + "</a>",
+			opts:    []ParserOption{WithEndElementMatching()},
+			offset:  43,
+			snippet: long[:32] + "‸</c>" + long[:28],
+		},
+		{
+			name:    "utf-8 boundaries",
+			input:   "<a>" + strings.Repeat("ж", 20) + "x</c>y" + strings.Repeat("ж", 20) + "</a>",
+			opts:    []ParserOption{WithEndElementMatching()},
+			offset:  44,
+			snippet: strings.Repeat("ж", 15) + "x‸</c>y" + strings.Repeat("ж", 13),
+		},
+		{
+			name:    "less-than in attribute",
+			input:   `<r><a b="x<y"></a></r>`,
+			opts:    []ParserOption{WithStrict()},
+			offset:  10,
+			snippet: `<r><a b="x‸<y"></a></r>`,
+		},
+		{
+			name:    "double hyphen in comment",
+			input:   `<r><!-- a -- b --></r>`,
+			opts:    []ParserOption{WithStrict()},
+			offset:  10,
+			snippet: `<r><!-- a ‸-- b --></r>`,
+		},
+		{
+			name:    "undeclared entity",
+			input:   `<?xml version="1.0" standalone="yes"?><r>a&u;</r>`,
+			opts:    []ParserOption{WithStrict()},
+			offset:  42,
+			snippet: `ion="1.0" standalone="yes"?><r>a‸&u;</r>`,
+		},
+		{
+			name:    "undeclared entity in stream",
+			input:   `<?xml version="1.0" standalone="yes"?><r>a&u;</r>`,
+			opts:    []ParserOption{WithStrict()},
+			offset:  42,
+			snippet: `<r>a‸&u;</r>`,
+			stream:  true,
+		},
+		{
+			name:    "unclosed element",
+			input:   `<a><b></b>`,
+			opts:    []ParserOption{WithEndElementMatching()},
+			offset:  10,
+			snippet: `<a><b></b>‸`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			p := NewParser([]byte(test.input), false, test.opts...)
+			if test.stream {
+				p = NewStreamParser(test.opts...)
+				p.Feed([]byte(test.input[:38]))
+
+				_, err := p.Next()
+				require.NoError(t, err)
+
+				p.Feed([]byte(test.input[38:]))
+				p.streaming = false
+			}
+
+			var err error
+			for err == nil {
+				_, err = p.Next()
+			}
+
+			var parseErr *ParseError
+
+			require.True(t, errors.As(err, &parseErr), err)
+			assert.Equal(t, test.offset, parseErr.Offset)
+			assert.Equal(t, test.snippet, parseErr.Snippet)
+			assert.Equal(t, parseErr.Err.Error(), err.Error())
+		})
+	}
+
+	p := NewParser([]byte(`<a/>`), false)
+
+	_, err := p.Next()
+	require.NoError(t, err)
+	_, err = p.Next()
+	require.NoError(t, err)
+	_, err = p.Next()
+	assert.Same(t, io.EOF, err, "io.EOF is not wrapped")
+}
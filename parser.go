@@ -6,8 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"time"
 	"unicode/utf8"
-	"unsafe"
 )
 
 var _ = xml.Header
@@ -17,13 +17,44 @@ var (
 	ErrInvalidClosingElement = errors.New("invalid closing tag")
 )
 
+// Errors of the scanning helpers, like NextWordIndex.
+var (
+	// ErrEndOfBuffer is returned when buffer ends before the word starts.
+	ErrEndOfBuffer = errors.New("end of buffer")
+	// ErrNotQuoted is returned when quoted word does not start with quotation mark.
+	ErrNotQuoted = errors.New("no quotation mark on the beginning of the word")
+	// ErrUnterminatedQuote is returned when quoted word does not end before the end of the buffer,
+	// wrapped into QuoteError.
+	ErrUnterminatedQuote = errors.New("word is not properly quoted")
+)
+
+// QuoteError is returned when quoted word does not end before the end of the buffer.
+type QuoteError struct {
+	// Quote is the quotation mark that opened the word.
+	Quote rune
+	// Offset is the offset of the opening quotation mark, counted from the start of the scanned buffer.
+	Offset int
+}
+
+func (e *QuoteError) Error() string {
+	return fmt.Sprintf("%s: %q opened at offset %d is not closed", ErrUnterminatedQuote, e.Quote, e.Offset)
+}
+
+func (e *QuoteError) Unwrap() error {
+	return ErrUnterminatedQuote
+}
+
+// errSkipToken is returned internally for tokens that must not be returned to the caller.
+var errSkipToken = errors.New("skip token")
+
 var (
 	docTypePrefix = []byte("<!DOCTYPE")
 	elementPrefix = []byte("<!ELEMENT")
 	attListPrefix = []byte("<!ATTLIST")
 )
 
-// TokenDecoderFunc if no token can be decoded - error MUST be returned.
+// TokenDecoderFunc decodes raw token, see WithTokenDecoder.
+// If no token can be decoded - error MUST be returned.
 type TokenDecoderFunc func([]byte) (xml.Token, error)
 
 // Parser currently guarantees to supports only ASCII, UTF8 might chars/sequences be broken.
@@ -40,73 +71,367 @@ type Parser struct {
 		comment      Comment    // <!-- comment -->
 		directive    Directive  // <!directive>
 		startElement StartToken // <some_tag>
-		endElement   EndElement // </some_tag>
 		procInst     ProcInst   // <?xmxl encoding="UTF-8" ?>
 	}
+	// endElements hold end elements by depth of the elements they close, see Parser.endElement.
+	endElements []EndElement
 	// currentPointer ALWAYS points to next byte that needs to be processed.
-	currentPointer uint32
+	currentPointer int
+	// streaming is set for parsers which data is provided with Parser.Feed.
+	streaming bool
+	// discarded is the number of already parsed bytes that were discarded by Parser.Feed.
+	discarded int
+	// retainedBytes is the number of bytes allocated and held by the parser.
+	retainedBytes int
+	// memoryBudget is the maximum allowed value of retainedBytes, 0 means no limit.
+	memoryBudget int
+	// deadline is the time after which parsing is stopped, if set.
+	deadline time.Time
+	// arena holds tokens retained by the caller.
+	arena arena
+	// ownedTokens makes Next return a new copy of each token.
+	ownedTokens bool
+	// lineEndings is the handling of line endings in returned tokens.
+	lineEndings LineEndings
+	// lineEndingsBuf holds data of the last token which line endings were normalized.
+	lineEndingsBuf []byte
+	// metrics receives instrumentation events, if set.
+	metrics Metrics
+	// interner is used to intern element names, if set.
+	interner *Interner
+	// ids holds offsets of elements by their `xml:id`, if tracking is enabled.
+	ids map[string]int
+	// stack holds names of currently open elements.
+	stack []string
+	// defaultNamespace enables reporting of the default namespace of unprefixed elements.
+	defaultNamespace bool
+	// namespaces holds default namespaces of open elements, if defaultNamespace is set.
+	namespaces []string
+	// ownedStackNames is the number of names at the bottom of the stack that were copied from the buffer.
+	ownedStackNames int
+	// matchEndElements enables verification of end element names against the stack.
+	matchEndElements bool
+	// html enables handling of HTML specifics.
+	html bool
+	// rawTextElement is the name of currently open HTML element which content is raw text.
+	rawTextElement string
+	// skipDTD enables skipping of DOCTYPE declaration without decoding it.
+	skipDTD bool
+	// skipKinds is a bit set of token kinds that are skipped without decoding.
+	skipKinds uint16
+	// descendFunc decides if element should be returned or skipped, if set.
+	descendFunc DescendFunc
+	// skipDepth is the depth of the skipped element that parser is currently inside of.
+	skipDepth int
+	// stopped is set when parsing was stopped by descendFunc.
+	stopped bool
+	// strict enables additional checks required by the specification.
+	strict bool
+	// typographicQuotes enables attribute values in typographic quotes, if strict is not set.
+	typographicQuotes bool
+	// tolerantAttrs makes start elements skip malformed attributes.
+	tolerantAttrs bool
+	// standalone is the value of standalone declaration, if standaloneDeclared is set.
+	standalone         bool
+	standaloneDeclared bool
+	// declaredEntities holds general entities declared in internal subset of standalone document in strict mode.
+	declaredEntities map[string]struct{}
+	// tokenDecoders are decoders registered for tokens by their prefixes.
+	tokenDecoders []tokenDecoder
+	// attrDefaults hold default attributes declared in ATTLIST by element names, if WithAttributeDefaults is set.
+	attrDefaults map[string][]attributeDefault
+	// attrDefaultsBuf holds attributes of the last start element with added default attributes.
+	attrDefaultsBuf []byte
+	// auditHook is notified about ignored and changed constructs, if set.
+	auditHook AuditHook
+	// auditOffset is the offset of the last audited token.
+	auditOffset int
+	// subtreeStart is set if the last returned token is a start element, which attributes are subtreeAttrs.
+	subtreeStart bool
+	subtreeAttrs []byte
 }
 
 // NewParser will create a parser from input bytes.
 //
 // Parser MUST own provided buffer, so if input buffer must be modified outside of the parer -
 // set `mustCopy` to true and parser will copy full buffer to new slice and will use that.
-func NewParser(buf []byte, mustCopy bool) *Parser {
+func NewParser(buf []byte, mustCopy bool, opts ...ParserOption) *Parser {
+	p := Parser{
+		buf: buf,
+	}
+
 	if mustCopy {
 		newBuf := append([]byte(nil), buf...)
 
-		buf = newBuf
+		p.buf = newBuf
+		p.retain(cap(newBuf))
 	}
 
-	p := Parser{
-		buf: buf,
-	}
+	p.applyOptions(opts)
 
 	return &p
 }
 
+// NewParserString will create a parser over the bytes of the string, without copying them.
+//
+// As strings are immutable, input can not be modified outside of the parser.
+// Byte slices of returned tokens (like CharData) point into memory of the string,
+// so they MUST NOT be modified, use CopyToken if modifiable copy is needed.
+func NewParserString(s string, opts ...ParserOption) *Parser {
+	return NewParser(unsafeStringToByte(s), false, opts...)
+}
+
 // Peek can be used to fetch next token without actually advancing parser.
 //
 // Basically it is wrapper for Parser.Next with state restoration.
 func (p *Parser) Peek() (xml.Token, error) {
-	lastPos, lastTagName := p.currentPointer, p.lastTagName
-	defer func() {
-		p.currentPointer, p.lastTagName = lastPos, lastTagName
-	}()
+	state := p.peekState()
+	defer p.restorePeekState(state)
 
 	return p.Next()
 }
 
+// InputOffset returns offset of the next byte that will be parsed, counted from the start of the input.
+//
+// Offset taken before the call to Parser.Next is the start of the returned token,
+// and offset after the call is its end, so raw bytes of tokens and elements can be extracted.
+// For streaming parsers offset includes data that was already discarded by Parser.Feed.
+func (p *Parser) InputOffset() int {
+	return p.discarded + p.currentPointer
+}
+
+// peekState holds state of the parser that can be changed by Parser.Next.
+type peekState struct {
+	currentPointer int
+	lastTagName    string
+	stack          []string
+	namespaces     []string
+	rawTextElement string
+	skipDepth      int
+	stopped        bool
+	subtreeStart   bool
+	subtreeAttrs   []byte
+}
+
+func (p *Parser) peekState() peekState {
+	return peekState{
+		currentPointer: p.currentPointer,
+		lastTagName:    p.lastTagName,
+		stack:          p.stack,
+		namespaces:     p.namespaces,
+		rawTextElement: p.rawTextElement,
+		skipDepth:      p.skipDepth,
+		stopped:        p.stopped,
+		subtreeStart:   p.subtreeStart,
+		subtreeAttrs:   p.subtreeAttrs,
+	}
+}
+
+func (p *Parser) restorePeekState(state peekState) {
+	p.currentPointer = state.currentPointer
+	p.lastTagName = state.lastTagName
+	p.stack = state.stack
+	p.namespaces = state.namespaces
+	p.rawTextElement = state.rawTextElement
+	p.skipDepth = state.skipDepth
+	p.stopped = state.stopped
+	p.subtreeStart = state.subtreeStart
+	p.subtreeAttrs = state.subtreeAttrs
+}
+
 // Next will return next token and error, if any.
 //
 // Returned token will always be a pointer type.
 //
 // Caller MUST NOT hold onto returned tokens. Instead, it may store data from them, but don't hold onto pointers.
 func (p *Parser) Next() (xml.Token, error) {
+	for {
+		offset := p.currentPointer
+
+		token, err := p.next()
+		if errors.Is(err, errSkipToken) {
+			continue
+		}
+
+		if err != nil {
+			err = p.parseError(err, offset)
+		}
+
+		if p.lineEndings != LineEndingsRaw && err == nil {
+			p.normalizeLineEndings(token)
+		}
+
+		p.recordSubtreeStart(token)
+
+		if p.ownedTokens && err == nil {
+			token = CopyToken(token)
+		}
+
+		return token, err
+	}
+}
+
+// next will return next token, or errSkipToken if scanned token must not be returned to the caller.
+func (p *Parser) next() (xml.Token, error) {
+	if err := p.checkMemoryBudget(); err != nil {
+		return nil, err
+	}
+
+	if err := p.checkDeadline(); err != nil {
+		return nil, err
+	}
+
+	if p.stopped {
+		return nil, io.EOF
+	}
+
+	if err := p.skipSubtree(); err != nil {
+		return nil, err
+	}
+
 	if p.lastTagName != "" {
 		token := p.sendSelfClosingEnd()
 
+		_ = p.popElement(p.lastTagName) // Self-closing tag always matches itself.
+		p.popNamespace(token)
 		p.lastTagName = ""
 
+		if p.metrics != nil {
+			p.metrics.TokenScanned(TokenKindEndElement, 0)
+		}
+
 		return token, nil
 	}
 
-	if p.currentPointer >= uint32(len(p.buf)) {
+	if p.currentPointer >= len(p.buf) {
+		if p.streaming {
+			return nil, ErrNeedMoreData
+		}
+
+		if err := p.checkUnclosedElements(); err != nil {
+			return nil, err
+		}
+
 		return nil, io.EOF
 	}
 
+	if p.rawTextElement != "" {
+		return p.nextRawText()
+	}
+
+	if p.html {
+		if token, ok, err := p.nextHTMLText(); ok {
+			return token, err
+		}
+	}
+
+	if p.skipText() {
+		return nil, errSkipToken
+	}
+
+	scanStart := p.phaseStart()
+
 	tokenBytes, err := FetchNextToken(p.buf[p.currentPointer:])
+	if err != nil && len(p.tokenDecoders) != 0 {
+		tokenBytes, err = p.scanRegisteredDeclaration(p.buf[p.currentPointer:], tokenBytes, err)
+	}
+
+	p.observePhase(ParsePhaseScan, scanStart)
+
+	if errors.Is(err, ErrNeedMoreData) {
+		switch {
+		case p.streaming:
+			return nil, ErrNeedMoreData
+		case tokenBytes[0] != '<':
+			// Char data at the end of the document.
+			err = nil
+		default:
+			err = io.ErrUnexpectedEOF
+		}
+	}
+
 	if err != nil {
 		return nil, fmt.Errorf("fetch next token: %w", err)
 	}
 
-	p.currentPointer += uint32(len(tokenBytes))
+	p.currentPointer += len(tokenBytes)
+
+	if p.metrics != nil {
+		p.metrics.TokenScanned(scannedTokenKind(tokenBytes), len(tokenBytes))
+	}
+
+	if err := p.checkProcInst(tokenBytes); err != nil {
+		return nil, err
+	}
+
+	if err := p.checkComment(tokenBytes); err != nil {
+		return nil, err
+	}
+
+	if err := p.readStandalone(tokenBytes); err != nil {
+		return nil, err
+	}
+
+	if p.isSkipped(tokenBytes) {
+		return nil, errSkipToken
+	}
+
+	if fn := p.registeredDecoder(tokenBytes); fn != nil {
+		token, err := fn(tokenBytes)
+		if err != nil {
+			return nil, fmt.Errorf("decode token: index position %d: %w", p.currentPointer, err)
+		}
+
+		return token, nil
+	}
+
+	p.auditToken(tokenBytes)
+
+	decodeStart := p.phaseStart()
 
 	token, err := p.decodeToken(tokenBytes)
+
+	p.observePhase(ParsePhaseDecode, decodeStart)
+
 	if err != nil {
 		return nil, fmt.Errorf("decode token: index position %d: %w", p.currentPointer, err)
 	}
 
+	switch tkn := token.(type) {
+	case *StartToken:
+		if err := p.checkStartElement(tkn); err != nil {
+			return nil, err
+		}
+
+		if p.attrDefaults != nil {
+			p.applyAttributeDefaults(tkn)
+		}
+
+		if err := p.decideDescend(tkn); err != nil {
+			return nil, err
+		}
+
+		p.pushElement(tkn.Name)
+		p.pushNamespace(tkn)
+		p.startRawText(tkn.Name)
+
+		if p.ids != nil {
+			if err := p.trackID(tkn, p.discarded+p.currentPointer-len(tokenBytes)); err != nil {
+				return nil, fmt.Errorf("track id: index position %d: %w", p.currentPointer, err)
+			}
+		}
+	case *CharData:
+		if err := p.checkCharData(tokenBytes); err != nil {
+			return nil, err
+		}
+	case *EndElement:
+		if err := p.popElement(tkn.Name.Local); err != nil {
+			return nil, fmt.Errorf("match end element: index position %d: %w", p.currentPointer, err)
+		}
+
+		p.popNamespace(tkn)
+	}
+
 	return token, nil
 }
 
@@ -126,25 +451,45 @@ func (p *Parser) decodeToken(buf []byte) (xml.Token, error) { //nolint:gocyclo,c
 	switch {
 	case buf[0] != '<':
 		return p.decodeString(buf)
-	case buf[0] == '<' && buf[1] == '/':
+	case buf[1] == '/':
 		return p.decodeClosingTag(buf)
-	case buf[0] == '<' && buf[1] == '!' && buf[2] == '-' && buf[3] == '-':
+	case bytes.HasPrefix(buf, commentPrefix):
 		return p.decodeComment(buf)
-	case len(buf) >= 11 && buf[0] == '<' && buf[1] == '!' && buf[2] == '[':
+	case bytes.HasPrefix(buf, cdataPrefix):
 		return p.decodeCdata(buf)
-	case buf[0] == '<' && buf[1] == '?':
+	case buf[1] == '?':
 		return nil, nil // No implementation is available currently.
-	case buf[0] == '<' && buf[1] == '!':
+	case buf[1] == '!':
 		return p.decodeDeclaration(buf) // Some sort of declaration(ignore, element, attrlist, etc).
 	default: // This will be our "catch-all" start tag decoder.
 		return p.decodeSimpleTag(buf)
 	}
 }
 
-func (p *Parser) sendSelfClosingEnd() xml.Token {
-	p.innerData.endElement.Name.Local = p.lastTagName
+func (p *Parser) sendSelfClosingEnd() *EndElement {
+	end := p.endElement()
+	end.Name.Local = p.lastTagName
+
+	return end
+}
+
+// endElement returns the token for end element, which closes the innermost open element.
+//
+// Each depth has its own token, so end element that was returned to the caller
+// is not overwritten by the next end element, which is of the outer element and can be peeked with Parser.Peek.
+func (p *Parser) endElement() *EndElement {
+	depth := len(p.stack)
+
+	if p.endElements == nil {
+		// Most documents are not deeper than this, so tokens are allocated once.
+		p.endElements = make([]EndElement, 0, 8)
+	}
+
+	for len(p.endElements) <= depth {
+		p.endElements = append(p.endElements, EndElement{})
+	}
 
-	return &p.innerData.endElement
+	return &p.endElements[depth]
 }
 
 // decodeClosingTag is used to decode closing tag.
@@ -156,28 +501,31 @@ func (p *Parser) decodeClosingTag(buf []byte) (xml.Token, error) {
 	buf = buf[2:]
 
 	nameEndIdx := scanTillWordEnd(buf)
-	if nameEndIdx == 0 {
+	if nameEndIdx == 0 || nameEndIdx >= len(buf) {
 		return nil, ErrInvalidClosingElement
 	}
 
-	_ = buf[nameEndIdx] // Remove boundary check
-	p.innerData.endElement.Name.Local = unsafeByteToString(buf[:nameEndIdx])
+	end := p.endElement()
+	end.Name.Local = p.name(buf[:nameEndIdx])
 
-	return &p.innerData.endElement, nil
+	return end, nil
 }
 
 func (p *Parser) decodeComment(buf []byte) (xml.Token, error) {
-	commentEndIdx := bytes.Index(buf, []byte{'-', '-', '>'})
-	if commentEndIdx == -1 || (buf[commentEndIdx-1] == '-' && len(buf) < 7) {
+	if len(buf) < len(commentPrefix)+len(commentSuffix) || !bytes.HasSuffix(buf, commentSuffix) {
 		return nil, errors.New("comment is not properly formatted")
 	}
 
-	p.innerData.comment = buf[4:commentEndIdx]
+	p.innerData.comment = buf[len(commentPrefix) : len(buf)-len(commentSuffix)]
 
 	return &p.innerData.comment, nil
 }
 
 func (p *Parser) decodeCdata(buf []byte) (xml.Token, error) {
+	if len(buf) < cdataPrefLen+cdataSufLen || !bytes.HasSuffix(buf, cdataSuffix) {
+		return nil, errors.New("CDATA is not properly formatted")
+	}
+
 	p.innerData.charData = buf[cdataPrefLen : len(buf)-cdataSufLen]
 
 	return &p.innerData.charData, nil
@@ -190,9 +538,14 @@ func (p *Parser) decodeString(buf []byte) (xml.Token, error) {
 }
 
 func (p *Parser) decodeSimpleTag(buf []byte) (xml.Token, error) {
+	// Smallest tag is `<a>`, and every tag must end with '>'.
+	if len(buf) < 3 || buf[len(buf)-1] != '>' {
+		return nil, ErrNotAValidTag
+	}
+
 	tagNameIdx := scanTillWordEnd(buf[1:])
 
-	tagName := unsafeByteToString(buf[1 : tagNameIdx+1])
+	tagName := p.name(buf[1 : tagNameIdx+1])
 
 	if buf[len(buf)-2] == '/' {
 		p.lastTagName = tagName
@@ -200,6 +553,10 @@ func (p *Parser) decodeSimpleTag(buf []byte) (xml.Token, error) {
 
 	p.innerData.startElement.Name = tagName
 	p.innerData.startElement.attrBuf = nil
+	p.innerData.startElement.allowBoolAttrs = p.html
+	p.innerData.startElement.typographicQuotes = p.typographicQuotes && !p.strict
+	p.innerData.startElement.tolerantAttrs = p.tolerantAttrs
+	p.innerData.startElement.attrWarnings = p.innerData.startElement.attrWarnings[:0]
 
 	buf = buf[tagNameIdx+1:]
 
@@ -222,8 +579,13 @@ func (p *Parser) decodeSimpleTag(buf []byte) (xml.Token, error) {
 
 func (p *Parser) decodeDeclaration(buf []byte) (xml.Token, error) {
 	switch {
-	case bytes.HasPrefix(buf, docTypePrefix),
-		bytes.HasPrefix(buf, elementPrefix),
+	case bytes.HasPrefix(buf, docTypePrefix):
+		if p.attrDefaults != nil {
+			p.readAttributeDefaults(buf)
+		}
+
+		return nil, nil
+	case bytes.HasPrefix(buf, elementPrefix),
 		bytes.HasPrefix(buf, attListPrefix):
 		return nil, nil
 	default:
@@ -231,7 +593,8 @@ func (p *Parser) decodeDeclaration(buf []byte) (xml.Token, error) {
 	}
 }
 
-func decodeTagAttribute(buf []byte) (string, string, int, error) {
+// decodeTagAttribute decodes the next attribute, accepting values in typographic quotes if typographic is set.
+func decodeTagAttribute(buf []byte, typographic bool) (string, string, int, error) {
 	if len(buf) == 0 || buf[0] == '>' {
 		return "", "", -1, nil
 	}
@@ -254,13 +617,49 @@ func decodeTagAttribute(buf []byte) (string, string, int, error) {
 	// Now we need to find equal sign and pass over it.
 	equalIdx := nextTokenStartIndex(buf[endAttrNameIdx-1:], '=')
 
-	attrValue, endAttrValueIdx, err := NextQuotedWord(buf[endAttrNameIdx+equalIdx:])
+	valueBuf := buf[endAttrNameIdx+equalIdx:]
+
+	startAttrValueIdx, endAttrValueIdx, quoteLen, err := nextQuotedWordIndex(valueBuf, typographic)
 	if err != nil {
+		var quoteErr *QuoteError
+		if errors.As(err, &quoteErr) {
+			// Offset is reported from the start of the attribute.
+			quoteErr.Offset += endAttrNameIdx + equalIdx
+		}
+
 		return "", "", 0, err
 	}
 
-	// 1 is added to skip index to go over the last quotation mark.
-	return attrName, attrValue, endAttrNameIdx + endAttrValueIdx + equalIdx + 1, nil
+	attrValue := unsafeByteToString(valueBuf[startAttrValueIdx+quoteLen : endAttrValueIdx])
+
+	// Length of the quotation mark is added to skip index to go over the last one.
+	return attrName, attrValue, endAttrNameIdx + endAttrValueIdx + equalIdx + quoteLen, nil
+}
+
+// decodeBoolTagAttribute decodes attribute without value.
+//
+// If attribute has a value - empty name and skip index of 0 are returned,
+// so it can be decoded with decodeTagAttribute.
+// If there are no more attributes - skip index of -1 is returned.
+func decodeBoolTagAttribute(buf []byte) (string, int, error) {
+	nameStartIdx := NextNonSpaceIndex(buf)
+	if nameStartIdx >= len(buf) || buf[nameStartIdx] == '>' || buf[nameStartIdx] == '/' {
+		return "", -1, nil
+	}
+
+	nameLen := scanTillWordEnd(buf[nameStartIdx:])
+	if nameLen == 0 {
+		return "", 0, fmt.Errorf("rune is not valid start of name: '%c'", buf[nameStartIdx])
+	}
+
+	nameEndIdx := nameStartIdx + nameLen
+
+	nextIdx := nameEndIdx + NextNonSpaceIndex(buf[nameEndIdx:])
+	if nextIdx < len(buf) && buf[nextIdx] == '=' {
+		return "", 0, nil
+	}
+
+	return unsafeByteToString(buf[nameStartIdx:nameEndIdx]), nameEndIdx, nil
 }
 
 // CopyString will return copy of the input string.
@@ -297,12 +696,30 @@ func NextQuotedWord(buf []byte) (word string, endIdx int, err error) {
 	return unsafeByteToString(buf[startIdx+1 : endIdx]), endIdx, nil
 }
 
+// NextQuotedValue will return next quoted word with references replaced, see Unescape.
+//
+// As references never contain quotation marks, value is found in raw data
+// and references are replaced afterwards, so `"say &quot;hi&quot;"` becomes `say "hi"`.
+func NextQuotedValue(buf []byte) (value string, endIdx int, err error) {
+	value, endIdx, err = NextQuotedWord(buf)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return Unescape(value), endIdx, nil
+}
+
 // NextWordIndex returns two offsets: for start and the end of the word.
 // Word is a sequence of alphabetic characters separated by underscore.
 //
 // On error `start` will hold starting index of the rune that is invalid, `end` will be always 0.
+// If buffer holds only spaces - ErrEndOfBuffer is returned with `start` equal to the length of the buffer.
 func NextWordIndex(buf []byte) (start, end int, err error) {
 	start = NextNonSpaceIndex(buf)
+	if start == len(buf) {
+		return start, 0, ErrEndOfBuffer
+	}
+
 	currPtr := start
 
 	decodedRune, size := utf8.DecodeRune(buf[currPtr:])
@@ -335,40 +752,90 @@ func NextWordIndex(buf []byte) (start, end int, err error) {
 //
 // Word is a sequence of alphabetic characters separated by underscore.
 //
-// On error `start` will hold starting index of the rune that is invalid, `end` will be always 0.
+// On error both `start` and `end` will be 0. Error is ErrEndOfBuffer if buffer holds only spaces,
+// ErrNotQuoted if word does not start with quotation mark and *QuoteError if it does not end,
+// which tells the opening quotation mark and its offset, and wraps ErrUnterminatedQuote.
 //
 // Returned indexes will not include quotation mark itself.
 //
 // Note: current implementation differs from NextWordIndex in a way that
 // this function does not validate runes inside of found word.
+//
+// References are not replaced, but they can not hold quotation marks,
+// so escaped quotes like `&quot;` do not end the word. See NextQuotedValue.
 func NextQuotedWordIndex(buf []byte) (start, end int, err error) {
+	start, end, _, err = nextQuotedWordIndex(buf, false)
+
+	return start, end, err
+}
+
+// nextQuotedWordIndex is NextQuotedWordIndex, which also accepts typographic quotes if typographic is set.
+// quoteLen is the length of opening and closing quotation marks, so word is `buf[start+quoteLen:end]`.
+func nextQuotedWordIndex(buf []byte, typographic bool) (start, end, quoteLen int, err error) {
 	start = NextNonSpaceIndex(buf)
+	if start == len(buf) {
+		return 0, 0, 0, ErrEndOfBuffer
+	}
+
+	opening, closing := buf[start:start+1], buf[start:start+1]
+
+	if quote := buf[start]; quote != '\'' && quote != '"' {
+		if !typographic {
+			return 0, 0, 0, ErrNotQuoted
+		}
+
+		opening, closing = nil, nil
+
+		for _, quotes := range typographicQuotes {
+			if bytes.HasPrefix(buf[start:], quotes.opening) {
+				opening, closing = quotes.opening, quotes.closing
 
-	quote := buf[start]
-	if quote != '\'' && quote != '"' {
-		return 0, 0, errors.New("no quotation mark on the beginning of the word")
+				break
+			}
+		}
+
+		if opening == nil {
+			return 0, 0, 0, ErrNotQuoted
+		}
 	}
 
-	end = bytes.IndexByte(buf[start+1:], quote)
+	end = bytes.Index(buf[start+len(opening):], closing)
 	if end == -1 {
-		return 0, 0, errors.New("word is not properly quoted")
+		quote, _ := utf8.DecodeRune(opening)
+
+		return 0, 0, 0, &QuoteError{Quote: quote, Offset: start}
 	}
 
-	return start, start + end + 1, nil
+	return start, start + len(opening) + end, len(opening), nil
 }
 
 // NextNonSpaceIndex will return index on which next rune will be non-space.
+//
+// If buffer is empty or holds only spaces - length of the buffer is returned, never -1,
+// so result can always be used to slice the buffer, like `buf[NextNonSpaceIndex(buf):]`.
 func NextNonSpaceIndex(buf []byte) (idx int) {
-	for {
-		rn, size := utf8.DecodeRune(buf[idx:])
-		if !IsHTMLSpaceChar(rn) {
-			return
-		}
+	for idx < len(buf) && IsHTMLSpaceChar(rune(buf[idx])) {
+		idx++
+	}
+
+	return idx
+}
 
-		idx += size
+// NextSpaceIndex will return index of the next space.
+//
+// If buffer is empty or does not have spaces - length of the buffer is returned, never -1,
+// same as for NextNonSpaceIndex.
+func NextSpaceIndex(buf []byte) int {
+	for idx, c := range buf {
+		if IsHTMLSpaceChar(rune(c)) {
+			return idx
+		}
 	}
+
+	return len(buf)
 }
 
+// IsHTMLSpaceChar reports if rune is a space, as defined by XML: space, tab, carriage return or line feed.
 func IsHTMLSpaceChar(rn rune) bool {
 	switch rn {
 	case ' ', '\t', '\r', '\n':
@@ -388,7 +855,3 @@ func isNameChar(rn rune) bool {
 	return isNameStartChar(rn) || rn == '-' || rn == '.' ||
 		(rn >= '0' && rn <= '9')
 }
-
-func unsafeByteToString(b []byte) string {
-	return *(*string)(unsafe.Pointer(&b)) // nolint:gosec // This is valid and simple conversion.
-}
@@ -68,6 +68,9 @@ func TestNextNonSpaceIndex(t *testing.T) {
 	}{
 		{"simple", "  a", 2},
 		{"simple", "a  ", 0},
+		{"empty", "", 0},
+		{"only spaces", " \t\r\n", 4},
+		{"multibyte", "\n\u00a0", 1},
 	}
 
 	for _, test := range tests {
@@ -79,6 +82,48 @@ func TestNextNonSpaceIndex(t *testing.T) {
 	}
 }
 
+func TestNextSpaceIndex(t *testing.T) {
+	tests := []struct {
+		name       string
+		stringData string
+		idx        int
+	}{
+		{"simple", "ab c", 2},
+		{"leading space", "\ta", 0},
+		{"empty", "", 0},
+		{"no spaces", "abc\u00a0", 5},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.idx, NextSpaceIndex([]byte(test.stringData)))
+		})
+	}
+}
+
+func TestScanningHelpers_EndOfBuffer(t *testing.T) {
+	for _, input := range []string{"", "  \n"} {
+		start, end, err := NextWordIndex([]byte(input))
+		assert.ErrorIs(t, err, ErrEndOfBuffer)
+		assert.Equal(t, len(input), start)
+		assert.Equal(t, 0, end)
+
+		_, _, err = NextWord([]byte(input))
+		assert.ErrorIs(t, err, ErrEndOfBuffer)
+
+		_, _, err = NextQuotedWordIndex([]byte(input))
+		assert.ErrorIs(t, err, ErrEndOfBuffer)
+	}
+
+	_, _, err := NextQuotedWord([]byte(` word`))
+	assert.ErrorIs(t, err, ErrNotQuoted)
+
+	_, _, err = NextQuotedValue([]byte(` 'value`))
+	assert.ErrorIs(t, err, ErrUnterminatedQuote)
+}
+
 func TestDecodeTagAttribute(t *testing.T) {
 	tests := []struct {
 		name              string
@@ -90,7 +135,7 @@ func TestDecodeTagAttribute(t *testing.T) {
 		{"simple", "tag='val'", "tag", "val", 9, ""},
 		{"simple another quote", `tag="val"`, "tag", "val", 9, ""},
 		{"simple empty value", `tag=""`, "tag", "", 6, ""},
-		{"simple no end quote", `tag="`, "", "", 0, "word is not properly quoted"},
+		{"simple no end quote", `tag="`, "", "", 0, `word is not properly quoted: '"' opened at offset 4 is not closed`},
 		{"simple with space", "tag = 'val'", "tag", "val", 11, ""},
 		{"attribute must have name", "='val'", "", "", 0, "rune is not valid start of name: '='"},
 		{"attribute must have name", " ='val'", "", "", 0, "rune is not valid start of name: '='"},
@@ -100,7 +145,7 @@ func TestDecodeTagAttribute(t *testing.T) {
 		test := test
 
 		t.Run(test.name, func(t *testing.T) {
-			attrName, attrVal, skipIdx, err := decodeTagAttribute([]byte(test.input))
+			attrName, attrVal, skipIdx, err := decodeTagAttribute([]byte(test.input), false)
 
 			if test.err != "" {
 				require.EqualError(t, err, test.err)
@@ -115,6 +160,46 @@ func TestDecodeTagAttribute(t *testing.T) {
 	}
 }
 
+func TestNextQuotedValue(t *testing.T) {
+	tests := []struct {
+		input  string
+		value  string
+		endIdx int
+	}{
+		{`"say &quot;hi&quot;" b="c"`, `say "hi"`, 19},
+		{`'it&apos;s "x" &#38; &#x3E;'`, `it's "x" & >`, 27},
+		{` "plain"`, "plain", 7},
+	}
+
+	for _, test := range tests {
+		value, endIdx, err := NextQuotedValue([]byte(test.input))
+		require.NoError(t, err, test.input)
+		assert.Equal(t, test.value, value, test.input)
+		assert.Equal(t, test.endIdx, endIdx, test.input)
+	}
+
+	_, _, err := NextQuotedValue([]byte(`"unterminated`))
+	assert.EqualError(t, err, `word is not properly quoted: '"' opened at offset 0 is not closed`)
+}
+
+func TestNextQuotedWordIndex_QuoteError(t *testing.T) {
+	_, _, err := NextQuotedWordIndex([]byte(`  'it"s`))
+	require.ErrorIs(t, err, ErrUnterminatedQuote)
+
+	var quoteErr *QuoteError
+
+	require.True(t, errors.As(err, &quoteErr))
+	assert.Equal(t, &QuoteError{Quote: '\'', Offset: 2}, quoteErr)
+
+	_, _, _, err = decodeTagAttribute([]byte(`b = "2`), false)
+	require.True(t, errors.As(err, &quoteErr))
+	assert.Equal(t, &QuoteError{Quote: '"', Offset: 4}, quoteErr)
+
+	_, _, _, err = decodeTagAttribute([]byte(`b=“2`), true)
+	require.True(t, errors.As(err, &quoteErr))
+	assert.Equal(t, &QuoteError{Quote: '“', Offset: 2}, quoteErr)
+}
+
 func TestStartElement_NextAttribute(t *testing.T) {
 	input := []byte(`<tag id='1' attr="222'2">`)
 	tag, err := (&Parser{}).decodeSimpleTag(input)
@@ -177,7 +262,7 @@ func TestParser_Next(t *testing.T) {
 			break
 		}
 
-		results = append(results, fmt.Sprintf("%T: %q", token, token))
+		results = append(results, tokenString(token))
 	}
 
 	assert.Equal(t, mustResult, results)
@@ -238,7 +323,27 @@ func TestParser_DecodeToken(t *testing.T) {
 		{
 			name:  "small invalid comment",
 			input: `<!--->`,
-			err:   "decode token: index position 6: comment is not properly formatted",
+			err:   "fetch next token: unexpected EOF",
+		},
+		{
+			name:   "comment starting with closing bracket",
+			input:  `<!--> x -->`,
+			result: Comment("> x "),
+		},
+		{
+			name:   "comment starting with dash and closing bracket",
+			input:  `<!--->-->`,
+			result: Comment("->"),
+		},
+		{
+			name:  "truncated tag",
+			input: `<tag attr='val'`,
+			err:   "fetch next token: unexpected EOF",
+		},
+		{
+			name:   "char data at the end",
+			input:  `end of the document`,
+			result: CharData("end of the document"),
 		},
 	}
 
@@ -257,6 +362,24 @@ func TestParser_DecodeToken(t *testing.T) {
 	}
 }
 
+func TestNewParserString(t *testing.T) {
+	input := `<a x="1&amp;2">text<b/></a>`
+
+	tokens, err := Tokens([]byte(input))
+	require.NoError(t, err)
+
+	p := NewParserString(input)
+
+	for _, mustGet := range tokens {
+		token, err := p.Next()
+		require.NoError(t, err)
+		assert.Equal(t, ToStd(mustGet), ToStd(token))
+	}
+
+	_, err = p.Next()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
 func TestParser_Peek(t *testing.T) {
 	input := `<a/>`
 
@@ -275,6 +398,62 @@ func TestParser_Peek(t *testing.T) {
 	require.Equal(t, mustGet, next)
 }
 
+func TestParser_Peek_EndElements(t *testing.T) {
+	p := NewParser([]byte(`<x><y><z/></y></x>`), false)
+
+	for i := 0; i < 3; i++ {
+		_, err := p.Next()
+		require.NoError(t, err)
+	}
+
+	var ends []xml.Token
+
+	for i := 0; i < 3; i++ {
+		end, err := p.Next()
+		require.NoError(t, err)
+
+		// Peeked end element must not overwrite the returned one.
+		peeked, err := p.Peek()
+		if i < 2 {
+			require.NoError(t, err)
+			assert.IsType(t, &EndElement{}, peeked)
+		}
+
+		ends = append(ends, end)
+	}
+
+	assert.Equal(t, []xml.Token{
+		&EndElement{Name: xml.Name{Local: "z"}},
+		&EndElement{Name: xml.Name{Local: "y"}},
+		&EndElement{Name: xml.Name{Local: "x"}},
+	}, ends)
+}
+
+func TestParser_InputOffset(t *testing.T) {
+	input := `<a x="1">text<b/></a>`
+
+	mustRaw := []string{`<a x="1">`, `text`, `<b/>`, ``, `</a>`}
+
+	p := NewParser([]byte(input), false)
+
+	var raw []string
+
+	for {
+		start := p.InputOffset()
+
+		_, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+
+		raw = append(raw, input[start:p.InputOffset()])
+	}
+
+	assert.Equal(t, mustRaw, raw)
+}
+
 func TestIBM_XMLSuite(t *testing.T) {
 	descFilePath := path.Join(testdata.PackagePath(t), "testdata/suite/ibm/ibm_oasis_valid.xml")
 
@@ -385,3 +564,15 @@ func indirectValue(val interface{}) interface{} {
 
 	return reflect.Indirect(reflect.ValueOf(val)).Interface()
 }
+
+// tokenString formats token for comparison in tests.
+//
+// Start tokens are formatted only with name and attributes buffer,
+// so internal fields of the token do not change expected results.
+func tokenString(token xml.Token) string {
+	if start, ok := token.(*StartToken); ok {
+		return fmt.Sprintf("%T: &{%q %q}", start, start.Name, start.attrBuf)
+	}
+
+	return fmt.Sprintf("%T: %q", token, token)
+}
@@ -0,0 +1,58 @@
+package fastxml
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+)
+
+// TokenResult is a single result of the token pipeline.
+//
+// Only one of the fields is set. Error is always the last result that is sent.
+type TokenResult struct {
+	Token xml.Token
+	Err   error
+}
+
+// ParseAsync will read and parse r on a separate goroutine,
+// sending copies of parsed tokens to the returned channel.
+//
+// This allows to overlap reading and scanning of data with processing of tokens
+// by the caller, which is beneficial for big files.
+// As tokens are copied with CopyToken - caller may hold onto them.
+//
+// bufferSize is the capacity of the returned channel.
+// Channel is closed when all tokens are sent, error occurred or ctx is done.
+// Reaching the end of r is not reported as an error.
+func ParseAsync(ctx context.Context, r io.Reader, bufferSize int, opts ...ParserOption) <-chan TokenResult {
+	results := make(chan TokenResult, bufferSize)
+
+	go func() {
+		defer close(results)
+
+		send := func(result TokenResult) error {
+			select {
+			case results <- result:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		w := NewParserWriter(func(token xml.Token) error {
+			return send(TokenResult{Token: CopyToken(token)})
+		}, opts...)
+
+		_, err := io.Copy(w, r)
+		if err == nil {
+			err = w.Close()
+		}
+
+		if err != nil && !errors.Is(err, ctx.Err()) {
+			_ = send(TokenResult{Err: err})
+		}
+	}()
+
+	return results
+}
@@ -0,0 +1,61 @@
+package fastxml
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAsync(t *testing.T) {
+	data := `<root><item id="1">one</item><item id="2">two</item></root>`
+
+	mustResult := []string{
+		`*fastxml.StartToken: &{"root" ""}`,
+		`*fastxml.StartToken: &{"item" "id=\"1\">"}`,
+		`*fastxml.CharData: &"one"`,
+		`*fastxml.EndElement: &{{"" "item"}}`,
+		`*fastxml.StartToken: &{"item" "id=\"2\">"}`,
+		`*fastxml.CharData: &"two"`,
+		`*fastxml.EndElement: &{{"" "item"}}`,
+		`*fastxml.EndElement: &{{"" "root"}}`,
+	}
+
+	var results []string
+
+	for result := range ParseAsync(context.Background(), strings.NewReader(data), 2) {
+		require.NoError(t, result.Err)
+
+		results = append(results, tokenString(result.Token))
+	}
+
+	assert.Equal(t, mustResult, results)
+}
+
+func TestParseAsync_Error(t *testing.T) {
+	var lastErr error
+
+	for result := range ParseAsync(context.Background(), strings.NewReader("<root><item"), 0) {
+		lastErr = result.Err
+	}
+
+	require.True(t, errors.Is(lastErr, io.ErrUnexpectedEOF), lastErr)
+}
+
+func TestParseAsync_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	results := ParseAsync(ctx, strings.NewReader(strings.Repeat("<a/>", 1000)), 0)
+
+	<-results
+	cancel()
+
+	// Channel must be closed after cancellation, so this loop will finish.
+	for result := range results {
+		require.NoError(t, result.Err)
+	}
+}
@@ -0,0 +1,418 @@
+/*
+Package plist decodes Apple XML property lists.
+
+Values are decoded into Go types as follows:
+  - dict - map[string]interface{};
+  - array - []interface{};
+  - string - string;
+  - integer - int64;
+  - real - float64;
+  - true and false - bool;
+  - date - time.Time;
+  - data - []byte.
+
+Unmarshal additionally stores decoded values into typed Go values, like structs.
+Fields of structs are matched by `plist` tag, or field name if tag is not set.
+Fields with "-" tag are skipped.
+*/
+package plist
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"fastxml"
+)
+
+// dateLayout is the layout of date values.
+const dateLayout = "2006-01-02T15:04:05Z"
+
+var (
+	ErrInvalidPlist = errors.New("invalid plist")
+	ErrTypeMismatch = errors.New("type mismatch")
+)
+
+var (
+	cdataPrefix = []byte("<![CDATA[")
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+// Decode returns the top level value of the property list.
+func Decode(buf []byte) (interface{}, error) {
+	d := decoder{buf: buf, p: fastxml.NewParser(buf, false)}
+
+	name, err := d.nextStart()
+	if err != nil {
+		return nil, err
+	}
+
+	if name != "plist" {
+		return nil, fmt.Errorf("%w: unexpected root <%s>", ErrInvalidPlist, name)
+	}
+
+	if name, err = d.nextStart(); err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		return nil, fmt.Errorf("%w: empty plist", ErrInvalidPlist)
+	}
+
+	value, err := d.readValue(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if name, err = d.nextStart(); err != nil {
+		return nil, err
+	}
+
+	if name != "" {
+		return nil, fmt.Errorf("%w: more than one top level value", ErrInvalidPlist)
+	}
+
+	return value, nil
+}
+
+// Unmarshal will decode the property list and store its top level value in v,
+// which must be a non-nil pointer.
+func Unmarshal(buf []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("%w: non-pointer or nil %T", ErrTypeMismatch, v)
+	}
+
+	value, err := Decode(buf)
+	if err != nil {
+		return err
+	}
+
+	return assign(rv.Elem(), value, "")
+}
+
+type decoder struct {
+	buf []byte
+	p   *fastxml.Parser
+}
+
+// readValue reads value of the element, which start was already read.
+func (d *decoder) readValue(name string) (interface{}, error) { //nolint:gocyclo,cyclop // Flat switch over types.
+	switch name {
+	case "dict":
+		return d.readDict()
+	case "array":
+		return d.readArray()
+	case "true", "false":
+		if err := d.expectEnd(); err != nil {
+			return nil, err
+		}
+
+		return name == "true", nil
+	}
+
+	text, err := d.readText()
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case "string":
+		return text, nil
+	case "integer":
+		v, err := strconv.ParseInt(strings.TrimSpace(text), 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid integer %q", ErrInvalidPlist, text)
+		}
+
+		return v, nil
+	case "real":
+		v, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid real %q", ErrInvalidPlist, text)
+		}
+
+		return v, nil
+	case "date":
+		v, err := time.Parse(dateLayout, strings.TrimSpace(text))
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid date %q", ErrInvalidPlist, text)
+		}
+
+		return v, nil
+	case "data":
+		v, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(text), ""))
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid data: %v", ErrInvalidPlist, err)
+		}
+
+		return v, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown element <%s>", ErrInvalidPlist, name)
+	}
+}
+
+func (d *decoder) readDict() (map[string]interface{}, error) {
+	dict := map[string]interface{}{}
+
+	for {
+		name, err := d.nextStart()
+		if err != nil {
+			return nil, err
+		}
+
+		switch name {
+		case "":
+			return dict, nil
+		case "key":
+		default:
+			return nil, fmt.Errorf("%w: expected <key> in dict, got <%s>", ErrInvalidPlist, name)
+		}
+
+		key, err := d.readText()
+		if err != nil {
+			return nil, err
+		}
+
+		if name, err = d.nextStart(); err != nil {
+			return nil, err
+		}
+
+		if name == "" {
+			return nil, fmt.Errorf("%w: key %q without value", ErrInvalidPlist, key)
+		}
+
+		if dict[key], err = d.readValue(name); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (d *decoder) readArray() ([]interface{}, error) {
+	array := []interface{}{}
+
+	for {
+		name, err := d.nextStart()
+		if err != nil {
+			return nil, err
+		}
+
+		if name == "" {
+			return array, nil
+		}
+
+		value, err := d.readValue(name)
+		if err != nil {
+			return nil, err
+		}
+
+		array = append(array, value)
+	}
+}
+
+// nextStart returns name of the next start element, or empty name if end element was read.
+//
+// Char data between elements is ignored.
+func (d *decoder) nextStart() (string, error) {
+	for {
+		token, err := d.p.Next()
+		if err != nil {
+			return "", fmt.Errorf("read element: %w", err)
+		}
+
+		switch tkn := token.(type) {
+		case *fastxml.StartToken:
+			return tkn.Name, nil
+		case *fastxml.EndElement:
+			return "", nil
+		}
+	}
+}
+
+func (d *decoder) expectEnd() error {
+	name, err := d.nextStart()
+	if err != nil {
+		return err
+	}
+
+	if name != "" {
+		return fmt.Errorf("%w: unexpected <%s>", ErrInvalidPlist, name)
+	}
+
+	return nil
+}
+
+// readText returns text of the element, which start was already read.
+func (d *decoder) readText() (string, error) {
+	var text strings.Builder
+
+	for {
+		start := d.p.InputOffset()
+
+		token, err := d.p.Next()
+		if err != nil {
+			return "", fmt.Errorf("read text: %w", err)
+		}
+
+		switch tkn := token.(type) {
+		case *fastxml.CharData:
+			if bytes.HasPrefix(d.buf[start:], cdataPrefix) {
+				text.Write(*tkn)
+			} else {
+				text.WriteString(fastxml.Unescape(string(*tkn)))
+			}
+		case *fastxml.StartToken:
+			return "", fmt.Errorf("%w: unexpected <%s> in text", ErrInvalidPlist, tkn.Name)
+		case *fastxml.EndElement:
+			return text.String(), nil
+		}
+	}
+}
+
+// assign stores decoded value in dst. Path is used in errors to locate the value.
+func assign(dst reflect.Value, value interface{}, path string) error { //nolint:gocyclo,cyclop // Switch over kinds.
+	mismatch := func() error {
+		return fmt.Errorf("%w: can not store %T in %s at %q", ErrTypeMismatch, value, dst.Type(), path)
+	}
+
+	if dst.Kind() == reflect.Ptr {
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+
+		return assign(dst.Elem(), value, path)
+	}
+
+	if dst.Kind() == reflect.Interface && dst.NumMethod() == 0 {
+		dst.Set(reflect.ValueOf(value))
+
+		return nil
+	}
+
+	switch v := value.(type) {
+	case string:
+		if dst.Kind() != reflect.String {
+			return mismatch()
+		}
+
+		dst.SetString(v)
+	case bool:
+		if dst.Kind() != reflect.Bool {
+			return mismatch()
+		}
+
+		dst.SetBool(v)
+	case int64:
+		switch dst.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if dst.OverflowInt(v) {
+				return fmt.Errorf("%w: %d overflows %s at %q", ErrTypeMismatch, v, dst.Type(), path)
+			}
+
+			dst.SetInt(v)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if v < 0 || dst.OverflowUint(uint64(v)) {
+				return fmt.Errorf("%w: %d overflows %s at %q", ErrTypeMismatch, v, dst.Type(), path)
+			}
+
+			dst.SetUint(uint64(v))
+		case reflect.Float32, reflect.Float64:
+			dst.SetFloat(float64(v))
+		default:
+			return mismatch()
+		}
+	case float64:
+		if dst.Kind() != reflect.Float32 && dst.Kind() != reflect.Float64 {
+			return mismatch()
+		}
+
+		dst.SetFloat(v)
+	case time.Time:
+		if dst.Type() != timeType {
+			return mismatch()
+		}
+
+		dst.Set(reflect.ValueOf(v))
+	case []byte:
+		if dst.Kind() != reflect.Slice || dst.Type().Elem().Kind() != reflect.Uint8 {
+			return mismatch()
+		}
+
+		dst.SetBytes(v)
+	case []interface{}:
+		if dst.Kind() != reflect.Slice {
+			return mismatch()
+		}
+
+		slice := reflect.MakeSlice(dst.Type(), len(v), len(v))
+
+		for i, elem := range v {
+			if err := assign(slice.Index(i), elem, path+"/"+strconv.Itoa(i)); err != nil {
+				return err
+			}
+		}
+
+		dst.Set(slice)
+	case map[string]interface{}:
+		switch {
+		case dst.Kind() == reflect.Struct:
+			return assignStruct(dst, v, path)
+		case dst.Kind() == reflect.Map && dst.Type().Key().Kind() == reflect.String:
+			return assignMap(dst, v, path)
+		default:
+			return mismatch()
+		}
+	}
+
+	return nil
+}
+
+func assignMap(dst reflect.Value, dict map[string]interface{}, path string) error {
+	if dst.IsNil() {
+		dst.Set(reflect.MakeMapWithSize(dst.Type(), len(dict)))
+	}
+
+	for key, value := range dict {
+		elem := reflect.New(dst.Type().Elem()).Elem()
+
+		if err := assign(elem, value, path+"/"+key); err != nil {
+			return err
+		}
+
+		dst.SetMapIndex(reflect.ValueOf(key).Convert(dst.Type().Key()), elem)
+	}
+
+	return nil
+}
+
+// assignStruct stores values of the dict in matching fields of the struct.
+// Keys without matching fields are ignored.
+func assignStruct(dst reflect.Value, dict map[string]interface{}, path string) error {
+	for i := 0; i < dst.NumField(); i++ {
+		field := dst.Type().Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("plist"); ok {
+			name = tag
+		}
+
+		value, ok := dict[name]
+		if name == "-" || !ok {
+			continue
+		}
+
+		if err := assign(dst.Field(i), value, path+"/"+name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package plist
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const infoPlist = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>CFBundleName</key>
+	<string>Example &amp; Co</string>
+	<key>CFBundleVersion</key>
+	<integer>42</integer>
+	<key>Scale</key>
+	<real>1.5</real>
+	<key>LSRequiresIPhoneOS</key>
+	<true/>
+	<key>Hidden</key>
+	<false/>
+	<key>Released</key>
+	<date>2021-03-04T05:06:07Z</date>
+	<key>Icon</key>
+	<data>
+	aGVs
+	bG8=
+	</data>
+	<key>Architectures</key>
+	<array>
+		<string>arm64</string>
+		<string><![CDATA[x86_64 & <more>]]></string>
+	</array>
+	<key>Nested</key>
+	<dict>
+		<key>Empty</key>
+		<array/>
+	</dict>
+</dict>
+</plist>`
+
+func TestDecode(t *testing.T) {
+	value, err := Decode([]byte(infoPlist))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"CFBundleName":       "Example & Co",
+		"CFBundleVersion":    int64(42),
+		"Scale":              1.5,
+		"LSRequiresIPhoneOS": true,
+		"Hidden":             false,
+		"Released":           time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC),
+		"Icon":               []byte("hello"),
+		"Architectures":      []interface{}{"arm64", "x86_64 & <more>"},
+		"Nested":             map[string]interface{}{"Empty": []interface{}{}},
+	}, value)
+}
+
+func TestDecode_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "wrong root", input: `<dict></dict>`},
+		{name: "empty plist", input: `<plist></plist>`},
+		{name: "two values", input: `<plist><true/><false/></plist>`},
+		{name: "unknown element", input: `<plist><set/></plist>`},
+		{name: "value without key", input: `<plist><dict><string>a</string></dict></plist>`},
+		{name: "key without value", input: `<plist><dict><key>a</key></dict></plist>`},
+		{name: "invalid integer", input: `<plist><integer>1.5</integer></plist>`},
+		{name: "invalid real", input: `<plist><real>one</real></plist>`},
+		{name: "invalid date", input: `<plist><date>yesterday</date></plist>`},
+		{name: "invalid data", input: `<plist><data>!!</data></plist>`},
+		{name: "element in string", input: `<plist><string>a<b/></string></plist>`},
+		{name: "content in true", input: `<plist><true><false/></true></plist>`},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Decode([]byte(test.input))
+			require.ErrorIs(t, err, ErrInvalidPlist)
+		})
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	type nested struct {
+		Empty []string
+	}
+
+	type info struct {
+		Name          string `plist:"CFBundleName"`
+		Version       uint16 `plist:"CFBundleVersion"`
+		Scale         float32
+		RequiresIOS   bool `plist:"LSRequiresIPhoneOS"`
+		Hidden        *bool
+		Released      time.Time
+		Icon          []byte
+		Architectures []string
+		Nested        nested
+		Ignored       string `plist:"-"`
+		Missing       int
+		unexported    string
+	}
+
+	var v info
+
+	require.NoError(t, Unmarshal([]byte(infoPlist), &v))
+
+	hidden := false
+
+	assert.Equal(t, info{
+		Name:          "Example & Co",
+		Version:       42,
+		Scale:         1.5,
+		RequiresIOS:   true,
+		Hidden:        &hidden,
+		Released:      time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC),
+		Icon:          []byte("hello"),
+		Architectures: []string{"arm64", "x86_64 & <more>"},
+		Nested:        nested{Empty: []string{}},
+	}, v)
+}
+
+func TestUnmarshal_Generic(t *testing.T) {
+	var dict map[string]interface{}
+
+	require.NoError(t, Unmarshal([]byte(`<plist><dict><key>a</key><integer>0x10</integer></dict></plist>`), &dict))
+	assert.Equal(t, map[string]interface{}{"a": int64(16)}, dict)
+
+	var reals map[string]float64
+
+	require.NoError(t, Unmarshal([]byte(`<plist><dict><key>a</key><integer>2</integer></dict></plist>`), &reals))
+	assert.Equal(t, map[string]float64{"a": 2}, reals)
+}
+
+func TestUnmarshal_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		dst   interface{}
+	}{
+		{name: "not pointer", input: `<plist><true/></plist>`, dst: true},
+		{name: "string into int", input: `<plist><string>a</string></plist>`, dst: new(int)},
+		{name: "overflow", input: `<plist><integer>300</integer></plist>`, dst: new(uint8)},
+		{name: "negative uint", input: `<plist><integer>-1</integer></plist>`, dst: new(uint)},
+		{name: "real into int", input: `<plist><real>1.5</real></plist>`, dst: new(int)},
+		{name: "array into string", input: `<plist><array/></plist>`, dst: new(string)},
+		{name: "dict into slice", input: `<plist><dict/></plist>`, dst: new([]string)},
+		{
+			name:  "nested mismatch",
+			input: `<plist><dict><key>A</key><array><true/></array></dict></plist>`,
+			dst:   &struct{ A []string }{},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			err := Unmarshal([]byte(test.input), test.dst)
+			require.ErrorIs(t, err, ErrTypeMismatch)
+		})
+	}
+}
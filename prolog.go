@@ -0,0 +1,187 @@
+package fastxml
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrInvalidProlog is returned when XML declaration or DOCTYPE can not be written as requested.
+var ErrInvalidProlog = errors.New("invalid prolog")
+
+// Declaration is the XML declaration of the document, like `<?xml version="1.0" encoding="UTF-8"?>`.
+type Declaration struct {
+	// Version is "1.0" if not set.
+	Version string
+	// Encoding is not written if not set.
+	Encoding string
+	// Standalone is "yes", "no" or not set, in which case it is not written.
+	Standalone string
+}
+
+// DocType is the document type declaration, like `<!DOCTYPE html PUBLIC "public-id" "system-id">`.
+type DocType struct {
+	// Name is the name of the root element.
+	Name string
+	// PublicID requires SystemID to be set.
+	PublicID string
+	SystemID string
+	// InternalSubset holds markup declarations, like `<!ENTITY name "value">`, and is written as is.
+	InternalSubset string
+}
+
+// WriteDeclaration will write XML declaration.
+//
+// Declaration must be written before anything else in the document.
+func (e *Encoder) WriteDeclaration(decl Declaration) error {
+	if decl.Version == "" {
+		decl.Version = "1.0"
+	}
+
+	if !isVersionNum(decl.Version) {
+		return fmt.Errorf("%w: version %q", ErrInvalidProlog, decl.Version)
+	}
+
+	if decl.Encoding != "" && !isEncodingName(decl.Encoding) {
+		return fmt.Errorf("%w: encoding %q", ErrInvalidProlog, decl.Encoding)
+	}
+
+	switch decl.Standalone {
+	case "", "yes", "no":
+	default:
+		return fmt.Errorf("%w: standalone %q", ErrInvalidProlog, decl.Standalone)
+	}
+
+	e.closeStart()
+	e.indentItem()
+
+	e.w.WriteString(`<?xml version="`)
+	e.w.WriteString(decl.Version)
+	e.w.WriteByte('"')
+
+	if decl.Encoding != "" {
+		e.w.WriteString(` encoding="`)
+		e.w.WriteString(decl.Encoding)
+		e.w.WriteByte('"')
+	}
+
+	if decl.Standalone != "" {
+		e.w.WriteString(` standalone="`)
+		e.w.WriteString(decl.Standalone)
+		e.w.WriteByte('"')
+	}
+
+	e.w.WriteString("?>")
+
+	_, err := e.w.Write(nil)
+
+	return err
+}
+
+// WriteDocType will write document type declaration.
+//
+// Internal subset is not verified, as it is written as is.
+func (e *Encoder) WriteDocType(docType DocType) error {
+	if !isName(docType.Name) {
+		return fmt.Errorf("%w: DOCTYPE name %q", ErrInvalidProlog, docType.Name)
+	}
+
+	if docType.PublicID != "" && docType.SystemID == "" {
+		return fmt.Errorf("%w: DOCTYPE public ID without system ID", ErrInvalidProlog)
+	}
+
+	if strings.IndexFunc(docType.PublicID, func(r rune) bool { return !isPubidChar(r) }) != -1 {
+		return fmt.Errorf("%w: DOCTYPE public ID %q", ErrInvalidProlog, docType.PublicID)
+	}
+
+	// System literal can be quoted with either quote, but can not contain both of them.
+	quote := byte('"')
+	if strings.IndexByte(docType.SystemID, '"') != -1 {
+		quote = '\''
+
+		if strings.IndexByte(docType.SystemID, '\'') != -1 {
+			return fmt.Errorf("%w: DOCTYPE system ID %q", ErrInvalidProlog, docType.SystemID)
+		}
+	}
+
+	e.closeStart()
+	e.indentItem()
+
+	e.w.WriteString("<!DOCTYPE ")
+	e.w.WriteString(docType.Name)
+
+	switch {
+	case docType.PublicID != "":
+		e.w.WriteString(` PUBLIC "`)
+		e.w.WriteString(docType.PublicID)
+		e.w.WriteString(`" `)
+	case docType.SystemID != "":
+		e.w.WriteString(" SYSTEM ")
+	}
+
+	if docType.SystemID != "" {
+		e.w.WriteByte(quote)
+		e.w.WriteString(docType.SystemID)
+		e.w.WriteByte(quote)
+	}
+
+	if docType.InternalSubset != "" {
+		e.w.WriteString(" [")
+		e.w.WriteString(docType.InternalSubset)
+		e.w.WriteByte(']')
+	}
+
+	e.w.WriteByte('>')
+
+	_, err := e.w.Write(nil)
+
+	return err
+}
+
+// isVersionNum reports if s is a valid XML version, like "1.0".
+func isVersionNum(s string) bool {
+	if len(s) < 3 || !strings.HasPrefix(s, "1.") {
+		return false
+	}
+
+	return strings.IndexFunc(s[2:], func(r rune) bool { return r < '0' || r > '9' }) == -1
+}
+
+// isEncodingName reports if s is a valid encoding name, like "UTF-8".
+func isEncodingName(s string) bool {
+	for i, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && (r >= '0' && r <= '9' || r == '.' || r == '_' || r == '-'):
+		default:
+			return false
+		}
+	}
+
+	return s != ""
+}
+
+// isName reports if s is a valid XML name.
+func isName(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	first, size := utf8.DecodeRuneInString(s)
+	if !isNameStartChar(first) {
+		return false
+	}
+
+	return strings.IndexFunc(s[size:], func(r rune) bool { return !isNameChar(r) }) == -1
+}
+
+// isPubidChar reports if r can appear in the public ID.
+func isPubidChar(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	default:
+		return strings.ContainsRune(" \r\n-'()+,./:=?;!*#@$_%", r)
+	}
+}
@@ -0,0 +1,150 @@
+package fastxml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncoder_WriteDeclaration(t *testing.T) {
+	tests := []struct {
+		name   string
+		decl   Declaration
+		result string
+		err    string
+	}{
+		{name: "default", result: `<?xml version="1.0"?>`},
+		{
+			name:   "all",
+			decl:   Declaration{Version: "1.1", Encoding: "UTF-8", Standalone: "yes"},
+			result: `<?xml version="1.1" encoding="UTF-8" standalone="yes"?>`,
+		},
+		{name: "invalid version", decl: Declaration{Version: "2.0"}, err: `invalid prolog: version "2.0"`},
+		{name: "invalid encoding", decl: Declaration{Encoding: `UTF"8`}, err: `invalid prolog: encoding "UTF\"8"`},
+		{name: "invalid standalone", decl: Declaration{Standalone: "true"}, err: `invalid prolog: standalone "true"`},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			enc := NewEncoder(&buf)
+
+			err := enc.WriteDeclaration(test.decl)
+			if test.err != "" {
+				require.ErrorIs(t, err, ErrInvalidProlog)
+				assert.EqualError(t, err, test.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.NoError(t, enc.Flush())
+			assert.Equal(t, test.result, buf.String())
+		})
+	}
+}
+
+func TestEncoder_WriteDocType(t *testing.T) {
+	tests := []struct {
+		name    string
+		docType DocType
+		result  string
+		err     string
+	}{
+		{name: "name only", docType: DocType{Name: "html"}, result: `<!DOCTYPE html>`},
+		{
+			name:    "system",
+			docType: DocType{Name: "note", SystemID: "note.dtd"},
+			result:  `<!DOCTYPE note SYSTEM "note.dtd">`,
+		},
+		{
+			name:    "system with quote",
+			docType: DocType{Name: "note", SystemID: `a"b.dtd`},
+			result:  `<!DOCTYPE note SYSTEM 'a"b.dtd'>`,
+		},
+		{
+			name: "public",
+			docType: DocType{
+				Name:     "html",
+				PublicID: "-//W3C//DTD XHTML 1.0 Strict//EN",
+				SystemID: "http://www.w3.org/TR/xhtml1/DTD/xhtml1-strict.dtd",
+			},
+			result: `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Strict//EN" ` +
+				`"http://www.w3.org/TR/xhtml1/DTD/xhtml1-strict.dtd">`,
+		},
+		{
+			name:    "internal subset",
+			docType: DocType{Name: "doc", SystemID: "doc.dtd", InternalSubset: `<!ENTITY e "v">`},
+			result:  `<!DOCTYPE doc SYSTEM "doc.dtd" [<!ENTITY e "v">]>`,
+		},
+		{name: "no name", docType: DocType{}, err: `invalid prolog: DOCTYPE name ""`},
+		{name: "invalid name", docType: DocType{Name: "1a"}, err: `invalid prolog: DOCTYPE name "1a"`},
+		{
+			name:    "public without system",
+			docType: DocType{Name: "a", PublicID: "id"},
+			err:     "invalid prolog: DOCTYPE public ID without system ID",
+		},
+		{
+			name:    "invalid public",
+			docType: DocType{Name: "a", PublicID: `"`, SystemID: "a"},
+			err:     `invalid prolog: DOCTYPE public ID "\""`,
+		},
+		{
+			name:    "invalid system",
+			docType: DocType{Name: "a", SystemID: `'"`},
+			err:     `invalid prolog: DOCTYPE system ID "'\""`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			enc := NewEncoder(&buf)
+
+			err := enc.WriteDocType(test.docType)
+			if test.err != "" {
+				require.ErrorIs(t, err, ErrInvalidProlog)
+				assert.EqualError(t, err, test.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.NoError(t, enc.Flush())
+			assert.Equal(t, test.result, buf.String())
+		})
+	}
+}
+
+func TestEncoder_Prolog(t *testing.T) {
+	var buf bytes.Buffer
+
+	enc := NewEncoder(&buf)
+
+	require.NoError(t, enc.WriteDeclaration(Declaration{Encoding: "UTF-8", Standalone: "yes"}))
+	require.NoError(t, enc.WriteDocType(DocType{Name: "doc", InternalSubset: `<!ENTITY e "entity">`}))
+	require.NoError(t, enc.EncodeElement("&e;", xml.StartElement{Name: xml.Name{Local: "doc"}}))
+	require.NoError(t, enc.Flush())
+
+	p := NewParser(buf.Bytes(), false, WithStrict())
+
+	for {
+		_, err := p.Next()
+		if err != nil {
+			break
+		}
+	}
+
+	standalone, declared := p.Standalone()
+	assert.True(t, declared)
+	assert.True(t, standalone)
+}
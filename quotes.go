@@ -0,0 +1,23 @@
+package fastxml
+
+// WithTypographicQuotes makes parser accept attribute values in typographic quotes, like `<a title=“value”>`,
+// which are found in real-world documents produced by word processors.
+// Values opened with “ or ” end with ”, and values opened with ‘ or ’ end with ’.
+// Returned values do not include quotation marks. Values in typographic quotes must not contain
+// '>' and ASCII quotation marks, as tags are scanned before their attributes are decoded.
+//
+// Such documents are not well-formed, so this option has no effect in strict mode, see WithStrict.
+func WithTypographicQuotes() ParserOption {
+	return func(p *Parser) {
+		p.typographicQuotes = true
+	}
+}
+
+// typographicQuotes are opening quotation marks that are accepted with WithTypographicQuotes,
+// and their closing quotation marks. Word processors replace both quotes with the closing one sometimes.
+var typographicQuotes = [...]struct{ opening, closing []byte }{
+	{[]byte("\u201C"), []byte("\u201D")}, // “ ”
+	{[]byte("\u201D"), []byte("\u201D")}, // ” ”
+	{[]byte("\u2018"), []byte("\u2019")}, // ‘ ’
+	{[]byte("\u2019"), []byte("\u2019")}, // ’ ’
+}
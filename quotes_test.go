@@ -0,0 +1,66 @@
+package fastxml
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTypographicQuotes(t *testing.T) {
+	input := `<p a=“double” b=‘single’ c=”same” d=’same’ e="plain" f=“it’s ‘nested’”>text</p>`
+
+	attributes := func(opts ...ParserOption) (map[string]string, error) {
+		p := NewParser([]byte(input), false, opts...)
+
+		token, err := p.Next()
+		require.NoError(t, err)
+
+		start := CopyToken(token).(*StartToken)
+		attrs := map[string]string{}
+
+		for {
+			name, val, err := start.NextAttribute()
+			if errors.Is(err, io.EOF) {
+				return attrs, nil
+			}
+
+			if err != nil {
+				return attrs, err
+			}
+
+			attrs[name] = val
+		}
+	}
+
+	attrs, err := attributes(WithTypographicQuotes())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"a": "double",
+		"b": "single",
+		"c": "same",
+		"d": "same",
+		"e": "plain",
+		"f": "it’s ‘nested’",
+	}, attrs)
+
+	_, err = attributes()
+	assert.ErrorIs(t, err, ErrNotQuoted)
+
+	_, err = attributes(WithTypographicQuotes(), WithStrict())
+	assert.ErrorIs(t, err, ErrNotQuoted, "typographic quotes are not accepted in strict mode")
+
+	p := NewParser([]byte(`<p a=“open>text</p>`), false, WithTypographicQuotes())
+
+	token, err := p.Next()
+	require.NoError(t, err)
+
+	_, _, err = token.(*StartToken).NextAttribute()
+
+	var quoteErr *QuoteError
+
+	require.True(t, errors.As(err, &quoteErr))
+	assert.Equal(t, &QuoteError{Quote: '“', Offset: 2}, quoteErr)
+}
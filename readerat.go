@@ -0,0 +1,90 @@
+package fastxml
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// defaultWindowSize is the size of windows read by ReaderAtParser if size was not provided.
+const defaultWindowSize = 64 << 10
+
+// ReaderAtParser parses document from io.ReaderAt, reading it in windows of fixed size on demand.
+//
+// This allows to parse seekable sources, like files or objects of blob storages read with ranged requests,
+// without reading full document into memory. Only the window and the not yet parsed data are held in memory.
+type ReaderAtParser struct {
+	p      *Parser
+	r      io.ReaderAt
+	size   int64
+	offset int64
+	window []byte
+}
+
+// NewReaderAtParser will create a parser of the first size bytes of r, that reads windowSize bytes at a time.
+//
+// If windowSize is not positive - 64 KiB windows are read.
+func NewReaderAtParser(r io.ReaderAt, size int64, windowSize int, opts ...ParserOption) *ReaderAtParser {
+	if windowSize <= 0 {
+		windowSize = defaultWindowSize
+	}
+
+	return &ReaderAtParser{
+		p:      NewStreamParser(opts...),
+		r:      r,
+		size:   size,
+		window: make([]byte, windowSize),
+	}
+}
+
+// Next returns next token, same as Parser.Next.
+//
+// Next window is read only when parsed data does not contain full token.
+// Returned token MUST NOT be used after next call to ReaderAtParser.Next.
+func (r *ReaderAtParser) Next() (xml.Token, error) {
+	for {
+		token, err := r.p.Next()
+		if !errors.Is(err, ErrNeedMoreData) {
+			return token, err
+		}
+
+		if err := r.readWindow(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// InputOffset returns offset of the next byte that will be parsed, same as Parser.InputOffset.
+func (r *ReaderAtParser) InputOffset() int {
+	return r.p.InputOffset()
+}
+
+// readWindow feeds next window to the parser, or marks the end of data.
+func (r *ReaderAtParser) readWindow() error {
+	if r.offset >= r.size {
+		r.p.streaming = false
+
+		return nil
+	}
+
+	window := r.window
+	if remaining := r.size - r.offset; remaining < int64(len(window)) {
+		window = window[:remaining]
+	}
+
+	n, err := r.r.ReadAt(window, r.offset)
+	if n == len(window) {
+		// ReadAt may return io.EOF along with the last bytes.
+		err = nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("read at %d: %w", r.offset, err)
+	}
+
+	r.offset += int64(n)
+	r.p.Feed(window)
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package fastxml
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingReaderAt counts calls to ReadAt.
+type countingReaderAt struct {
+	r     io.ReaderAt
+	reads int
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	c.reads++
+
+	return c.r.ReadAt(p, off)
+}
+
+func TestReaderAtParser(t *testing.T) {
+	data := `<?xml version="1.0"?><root><item id="1">text</item><item/><!-- comment --></root>` + "\n"
+
+	mustTokens, err := Tokens([]byte(data))
+	require.NoError(t, err)
+
+	for _, windowSize := range []int{1, 7, 64, 0} {
+		r := &countingReaderAt{r: strings.NewReader(data)}
+		p := NewReaderAtParser(r, int64(len(data)), windowSize)
+
+		var tokens []xml.Token
+
+		for {
+			token, err := p.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			require.NoError(t, err)
+
+			if token != nil {
+				tokens = append(tokens, ToStd(token))
+			}
+		}
+
+		assert.Equal(t, len(data), p.InputOffset())
+		require.Len(t, tokens, len(mustTokens))
+
+		for i := range tokens {
+			assert.Equal(t, ToStd(mustTokens[i]), tokens[i])
+		}
+
+		if windowSize == 1 {
+			assert.Equal(t, len(data), r.reads)
+		}
+	}
+}
+
+func TestReaderAtParser_Errors(t *testing.T) {
+	data := `<root><item`
+
+	p := NewReaderAtParser(strings.NewReader(data), int64(len(data)), 4)
+
+	var err error
+	for err == nil {
+		_, err = p.Next()
+	}
+
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	// Size is larger than the data.
+	p = NewReaderAtParser(strings.NewReader(data), int64(len(data))+10, 4)
+
+	for err = nil; err == nil; {
+		_, err = p.Next()
+	}
+
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Contains(t, err.Error(), "read at 8")
+}
@@ -0,0 +1,167 @@
+package fastxml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// RedactionMask is the byte that redacted values are overwritten with.
+const RedactionMask = '*'
+
+// Redactor overwrites values of selected elements and attributes in place,
+// to produce sanitized copies of production payloads for debugging.
+//
+// Values are masked byte by byte, so the document keeps its size and offsets of all tokens,
+// and errors or logs that refer to offsets of the original document still point to the same markup.
+// References in masked values are masked as well, and whitespace is kept as is.
+type Redactor struct {
+	elements *ElementMatcher
+	// attrs hold matchers of elements by names of their redacted attributes,
+	// nil matcher selects attribute of any element.
+	attrs map[string]*ElementMatcher
+}
+
+// NewRedactor will create redactor of the values selected by selectors.
+//
+// Selector is an element path, as for NewElementMatcher, which selects all text inside the element,
+// including text of its children. Selector with '@' selects the attribute of the element instead,
+// like `user@token`, and selector starting with '@', like `@password`, selects attribute of any element.
+// Names are compared as written in the document, including namespace prefixes.
+func NewRedactor(selectors ...string) *Redactor {
+	r := &Redactor{attrs: map[string]*ElementMatcher{}}
+
+	var elements []string
+
+	attrElements := map[string][]string{}
+
+	for _, selector := range selectors {
+		idx := strings.LastIndexByte(selector, '@')
+
+		switch {
+		case idx == -1:
+			elements = append(elements, selector)
+		case idx != len(selector)-1:
+			attr := selector[idx+1:]
+			attrElements[attr] = append(attrElements[attr], selector[:idx])
+		}
+	}
+
+	r.elements = NewElementMatcher(elements...)
+
+	for attr, paths := range attrElements {
+		r.attrs[attr] = NewElementMatcher(paths...)
+
+		for _, path := range paths {
+			if path == "" {
+				r.attrs[attr] = nil
+			}
+		}
+	}
+
+	return r
+}
+
+// Redact will overwrite selected values in buf with RedactionMask.
+//
+// buf is modified even if error is returned, up to the position of the error.
+func (r *Redactor) Redact(buf []byte) error {
+	p := NewParser(buf, false)
+
+	// depth is the depth of the outermost open redacted element, or 0.
+	var depth int
+
+	for {
+		token, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("redact: %w", err)
+		}
+
+		switch tkn := token.(type) {
+		case *StartToken:
+			stack := p.Stack()
+
+			if depth == 0 && r.elements.Match(tkn.Name, stack[:len(stack)-1]) {
+				depth = len(stack)
+			}
+
+			if len(r.attrs) != 0 {
+				if err := r.redactAttributes(p, tkn, stack[:len(stack)-1]); err != nil {
+					return fmt.Errorf("redact: index position %d: %w", p.currentPointer, err)
+				}
+			}
+		case *EndElement:
+			if p.Depth() < depth {
+				depth = 0
+			}
+		case *CharData:
+			if depth != 0 {
+				maskValue(p.buf[textOffset(p, *tkn):][:len(*tkn)])
+			}
+		}
+	}
+}
+
+// RedactBytes is like Redactor.Redact, but returns redacted copy of buf.
+func (r *Redactor) RedactBytes(buf []byte) ([]byte, error) {
+	buf = copyBytes(buf)
+
+	if err := r.Redact(buf); err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
+
+// redactAttributes masks selected attributes of the just returned start element.
+func (r *Redactor) redactAttributes(p *Parser, start *StartToken, parents []string) error {
+	for {
+		name, val, err := start.NextAttribute()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		matcher, ok := r.attrs[name]
+		if !ok || matcher != nil && !matcher.Match(start.Name, parents) {
+			continue
+		}
+
+		// Attribute buffer ends where the tag ends and starts after the closing quote of the value.
+		end := p.currentPointer - len(start.attrBuf) - 1
+		maskValue(p.buf[end-len(val) : end])
+	}
+}
+
+// textOffset returns offset of the just returned char data in the buffer of the parser.
+//
+// Text that is preceded by the start of CDATA section is its content, as plain text always follows '>'.
+func textOffset(p *Parser, text CharData) int {
+	end := p.currentPointer
+
+	if bytes.HasSuffix(p.buf[:end], cdataSuffix) {
+		if start := end - len(cdataSuffix) - len(text); start >= 0 && bytes.HasSuffix(p.buf[:start], cdataPrefix) {
+			end -= len(cdataSuffix)
+		}
+	}
+
+	return end - len(text)
+}
+
+// maskValue overwrites all non-whitespace bytes of data with RedactionMask.
+func maskValue(data []byte) {
+	for i, b := range data {
+		if !IsHTMLSpaceChar(rune(b)) {
+			data[i] = RedactionMask
+		}
+	}
+}
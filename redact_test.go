@@ -0,0 +1,73 @@
+package fastxml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactor_Redact(t *testing.T) {
+	tests := []struct {
+		name      string
+		selectors []string
+		input     string
+		result    string
+	}{
+		{
+			name:      "element text",
+			selectors: []string{"password"},
+			input:     `<user><name>bob</name><password>s3cr&amp;t</password></user>`,
+			result:    `<user><name>bob</name><password>**********</password></user>`,
+		},
+		{
+			name:      "nested text and whitespace",
+			selectors: []string{"/user/card"},
+			input:     "<user><card>\n  <number>4111 1111</number><!-- kept --><cvv><![CDATA[123]]></cvv>\n</card><card/></user>",
+			result:    "<user><card>\n  <number>**** ****</number><!-- kept --><cvv><![CDATA[***]]></cvv>\n</card><card/></user>",
+		},
+		{
+			name:      "attributes",
+			selectors: []string{"session@token", "@password"},
+			input:     `<a token="t1"><session id='1' token='abc"d'/><login password="p" user="u"/></a>`,
+			result:    `<a token="t1"><session id='1' token='*****'/><login password="*" user="u"/></a>`,
+		},
+		{
+			name:      "path of attribute",
+			selectors: []string{"b/c@x"},
+			input:     `<a><c x="1"/><b><c x="2"></c></b></a>`,
+			result:    `<a><c x="1"/><b><c x="*"></c></b></a>`,
+		},
+		{
+			name:      "no selectors",
+			selectors: nil,
+			input:     `<a x="1">text</a>`,
+			result:    `<a x="1">text</a>`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			input := []byte(test.input)
+
+			result, err := NewRedactor(test.selectors...).RedactBytes(input)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.result, string(result))
+			assert.Equal(t, test.input, string(input), "input is not modified")
+
+			require.NoError(t, NewRedactor(test.selectors...).Redact(input))
+			assert.Equal(t, test.result, string(input))
+		})
+	}
+}
+
+func TestRedactor_Redact_Error(t *testing.T) {
+	input := []byte(`<a><p>secret</p><b x=1/></a>`)
+
+	err := NewRedactor("p", "@x").Redact(input)
+	assert.Error(t, err)
+	assert.Equal(t, `<a><p>******</p><b x=1/></a>`, string(input))
+}
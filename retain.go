@@ -0,0 +1,171 @@
+package fastxml
+
+import "encoding/xml"
+
+// arenaChunkSize is the size of memory chunks that retained tokens are copied into.
+const arenaChunkSize = 16 << 10
+
+// arena holds memory of tokens retained with Parser.Retain.
+//
+// Tokens are copied into chunks one after another, and chunk is reused
+// once all tokens that were copied into it are released.
+type arena struct {
+	current *arenaChunk
+	free    []*arenaChunk
+	// owners holds chunk of each retained token.
+	owners map[xml.Token]*arenaChunk
+}
+
+type arenaChunk struct {
+	buf []byte
+	// live is the number of retained tokens that were not released yet.
+	live int
+}
+
+// Retain returns a copy of the token that is owned by the caller,
+// so it stays valid after next calls to Parser.Next.
+//
+// Unlike CopyToken, data of retained tokens is copied into memory chunks of the parser,
+// which are reused after tokens are released with Parser.Release.
+// Memory of chunks is accounted in Parser.RetainedBytes.
+//
+// Retained tokens that are never released are held until the parser is collected.
+func (p *Parser) Retain(token xml.Token) xml.Token {
+	var size int
+
+	switch tkn := token.(type) {
+	case *StartToken:
+		size = len(tkn.Name) + len(tkn.attrBuf)
+	case *EndElement:
+		size = len(tkn.Name.Space) + len(tkn.Name.Local)
+	case *CharData:
+		size = len(*tkn)
+	case *Comment:
+		size = len(*tkn)
+	case *Directive:
+		size = len(*tkn)
+	case *ProcInst:
+		size = len(tkn.Target) + len(tkn.Inst)
+	default:
+		return token
+	}
+
+	chunk := p.arenaChunk(size)
+	chunk.live++
+
+	var retained xml.Token
+
+	switch tkn := token.(type) {
+	case *StartToken:
+		retained = &StartToken{
+			Name:              chunk.string(tkn.Name),
+			attrBuf:           chunk.bytes(tkn.attrBuf),
+			allowBoolAttrs:    tkn.allowBoolAttrs,
+			typographicQuotes: tkn.typographicQuotes,
+			tolerantAttrs:     tkn.tolerantAttrs,
+		}
+	case *EndElement:
+		retained = &EndElement{Name: xml.Name{Space: chunk.string(tkn.Name.Space), Local: chunk.string(tkn.Name.Local)}}
+	case *CharData:
+		charData := CharData(chunk.bytes(*tkn))
+		retained = &charData
+	case *Comment:
+		comment := Comment(chunk.bytes(*tkn))
+		retained = &comment
+	case *Directive:
+		directive := Directive(chunk.bytes(*tkn))
+		retained = &directive
+	case *ProcInst:
+		retained = &ProcInst{Target: chunk.string(tkn.Target), Inst: chunk.bytes(tkn.Inst)}
+	}
+
+	if p.arena.owners == nil {
+		p.arena.owners = make(map[xml.Token]*arenaChunk)
+	}
+
+	p.arena.owners[retained] = chunk
+
+	return retained
+}
+
+// Release returns memory of the token retained with Parser.Retain to the parser.
+//
+// Token, and any data that was taken from it, must not be used after it was released.
+// Tokens that were not returned by Parser.Retain are ignored.
+func (p *Parser) Release(token xml.Token) {
+	chunk, ok := p.arena.owners[token]
+	if !ok {
+		return
+	}
+
+	delete(p.arena.owners, token)
+
+	chunk.live--
+	if chunk.live != 0 {
+		return
+	}
+
+	switch {
+	case chunk == p.arena.current:
+		chunk.buf = chunk.buf[:0]
+	case cap(chunk.buf) == arenaChunkSize:
+		chunk.buf = chunk.buf[:0]
+		p.arena.free = append(p.arena.free, chunk)
+	default:
+		// Chunk was allocated for a single large token.
+		p.retain(-cap(chunk.buf))
+	}
+}
+
+// arenaChunk returns chunk that has at least size bytes available.
+func (p *Parser) arenaChunk(size int) *arenaChunk {
+	if size > arenaChunkSize {
+		p.retain(size)
+
+		return &arenaChunk{buf: make([]byte, 0, size)}
+	}
+
+	current := p.arena.current
+	if current != nil && cap(current.buf)-len(current.buf) >= size {
+		return current
+	}
+
+	if len(p.arena.free) != 0 {
+		current = p.arena.free[len(p.arena.free)-1]
+		p.arena.free = p.arena.free[:len(p.arena.free)-1]
+	} else {
+		p.retain(arenaChunkSize)
+
+		current = &arenaChunk{buf: make([]byte, 0, arenaChunkSize)}
+	}
+
+	// Previous chunk is not referenced by the arena anymore, unless some of its tokens are still retained.
+	if previous := p.arena.current; previous != nil && previous.live == 0 {
+		previous.buf = previous.buf[:0]
+		p.arena.free = append(p.arena.free, previous)
+	}
+
+	p.arena.current = current
+
+	return current
+}
+
+// bytes copies b to the chunk.
+func (c *arenaChunk) bytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+
+	start := len(c.buf)
+	c.buf = append(c.buf, b...)
+
+	return c.buf[start:len(c.buf):len(c.buf)]
+}
+
+// string copies s to the chunk.
+func (c *arenaChunk) string(s string) string {
+	start := len(c.buf)
+	c.buf = append(c.buf, s...)
+
+	return unsafeByteToString(c.buf[start:len(c.buf)])
+}
@@ -0,0 +1,76 @@
+package fastxml
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParser_Retain(t *testing.T) {
+	input := []byte(`<?pi data?><a x="1"><!--c-->text<b/></a>`)
+	p := NewParser(input, false)
+
+	var retained []xml.Token
+
+	for {
+		token, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+
+		if token != nil {
+			retained = append(retained, p.Retain(token))
+		}
+	}
+
+	// Input is modified to make sure that retained tokens do not point into it.
+	copy(input, strings.Repeat("-", len(input)))
+
+	require.Len(t, retained, 6)
+	assert.Equal(t, "a", retained[0].(*StartToken).Name)
+	assert.Equal(t, Comment("c"), *retained[1].(*Comment))
+	assert.Equal(t, CharData("text"), *retained[2].(*CharData))
+	assert.Equal(t, "b", retained[3].(*StartToken).Name)
+	assert.Equal(t, "b", retained[4].(*EndElement).Name.Local)
+	assert.Equal(t, "a", retained[5].(*EndElement).Name.Local)
+
+	name, val, err := retained[0].(*StartToken).NextAttribute()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"x", "1"}, []string{name, val})
+
+	assert.Equal(t, arenaChunkSize, p.RetainedBytes())
+}
+
+func TestParser_Release(t *testing.T) {
+	p := NewParser([]byte(`<a>text</a>`), false)
+
+	token, err := p.Next()
+	require.NoError(t, err)
+
+	retained := p.Retain(token)
+	retainedBytes := p.RetainedBytes()
+
+	p.Release(retained)
+	p.Release(retained)
+	p.Release(token)
+
+	for i := 0; i < 2*arenaChunkSize; i++ {
+		p.Release(p.Retain(token))
+	}
+
+	assert.Equal(t, retainedBytes, p.RetainedBytes(), "memory of released tokens must be reused")
+
+	large := CharData(strings.Repeat("a", arenaChunkSize+1))
+	retained = p.Retain(&large)
+	assert.Equal(t, retainedBytes+len(large), p.RetainedBytes())
+
+	p.Release(retained)
+	assert.Equal(t, retainedBytes, p.RetainedBytes())
+}
@@ -0,0 +1,16 @@
+//go:build fastxml_safe
+// +build fastxml_safe
+
+package fastxml
+
+// unsafeByteToString copies bytes to a new string when built with `fastxml_safe` tag.
+//
+// This is slower, but allows to use parser where `unsafe` package is forbidden.
+func unsafeByteToString(b []byte) string {
+	return string(b)
+}
+
+// unsafeStringToByte copies string to a new slice when built with `fastxml_safe` tag.
+func unsafeStringToByte(s string) []byte {
+	return []byte(s)
+}
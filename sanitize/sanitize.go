@@ -0,0 +1,253 @@
+/*
+Package sanitize removes unsafe markup from XML and XHTML fragments.
+
+Policy describes which elements, attributes and URL schemes are allowed.
+Everything else is removed while content of removed elements is kept,
+unless element is configured to be removed together with its content (like `script`).
+Comments, processing instructions and declarations are always removed.
+
+Output is well-formed: elements that were left open in the input are closed.
+*/
+package sanitize
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+
+	"fastxml"
+)
+
+// Policy is an allowlist of elements, attributes and URL schemes.
+//
+// Names of elements and attributes are matched case-insensitively.
+// Policy must not be modified while it is used to sanitize documents.
+type Policy struct {
+	// elements holds allowed elements and attributes allowed only for them.
+	elements map[string]map[string]struct{}
+	// globalAttrs are attributes allowed for all allowed elements.
+	globalAttrs map[string]struct{}
+	urlAttrs    map[string]struct{}
+	schemes     map[string]struct{}
+	// dropContent are elements that are removed together with their content, if not allowed.
+	dropContent map[string]struct{}
+}
+
+// NewPolicy will create a policy that does not allow any markup.
+//
+// Attributes `href`, `src`, `cite`, `action`, `formaction`, `poster` and `xlink:href`
+// are considered to hold URLs, and no URL schemes are allowed, so only relative URLs are kept.
+// Content of `script` and `style` elements is removed.
+func NewPolicy() *Policy {
+	p := &Policy{
+		elements:    make(map[string]map[string]struct{}),
+		globalAttrs: make(map[string]struct{}),
+		urlAttrs:    make(map[string]struct{}),
+		schemes:     make(map[string]struct{}),
+		dropContent: make(map[string]struct{}),
+	}
+
+	p.URLAttributes("href", "src", "cite", "action", "formaction", "poster", "xlink:href")
+	p.DropContent("script", "style")
+
+	return p
+}
+
+// AllowElements allows elements with provided names.
+func (p *Policy) AllowElements(names ...string) *Policy {
+	for _, name := range names {
+		name = strings.ToLower(name)
+
+		if _, ok := p.elements[name]; !ok {
+			p.elements[name] = make(map[string]struct{})
+		}
+	}
+
+	return p
+}
+
+// AllowAttributes allows attributes for all allowed elements.
+func (p *Policy) AllowAttributes(attrs ...string) *Policy {
+	addNames(p.globalAttrs, attrs)
+
+	return p
+}
+
+// AllowElementAttributes allows the element and provided attributes for it.
+func (p *Policy) AllowElementAttributes(element string, attrs ...string) *Policy {
+	p.AllowElements(element)
+	addNames(p.elements[strings.ToLower(element)], attrs)
+
+	return p
+}
+
+// AllowURLSchemes allows absolute URLs with provided schemes, like `https` or `mailto`.
+func (p *Policy) AllowURLSchemes(schemes ...string) *Policy {
+	addNames(p.schemes, schemes)
+
+	return p
+}
+
+// URLAttributes marks attributes as holding URLs, so their values are checked against allowed URL schemes.
+func (p *Policy) URLAttributes(attrs ...string) *Policy {
+	addNames(p.urlAttrs, attrs)
+
+	return p
+}
+
+// DropContent makes not allowed elements to be removed together with their content.
+func (p *Policy) DropContent(elements ...string) *Policy {
+	addNames(p.dropContent, elements)
+
+	return p
+}
+
+func addNames(set map[string]struct{}, names []string) {
+	for _, name := range names {
+		set[strings.ToLower(name)] = struct{}{}
+	}
+}
+
+// Sanitize will write sanitized src to dst.
+//
+// Source is parsed in HTML mode, so content of `script` and `style` elements is handled as raw text,
+// and attributes without values are allowed.
+func (p *Policy) Sanitize(dst io.Writer, src []byte) error {
+	parser := fastxml.NewParser(src, false, fastxml.WithHTMLMode())
+	enc := fastxml.NewEncoder(dst)
+
+	var (
+		// open holds names of open elements that were written, or empty names for removed ones.
+		open []string
+		// dropDepth is the depth of the removed element with content, 0 if there is no such element.
+		dropDepth int
+		attrs     []xml.Attr
+	)
+
+	for {
+		token, err := parser.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		switch tkn := token.(type) {
+		case *fastxml.StartToken:
+			name := strings.ToLower(tkn.Name)
+
+			elementAttrs, allowed := p.elements[name]
+			if _, drop := p.dropContent[name]; !allowed && drop && dropDepth == 0 {
+				dropDepth = len(open) + 1
+			}
+
+			if !allowed || dropDepth != 0 {
+				open = append(open, "")
+
+				continue
+			}
+
+			open = append(open, tkn.Name)
+
+			attrs = p.appendAttributes(attrs[:0], tkn, elementAttrs)
+
+			err = enc.WriteToken(xml.StartElement{Name: xml.Name{Local: tkn.Name}, Attr: attrs})
+		case *fastxml.EndElement:
+			if len(open) == 0 {
+				continue // End element without start element.
+			}
+
+			name := open[len(open)-1]
+			open = open[:len(open)-1]
+
+			if dropDepth > len(open) {
+				dropDepth = 0
+			}
+
+			if name != "" {
+				err = enc.WriteToken(xml.EndElement{Name: xml.Name{Local: name}})
+			}
+		case *fastxml.CharData:
+			if dropDepth == 0 {
+				err = enc.WriteToken(tkn)
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		if open[i] != "" {
+			if err := enc.WriteToken(xml.EndElement{Name: xml.Name{Local: open[i]}}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return enc.Flush()
+}
+
+// SanitizeBytes is like Policy.Sanitize, but returns sanitized data.
+func (p *Policy) SanitizeBytes(src []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := p.Sanitize(&buf, src); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// appendAttributes appends allowed attributes of the element to attrs.
+//
+// Malformed attributes are removed.
+func (p *Policy) appendAttributes(attrs []xml.Attr, start *fastxml.StartToken, elementAttrs map[string]struct{}) []xml.Attr {
+	for {
+		name, val, err := start.NextAttribute()
+		if err != nil {
+			return attrs
+		}
+
+		lowerName := strings.ToLower(name)
+
+		_, allowed := elementAttrs[lowerName]
+		if _, global := p.globalAttrs[lowerName]; !allowed && !global {
+			continue
+		}
+
+		val = fastxml.Unescape(val)
+
+		if _, isURL := p.urlAttrs[lowerName]; isURL && !p.allowedURL(val) {
+			continue
+		}
+
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: name}, Value: val})
+	}
+}
+
+// allowedURL reports if URL is relative or has allowed scheme.
+func (p *Policy) allowedURL(val string) bool {
+	// Browsers ignore whitespace and control characters in URLs, so `java\tscript:` is still a scheme.
+	val = strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7F {
+			return -1
+		}
+
+		return r
+	}, val)
+
+	colonIdx := strings.IndexByte(val, ':')
+	if colonIdx == -1 || strings.ContainsAny(val[:colonIdx], "/?#") {
+		return true // Relative URL.
+	}
+
+	_, ok := p.schemes[strings.ToLower(val[:colonIdx])]
+
+	return ok
+}
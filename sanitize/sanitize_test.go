@@ -0,0 +1,83 @@
+package sanitize
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy_Sanitize(t *testing.T) {
+	policy := NewPolicy().
+		AllowElements("p", "b", "i").
+		AllowElementAttributes("a", "href", "title").
+		AllowAttributes("class").
+		AllowURLSchemes("https", "mailto")
+
+	tests := []struct {
+		name   string
+		input  string
+		result string
+	}{
+		{
+			name:   "allowed markup",
+			input:  `<p class="x">Hello <b>world</b> &amp; <I>all</I></p>`,
+			result: `<p class="x">Hello <b>world</b> &amp; <I>all</I></p>`,
+		},
+		{
+			name:   "removed elements keep content",
+			input:  `<div><p>a<span style="color: red">b</span></p></div>`,
+			result: `<p>ab</p>`,
+		},
+		{
+			name:   "removed attributes",
+			input:  `<p onclick="alert(1)" id="x" class='a "b"'>t</p>`,
+			result: `<p class="a &#34;b&#34;">t</p>`,
+		},
+		{
+			name:   "script and style content",
+			input:  `<p>a<script>if (a<b) alert("</p>")</script><style>p{}</style>b</p>`,
+			result: `<p>ab</p>`,
+		},
+		{
+			name:   "urls",
+			input:  `<a href="https://example.com/?a=1&amp;b=2">1</a><a href="JavaScript:alert(1)">2</a><a href="java&#x09;script:x">3</a><a href="/local:path" title="t">4</a><a href="mailto:x@example.com">5</a>`,
+			result: `<a href="https://example.com/?a=1&amp;b=2">1</a><a>2</a><a>3</a><a href="/local:path" title="t">4</a><a href="mailto:x@example.com">5</a>`,
+		},
+		{
+			name:   "comments and declarations",
+			input:  `<?xml version="1.0"?><!DOCTYPE p><p><!-- secret -->text<![CDATA[<b>not a tag</b>]]></p>`,
+			result: `<p>text&lt;b>not a tag&lt;/b></p>`,
+		},
+		{
+			name:   "less-than in text",
+			input:  `<p>a < b and 1 <2</p> c <`,
+			result: `<p>a &lt; b and 1 &lt;2</p> c &lt;`,
+		},
+		{
+			name:   "unclosed and stray elements",
+			input:  `</i><p><b>text`,
+			result: `<p><b>text</b></p>`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			result, err := policy.SanitizeBytes([]byte(test.input))
+			require.NoError(t, err)
+
+			assert.Equal(t, test.result, string(result))
+		})
+	}
+}
+
+func TestPolicy_DropContent(t *testing.T) {
+	policy := NewPolicy().AllowElements("script").DropContent("iframe")
+
+	result, err := policy.SanitizeBytes([]byte(`<iframe>hidden<b>x</b></iframe><script>shown</script>`))
+	require.NoError(t, err)
+
+	assert.Equal(t, `<script>shown</script>`, string(result))
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 )
 
 var (
@@ -11,6 +12,8 @@ var (
 	cdataSuffix   = []byte("]]>")
 	commentPrefix = []byte("<!--")
 	commentSuffix = []byte("-->")
+	// procInstSuffix ends processing instruction, which starts with procInstPrefix.
+	procInstSuffix = []byte("?>")
 )
 
 var (
@@ -18,9 +21,20 @@ var (
 	cdataSufLen  = len(cdataSuffix)
 )
 
+// ErrNeedMoreData is returned when buffer ends in the middle of the token.
+//
+// This error does not mean that data is malformed, it only tells that
+// token can be fully scanned only after more data will be available.
+var ErrNeedMoreData = errors.New("need more data")
+
 // FetchNextToken will return next tag bytes.
 //
 // Next call to this method must be advanced by the length of the previously returned bytes.
+//
+// If buffer ends before the token does - ErrNeedMoreData is returned along
+// with the part of the token that was consumed so far.
+// This is also true for char data that is not followed by any tag, as
+// it is not possible to say if it ends with the buffer.
 func FetchNextToken(buf []byte) (data []byte, err error) {
 	if len(buf) == 0 {
 		return nil, nil
@@ -39,6 +53,10 @@ func FetchNextToken(buf []byte) (data []byte, err error) {
 		tagEnd, err = scanFullCharData(buf)
 	}
 
+	if errors.Is(err, ErrNeedMoreData) {
+		return buf, err
+	}
+
 	if err != nil {
 		return nil, err
 	}
@@ -50,15 +68,91 @@ func FetchNextToken(buf []byte) (data []byte, err error) {
 	return buf[:tagEnd], nil
 }
 
+// SplitTokens is a bufio.SplitFunc that splits data into raw XML tokens.
+//
+// It allows to receive tokens from arbitrary readers with bufio.Scanner:
+//
+//	scanner := bufio.NewScanner(r)
+//	scanner.Split(fastxml.SplitTokens)
+//
+// Note that scanner's buffer must be big enough to fit the largest token.
+func SplitTokens(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	token, err = FetchNextToken(data)
+	if errors.Is(err, ErrNeedMoreData) {
+		switch {
+		case !atEOF:
+			return 0, nil, nil
+		case data[0] != '<':
+			// Char data at the end of the input.
+			return len(data), data, nil
+		default:
+			return 0, nil, io.ErrUnexpectedEOF
+		}
+	}
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return len(token), token, nil
+}
+
 func isSpecialTag(buf []byte) bool {
 	return bytes.HasPrefix(buf, []byte{'<', '!'})
 }
 
 // scanFullTag will return end index of the current tag.
 //
+// In start tags '>' inside of quoted attribute values does not end the tag.
 // It might return error on some broken tags.
 func scanFullTag(buf []byte) (int, error) {
-	return nextTokenStartIndex(buf, '>') + 1, nil
+	closeIdx := nextTokenStartIndex(buf, '>')
+	if closeIdx <= 0 {
+		return 0, ErrNeedMoreData
+	}
+
+	if len(buf) > 1 && (buf[1] == '?' || buf[1] == '/') {
+		return closeIdx + 1, nil
+	}
+
+	for searchIdx := 1; ; {
+		quoteIdx := indexQuote(buf[searchIdx:closeIdx])
+		if quoteIdx == -1 {
+			return closeIdx + 1, nil
+		}
+
+		quoteIdx += searchIdx
+
+		valueEnd := bytes.IndexByte(buf[quoteIdx+1:], buf[quoteIdx])
+		if valueEnd == -1 {
+			return 0, ErrNeedMoreData
+		}
+
+		searchIdx = quoteIdx + 1 + valueEnd + 1
+		if searchIdx > closeIdx {
+			closeIdx = nextTokenStartIndex(buf[searchIdx-1:], '>')
+			if closeIdx <= 0 {
+				return 0, ErrNeedMoreData
+			}
+
+			closeIdx += searchIdx - 1
+		}
+	}
+}
+
+// indexQuote returns index of the first quotation mark in buf, or -1 if there is none.
+func indexQuote(buf []byte) int {
+	for i, b := range buf {
+		if b == '"' || b == '\'' {
+			return i
+		}
+	}
+
+	return -1
 }
 
 func scanSpecial(buf []byte) (int, error) {
@@ -69,36 +163,105 @@ func scanSpecial(buf []byte) (int, error) {
 		return scanDoctypeDeclaration(buf)
 	case bytes.HasPrefix(buf, commentPrefix):
 		return scanComment(buf)
+	case isPrefixOfSpecial(buf):
+		return 0, ErrNeedMoreData
 	default:
 		return 0, fmt.Errorf("unknown declaration: %s", buf[:NextNonSpaceIndex(buf)])
 	}
 }
 
+// isPrefixOfSpecial reports if buf is too short to tell which special tag it starts.
+func isPrefixOfSpecial(buf []byte) bool {
+	return bytes.HasPrefix(cdataPrefix, buf) ||
+		bytes.HasPrefix(docTypePrefix, buf) ||
+		bytes.HasPrefix(commentPrefix, buf)
+}
+
 func scanCDATADeclaration(buf []byte) (int, error) {
-	endIdx := bytes.Index(buf, cdataSuffix)
+	endIdx := bytes.Index(buf[cdataPrefLen:], cdataSuffix)
 	if endIdx == -1 {
-		return 0, errors.New("no CDATA suffix found")
+		return 0, ErrNeedMoreData
 	}
 
-	return endIdx + cdataSufLen, nil
+	return cdataPrefLen + endIdx + cdataSufLen, nil
 }
 
+// scanDoctypeDeclaration will return end index of DOCTYPE declaration.
+//
+// Quoted literals, comments and processing instructions may contain '>' and ']',
+// so they are skipped as a whole instead of being searched in.
 func scanDoctypeDeclaration(buf []byte) (int, error) {
-	closeBracket := nextTokenStartIndex(buf, ']')
-	if closeBracket == -1 {
-		return nextTokenStartIndex(buf, '>'), nil
+	var inSubset bool
+
+	for i := len(docTypePrefix); i < len(buf); i++ {
+		switch buf[i] {
+		case '"', '\'':
+			end := bytes.IndexByte(buf[i+1:], buf[i])
+			if end == -1 {
+				return 0, ErrNeedMoreData
+			}
+
+			i += end + 1
+		case '[':
+			inSubset = true
+		case ']':
+			inSubset = false
+		case '<':
+			if !inSubset {
+				continue
+			}
+
+			end, err := scanSubsetMarkup(buf[i:])
+			if err != nil {
+				return 0, err
+			}
+
+			i += end - 1
+		case '>':
+			if !inSubset {
+				return i + 1, nil
+			}
+		}
 	}
 
-	return closeBracket + nextTokenStartIndex(buf[closeBracket:], '>'), nil
+	return 0, ErrNeedMoreData
 }
 
+// scanSubsetMarkup returns length of comment or processing instruction at the start of buf,
+// or 1 for other markup, as its quoted literals are skipped by the caller.
+func scanSubsetMarkup(buf []byte) (int, error) {
+	var prefix, suffix []byte
+
+	switch {
+	case bytes.HasPrefix(buf, commentPrefix):
+		prefix, suffix = commentPrefix, commentSuffix
+	case bytes.HasPrefix(buf, procInstPrefix):
+		prefix, suffix = procInstPrefix, procInstSuffix
+	case bytes.HasPrefix(commentPrefix, buf):
+		return 0, ErrNeedMoreData
+	default:
+		return 1, nil
+	}
+
+	end := bytes.Index(buf[len(prefix):], suffix)
+	if end == -1 {
+		return 0, ErrNeedMoreData
+	}
+
+	return len(prefix) + end + len(suffix), nil
+}
+
+// scanComment will return end index of the comment.
+//
+// Suffix is searched after the prefix, as they may overlap, like in `<!-->`,
+// which is not the end of the comment.
 func scanComment(buf []byte) (int, error) {
-	idx := bytes.Index(buf, commentSuffix)
+	idx := bytes.Index(buf[len(commentPrefix):], commentSuffix)
 	if idx == -1 {
-		return 0, errors.New("comment does not have closing suffix")
+		return 0, ErrNeedMoreData
 	}
 
-	return idx + len(commentSuffix), nil
+	return len(commentPrefix) + idx + len(commentSuffix), nil
 }
 
 // scanFulLCharData will return end index of char data.
@@ -111,12 +274,9 @@ func scanFullCharData(buf []byte) (int, error) {
 	// Also as we don't validate XML - no need to be strict about it.
 	openIdx := bytes.IndexByte(buf, '<')
 	if openIdx == -1 {
-		// If no opening char is found - seems that we found the end of the stream.
-		// FIXME: Check out which characters are allowed to be added at the end of the file.
-		// Some validators say that new line is okay.
-		//
-		// For now we will assume that all of them are okay(even invalid ones).
-		return len(buf), nil
+		// If no opening char is found - either more data is coming or this is the end of the document.
+		// Only caller can tell which one is the case.
+		return len(buf), ErrNeedMoreData
 	}
 
 	return openIdx, nil
@@ -138,7 +298,7 @@ func scanTillWordEnd(buf []byte) int {
 		}
 	}
 
-	return 1
+	return len(buf)
 }
 
 // nextTokenStartIndex checks that in current buffer there is always visible start of next tag.
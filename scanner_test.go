@@ -1,12 +1,15 @@
 package fastxml
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/xml"
 	"errors"
 	"io"
 	"os"
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -28,6 +31,30 @@ func TestFetchNextToken(t *testing.T) {
 		{name: "", input: "<![CDATA[]]> ", token: "<![CDATA[]]>"},
 		{name: "", input: "<![CDATA[<><><><><>]]> ", token: "<![CDATA[<><><><><>]]>"},
 		{name: "", input: "<![CDATA[<greeting>Hello, world!</greeting>]]> ", token: "<![CDATA[<greeting>Hello, world!</greeting>]]>"},
+		{name: "", input: "<!DOCTYPE greeting SYSTEM 'hello.dtd'><greeting/>", token: "<!DOCTYPE greeting SYSTEM 'hello.dtd'>"},
+		{name: "", input: "<!DOCTYPE a [<!ELEMENT a ANY>]><a/>", token: "<!DOCTYPE a [<!ELEMENT a ANY>]>"},
+		{name: "quoted '>' in attribute", input: `<a expr='1 > 0' b="c>d">x`, token: `<a expr='1 > 0' b="c>d">`},
+		{name: "quotes in processing instruction", input: `<?pi don't?><a/>`, token: `<?pi don't?>`},
+		{name: "need more data tag", input: "<test", token: "<test", err: "need more data"},
+		{name: "need more data quoted attribute", input: `<a b="c>`, token: `<a b="c>`, err: "need more data"},
+		{name: "need more data char data", input: "some text", token: "some text", err: "need more data"},
+		{name: "need more data comment", input: "<!-- a > b -", token: "<!-- a > b -", err: "need more data"},
+		{name: "need more data CDATA", input: "<![CDATA[ > ]]", token: "<![CDATA[ > ]]", err: "need more data"},
+		{name: "need more data CDATA prefix", input: "<![CDA", token: "<![CDA", err: "need more data"},
+		{
+			name:  "DOCTYPE with quoted markup",
+			input: `<!DOCTYPE a SYSTEM "a>b.dtd" [<!ENTITY e "]>"><!ATTLIST a x CDATA '>'>]><a/>`,
+			token: `<!DOCTYPE a SYSTEM "a>b.dtd" [<!ENTITY e "]>"><!ATTLIST a x CDATA '>'>]>`,
+		},
+		{
+			name:  "DOCTYPE with comment and processing instruction",
+			input: `<!DOCTYPE a [<!-- ]> "' --><?pi ]>'?><!ELEMENT a ANY>]><a/>`,
+			token: `<!DOCTYPE a [<!-- ]> "' --><?pi ]>'?><!ELEMENT a ANY>]>`,
+		},
+		{name: "need more data DOCTYPE literal", input: `<!DOCTYPE a [<!ENTITY e "]>`, token: `<!DOCTYPE a [<!ENTITY e "]>`, err: "need more data"},
+		{name: "need more data DOCTYPE comment", input: `<!DOCTYPE a [<!-`, token: `<!DOCTYPE a [<!-`, err: "need more data"},
+		{name: "need more data DOCTYPE", input: "<!DOCTYPE a [<!ELEMENT a ANY>", token: "<!DOCTYPE a [<!ELEMENT a ANY>", err: "need more data"},
+		{name: "unknown declaration", input: "<!UNKNOWN >", err: "unknown declaration: "},
 	}
 
 	for _, test := range tests {
@@ -47,6 +74,50 @@ func TestFetchNextToken(t *testing.T) {
 	}
 }
 
+func TestSplitTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		tokens []string
+		err    string
+	}{
+		{
+			name:   "simple",
+			input:  `<a attr="1">text<!-- comment --></a>` + "\n",
+			tokens: []string{`<a attr="1">`, "text", "<!-- comment -->", "</a>", "\n"},
+		},
+		{
+			name:   "truncated",
+			input:  `<a><b`,
+			tokens: []string{`<a>`},
+			err:    "unexpected EOF",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			scanner := bufio.NewScanner(iotest.OneByteReader(strings.NewReader(test.input)))
+			scanner.Split(SplitTokens)
+
+			var tokens []string
+
+			for scanner.Scan() {
+				tokens = append(tokens, scanner.Text())
+			}
+
+			if test.err == "" {
+				require.NoError(t, scanner.Err())
+			} else {
+				require.EqualError(t, scanner.Err(), test.err)
+			}
+
+			assert.Equal(t, test.tokens, tokens)
+		})
+	}
+}
+
 func TestScanFullCharData(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -54,12 +125,12 @@ func TestScanFullCharData(t *testing.T) {
 		idx        int
 		err        string
 	}{
-		{"", "abcdefg", 7, ""},
-		{"", "abc defg", 8, ""},
-		{"", "    defg", 8, ""},
-		{"", "    defg    ", 12, ""},
-		{"", "        ", 8, ""},
-		{"", "\n", 1, ""},
+		{"", "abcdefg", 7, "need more data"},
+		{"", "abc defg", 8, "need more data"},
+		{"", "    defg", 8, "need more data"},
+		{"", "    defg    ", 12, "need more data"},
+		{"", "        ", 8, "need more data"},
+		{"", "\n", 1, "need more data"},
 		{"", "a<", 1, ""},
 	}
 
@@ -0,0 +1,244 @@
+/*
+Package sitemap provides streaming parsing of sitemap documents (https://www.sitemaps.org/protocol.html).
+
+Both `urlset` and `sitemapindex` documents are supported. Entries are returned
+one by one, so even huge sitemaps can be processed without building the full document in memory.
+*/
+package sitemap
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"fastxml"
+)
+
+// DefaultPriority is the priority of the URL if it was not set in the document.
+const DefaultPriority = 0.5
+
+// Kind is the type of the sitemap document.
+type Kind uint8
+
+const (
+	// KindURLSet is a document with `urlset` root element.
+	KindURLSet Kind = iota + 1
+	// KindSitemapIndex is a document with `sitemapindex` root element.
+	KindSitemapIndex
+)
+
+var ErrUnknownRoot = errors.New("unknown sitemap root element")
+
+// lastModLayouts are layouts of W3C Datetime format (https://www.w3.org/TR/NOTE-datetime).
+var lastModLayouts = []string{
+	"2006-01-02T15:04:05.999999999Z07:00",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+// Entry is a single `url` or `sitemap` entry of the document.
+//
+// For sitemap index entries only Loc and LastMod will be set.
+type Entry struct {
+	Loc        string
+	LastMod    time.Time
+	ChangeFreq string
+	Priority   float64
+}
+
+// Reader iterates over entries of the sitemap document.
+type Reader struct {
+	p         *fastxml.Parser
+	kind      Kind
+	entryName string
+}
+
+// NewReader will create a reader over sitemap document.
+//
+// Root element of the document is read immediately to find out kind of the document.
+func NewReader(buf []byte) (*Reader, error) {
+	r := &Reader{
+		p: fastxml.NewParser(buf, false),
+	}
+
+	for {
+		token, err := r.p.Next()
+		if err != nil {
+			return nil, fmt.Errorf("find root element: %w", err)
+		}
+
+		start, ok := token.(*fastxml.StartToken)
+		if !ok {
+			continue
+		}
+
+		switch localName(start.Name) {
+		case "urlset":
+			r.kind, r.entryName = KindURLSet, "url"
+		case "sitemapindex":
+			r.kind, r.entryName = KindSitemapIndex, "sitemap"
+		default:
+			return nil, fmt.Errorf("%w: %q", ErrUnknownRoot, start.Name)
+		}
+
+		return r, nil
+	}
+}
+
+// Kind returns kind of the document.
+func (r *Reader) Kind() Kind {
+	return r.kind
+}
+
+// Next will return next entry of the document.
+// io.EOF is returned when no more entries are available.
+func (r *Reader) Next() (Entry, error) {
+	for {
+		token, err := r.p.Next()
+		if err != nil {
+			return Entry{}, err
+		}
+
+		switch tkn := token.(type) {
+		case *fastxml.StartToken:
+			if localName(tkn.Name) != r.entryName {
+				if err := skipElement(r.p); err != nil {
+					return Entry{}, err
+				}
+
+				continue
+			}
+
+			return r.readEntry()
+		case *fastxml.EndElement:
+			// Only root element can be closed here.
+			return Entry{}, io.EOF
+		}
+	}
+}
+
+func (r *Reader) readEntry() (Entry, error) {
+	entry := Entry{Priority: DefaultPriority}
+
+	for {
+		token, err := r.p.Next()
+		if err != nil {
+			return Entry{}, fmt.Errorf("read %s: %w", r.entryName, err)
+		}
+
+		var start *fastxml.StartToken
+
+		switch tkn := token.(type) {
+		case *fastxml.StartToken:
+			start = tkn
+		case *fastxml.EndElement:
+			return entry, nil
+		default:
+			continue
+		}
+
+		name := localName(start.Name)
+
+		text, err := readText(r.p)
+		if err != nil {
+			return Entry{}, fmt.Errorf("read %s: %w", name, err)
+		}
+
+		switch name {
+		case "loc":
+			entry.Loc = text
+		case "lastmod":
+			entry.LastMod, err = ParseLastMod(text)
+		case "changefreq":
+			entry.ChangeFreq = text
+		case "priority":
+			entry.Priority, err = ParsePriority(text)
+		}
+
+		if err != nil {
+			return Entry{}, fmt.Errorf("read %s: %w", name, err)
+		}
+	}
+}
+
+// ParseLastMod parses value in W3C Datetime format, which is used by `lastmod` element.
+func ParseLastMod(val string) (time.Time, error) {
+	for _, layout := range lastModLayouts {
+		if t, err := time.Parse(layout, val); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid lastmod value: %q", val)
+}
+
+// ParsePriority parses value of `priority` element, which must be in range [0.0, 1.0].
+func ParsePriority(val string) (float64, error) {
+	priority, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid priority value: %q", val)
+	}
+
+	if priority < 0 || priority > 1 {
+		return 0, fmt.Errorf("priority is out of range: %q", val)
+	}
+
+	return priority, nil
+}
+
+// readText will return text content of current element and advance parser past its end.
+//
+// Nested elements are skipped.
+func readText(p *fastxml.Parser) (string, error) {
+	var text string
+
+	for {
+		token, err := p.Next()
+		if err != nil {
+			return "", err
+		}
+
+		switch tkn := token.(type) {
+		case *fastxml.CharData:
+			text += string(*tkn)
+		case *fastxml.StartToken:
+			if err := skipElement(p); err != nil {
+				return "", err
+			}
+		case *fastxml.EndElement:
+			return fastxml.Unescape(strings.TrimSpace(text)), nil
+		}
+	}
+}
+
+// skipElement will advance parser past the end of current element.
+func skipElement(p *fastxml.Parser) error {
+	for depth := 1; depth > 0; {
+		token, err := p.Next()
+		if err != nil {
+			return err
+		}
+
+		switch token.(type) {
+		case *fastxml.StartToken:
+			depth++
+		case *fastxml.EndElement:
+			depth--
+		}
+	}
+
+	return nil
+}
+
+func localName(name string) string {
+	if idx := strings.IndexByte(name, ':'); idx != -1 {
+		return name[idx+1:]
+	}
+
+	return name
+}
@@ -0,0 +1,151 @@
+package sitemap
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReader_URLSet(t *testing.T) {
+	input := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:image="http://www.google.com/schemas/sitemap-image/1.1">
+	<!-- first entry -->
+	<url>
+		<loc>http://www.example.com/</loc>
+		<lastmod>2005-01-01</lastmod>
+		<changefreq>monthly</changefreq>
+		<priority>0.8</priority>
+	</url>
+	<url>
+		<loc>
+			http://www.example.com/catalog?item=12&amp;desc=vacation_hawaii
+		</loc>
+		<image:image><image:loc>http://example.com/image.jpg</image:loc></image:image>
+	</url>
+	<url><loc><![CDATA[http://www.example.com/a&b]]></loc><lastmod>2004-12-23T18:00:15+00:00</lastmod></url>
+</urlset>`
+
+	r, err := NewReader([]byte(input))
+	require.NoError(t, err)
+	require.Equal(t, KindURLSet, r.Kind())
+
+	mustEntries := []Entry{
+		{
+			Loc:        "http://www.example.com/",
+			LastMod:    time.Date(2005, 1, 1, 0, 0, 0, 0, time.UTC),
+			ChangeFreq: "monthly",
+			Priority:   0.8,
+		},
+		{
+			Loc:      "http://www.example.com/catalog?item=12&desc=vacation_hawaii",
+			Priority: DefaultPriority,
+		},
+		{
+			Loc:      "http://www.example.com/a&b",
+			LastMod:  time.Date(2004, 12, 23, 18, 0, 15, 0, time.UTC),
+			Priority: DefaultPriority,
+		},
+	}
+
+	for _, mustEntry := range mustEntries {
+		entry, err := r.Next()
+		require.NoError(t, err)
+
+		assert.Equal(t, mustEntry.Loc, entry.Loc)
+		assert.True(t, mustEntry.LastMod.Equal(entry.LastMod), entry.LastMod)
+		assert.Equal(t, mustEntry.ChangeFreq, entry.ChangeFreq)
+		assert.Equal(t, mustEntry.Priority, entry.Priority)
+	}
+
+	_, err = r.Next()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestReader_SitemapIndex(t *testing.T) {
+	input := `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<sitemap>
+		<loc>http://www.example.com/sitemap1.xml.gz</loc>
+		<lastmod>2004-10-01T18:23:17Z</lastmod>
+	</sitemap>
+	<sitemap>
+		<loc>http://www.example.com/sitemap2.xml.gz</loc>
+	</sitemap>
+</sitemapindex>`
+
+	r, err := NewReader([]byte(input))
+	require.NoError(t, err)
+	require.Equal(t, KindSitemapIndex, r.Kind())
+
+	var locs []string
+
+	for {
+		entry, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+
+		locs = append(locs, entry.Loc)
+	}
+
+	assert.Equal(t, []string{
+		"http://www.example.com/sitemap1.xml.gz",
+		"http://www.example.com/sitemap2.xml.gz",
+	}, locs)
+}
+
+func TestReader_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"unknown root", "<feed></feed>", `unknown sitemap root element: "feed"`},
+		{"invalid priority", "<urlset><url><priority>high</priority></url></urlset>", `read priority: invalid priority value: "high"`},
+		{"priority out of range", "<urlset><url><priority>1.5</priority></url></urlset>", `read priority: priority is out of range: "1.5"`},
+		{"invalid lastmod", "<urlset><url><lastmod>yesterday</lastmod></url></urlset>", `read lastmod: invalid lastmod value: "yesterday"`},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			r, err := NewReader([]byte(test.input))
+			if err == nil {
+				_, err = r.Next()
+			}
+
+			require.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestParseLastMod(t *testing.T) {
+	tests := []struct {
+		input  string
+		result time.Time
+	}{
+		{"1997", time.Date(1997, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"1997-07", time.Date(1997, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{"1997-07-16", time.Date(1997, 7, 16, 0, 0, 0, 0, time.UTC)},
+		{"1997-07-16T19:20+01:00", time.Date(1997, 7, 16, 18, 20, 0, 0, time.UTC)},
+		{"1997-07-16T19:20:30+01:00", time.Date(1997, 7, 16, 18, 20, 30, 0, time.UTC)},
+		{"1997-07-16T19:20:30.45+01:00", time.Date(1997, 7, 16, 18, 20, 30, 450000000, time.UTC)},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.input, func(t *testing.T) {
+			result, err := ParseLastMod(test.input)
+			require.NoError(t, err)
+
+			assert.True(t, test.result.Equal(result), result)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package fastxml
+
+import "bytes"
+
+// WithSkipDTD makes parser skip DOCTYPE declaration(including internal subset) without decoding it.
+//
+// Declaration is still scanned to find its end, but nothing is returned to the caller for it.
+func WithSkipDTD() ParserOption {
+	return func(p *Parser) {
+		p.skipDTD = true
+	}
+}
+
+// WithSkipComments makes parser skip comments without decoding them.
+func WithSkipComments() ParserOption {
+	return withSkipKind(TokenKindComment)
+}
+
+// WithSkipProcInst makes parser skip processing instructions(including XML declaration) without decoding them.
+func WithSkipProcInst() ParserOption {
+	return withSkipKind(TokenKindProcInst)
+}
+
+// WithSkipDirectives makes parser skip directives(like DOCTYPE) without decoding them.
+func WithSkipDirectives() ParserOption {
+	return withSkipKind(TokenKindDirective)
+}
+
+func withSkipKind(kind TokenKind) ParserOption {
+	return func(p *Parser) {
+		p.skipKinds |= 1 << kind
+	}
+}
+
+// isSkipped reports if token must be skipped without decoding.
+func (p *Parser) isSkipped(tokenBytes []byte) bool {
+	if p.skipDTD && bytes.HasPrefix(tokenBytes, docTypePrefix) {
+		return true
+	}
+
+	return p.skipKinds != 0 && p.skipKinds&(1<<scannedTokenKind(tokenBytes)) != 0
+}
+
+// WithStructureOnly makes parser skip all char data(including CDATA sections),
+// returning only tokens that describe structure of the document.
+//
+// Text is skipped with a simple search for the next tag, so this is
+// noticeably faster for documents with a lot of text.
+func WithStructureOnly() ParserOption {
+	return withSkipKind(TokenKindCharData)
+}
+
+// skipText advances parser past the text at current position, if char data is skipped.
+//
+// It reports if any text was skipped.
+func (p *Parser) skipText() bool {
+	buf := p.buf[p.currentPointer:]
+
+	if p.skipKinds&(1<<TokenKindCharData) == 0 || buf[0] == '<' {
+		return false
+	}
+
+	textEnd := bytes.IndexByte(buf, '<')
+	if textEnd == -1 {
+		// Rest of the data is text, which can be safely skipped even for stream parser.
+		textEnd = len(buf)
+	}
+
+	p.currentPointer += textEnd
+
+	if p.metrics != nil {
+		p.metrics.TokenScanned(TokenKindCharData, textEnd)
+	}
+
+	return true
+}
@@ -0,0 +1,144 @@
+package fastxml
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collectTokens returns string representation of all tokens in the input.
+func collectTokens(t *testing.T, input string, opts ...ParserOption) []string {
+	t.Helper()
+
+	p := NewParser([]byte(input), false, opts...)
+
+	var results []string
+
+	for {
+		token, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			return results
+		}
+
+		require.NoError(t, err)
+
+		results = append(results, tokenString(token))
+	}
+}
+
+func TestWithSkipDTD(t *testing.T) {
+	input := `<!DOCTYPE root [
+	<!ELEMENT root (item)*>
+	<!ATTLIST item id ID #REQUIRED>
+]><root/>`
+
+	assert.Equal(t, []string{
+		`*fastxml.StartToken: &{"root" ""}`,
+		`*fastxml.EndElement: &{{"" "root"}}`,
+	}, collectTokens(t, input, WithSkipDTD()))
+}
+
+func TestWithSkipTokenKinds(t *testing.T) {
+	input := `<?xml version="1.0"?><!DOCTYPE a><!-- first --><a><!-- second -->text<?pi data?></a>`
+
+	tests := []struct {
+		name   string
+		opts   []ParserOption
+		result []string
+	}{
+		{
+			name: "comments",
+			opts: []ParserOption{WithSkipComments()},
+			result: []string{
+				`<nil>: %!q(<nil>)`,
+				`<nil>: %!q(<nil>)`,
+				`*fastxml.StartToken: &{"a" ""}`,
+				`*fastxml.CharData: &"text"`,
+				`<nil>: %!q(<nil>)`,
+				`*fastxml.EndElement: &{{"" "a"}}`,
+			},
+		},
+		{
+			name: "all",
+			opts: []ParserOption{WithSkipComments(), WithSkipProcInst(), WithSkipDirectives()},
+			result: []string{
+				`*fastxml.StartToken: &{"a" ""}`,
+				`*fastxml.CharData: &"text"`,
+				`*fastxml.EndElement: &{{"" "a"}}`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.result, collectTokens(t, input, test.opts...))
+		})
+	}
+}
+
+func TestWithStructureOnly(t *testing.T) {
+	input := `<a> text <b><![CDATA[<data>]]></b>tail<c/></a> end`
+
+	assert.Equal(t, []string{
+		`*fastxml.StartToken: &{"a" ""}`,
+		`*fastxml.StartToken: &{"b" ""}`,
+		`*fastxml.EndElement: &{{"" "b"}}`,
+		`*fastxml.StartToken: &{"c" ""}`,
+		`*fastxml.EndElement: &{{"" "c"}}`,
+		`*fastxml.EndElement: &{{"" "a"}}`,
+	}, collectTokens(t, input, WithStructureOnly()))
+}
+
+func TestWithStructureOnly_Stream(t *testing.T) {
+	p := NewStreamParser(WithStructureOnly())
+	p.Feed([]byte("<a>some long te"))
+
+	_, err := p.Next()
+	require.NoError(t, err)
+
+	_, err = p.Next()
+	require.ErrorIs(t, err, ErrNeedMoreData)
+
+	p.Feed([]byte("xt</a>"))
+
+	token, err := p.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "a", token.(*EndElement).Name.Local)
+}
+
+func BenchmarkWithStructureOnly(b *testing.B) {
+	buf := []byte(`<doc>` + strings.Repeat(`<p>`+strings.Repeat("Lorem ipsum dolor sit amet. ", 20)+`</p>`, 100) + `</doc>`)
+
+	benchmarks := []struct {
+		name string
+		opts []ParserOption
+	}{
+		{"all tokens", nil},
+		{"structure only", []ParserOption{WithStructureOnly()}},
+	}
+
+	for _, bench := range benchmarks {
+		bench := bench
+
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(buf)))
+
+			for i := 0; i < b.N; i++ {
+				p := NewParser(buf, false, bench.opts...)
+
+				for {
+					if _, err := p.Next(); err != nil {
+						break
+					}
+				}
+			}
+		})
+	}
+}
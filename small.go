@@ -0,0 +1,80 @@
+package fastxml
+
+import (
+	"encoding/xml"
+	"sync"
+)
+
+// parserPool holds parsers of Parse, so they are not allocated for each document.
+var parserPool = sync.Pool{
+	New: func() interface{} {
+		return new(Parser)
+	},
+}
+
+// Iterator goes over tokens of the document, see Parse.
+type Iterator struct {
+	p *Parser
+}
+
+// Parse returns iterator over tokens of the document in buf.
+//
+// It is the cheapest way to parse many small documents, like API payloads,
+// where setup of the parser costs as much as parsing itself:
+// parser is taken from the pool and is returned to it with Iterator.Close,
+// so after warm up parsing of the document does not allocate.
+//
+// Same as with NewParser, parser must own provided buffer until iterator is closed.
+func Parse(buf []byte, opts ...ParserOption) Iterator {
+	p, _ := parserPool.Get().(*Parser)
+	p.reset(buf)
+	p.applyOptions(opts)
+
+	return Iterator{p: p}
+}
+
+// Next returns next token, same as Parser.Next.
+func (it Iterator) Next() (xml.Token, error) {
+	return it.p.Next()
+}
+
+// Parser returns the underlying parser, to access its state, like Parser.Stack.
+//
+// Parser MUST NOT be used after the iterator is closed.
+func (it Iterator) Parser() *Parser {
+	return it.p
+}
+
+// Close will return parser to the pool.
+//
+// Tokens returned by the iterator MUST NOT be used after it is closed. Close can be called more than once.
+func (it *Iterator) Close() {
+	if it.p == nil {
+		return
+	}
+
+	it.p.reset(nil)
+	parserPool.Put(it.p)
+	it.p = nil
+}
+
+// reset will make parser parse buf from the start with default options, reusing its allocated memory.
+func (p *Parser) reset(buf []byte) {
+	// Names in the stack point into the previous buffer, so they are cleared to not keep it alive.
+	for i := range p.stack {
+		p.stack[i] = ""
+	}
+
+	stack := p.stack[:0]
+
+	// End elements are kept for reuse, but their names are cleared for the same reason.
+	for i := range p.endElements {
+		p.endElements[i] = EndElement{}
+	}
+
+	*p = Parser{
+		buf:         buf,
+		stack:       stack,
+		endElements: p.endElements,
+	}
+}
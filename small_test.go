@@ -0,0 +1,88 @@
+package fastxml
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const smallDocument = `<?xml version="1.0"?><order id="42"><item sku="a-1" qty="2">Widget</item><paid/></order>`
+
+func iteratorTokens(t *testing.T, it Iterator) []string {
+	t.Helper()
+
+	var results []string
+
+	for {
+		token, err := it.Next()
+		if errors.Is(err, io.EOF) {
+			return results
+		}
+
+		require.NoError(t, err)
+
+		results = append(results, tokenString(token))
+	}
+}
+
+func TestParse(t *testing.T) {
+	it := Parse([]byte(smallDocument))
+	assert.Equal(t, collectTokens(t, smallDocument), iteratorTokens(t, it))
+
+	it.Close()
+	it.Close()
+
+	// Options of the previous document are not kept by the pooled parser.
+	it = Parse([]byte(`<a></b>`), WithEndElementMatching())
+	_, err := it.Next()
+	require.NoError(t, err)
+	_, err = it.Next()
+	assert.ErrorIs(t, err, ErrMismatchedEndElement)
+	it.Close()
+
+	it = Parse([]byte(`<a><b></c>`))
+	defer it.Close()
+
+	assert.Len(t, iteratorTokens(t, it), 3)
+	assert.Equal(t, []string{"a"}, it.Parser().Stack())
+}
+
+func BenchmarkParse_Small(b *testing.B) {
+	buf := []byte(smallDocument)
+
+	drain := func(next func() (interface{}, error)) {
+		for {
+			if _, err := next(); err != nil {
+				if !errors.Is(err, io.EOF) {
+					b.Fatal(err)
+				}
+
+				return
+			}
+		}
+	}
+
+	b.Run("NewParser", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(buf)))
+
+		for i := 0; i < b.N; i++ {
+			p := NewParser(buf, false)
+			drain(func() (interface{}, error) { return p.Next() })
+		}
+	})
+
+	b.Run("Parse", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(buf)))
+
+		for i := 0; i < b.N; i++ {
+			it := Parse(buf)
+			drain(func() (interface{}, error) { return it.Next() })
+			it.Close()
+		}
+	})
+}
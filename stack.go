@@ -0,0 +1,91 @@
+package fastxml
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrMismatchedEndElement is returned by the parser with enabled end element matching
+// when end element does not match the most recent open start element.
+var ErrMismatchedEndElement = errors.New("mismatched end element")
+
+// WithEndElementMatching makes parser verify that each end element closes the most recent open element,
+// and that all elements are closed at the end of the document.
+//
+// This is the cheapest well-formedness check that catches most of the truncated or broken documents.
+func WithEndElementMatching() ParserOption {
+	return func(p *Parser) {
+		p.matchEndElements = true
+	}
+}
+
+// Stack returns names of currently open elements, from the root to the innermost one.
+//
+// Returned slice is owned by the parser: it MUST NOT be modified
+// and is only valid until the next call to Parser.Next.
+// Copy it if it needs to be stored.
+func (p *Parser) Stack() []string {
+	return p.stack
+}
+
+// Depth returns number of currently open elements.
+func (p *Parser) Depth() int {
+	return len(p.stack)
+}
+
+// pushElement is called for each start element.
+func (p *Parser) pushElement(name string) {
+	p.stack = append(p.stack, name)
+}
+
+// popElement is called for each end element.
+//
+// End elements without matching start element are ignored,
+// unless end element matching is enabled.
+func (p *Parser) popElement(name string) error {
+	if p.matchEndElements {
+		if len(p.stack) == 0 {
+			return fmt.Errorf("%w: </%s> without start element", ErrMismatchedEndElement, name)
+		}
+
+		if expected := p.stack[len(p.stack)-1]; expected != name {
+			return fmt.Errorf("%w: expected </%s>, got </%s>", ErrMismatchedEndElement, expected, name)
+		}
+	}
+
+	if len(p.stack) > 0 {
+		p.stack = p.stack[:len(p.stack)-1]
+	}
+
+	if p.ownedStackNames > len(p.stack) {
+		p.ownedStackNames = len(p.stack)
+	}
+
+	return nil
+}
+
+// checkUnclosedElements returns error if end element matching is enabled
+// and some elements were not closed at the end of the document.
+func (p *Parser) checkUnclosedElements() error {
+	if !p.matchEndElements || len(p.stack) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("unclosed element <%s>: %w", p.stack[len(p.stack)-1], io.ErrUnexpectedEOF)
+}
+
+// copyStackNames makes names in the stack independent of the parsed buffer.
+//
+// This is needed before the buffer is overwritten by Parser.Feed.
+func (p *Parser) copyStackNames() {
+	if p.interner != nil {
+		return
+	}
+
+	for i := p.ownedStackNames; i < len(p.stack); i++ {
+		p.stack[i] = CopyString(p.stack[i])
+	}
+
+	p.ownedStackNames = len(p.stack)
+}
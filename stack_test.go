@@ -0,0 +1,93 @@
+package fastxml
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParser_Stack(t *testing.T) {
+	input := `<a><b><c/>text</b><d></d></a>`
+
+	mustStacks := []string{
+		"a",
+		"a/b",
+		"a/b/c",
+		"a/b",
+		"a/b",
+		"a",
+		"a/d",
+		"a",
+		"",
+	}
+
+	p := NewParser([]byte(input), false)
+
+	var stacks []string
+
+	for {
+		// Peek must not change the stack.
+		_, _ = p.Peek()
+
+		_, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+
+		stacks = append(stacks, strings.Join(p.Stack(), "/"))
+		assert.Equal(t, len(p.Stack()), p.Depth())
+	}
+
+	assert.Equal(t, mustStacks, stacks)
+}
+
+func TestParser_StackFeed(t *testing.T) {
+	p := NewStreamParser()
+
+	for _, chunk := range []string{"<stream>", "<message>", "<body>"} {
+		p.Feed([]byte(chunk))
+
+		_, err := p.Next()
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, []string{"stream", "message", "body"}, p.Stack())
+}
+
+func TestWithEndElementMatching(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"valid", `<a><b/><c></c></a>`, ""},
+		{"mismatched", `<a><b></a>`, "match end element: index position 10: mismatched end element: expected </b>, got </a>"},
+		{"without start", `<a></a></b>`, "match end element: index position 11: mismatched end element: </b> without start element"},
+		{"truncated", `<a><b></b>`, "unclosed element <a>: unexpected EOF"},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			p := NewParser([]byte(test.input), false, WithEndElementMatching())
+
+			var err error
+			for err == nil {
+				_, err = p.Next()
+			}
+
+			if test.err == "" {
+				require.ErrorIs(t, err, io.EOF)
+			} else {
+				require.EqualError(t, err, test.err)
+			}
+		})
+	}
+}
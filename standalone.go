@@ -0,0 +1,108 @@
+package fastxml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidStandalone is returned in strict mode when standalone declaration is neither "yes" nor "no".
+	ErrInvalidStandalone = errors.New("invalid standalone declaration")
+	// ErrUndeclaredEntity is returned in strict mode when standalone document references entity
+	// that is not declared in the internal subset of DOCTYPE.
+	ErrUndeclaredEntity = errors.New("undeclared entity in standalone document")
+)
+
+var entityDeclPrefix = []byte("<!ENTITY")
+
+// Standalone returns value of the `standalone` pseudo-attribute of the XML declaration,
+// and if it was declared at all.
+//
+// Value is available once the declaration was parsed, which is the first token of the document.
+// Parser only enforces constraints of standalone documents in strict mode, see WithStrict,
+// so callers can implement their own policy based on this value.
+func (p *Parser) Standalone() (standalone, declared bool) {
+	return p.standalone, p.standaloneDeclared
+}
+
+// readStandalone records standalone declaration of the document and,
+// for standalone documents in strict mode, entities declared in internal subset of DOCTYPE.
+func (p *Parser) readStandalone(buf []byte) error {
+	switch {
+	case isDeclaration(buf) && p.atDocumentStart(buf):
+		switch value := declarationValue(buf, "standalone"); value {
+		case "":
+			return nil
+		case "yes", "no":
+			p.standalone = value == "yes"
+			p.standaloneDeclared = true
+		default:
+			if p.strict {
+				return fmt.Errorf("%w: %q", ErrInvalidStandalone, value)
+			}
+		}
+	case p.strict && p.standalone && bytes.HasPrefix(buf, docTypePrefix):
+		p.declaredEntities = make(map[string]struct{})
+
+		for idx := bytes.Index(buf, entityDeclPrefix); idx != -1; idx = bytes.Index(buf, entityDeclPrefix) {
+			buf = buf[idx+len(entityDeclPrefix):]
+			buf = buf[NextNonSpaceIndex(buf):]
+
+			// Parameter entities can not be referenced from the content.
+			if len(buf) == 0 || buf[0] == '%' {
+				continue
+			}
+
+			nameEnd := bytes.IndexFunc(buf, func(r rune) bool { return IsHTMLSpaceChar(r) })
+			if nameEnd == -1 {
+				break
+			}
+
+			p.declaredEntities[string(buf[:nameEnd])] = struct{}{}
+		}
+	}
+
+	return nil
+}
+
+// checkCharData does strict mode checks of just scanned char data.
+func (p *Parser) checkCharData(buf []byte) error {
+	if !p.strict || bytes.HasPrefix(buf, cdataPrefix) {
+		return nil
+	}
+
+	return p.checkEntityReferences(buf, p.tokenOffset(buf))
+}
+
+// checkEntityReferences verifies that standalone document only references declared entities.
+//
+// offset is the offset of data from the start of the input.
+func (p *Parser) checkEntityReferences(data []byte, offset int) error {
+	if !p.standalone {
+		return nil
+	}
+
+	for idx := bytes.IndexByte(data, '&'); idx != -1; {
+		semicolonIdx := bytes.IndexByte(data[idx:], ';')
+		if semicolonIdx == -1 {
+			return nil
+		}
+
+		name := data[idx+1 : idx+semicolonIdx]
+		if _, ok := predefinedEntities[string(name)]; !ok && (len(name) == 0 || name[0] != '#') {
+			if _, ok := p.declaredEntities[string(name)]; !ok {
+				return errorAt(fmt.Errorf("%w: &%s; at offset %d", ErrUndeclaredEntity, name, offset+idx), offset+idx)
+			}
+		}
+
+		next := bytes.IndexByte(data[idx+semicolonIdx:], '&')
+		if next == -1 {
+			return nil
+		}
+
+		idx += semicolonIdx + next
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package fastxml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParser_Standalone(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          string
+		mustStandalone bool
+		mustDeclared   bool
+	}{
+		{"not declared", `<?xml version="1.0"?><a/>`, false, false},
+		{"no declaration", `<a/>`, false, false},
+		{"yes", `<?xml version="1.0" standalone="yes"?><a/>`, true, true},
+		{"no", `<?xml version="1.0" standalone='no'?><a/>`, false, true},
+		{"invalid", `<?xml version="1.0" standalone="true"?><a/>`, false, false},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			p := NewParser([]byte(test.input), false)
+
+			_, err := p.Next()
+			require.NoError(t, err)
+
+			standalone, declared := p.Standalone()
+			assert.Equal(t, test.mustStandalone, standalone)
+			assert.Equal(t, test.mustDeclared, declared)
+		})
+	}
+}
+
+func TestWithStrict_Standalone(t *testing.T) {
+	const decl = `<?xml version="1.0" standalone="yes"?>`
+
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"predefined entities", decl + `<a b="&amp;&#38;">&lt;&#x3C;</a>`, ""},
+		{
+			"declared entities",
+			decl + `<!DOCTYPE a [<!ENTITY % p "x"><!ENTITY e "y"> <!ENTITY f SYSTEM "f.xml">]><a b="&e;">&f;</a>`,
+			"",
+		},
+		{"not standalone", `<?xml version="1.0" standalone="no"?><a>&e;</a>`, ""},
+		{"cdata", decl + `<a><![CDATA[&e;]]></a>`, ""},
+		{
+			"undeclared in char data",
+			decl + `<a>&amp;&e;</a>`,
+			"undeclared entity in standalone document: &e; at offset 46",
+		},
+		{
+			"undeclared in attribute",
+			decl + `<a b="&lt;" c="&e;"/>`,
+			"undeclared entity in standalone document: &e; at offset 53",
+		},
+		{
+			"parameter entity",
+			decl + `<!DOCTYPE a [<!ENTITY % e "x">]><a>&e;</a>`,
+			"undeclared entity in standalone document: &e; at offset 73",
+		},
+		{"invalid value", `<?xml version="1.0" standalone="true"?><a/>`, `invalid standalone declaration: "true"`},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			p := NewParser([]byte(test.input), false, WithStrict())
+
+			var err error
+			for err == nil {
+				_, err = p.Next()
+			}
+
+			if test.err == "" {
+				require.EqualError(t, err, "EOF")
+			} else {
+				require.EqualError(t, err, test.err)
+			}
+		})
+	}
+}
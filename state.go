@@ -0,0 +1,277 @@
+package fastxml
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrInvalidState is returned when parser state can not be restored.
+var ErrInvalidState = errors.New("invalid parser state")
+
+// parserStateMagic starts each serialized parser state, last byte is the version of the format.
+var parserStateMagic = []byte("FXPS\x02")
+
+// Flags of serialized parser state.
+const (
+	stateStopped byte = 1 << iota
+	stateStandalone
+	stateStandaloneDeclared
+	stateDeclaredEntities
+)
+
+// State returns position of the parser in the input, along with the stack of open elements and their namespaces
+// and what was learned from the prolog, serialized into a small blob.
+//
+// Parsing can be resumed later with Parser.RestoreState against the same input,
+// which allows to checkpoint long running jobs over large documents.
+// State is taken between calls to Parser.Next, so the last returned token is not returned again.
+func (p *Parser) State() []byte {
+	state := append([]byte(nil), parserStateMagic...)
+
+	var flags byte
+	if p.stopped {
+		flags |= stateStopped
+	}
+
+	if p.standalone {
+		flags |= stateStandalone
+	}
+
+	if p.standaloneDeclared {
+		flags |= stateStandaloneDeclared
+	}
+
+	if p.declaredEntities != nil {
+		flags |= stateDeclaredEntities
+	}
+
+	state = appendUvarint(state, uint64(p.InputOffset()))
+	state = append(state, flags)
+	state = appendUvarint(state, uint64(p.skipDepth))
+	state = appendStateString(state, p.lastTagName)
+	state = appendStateString(state, p.rawTextElement)
+	state = appendStateStrings(state, p.stack)
+	state = appendStateStrings(state, p.namespaces)
+
+	entities := make([]string, 0, len(p.declaredEntities))
+	for name := range p.declaredEntities {
+		entities = append(entities, name)
+	}
+
+	sort.Strings(entities)
+	state = appendStateStrings(state, entities)
+
+	elements := make([]string, 0, len(p.attrDefaults))
+	for element := range p.attrDefaults {
+		elements = append(elements, element)
+	}
+
+	sort.Strings(elements)
+	state = appendUvarint(state, uint64(len(elements)))
+
+	for _, element := range elements {
+		state = appendStateString(state, element)
+		state = appendUvarint(state, uint64(len(p.attrDefaults[element])))
+
+		for _, def := range p.attrDefaults[element] {
+			state = appendStateString(state, def.name)
+			state = appendStateString(state, def.value)
+		}
+	}
+
+	ids := make([]string, 0, len(p.ids))
+	for id := range p.ids {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+	state = appendUvarint(state, uint64(len(ids)))
+
+	for _, id := range ids {
+		state = appendStateString(state, id)
+		state = appendUvarint(state, uint64(p.ids[id]))
+	}
+
+	return state
+}
+
+// RestoreState will continue parsing from the state returned by Parser.State.
+//
+// Parser created with NewParser must be given the same input as the parser that state was taken from.
+// Parser created with NewStreamParser must not have any data fed yet,
+// and must then be fed the input starting from the offset of the state, see Parser.InputOffset.
+// Default attributes and element IDs are only restored if parser is configured to collect them,
+// see WithAttributeDefaults and WithIDTracking.
+func (p *Parser) RestoreState(state []byte) error {
+	if !bytes.HasPrefix(state, parserStateMagic) {
+		return fmt.Errorf("%w: unknown header", ErrInvalidState)
+	}
+
+	r := stateReader{buf: state[len(parserStateMagic):]}
+
+	offset := r.uvarint()
+	flags := r.byte()
+	skipDepth := r.uvarint()
+	lastTagName := r.string()
+	rawTextElement := r.string()
+	stack := r.strings()
+	namespaces := r.strings()
+	entities := r.strings()
+
+	attrDefaults := map[string][]attributeDefault{}
+
+	for i := r.uvarint(); i > 0 && r.err == nil; i-- {
+		element := r.string()
+
+		for j := r.uvarint(); j > 0 && r.err == nil; j-- {
+			attrDefaults[element] = append(attrDefaults[element], attributeDefault{name: r.string(), value: r.string()})
+		}
+	}
+
+	ids := map[string]int{}
+
+	for i := r.uvarint(); i > 0 && r.err == nil; i-- {
+		ids[r.string()] = int(r.uvarint())
+	}
+
+	if r.err != nil {
+		return r.err
+	}
+
+	switch {
+	case p.streaming:
+		if p.InputOffset() != 0 || len(p.buf) != 0 {
+			return fmt.Errorf("%w: stream parser was already fed", ErrInvalidState)
+		}
+
+		p.discarded = int(offset)
+	case offset > uint64(len(p.buf)):
+		return fmt.Errorf("%w: offset %d is outside of the input", ErrInvalidState, offset)
+	default:
+		p.currentPointer = int(offset)
+	}
+
+	p.stopped = flags&stateStopped != 0
+	p.standalone = flags&stateStandalone != 0
+	p.standaloneDeclared = flags&stateStandaloneDeclared != 0
+	p.skipDepth = int(skipDepth)
+	p.lastTagName = lastTagName
+	p.rawTextElement = rawTextElement
+	p.stack = stack
+	p.ownedStackNames = len(stack)
+	p.namespaces = namespaces
+
+	p.declaredEntities = nil
+	if flags&stateDeclaredEntities != 0 {
+		p.declaredEntities = make(map[string]struct{}, len(entities))
+
+		for _, name := range entities {
+			p.declaredEntities[name] = struct{}{}
+		}
+	}
+
+	if p.attrDefaults != nil {
+		p.attrDefaults = attrDefaults
+	}
+
+	if p.ids != nil {
+		p.ids = ids
+	}
+
+	return nil
+}
+
+func appendUvarint(state []byte, v uint64) []byte {
+	var scratch [binary.MaxVarintLen64]byte
+
+	n := binary.PutUvarint(scratch[:], v)
+
+	return append(state, scratch[:n]...)
+}
+
+func appendStateString(state []byte, s string) []byte {
+	state = appendUvarint(state, uint64(len(s)))
+
+	return append(state, s...)
+}
+
+func appendStateStrings(state []byte, strs []string) []byte {
+	state = appendUvarint(state, uint64(len(strs)))
+
+	for _, s := range strs {
+		state = appendStateString(state, s)
+	}
+
+	return state
+}
+
+// stateReader reads values of serialized state, remembering the first error.
+type stateReader struct {
+	buf []byte
+	err error
+}
+
+func (r *stateReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+
+	v, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		r.err = fmt.Errorf("%w: malformed number", ErrInvalidState)
+
+		return 0
+	}
+
+	r.buf = r.buf[n:]
+
+	return v
+}
+
+func (r *stateReader) byte() byte {
+	if r.err != nil {
+		return 0
+	}
+
+	if len(r.buf) == 0 {
+		r.err = fmt.Errorf("%w: unexpected end of data", ErrInvalidState)
+
+		return 0
+	}
+
+	b := r.buf[0]
+	r.buf = r.buf[1:]
+
+	return b
+}
+
+func (r *stateReader) string() string {
+	n := r.uvarint()
+	if r.err != nil {
+		return ""
+	}
+
+	if n > uint64(len(r.buf)) {
+		r.err = fmt.Errorf("%w: unexpected end of data", ErrInvalidState)
+
+		return ""
+	}
+
+	s := string(r.buf[:n])
+	r.buf = r.buf[n:]
+
+	return s
+}
+
+func (r *stateReader) strings() []string {
+	var strs []string
+
+	for i := r.uvarint(); i > 0 && r.err == nil; i-- {
+		strs = append(strs, r.string())
+	}
+
+	return strs
+}
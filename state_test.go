@@ -0,0 +1,151 @@
+package fastxml
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseRemaining(t *testing.T, p *Parser) []xml.Token {
+	t.Helper()
+
+	var tokens []xml.Token
+
+	for {
+		token, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			return tokens
+		}
+
+		require.NoError(t, err)
+
+		if token != nil {
+			tokens = append(tokens, ToStd(token))
+		}
+	}
+}
+
+func TestParser_RestoreState(t *testing.T) {
+	input := `<root><a x="1">text</a><b/><c><d>more</d></c></root>`
+
+	for split := 1; split < 10; split++ {
+		p := NewParser([]byte(input), false, WithEndElementMatching())
+
+		var mustTokens []xml.Token
+
+		for i := 0; i < split; i++ {
+			_, err := p.Next()
+			require.NoError(t, err)
+		}
+
+		state := p.State()
+		mustTokens = parseRemaining(t, p)
+
+		restored := NewParser([]byte(input), false, WithEndElementMatching())
+		require.NoError(t, restored.RestoreState(state))
+		assert.Equal(t, mustTokens, parseRemaining(t, restored), "split %d", split)
+
+		// Stream parser is fed the input from the offset of the state.
+		stream := NewStreamParser(WithEndElementMatching())
+		require.NoError(t, stream.RestoreState(state))
+
+		offset := stream.InputOffset()
+		stream.Feed([]byte(input[offset:]))
+		stream.streaming = false
+
+		assert.Equal(t, mustTokens, parseRemaining(t, stream), "split %d", split)
+	}
+}
+
+func TestParser_RestoreState_Prolog(t *testing.T) {
+	input := `<?xml version="1.0" standalone="yes"?>` +
+		`<!DOCTYPE root [<!ENTITY e "x"><!ATTLIST item kind CDATA "a">]>` +
+		`<root><item xml:id="i1">&e;</item><item>&e;</item><item xml:id="i1"/></root>`
+	opts := []ParserOption{WithStrict(), WithAttributeDefaults(), WithIDTracking(), WithEndElementMatching()}
+
+	for split := 2; split < 7; split++ {
+		p := NewParser([]byte(input), false, opts...)
+
+		for i := 0; i < split; i++ {
+			_, err := p.Next()
+			require.NoError(t, err)
+		}
+
+		state := p.State()
+
+		restored := NewParser([]byte(input), false, opts...)
+		require.NoError(t, restored.RestoreState(state))
+
+		standalone, declared := restored.Standalone()
+		assert.True(t, standalone, "split %d", split)
+		assert.True(t, declared, "split %d", split)
+
+		id, ok := p.ByID("i1")
+		restoredID, restoredOK := restored.ByID("i1")
+		assert.Equal(t, ok, restoredOK, "split %d", split)
+		assert.Equal(t, id, restoredID, "split %d", split)
+
+		for _, parser := range []*Parser{p, restored} {
+			var (
+				tokens []xml.Token
+				err    error
+			)
+
+			for err == nil {
+				var token xml.Token
+
+				if token, err = parser.Next(); err == nil {
+					tokens = append(tokens, ToStd(token))
+				}
+			}
+
+			// Defaults are added, declared entity is accepted and duplicated ID is found after the restore.
+			require.ErrorIs(t, err, ErrDuplicateID, "split %d", split)
+			assert.Contains(t, tokens, xml.StartElement{
+				Name: xml.Name{Local: "item"},
+				Attr: []xml.Attr{{Name: xml.Name{Local: "kind"}, Value: "a"}},
+			}, "split %d", split)
+		}
+	}
+}
+
+func TestParser_RestoreState_Namespaces(t *testing.T) {
+	input := `<!DOCTYPE root><root xmlns="urn:root"><a xmlns="urn:a"><b/></a><c/></root>`
+
+	for split := 1; split < 6; split++ {
+		p := NewParser([]byte(input), false, WithDefaultNamespace(), WithEndElementMatching())
+
+		for i := 0; i < split; i++ {
+			_, err := p.Next()
+			require.NoError(t, err)
+		}
+
+		state := p.State()
+		mustTokens := parseRemaining(t, p)
+
+		restored := NewParser([]byte(input), false, WithDefaultNamespace(), WithEndElementMatching())
+		require.NoError(t, restored.RestoreState(state))
+		assert.Equal(t, mustTokens, parseRemaining(t, restored), "split %d", split)
+	}
+}
+
+func TestParser_RestoreState_Errors(t *testing.T) {
+	p := NewParser([]byte(`<root><a>`), false)
+
+	_, err := p.Next()
+	require.NoError(t, err)
+
+	state := p.State()
+
+	assert.ErrorIs(t, NewParser([]byte(`<r`), false).RestoreState(state), ErrInvalidState)
+	assert.ErrorIs(t, NewParser([]byte(`<root>`), false).RestoreState(state[:len(state)-1]), ErrInvalidState)
+	assert.ErrorIs(t, NewParser([]byte(`<root>`), false).RestoreState([]byte("state")), ErrInvalidState)
+
+	stream := NewStreamParser()
+	stream.Feed([]byte(`<root>`))
+	assert.ErrorIs(t, stream.RestoreState(state), ErrInvalidState)
+}
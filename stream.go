@@ -0,0 +1,55 @@
+package fastxml
+
+// NewStreamParser will create a parser that receives its data in chunks with Parser.Feed.
+//
+// Such parser never returns io.EOF, instead ErrNeedMoreData is returned when
+// all fed data is consumed or next token is not fully available yet.
+// Parsing can be continued after more data will be provided with Parser.Feed.
+// This allows to parse long-lived streams (like XMPP) as data arrives.
+//
+// Note that char data is only returned when the start of the following tag was fed,
+// as before that parser cannot know if char data ended.
+func NewStreamParser(opts ...ParserOption) *Parser {
+	p := Parser{
+		streaming: true,
+	}
+
+	p.applyOptions(opts)
+
+	return &p
+}
+
+// Feed will append chunk to the data that is yet to be parsed.
+//
+// Parser does not hold onto chunk, so caller may reuse it after the call.
+//
+// Already parsed data is discarded on each call, so tokens returned
+// before the call to Feed MUST NOT be used after it.
+//
+// If parser has memory budget - it is checked on next call to Parser.Next.
+//
+// Feed panics if parser was not created by NewStreamParser (or cloned from such parser),
+// as buffer of other parsers belongs to the caller and must not be modified.
+func (p *Parser) Feed(chunk []byte) {
+	if !p.streaming {
+		panic("fastxml: Feed called on a parser that was not created by NewStreamParser")
+	}
+
+	if p.currentPointer > 0 {
+		// Names of open elements point to the data that will be overwritten.
+		if p.lastTagName != "" {
+			p.lastTagName = CopyString(p.lastTagName)
+		}
+
+		p.copyStackNames()
+
+		n := copy(p.buf, p.buf[p.currentPointer:])
+		p.buf = p.buf[:n]
+		p.discarded += p.currentPointer
+		p.currentPointer = 0
+	}
+
+	oldCap := cap(p.buf)
+	p.buf = append(p.buf, chunk...)
+	p.retain(cap(p.buf) - oldCap)
+}
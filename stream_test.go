@@ -0,0 +1,96 @@
+package fastxml
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParser_Feed(t *testing.T) {
+	data := `<stream:stream to='example.com'><message><body>hello</body></message>` +
+		`<!-- comment with <tags> --><![CDATA[<cdata>]]><presence/>`
+
+	mustResult := []string{
+		`*fastxml.StartToken: &{"stream:stream" "to='example.com'>"}`,
+		`*fastxml.StartToken: &{"message" ""}`,
+		`*fastxml.StartToken: &{"body" ""}`,
+		`*fastxml.CharData: &"hello"`,
+		`*fastxml.EndElement: &{{"" "body"}}`,
+		`*fastxml.EndElement: &{{"" "message"}}`,
+		`*fastxml.Comment: &" comment with <tags> "`,
+		`*fastxml.CharData: &"<cdata>"`,
+		`*fastxml.StartToken: &{"presence" ""}`,
+		`*fastxml.EndElement: &{{"" "presence"}}`,
+	}
+
+	for _, chunkSize := range []int{1, 2, 3, 7, len(data)} {
+		chunkSize := chunkSize
+
+		t.Run(fmt.Sprintf("chunk size %d", chunkSize), func(t *testing.T) {
+			p := NewStreamParser()
+
+			var results []string
+
+			for start := 0; start < len(data); start += chunkSize {
+				end := start + chunkSize
+				if end > len(data) {
+					end = len(data)
+				}
+
+				p.Feed([]byte(data[start:end]))
+
+				for {
+					token, err := p.Next()
+					if errors.Is(err, ErrNeedMoreData) {
+						break
+					}
+
+					require.NoError(t, err)
+
+					results = append(results, tokenString(token))
+				}
+			}
+
+			assert.Equal(t, mustResult, results)
+		})
+	}
+}
+
+func TestParser_FeedSelfClosing(t *testing.T) {
+	p := NewStreamParser()
+	p.Feed([]byte("<a/>"))
+
+	token, err := p.Next()
+	require.NoError(t, err)
+	require.Equal(t, &StartToken{Name: "a"}, token)
+
+	// Feed will discard already parsed data, but end of self-closing tag must stay intact.
+	p.Feed([]byte("<b>"))
+
+	token, err = p.Next()
+	require.NoError(t, err)
+	require.Equal(t, "a", token.(*EndElement).Name.Local)
+
+	token, err = p.Next()
+	require.NoError(t, err)
+	require.Equal(t, &StartToken{Name: "b"}, token)
+}
+
+func TestParser_FeedNotStreaming(t *testing.T) {
+	buf := []byte("<a>text</a>")[:3]
+	full := buf[:cap(buf)]
+
+	p := NewParser(buf, false)
+
+	_, err := p.Next()
+	require.NoError(t, err)
+
+	assert.Panics(t, func() { p.Feed([]byte("<b>")) })
+	assert.Equal(t, "<a>text</a>", string(full), "caller buffer is not modified")
+
+	assert.Panics(t, func() { NewParserString("<a>").Feed([]byte("<b>")) })
+	assert.NotPanics(t, func() { NewStreamParser().Clone().Feed([]byte("<b>")) })
+}
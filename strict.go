@@ -0,0 +1,105 @@
+package fastxml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrLessThanInAttribute is returned in strict mode when attribute value contains literal '<'.
+	ErrLessThanInAttribute = errors.New("'<' is not allowed in attribute value")
+	// ErrReservedProcInstTarget is returned in strict mode when processing instruction
+	// with target "xml" is not the declaration at the start of the document.
+	ErrReservedProcInstTarget = errors.New("processing instruction target \"xml\" is reserved")
+	// ErrInvalidComment is returned in strict mode when comment contains "--" or ends with '-'.
+	ErrInvalidComment = errors.New("invalid comment")
+)
+
+// WithStrict enables additional checks of the document that are required by the specification,
+// but are not done by default for the sake of performance:
+//   - attribute values must not contain literal '<';
+//   - processing instruction with target "xml"(in any case) is only allowed as the declaration
+//     at the very start of the document;
+//   - comments must not contain "--" and must not end with '-', like `<!-- a --->`;
+//   - standalone declaration must be either "yes" or "no";
+//   - in standalone documents referenced entities must be declared in the internal subset of DOCTYPE.
+func WithStrict() ParserOption {
+	return func(p *Parser) {
+		p.strict = true
+	}
+}
+
+// checkStartElement does strict mode checks of just decoded start element.
+func (p *Parser) checkStartElement(start *StartToken) error {
+	if !p.strict {
+		return nil
+	}
+
+	// Attributes buffer always ends where the token ends.
+	attrOffset := p.discarded + p.currentPointer - len(start.attrBuf)
+
+	if idx := bytes.IndexByte(start.attrBuf, '<'); idx != -1 {
+		return errorAt(fmt.Errorf("%w: offset %d", ErrLessThanInAttribute, attrOffset+idx), attrOffset+idx)
+	}
+
+	return p.checkEntityReferences(start.attrBuf, attrOffset)
+}
+
+// checkProcInst does strict mode checks of just scanned processing instruction.
+func (p *Parser) checkProcInst(buf []byte) error {
+	if !p.strict || !bytes.HasPrefix(buf, procInstPrefix) {
+		return nil
+	}
+
+	target := buf[len(procInstPrefix):]
+	if end := bytes.IndexFunc(target, func(r rune) bool { return r == '?' || IsHTMLSpaceChar(r) }); end != -1 {
+		target = target[:end]
+	}
+
+	if !bytes.EqualFold(target, []byte("xml")) {
+		return nil
+	}
+
+	if p.atDocumentStart(buf) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: offset %d", ErrReservedProcInstTarget, p.tokenOffset(buf))
+}
+
+// checkComment does strict mode checks of just scanned comment.
+func (p *Parser) checkComment(buf []byte) error {
+	if !p.strict || !bytes.HasPrefix(buf, commentPrefix) || len(buf) < len(commentPrefix)+len(commentSuffix) {
+		return nil
+	}
+
+	content := buf[len(commentPrefix) : len(buf)-len(commentSuffix)]
+	start := p.tokenOffset(buf)
+
+	idx := bytes.Index(content, []byte("--"))
+	if idx == -1 && bytes.HasSuffix(content, []byte("-")) {
+		idx = len(content) - 1
+	}
+
+	if idx == -1 {
+		return nil
+	}
+
+	offset := start + len(commentPrefix) + idx
+
+	return errorAt(fmt.Errorf("%w: \"--\" at offset %d of the comment at offsets %d-%d",
+		ErrInvalidComment, offset, start, start+len(buf)), offset)
+}
+
+// atDocumentStart reports if just scanned token is at the start of the document, possibly after byte order mark.
+func (p *Parser) atDocumentStart(buf []byte) bool {
+	start := p.tokenOffset(buf)
+
+	return start == 0 || start == len(bomUTF8) && bytes.HasPrefix(p.buf, bomUTF8) && p.discarded == 0
+}
+
+// tokenOffset returns offset of just scanned token, counted from the start of the input.
+func (p *Parser) tokenOffset(buf []byte) int {
+	return p.discarded + p.currentPointer - len(buf)
+}
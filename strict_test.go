@@ -0,0 +1,126 @@
+package fastxml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithStrict_LessThanInAttribute(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"valid", `<a b="1 &lt; 2"/>`, ""},
+		{"in value", `<root><a b="1 < 2">`, "'<' is not allowed in attribute value: offset 14"},
+		{"in single quoted value", `<a b='<'>`, "'<' is not allowed in attribute value: offset 6"},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			p := NewParser([]byte(test.input), false, WithStrict())
+
+			var err error
+			for err == nil {
+				_, err = p.Next()
+			}
+
+			if test.err == "" {
+				require.EqualError(t, err, "EOF")
+			} else {
+				require.EqualError(t, err, test.err)
+				require.ErrorIs(t, err, ErrLessThanInAttribute)
+			}
+		})
+	}
+
+	// Without strict mode value is returned as is.
+	token, err := NewParser([]byte(`<a b="1 < 2">`), false).Next()
+	require.NoError(t, err)
+
+	_, value, err := token.(*StartToken).NextAttribute()
+	require.NoError(t, err)
+	require.Equal(t, "1 < 2", value)
+}
+
+func TestWithStrict_ReservedProcInstTarget(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"declaration", `<?xml version="1.0"?><a/>`, ""},
+		{"declaration after BOM", "\xEF\xBB\xBF<?xml version=\"1.0\"?><a/>", ""},
+		{"other target", `<a/><?xml-stylesheet href="a.xsl"?>`, ""},
+		{"after whitespace", ` <?xml version="1.0"?><a/>`, `processing instruction target "xml" is reserved: offset 1`},
+		{"inside element", `<a><?XML version="1.0"?></a>`, `processing instruction target "xml" is reserved: offset 3`},
+		{"without content", `<a/><?xml?>`, `processing instruction target "xml" is reserved: offset 4`},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			p := NewParser([]byte(test.input), false, WithStrict())
+
+			var err error
+			for err == nil {
+				_, err = p.Next()
+			}
+
+			if test.err == "" {
+				require.EqualError(t, err, "EOF")
+			} else {
+				require.EqualError(t, err, test.err)
+				require.ErrorIs(t, err, ErrReservedProcInstTarget)
+			}
+		})
+	}
+}
+
+func TestWithStrict_Comment(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"valid", `<a><!-- a - b --><!----><!--> x --></a>`, ""},
+		{"double dash", `<a><!-- a -- b --></a>`, `invalid comment: "--" at offset 10 of the comment at offsets 3-18`},
+		{"trailing dash", `<a/><!-- a --->`, `invalid comment: "--" at offset 11 of the comment at offsets 4-15`},
+		{"single dash", `<!---->--><a/>`, ""},
+		{"only dash", `<!---->`, ""},
+		{"dash content", `<!----->`, `invalid comment: "--" at offset 4 of the comment at offsets 0-8`},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			p := NewParser([]byte(test.input), false, WithStrict(), WithSkipComments())
+
+			var err error
+			for err == nil {
+				_, err = p.Next()
+			}
+
+			if test.err == "" {
+				require.EqualError(t, err, "EOF")
+			} else {
+				require.EqualError(t, err, test.err)
+				require.ErrorIs(t, err, ErrInvalidComment)
+			}
+
+			// Comments are accepted in lenient mode.
+			p = NewParser([]byte(test.input), false)
+
+			for err = nil; err == nil; {
+				_, err = p.Next()
+			}
+
+			require.EqualError(t, err, "EOF")
+		})
+	}
+}
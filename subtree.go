@@ -0,0 +1,79 @@
+package fastxml
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// ErrNotAtStartElement is returned by Parser.SubtreeHash if the last returned token is not a start element.
+var ErrNotAtStartElement = errors.New("last token is not a start element")
+
+// SubtreeHash will read the current element until its end and return hash of its canonical form.
+//
+// It must be called right after Parser.Next returned a start element. Attributes of the returned token
+// can be read before the call, they are still included. h is reset before use, and can be reused between calls.
+//
+// Canonical form is written the same way as by Canonicalizer with WithExclusive option,
+// so namespace declarations of ancestors that are not used by the element do not change its hash.
+// This makes hash suitable for cheap change detection of records between two versions of a document:
+// elements with equal content have equal hashes regardless of attribute order, quoting style
+// or formatting inside the tags, while equal hashes of different content are only as likely as collisions of h.
+//
+// After the call, the next token is the one after the end of the element.
+func (p *Parser) SubtreeHash(h hash.Hash64) (uint64, error) {
+	if !p.subtreeStart || len(p.stack) == 0 {
+		return 0, ErrNotAtStartElement
+	}
+
+	depth := len(p.stack)
+	start := StartToken{
+		Name:              p.stack[depth-1],
+		attrBuf:           p.subtreeAttrs,
+		allowBoolAttrs:    p.html,
+		typographicQuotes: p.typographicQuotes && !p.strict,
+		tolerantAttrs:     p.tolerantAttrs,
+	}
+
+	h.Reset()
+
+	c := NewCanonicalizer(h, WithExclusive())
+
+	if err := c.WriteToken(&start); err != nil {
+		return 0, fmt.Errorf("subtree hash: %w", err)
+	}
+
+	for len(p.stack) >= depth {
+		token, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+
+		if err != nil {
+			return 0, fmt.Errorf("subtree hash: %w", err)
+		}
+
+		if err := c.WriteToken(token); err != nil {
+			return 0, fmt.Errorf("subtree hash: %w", err)
+		}
+	}
+
+	if err := c.Flush(); err != nil {
+		return 0, fmt.Errorf("subtree hash: %w", err)
+	}
+
+	return h.Sum64(), nil
+}
+
+// recordSubtreeStart saves attributes of the start element for Parser.SubtreeHash,
+// as the caller can read them from the returned token before the call.
+func (p *Parser) recordSubtreeStart(token xml.Token) {
+	start, ok := token.(*StartToken)
+
+	p.subtreeStart = ok
+	if ok {
+		p.subtreeAttrs = start.attrBuf
+	}
+}
@@ -0,0 +1,124 @@
+package fastxml
+
+import (
+	"encoding/xml"
+	"hash/fnv"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func subtreeHashes(t *testing.T, input string, opts ...ParserOption) map[string]uint64 {
+	t.Helper()
+
+	p := NewParser([]byte(input), false, opts...)
+	h := fnv.New64a()
+	hashes := map[string]uint64{}
+
+	for {
+		token, err := p.Next()
+		if err == io.EOF {
+			return hashes
+		}
+
+		require.NoError(t, err)
+
+		start, ok := token.(*StartToken)
+		if !ok || p.Depth() != 2 {
+			continue
+		}
+
+		var id string
+
+		for {
+			name, val, err := start.NextAttribute()
+			if err != nil {
+				break
+			}
+
+			if name == "id" {
+				id = CopyString(val)
+			}
+		}
+
+		hashes[id], err = p.SubtreeHash(h)
+		require.NoError(t, err)
+	}
+}
+
+func TestParser_SubtreeHash(t *testing.T) {
+	old := subtreeHashes(t, `<dump>
+		<record id="1" kind="a"><name>first</name></record>
+		<record id="2"><name>second</name><empty/></record>
+		<record id="3"><name>third</name></record>
+		<record id="4" xmlns:x="urn:x"><x:name>fourth</x:name></record>
+	</dump>`)
+
+	updated := subtreeHashes(t, `<dump xmlns:unused="urn:unused">
+		<record   kind='a' id="1" ><name>first</name></record>
+		<record id="2"><name>second</name><empty></empty></record>
+		<record id="3"><name>third!</name></record>
+		<record id="4" xmlns:y="urn:x"><y:name>fourth</y:name></record>
+	</dump>`)
+
+	require.Len(t, old, 4)
+	require.Len(t, updated, 4)
+
+	assert.Equal(t, old["1"], updated["1"], "attribute order and quoting")
+	assert.Equal(t, old["2"], updated["2"], "empty element")
+	assert.NotEqual(t, old["3"], updated["3"], "changed text")
+	assert.NotEqual(t, old["4"], updated["4"], "changed prefix")
+}
+
+func TestParser_SubtreeHash_Position(t *testing.T) {
+	h := fnv.New64a()
+
+	p := NewParser([]byte(`<a><b x="1">text<c/></b><d/></a>`), false)
+
+	_, err := p.SubtreeHash(h)
+	assert.ErrorIs(t, err, ErrNotAtStartElement)
+
+	_, err = p.Next()
+	require.NoError(t, err)
+
+	token, err := p.Next()
+	require.NoError(t, err)
+
+	// Attributes read before the call and peeked tokens do not change the hash.
+	_, _, err = token.(*StartToken).NextAttribute()
+	require.NoError(t, err)
+
+	_, err = p.Peek()
+	require.NoError(t, err)
+
+	sum, err := p.SubtreeHash(h)
+	require.NoError(t, err)
+
+	h.Reset()
+	require.NoError(t, Canonicalize(h, []byte(`<b x="1">text<c/></b>`)))
+	assert.Equal(t, h.Sum64(), sum)
+
+	token, err = p.Next()
+	require.NoError(t, err)
+	assert.Equal(t, &StartToken{Name: "d"}, token)
+
+	_, err = p.SubtreeHash(h)
+	require.NoError(t, err)
+
+	token, err = p.Next()
+	require.NoError(t, err)
+	assert.Equal(t, &EndElement{Name: xml.Name{Local: "a"}}, token)
+
+	_, err = p.SubtreeHash(h)
+	assert.ErrorIs(t, err, ErrNotAtStartElement)
+
+	p = NewParser([]byte(`<a><b>text`), false)
+
+	_, err = p.Next()
+	require.NoError(t, err)
+
+	_, err = p.SubtreeHash(h)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
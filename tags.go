@@ -1,6 +1,7 @@
 package fastxml
 
 import (
+	"bytes"
 	"encoding/xml"
 	"io"
 )
@@ -15,10 +16,81 @@ type (
 	ProcInst   xml.ProcInst   // <?xml encoding="UTF-8" ?>
 )
 
+// IsWhitespace reports if char data consists only of whitespace characters.
+//
+// Empty char data is considered to be whitespace.
+func (c CharData) IsWhitespace() bool {
+	for _, b := range c {
+		if !IsHTMLSpaceChar(rune(b)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// TrimSpace returns char data without leading and trailing whitespace.
+//
+// Returned slice points to the same memory as char data, so no allocation is done.
+func (c CharData) TrimSpace() []byte {
+	start, end := 0, len(c)
+
+	for start < end && IsHTMLSpaceChar(rune(c[start])) {
+		start++
+	}
+
+	for end > start && IsHTMLSpaceChar(rune(c[end-1])) {
+		end--
+	}
+
+	return c[start:end]
+}
+
 // StartToken is current implementation of start tag type.
 type StartToken struct {
-	Name    string
+	Name string
+	// Space is the default namespace of unprefixed element, if parser reports it, see WithDefaultNamespace.
+	Space   string
 	attrBuf []byte
+	// allowBoolAttrs allows attributes without value, like `<input disabled>`.
+	allowBoolAttrs bool
+	// typographicQuotes allows attribute values in typographic quotes, see WithTypographicQuotes.
+	typographicQuotes bool
+	// tolerantAttrs makes NextAttribute skip malformed attributes, see WithTolerantAttributes.
+	tolerantAttrs bool
+	// attrWarnings hold attributes skipped by NextAttribute.
+	attrWarnings []AttributeWarning
+}
+
+// NewStartToken will create start token with provided attributes.
+//
+// Same as for tokens returned by the parser, attribute values are kept escaped,
+// so NextAttribute returns them in raw form. This allows sources other than the parser
+// to produce tokens that are handled the same way.
+func NewStartToken(name string, attrs ...xml.Attr) *StartToken {
+	tkn := &StartToken{Name: name}
+	if len(attrs) == 0 {
+		return tkn
+	}
+
+	var buf bytes.Buffer
+
+	for i, attr := range attrs {
+		if i != 0 {
+			buf.WriteByte(' ')
+		}
+
+		buf.WriteString(qualifiedName(attr.Name))
+		buf.WriteString(`="`)
+		_ = xml.EscapeText(&buf, []byte(attr.Value)) // Writes to bytes.Buffer never fail.
+		buf.WriteByte('"')
+	}
+
+	buf.WriteByte('>')
+
+	tkn.attrBuf = buf.Bytes()
+
+	return tkn
 }
 
 // HasAttributes only specifies if current tag has attributes.
@@ -39,13 +111,29 @@ func (s *StartToken) HasAttributes() bool {
 // they still will be returned and no error will be produced.
 //
 // So tag with these attributes will be properly parsed: <a a='1' a='2'>, with two attributes being returned: a=1, a=2.
+//
+// In HTML mode attributes without value are allowed, they are returned with empty value.
+// With WithTolerantAttributes malformed attributes are skipped, see StartToken.AttributeWarnings.
 func (s *StartToken) NextAttribute() (attrName, attrVal string, err error) {
-	if len(s.attrBuf) <= 4 {
+	if s.tolerantAttrs {
+		return s.nextTolerantAttribute()
+	}
+
+	return s.nextAttribute()
+}
+
+// nextAttribute returns next attribute, stopping at the malformed one.
+func (s *StartToken) nextAttribute() (attrName, attrVal string, err error) {
+	if s.allowBoolAttrs {
+		return s.nextLenientAttribute()
+	}
+
+	if attributesEnded(s.attrBuf) {
 		return "", "", io.EOF
 	}
 
 	var skipIdx int
-	attrName, attrVal, skipIdx, err = decodeTagAttribute(s.attrBuf)
+	attrName, attrVal, skipIdx, err = decodeTagAttribute(s.attrBuf, s.typographicQuotes)
 
 	if skipIdx != -1 {
 		s.attrBuf = s.attrBuf[skipIdx:]
@@ -53,3 +141,196 @@ func (s *StartToken) NextAttribute() (attrName, attrVal string, err error) {
 
 	return
 }
+
+// NextAttributeReader will return next attribute name and reader of its value,
+// with references replaced as value is read, see UnescapeReader.
+//
+// It allows to process huge values, like megabytes of base64 encoded data, without copying them.
+// Reader points to the parser's memory, so it must be read before the next token is requested.
+// This method will return io.EOF when no more attributes will be returned, same as NextAttribute.
+func (s *StartToken) NextAttributeReader() (attrName string, r io.Reader, err error) {
+	attrName, attrVal, err := s.NextAttribute()
+	if err != nil {
+		return "", nil, err
+	}
+
+	return attrName, UnescapeReader(attrVal), nil
+}
+
+// attributesEnded reports if there are no more attributes in the buffer,
+// which is when only whitespace is left before the end of the tag.
+func attributesEnded(buf []byte) bool {
+	buf = buf[NextNonSpaceIndex(buf):]
+
+	return len(buf) == 0 || buf[0] == '>' || buf[0] == '/' && (len(buf) == 1 || buf[1] == '>')
+}
+
+func (s *StartToken) nextLenientAttribute() (attrName, attrVal string, err error) {
+	attrName, attrVal, _, err = s.nextAttributeWithFlag()
+
+	return attrName, attrVal, err
+}
+
+// nextAttributeWithFlag returns next attribute in lenient mode, reporting if attribute had a value.
+func (s *StartToken) nextAttributeWithFlag() (attrName, attrVal string, hasValue bool, err error) {
+	attrName, skipIdx, err := decodeBoolTagAttribute(s.attrBuf)
+	if err != nil || attrName != "" {
+		if skipIdx > 0 {
+			s.attrBuf = s.attrBuf[skipIdx:]
+		}
+
+		return attrName, "", false, err
+	}
+
+	if skipIdx == -1 {
+		return "", "", false, io.EOF
+	}
+
+	// Attribute has value.
+	attrName, attrVal, skipIdx, err = decodeTagAttribute(s.attrBuf, s.typographicQuotes)
+	if skipIdx == -1 {
+		return "", "", false, io.EOF
+	}
+
+	if err == nil {
+		s.attrBuf = s.attrBuf[skipIdx:]
+	}
+
+	return attrName, attrVal, true, err
+}
+
+// Std returns start element as encoding/xml value, with attributes materialized.
+//
+// Names are split into prefix(as Name.Space) and local part, same as encoding/xml Decoder.RawToken does,
+// attribute values have references replaced with Unescape.
+// Unprefixed element name gets StartToken.Space as its namespace.
+// Attributes are read from the copy of the token, so they can still be read from s.
+// Attributes after the first malformed one are not returned, unless malformed attributes are skipped
+// with WithTolerantAttributes.
+//
+// Returned value does not point to parser's memory, so caller may hold onto it.
+func (s *StartToken) Std() xml.StartElement {
+	start := xml.StartElement{Name: splitName(CopyString(s.Name))}
+	if start.Name.Space == "" {
+		start.Name.Space = s.Space
+	}
+
+	attrs := *s
+
+	for {
+		name, val, err := attrs.NextAttribute()
+		if err != nil {
+			return start
+		}
+
+		start.Attr = append(start.Attr, xml.Attr{
+			Name:  splitName(CopyString(name)),
+			Value: CopyString(Unescape(val)),
+		})
+	}
+}
+
+// Std returns end element as encoding/xml value, with name split into prefix and local part.
+func (e *EndElement) Std() xml.EndElement {
+	if e.Name.Space != "" {
+		return xml.EndElement{Name: xml.Name{Space: CopyString(e.Name.Space), Local: CopyString(e.Name.Local)}}
+	}
+
+	return xml.EndElement{Name: splitName(CopyString(e.Name.Local))}
+}
+
+// Std returns char data as encoding/xml value, with references replaced with Unescape.
+//
+// Parser does not distinguish CDATA sections, so references are replaced in them as well.
+func (c *CharData) Std() xml.CharData {
+	return xml.CharData(Unescape(string(*c)))
+}
+
+// Std returns a copy of the comment as encoding/xml value.
+func (c *Comment) Std() xml.Comment {
+	return xml.Comment(copyBytes(*c))
+}
+
+// Std returns a copy of the directive as encoding/xml value.
+func (d *Directive) Std() xml.Directive {
+	return xml.Directive(copyBytes(*d))
+}
+
+// Std returns a copy of the processing instruction as encoding/xml value.
+func (p *ProcInst) Std() xml.ProcInst {
+	return xml.ProcInst{Target: CopyString(p.Target), Inst: copyBytes(p.Inst)}
+}
+
+// ToStd converts token returned by the parser to encoding/xml value, see Std methods of the tokens.
+//
+// Tokens of other types(including encoding/xml values) are returned as is.
+func ToStd(token xml.Token) xml.Token {
+	switch tkn := token.(type) {
+	case *StartToken:
+		return tkn.Std()
+	case *EndElement:
+		return tkn.Std()
+	case *CharData:
+		return tkn.Std()
+	case *Comment:
+		return tkn.Std()
+	case *Directive:
+		return tkn.Std()
+	case *ProcInst:
+		return tkn.Std()
+	default:
+		return token
+	}
+}
+
+// CopyToken will return a deep copy of the token.
+//
+// Returned token does not point to parser's memory, so caller may hold onto it.
+// Same as with Parser.Next, returned token will always be a pointer type.
+func CopyToken(token xml.Token) xml.Token {
+	switch tkn := token.(type) {
+	case *StartToken:
+		return &StartToken{
+			Name:              CopyString(tkn.Name),
+			Space:             CopyString(tkn.Space),
+			attrBuf:           copyBytes(tkn.attrBuf),
+			allowBoolAttrs:    tkn.allowBoolAttrs,
+			typographicQuotes: tkn.typographicQuotes,
+			tolerantAttrs:     tkn.tolerantAttrs,
+		}
+	case *EndElement:
+		return &EndElement{
+			Name: xml.Name{
+				Space: CopyString(tkn.Name.Space),
+				Local: CopyString(tkn.Name.Local),
+			},
+		}
+	case *CharData:
+		charData := CharData(copyBytes(*tkn))
+
+		return &charData
+	case *Comment:
+		comment := Comment(copyBytes(*tkn))
+
+		return &comment
+	case *Directive:
+		directive := Directive(copyBytes(*tkn))
+
+		return &directive
+	case *ProcInst:
+		return &ProcInst{
+			Target: CopyString(tkn.Target),
+			Inst:   copyBytes(tkn.Inst),
+		}
+	default:
+		return token
+	}
+}
+
+func copyBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+
+	return append(make([]byte, 0, len(b)), b...)
+}
@@ -1,7 +1,12 @@
 package fastxml
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
 	"io"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -35,3 +40,202 @@ func TestStartToken_NextAttribute(t *testing.T) {
 	_, _, err = startToken.NextAttribute()
 	require.Equal(t, io.EOF, err, "unexpected attributes are present")
 }
+
+func TestStartToken_NextAttributeReader(t *testing.T) {
+	data := bytes.Repeat([]byte("large <binary> value & more\n"), 10000)
+	encoded := base64.StdEncoding.EncodeToString(data)
+
+	// Base64 does not need escaping, but references are still allowed.
+	input := `<a name="x &amp; y" data="` + strings.ReplaceAll(encoded, "+", "&#43;") + `"/>`
+
+	token, err := NewParser([]byte(input), false).Next()
+	require.NoError(t, err)
+
+	start := token.(*StartToken)
+
+	name, r, err := start.NextAttributeReader()
+	require.NoError(t, err)
+	require.Equal(t, "name", name)
+
+	val, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "x & y", string(val))
+
+	name, r, err = start.NextAttributeReader()
+	require.NoError(t, err)
+	require.Equal(t, "data", name)
+
+	decoded, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, r))
+	require.NoError(t, err)
+	require.Equal(t, data, decoded)
+
+	_, _, err = start.NextAttributeReader()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestStartToken_NextAttribute_Edges(t *testing.T) {
+	tests := []struct {
+		name      string
+		attrBuf   string
+		mustAttrs [][2]string
+	}{
+		{"short last attribute", `a="1" x=''>`, [][2]string{{"a", "1"}, {"x", ""}}},
+		{"short attribute without end", `x=''`, [][2]string{{"x", ""}}},
+		{"short self-closing", `x=""/>`, [][2]string{{"x", ""}}},
+		{"space before self-closing end", `a="1"      />`, [][2]string{{"a", "1"}}},
+		{"space before end", "a=\"1\"\n\t  >", [][2]string{{"a", "1"}}},
+		{"no attributes", `  />`, nil},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			startToken := &StartToken{Name: "a", attrBuf: []byte(test.attrBuf)}
+
+			var attrs [][2]string
+
+			for {
+				name, val, err := startToken.NextAttribute()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+
+				require.NoError(t, err)
+
+				attrs = append(attrs, [2]string{name, val})
+			}
+
+			require.Equal(t, test.mustAttrs, attrs)
+		})
+	}
+}
+
+func TestNewStartToken(t *testing.T) {
+	require.False(t, NewStartToken("a").HasAttributes())
+
+	tkn := NewStartToken("x:a", xml.Attr{Name: xml.Name{Local: "b"}, Value: `1 < "2"`}, xml.Attr{Name: xml.Name{Space: "x", Local: "c"}})
+	require.True(t, tkn.HasAttributes())
+
+	require.Equal(t, xml.StartElement{
+		Name: xml.Name{Space: "x", Local: "a"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "b"}, Value: `1 < "2"`}, {Name: xml.Name{Space: "x", Local: "c"}}},
+	}, tkn.Std())
+}
+
+func TestCopyToken(t *testing.T) {
+	buf := []byte(`<a attr='1'>text<!--comment--></a>`)
+
+	p := NewParser(buf, false)
+
+	var tokens []xml.Token
+
+	for {
+		token, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+
+		tokens = append(tokens, CopyToken(token))
+	}
+
+	// Copied tokens must not be affected by changes of the parsed buffer.
+	for i := range buf {
+		buf[i] = 'x'
+	}
+
+	require.Equal(t, []xml.Token{
+		&StartToken{Name: "a", attrBuf: []byte("attr='1'>")},
+		(*CharData)(&[]byte{'t', 'e', 'x', 't'}),
+		(*Comment)(&[]byte{'c', 'o', 'm', 'm', 'e', 'n', 't'}),
+		&EndElement{Name: xml.Name{Local: "a"}},
+	}, tokens)
+}
+
+func TestToStd(t *testing.T) {
+	buf := []byte(`<x:a x:attr='1 &amp; 2' b="&#65;">text &lt;<!--comment--></x:a>`)
+
+	p := NewParser(buf, false)
+
+	var tokens []xml.Token
+
+	for {
+		token, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+
+		if start, ok := token.(*StartToken); ok {
+			// Attributes must still be available after conversion.
+			_ = start.Std()
+		}
+
+		tokens = append(tokens, ToStd(token))
+	}
+
+	for i := range buf {
+		buf[i] = 'x'
+	}
+
+	require.Equal(t, []xml.Token{
+		xml.StartElement{
+			Name: xml.Name{Space: "x", Local: "a"},
+			Attr: []xml.Attr{
+				{Name: xml.Name{Space: "x", Local: "attr"}, Value: "1 & 2"},
+				{Name: xml.Name{Local: "b"}, Value: "A"},
+			},
+		},
+		xml.CharData("text <"),
+		xml.Comment("comment"),
+		xml.EndElement{Name: xml.Name{Space: "x", Local: "a"}},
+	}, tokens)
+
+	require.Equal(t, xml.ProcInst{Target: "xml", Inst: []byte("v")}, ToStd(&ProcInst{Target: "xml", Inst: []byte("v")}))
+	require.Equal(t, xml.Directive("DOCTYPE a"), ToStd((*Directive)(&[]byte{'D', 'O', 'C', 'T', 'Y', 'P', 'E', ' ', 'a'})))
+	require.Equal(t, xml.CharData("std"), ToStd(xml.CharData("std")))
+}
+
+func TestCharData_IsWhitespace(t *testing.T) {
+	tests := []struct {
+		input  string
+		result bool
+	}{
+		{"", true},
+		{" \t\r\n", true},
+		{" a ", false},
+		{"text", false},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.input, func(t *testing.T) {
+			require.Equal(t, test.result, CharData(test.input).IsWhitespace())
+		})
+	}
+}
+
+func TestCharData_TrimSpace(t *testing.T) {
+	tests := []struct {
+		input  string
+		result string
+	}{
+		{"", ""},
+		{" \t\r\n", ""},
+		{" a ", "a"},
+		{"\n\ttext with spaces\n", "text with spaces"},
+		{"text", "text"},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.input, func(t *testing.T) {
+			require.Equal(t, test.result, string(CharData(test.input).TrimSpace()))
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package fastxml
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"time"
+)
+
+// Span is a traced parse operation.
+//
+// It mirrors the subset of OpenTelemetry trace.Span that is used by InstrumentedParser,
+// so adapter over OpenTelemetry span only needs to convert attributes:
+//
+//	func (s otelSpan) SetAttribute(key string, value int64) {
+//		s.Span.SetAttributes(attribute.Int64(key, value))
+//	}
+type Span interface {
+	SetAttribute(key string, value int64)
+	RecordError(err error)
+	End()
+}
+
+// Tracer starts spans of parse operations, like OpenTelemetry trace.Tracer.
+type Tracer interface {
+	Start(ctx context.Context, spanName string) (context.Context, Span)
+}
+
+// ParseStats are statistics of a single parse operation.
+type ParseStats struct {
+	// Size is the number of parsed bytes.
+	Size int
+	// Duration is the time from the start of the operation until it ended.
+	Duration time.Duration
+	// Tokens is the number of returned tokens by their kind.
+	Tokens [len(tokenKindNames)]int
+	// Err is the error that ended the operation, nil if document was fully parsed.
+	Err error
+}
+
+// TotalTokens returns the number of returned tokens of all kinds.
+func (s ParseStats) TotalTokens() int {
+	var total int
+
+	for _, count := range s.Tokens {
+		total += count
+	}
+
+	return total
+}
+
+// ParseRecorder receives statistics of finished parse operations.
+//
+// Implementation can record them with OpenTelemetry metric instruments,
+// like histogram of durations and counters of bytes and tokens.
+type ParseRecorder interface {
+	RecordParse(ctx context.Context, stats ParseStats)
+}
+
+// SpanName is the name of spans started by InstrumentedParser.
+const SpanName = "fastxml.parse"
+
+// Span attributes set by InstrumentedParser.
+const (
+	// AttrDocumentSize is the number of parsed bytes.
+	AttrDocumentSize = "fastxml.document.size"
+	// AttrTokens is the number of returned tokens.
+	// Number of tokens of each returned kind is set with the kind as suffix, like "fastxml.tokens.start_element".
+	AttrTokens = "fastxml.tokens"
+)
+
+// InstrumentOption configures InstrumentedParser.
+type InstrumentOption func(p *InstrumentedParser)
+
+// WithTracer sets tracer that will start span of the parse operation.
+func WithTracer(t Tracer) InstrumentOption {
+	return func(p *InstrumentedParser) {
+		p.tracer = t
+	}
+}
+
+// WithParseRecorder sets recorder that will receive statistics of the parse operation.
+func WithParseRecorder(r ParseRecorder) InstrumentOption {
+	return func(p *InstrumentedParser) {
+		p.recorder = r
+	}
+}
+
+// InstrumentedParser wraps Parser to trace and measure the whole parse operation.
+//
+// Operation starts when InstrumentedParser is created, and ends when Next returns an error,
+// including io.EOF, or when End is called.
+type InstrumentedParser struct {
+	p        *Parser
+	ctx      context.Context
+	tracer   Tracer
+	recorder ParseRecorder
+	span     Span
+	start    time.Time
+	stats    ParseStats
+	ended    bool
+}
+
+// NewInstrumentedParser will start instrumented parse operation with parser p.
+//
+// If no options are provided - operation is only measured, and its statistics are available with Stats.
+func NewInstrumentedParser(ctx context.Context, p *Parser, opts ...InstrumentOption) *InstrumentedParser {
+	ip := &InstrumentedParser{
+		p:   p,
+		ctx: ctx,
+	}
+
+	for _, opt := range opts {
+		opt(ip)
+	}
+
+	if ip.tracer != nil {
+		ip.ctx, ip.span = ip.tracer.Start(ctx, SpanName)
+	}
+
+	ip.start = time.Now()
+
+	return ip
+}
+
+// Context returns context of the operation, which holds its span if tracer was set.
+func (ip *InstrumentedParser) Context() context.Context {
+	return ip.ctx
+}
+
+// Next returns next token, same as Parser.Next.
+//
+// Operation ends with the first returned error. io.EOF is not recorded as an error of the span.
+func (ip *InstrumentedParser) Next() (xml.Token, error) {
+	token, err := ip.p.Next()
+	if err != nil {
+		ip.end(err)
+
+		return nil, err
+	}
+
+	if token != nil {
+		ip.stats.Tokens[tokenKind(token)]++
+	}
+
+	return token, nil
+}
+
+// End will end the operation, if it was not ended yet.
+// It should be called when parsing is stopped before the end of the document.
+func (ip *InstrumentedParser) End() {
+	ip.end(nil)
+}
+
+// Stats returns statistics of the operation so far.
+func (ip *InstrumentedParser) Stats() ParseStats {
+	if ip.ended {
+		return ip.stats
+	}
+
+	stats := ip.stats
+	stats.Size = ip.p.InputOffset()
+	stats.Duration = time.Since(ip.start)
+
+	return stats
+}
+
+func (ip *InstrumentedParser) end(err error) {
+	if ip.ended {
+		return
+	}
+
+	if !errors.Is(err, io.EOF) {
+		ip.stats.Err = err
+	}
+
+	ip.stats = ip.Stats()
+	ip.ended = true
+
+	if ip.span != nil {
+		ip.span.SetAttribute(AttrDocumentSize, int64(ip.stats.Size))
+		ip.span.SetAttribute(AttrTokens, int64(ip.stats.TotalTokens()))
+
+		for kind, count := range ip.stats.Tokens {
+			if count != 0 {
+				ip.span.SetAttribute(AttrTokens+"."+TokenKind(kind).String(), int64(count))
+			}
+		}
+
+		if ip.stats.Err != nil {
+			ip.span.RecordError(ip.stats.Err)
+		}
+
+		ip.span.End()
+	}
+
+	if ip.recorder != nil {
+		ip.recorder.RecordParse(ip.ctx, ip.stats)
+	}
+}
+
+// tokenKind returns kind of the token returned from Parser.Next.
+func tokenKind(token xml.Token) TokenKind {
+	switch token.(type) {
+	case *StartToken:
+		return TokenKindStartElement
+	case *EndElement:
+		return TokenKindEndElement
+	case *CharData:
+		return TokenKindCharData
+	case *Comment:
+		return TokenKindComment
+	case *ProcInst:
+		return TokenKindProcInst
+	case *Directive:
+		return TokenKindDirective
+	default:
+		return TokenKindUnknown
+	}
+}
@@ -0,0 +1,142 @@
+package fastxml
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testSpanKey struct{}
+
+type testSpan struct {
+	name  string
+	attrs map[string]int64
+	errs  []error
+	ended int
+}
+
+func (s *testSpan) SetAttribute(key string, value int64) {
+	s.attrs[key] = value
+}
+
+func (s *testSpan) RecordError(err error) {
+	s.errs = append(s.errs, err)
+}
+
+func (s *testSpan) End() {
+	s.ended++
+}
+
+type testTracer struct {
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, spanName string) (context.Context, Span) {
+	span := &testSpan{name: spanName, attrs: map[string]int64{}}
+	t.spans = append(t.spans, span)
+
+	return context.WithValue(ctx, testSpanKey{}, span), span
+}
+
+type testRecorder struct {
+	stats []ParseStats
+	ctx   context.Context
+}
+
+func (r *testRecorder) RecordParse(ctx context.Context, stats ParseStats) {
+	r.ctx = ctx
+	r.stats = append(r.stats, stats)
+}
+
+func TestInstrumentedParser(t *testing.T) {
+	input := `<?xml version="1.0"?><a><!-- c --><b/>text</a>`
+
+	tracer := &testTracer{}
+	recorder := &testRecorder{}
+
+	ip := NewInstrumentedParser(context.Background(), NewParser([]byte(input), false),
+		WithTracer(tracer), WithParseRecorder(recorder))
+
+	require.Len(t, tracer.spans, 1)
+	assert.Same(t, tracer.spans[0], ip.Context().Value(testSpanKey{}))
+
+	for {
+		_, err := ip.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+	}
+
+	span := tracer.spans[0]
+	assert.Equal(t, SpanName, span.name)
+	assert.Equal(t, 1, span.ended)
+	assert.Empty(t, span.errs)
+	assert.Equal(t, map[string]int64{
+		AttrDocumentSize:               int64(len(input)),
+		AttrTokens:                     6,
+		"fastxml.tokens.start_element": 2,
+		"fastxml.tokens.end_element":   2,
+		"fastxml.tokens.comment":       1,
+		"fastxml.tokens.char_data":     1,
+	}, span.attrs)
+
+	require.Len(t, recorder.stats, 1)
+	assert.Same(t, span, recorder.ctx.Value(testSpanKey{}))
+
+	stats := recorder.stats[0]
+	assert.Equal(t, len(input), stats.Size)
+	assert.Equal(t, 6, stats.TotalTokens())
+	assert.Equal(t, 2, stats.Tokens[TokenKindStartElement])
+	assert.NoError(t, stats.Err)
+	assert.Equal(t, stats, ip.Stats())
+
+	// Operation is ended only once.
+	ip.End()
+	_, err := ip.Next()
+	require.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, 1, span.ended)
+	assert.Len(t, recorder.stats, 1)
+}
+
+func TestInstrumentedParser_Error(t *testing.T) {
+	tracer := &testTracer{}
+	recorder := &testRecorder{}
+
+	ip := NewInstrumentedParser(context.Background(), NewParser([]byte(`<a><!-- unterminated`), false),
+		WithTracer(tracer), WithParseRecorder(recorder))
+
+	var err error
+	for err == nil {
+		_, err = ip.Next()
+	}
+
+	require.Len(t, tracer.spans[0].errs, 1)
+	assert.Equal(t, err, tracer.spans[0].errs[0])
+	assert.Equal(t, err, recorder.stats[0].Err)
+}
+
+func TestInstrumentedParser_End(t *testing.T) {
+	recorder := &testRecorder{}
+
+	ip := NewInstrumentedParser(context.Background(), NewParser([]byte(`<a><b/></a>`), false),
+		WithParseRecorder(recorder))
+
+	_, err := ip.Next()
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, ip.Stats().Size)
+	assert.Empty(t, recorder.stats)
+
+	ip.End()
+
+	require.Len(t, recorder.stats, 1)
+	assert.Equal(t, 3, recorder.stats[0].Size)
+	assert.Equal(t, 1, recorder.stats[0].TotalTokens())
+	assert.NoError(t, recorder.stats[0].Err)
+}
@@ -0,0 +1,92 @@
+package fastxml
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+// TextOption configures ExtractText.
+type TextOption func(o *textOptions)
+
+type textOptions struct {
+	normalizeSpace bool
+	expandEntities bool
+}
+
+// WithNormalizedSpace makes ExtractText collapse each run of whitespace into a single space
+// and trim whitespace from both ends of the result.
+func WithNormalizedSpace() TextOption {
+	return func(o *textOptions) {
+		o.normalizeSpace = true
+	}
+}
+
+// WithExpandedEntities makes ExtractText replace predefined entities and character references, see Unescape.
+//
+// Text of CDATA sections is not distinguished from other char data, so references in it are replaced as well.
+func WithExpandedEntities() TextOption {
+	return func(o *textOptions) {
+		o.expandEntities = true
+	}
+}
+
+// ExtractText returns concatenated char data of the document, stripping all markup.
+//
+// Comments, processing instructions and declarations are skipped without decoding.
+// Returned slice never points into buf.
+func ExtractText(buf []byte, opts ...TextOption) ([]byte, error) {
+	var o textOptions
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	p := NewParser(buf, false, WithSkipComments(), WithSkipProcInst(), WithSkipDirectives())
+
+	text := make([]byte, 0, len(buf)/2)
+	// pendingSpace is set when whitespace was collapsed, but not written yet.
+	var pendingSpace bool
+
+	for {
+		token, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			return text, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		charData, ok := token.(*CharData)
+		if !ok {
+			continue
+		}
+
+		data := []byte(*charData)
+		if o.expandEntities && bytes.IndexByte(data, '&') != -1 {
+			data = []byte(Unescape(string(data)))
+		}
+
+		if !o.normalizeSpace {
+			text = append(text, data...)
+
+			continue
+		}
+
+		for _, b := range data {
+			if IsHTMLSpaceChar(rune(b)) {
+				pendingSpace = len(text) != 0
+
+				continue
+			}
+
+			if pendingSpace {
+				text = append(text, ' ')
+				pendingSpace = false
+			}
+
+			text = append(text, b)
+		}
+	}
+}
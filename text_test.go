@@ -0,0 +1,44 @@
+package fastxml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractText(t *testing.T) {
+	input := `<?xml version="1.0"?>
+<!DOCTYPE doc>
+<doc>
+  <title>Fish &amp; Chips</title>
+  <!-- comment -->
+  <p>Price:  <b>&#36;5</b><![CDATA[ only]]></p>
+</doc>`
+
+	tests := []struct {
+		name   string
+		opts   []TextOption
+		result string
+	}{
+		{"raw", nil, "\n\n\n  Fish &amp; Chips\n  \n  Price:  &#36;5 only\n"},
+		{"normalized", []TextOption{WithNormalizedSpace()}, "Fish &amp; Chips Price: &#36;5 only"},
+		{"expanded", []TextOption{WithNormalizedSpace(), WithExpandedEntities()}, "Fish & Chips Price: $5 only"},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			text, err := ExtractText([]byte(input), test.opts...)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.result, string(text))
+		})
+	}
+}
+
+func TestExtractText_Error(t *testing.T) {
+	_, err := ExtractText([]byte(`<a>text<!-- unterminated`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,66 @@
+package fastxml
+
+import (
+	"bytes"
+	"errors"
+)
+
+// tokenDecoder is the decoder registered for tokens starting with prefix.
+type tokenDecoder struct {
+	prefix []byte
+	fn     TokenDecoderFunc
+}
+
+// WithTokenDecoder registers fn to decode tokens starting with prefix instead of the parser,
+// so constructs that the parser ignores or does not support, like `<!ENTITY`, vendor-specific
+// processing instructions (`<?vendor`) or nonstandard directives, become user-defined tokens instead of errors.
+//
+// Only tokens starting with "<!" or "<?" are decoded with registered decoders, the longest matching prefix wins.
+// Declarations that are unknown to the parser end with the first '>' outside of quoted literals.
+// fn receives raw token, like `<!ENTITY name "value">`, which is only valid until it returns,
+// and its result is returned by Parser.Next as is, including nil token. Error of fn stops parsing.
+// Tokens skipped with options like WithSkipDTD are not passed to decoders.
+func WithTokenDecoder(prefix string, fn TokenDecoderFunc) ParserOption {
+	return func(p *Parser) {
+		p.tokenDecoders = append(p.tokenDecoders, tokenDecoder{prefix: []byte(prefix), fn: fn})
+	}
+}
+
+// registeredDecoder returns decoder registered for the token, or nil if there is none.
+func (p *Parser) registeredDecoder(buf []byte) TokenDecoderFunc {
+	if len(p.tokenDecoders) == 0 || len(buf) < 2 || buf[0] != '<' || buf[1] != '!' && buf[1] != '?' {
+		return nil
+	}
+
+	var match *tokenDecoder
+
+	for i := range p.tokenDecoders {
+		decoder := &p.tokenDecoders[i]
+
+		if bytes.HasPrefix(buf, decoder.prefix) && (match == nil || len(decoder.prefix) > len(match.prefix)) {
+			match = decoder
+		}
+	}
+
+	if match == nil {
+		return nil
+	}
+
+	return match.fn
+}
+
+// scanRegisteredDeclaration scans declaration at the start of buf, which scanner does not know,
+// if it has registered decoder. Otherwise result of the scanner is returned as is.
+func (p *Parser) scanRegisteredDeclaration(buf, tokenBytes []byte, err error) ([]byte, error) {
+	if errors.Is(err, ErrNeedMoreData) || !isSpecialTag(buf) || p.registeredDecoder(buf) == nil {
+		return tokenBytes, err
+	}
+
+	// Quoted literals of the declaration may contain '>', same as attribute values.
+	end, err := scanFullTag(buf)
+	if err != nil {
+		return buf, err
+	}
+
+	return buf[:end], nil
+}
@@ -0,0 +1,51 @@
+package fastxml
+
+import (
+	"encoding/xml"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testEntityToken struct {
+	decl string
+}
+
+func TestWithTokenDecoder(t *testing.T) {
+	input := `<!ENTITY gt2 "a>b"><?vendor hint="1"?><?vendor-x data?><?other?><root><!-- c --></root>`
+
+	opts := []ParserOption{
+		WithTokenDecoder("<!ENTITY", func(buf []byte) (xml.Token, error) {
+			return &testEntityToken{decl: string(buf)}, nil
+		}),
+		WithTokenDecoder("<?vendor", func(buf []byte) (xml.Token, error) {
+			return &ProcInst{Target: "vendor", Inst: copyBytes(buf)}, nil
+		}),
+		WithTokenDecoder("<?vendor-x", func(buf []byte) (xml.Token, error) { return nil, nil }),
+		WithTokenDecoder("<root", func(buf []byte) (xml.Token, error) {
+			return nil, errors.New("start elements are not passed to decoders")
+		}),
+	}
+
+	_, err := NewParser([]byte(input), false).Next()
+	require.Error(t, err, "unknown declaration without decoder")
+
+	assert.Equal(t, []string{
+		`*fastxml.testEntityToken: &{"<!ENTITY gt2 \"a>b\">"}`,
+		`*fastxml.ProcInst: &{"vendor" "<?vendor hint=\"1\"?>"}`,
+		`<nil>: %!q(<nil>)`,
+		`<nil>: %!q(<nil>)`,
+		`*fastxml.StartToken: &{"root" ""}`,
+		`*fastxml.Comment: &" c "`,
+		`*fastxml.EndElement: &{{"" "root"}}`,
+	}, collectTokens(t, input, opts...))
+
+	p := NewParser([]byte(`<?vendor?>`), false, WithTokenDecoder("<?", func(buf []byte) (xml.Token, error) {
+		return nil, errors.New("broken")
+	}))
+
+	_, err = p.Next()
+	assert.EqualError(t, err, "decode token: index position 10: broken")
+}
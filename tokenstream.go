@@ -0,0 +1,275 @@
+package fastxml
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrInvalidTokenStream is returned when binary token stream is malformed.
+var ErrInvalidTokenStream = errors.New("invalid token stream")
+
+// tokenStreamMagic starts each binary token stream, last byte is the version of the format.
+var tokenStreamMagic = []byte("FXTS\x01")
+
+// Record kinds of the binary token stream.
+const (
+	recordStartElement byte = iota + 1
+	recordStartElementBoolAttrs
+	recordEndElement
+	recordCharData
+	recordComment
+	recordDirective
+	recordProcInst
+)
+
+var _ TokenWriter = (*TokenStreamWriter)(nil)
+
+// TokenStreamWriter writes tokens in compact binary form, which can be read back
+// with TokenStreamReader without tokenizing the document again.
+//
+// Tokens are stored raw, as they are returned by the parser, so parsing of attributes
+// and replacing of references is done only when requested, same as for parsed tokens.
+// Element names are stored once, subsequent occurrences are replaced with their index.
+//
+// Output is buffered, TokenStreamWriter.Flush must be called after the last token was written.
+type TokenStreamWriter struct {
+	w       *bufio.Writer
+	names   map[string]uint64
+	scratch [binary.MaxVarintLen64]byte
+	// headerWritten is set after magic bytes are written.
+	headerWritten bool
+}
+
+// NewTokenStreamWriter will create a writer of binary token stream to w.
+func NewTokenStreamWriter(w io.Writer) *TokenStreamWriter {
+	return &TokenStreamWriter{
+		w:     bufio.NewWriter(w),
+		names: make(map[string]uint64),
+	}
+}
+
+// WriteToken writes token to the stream.
+//
+// Only tokens of this package are accepted.
+func (s *TokenStreamWriter) WriteToken(token xml.Token) error {
+	if !s.headerWritten {
+		s.w.Write(tokenStreamMagic)
+		s.headerWritten = true
+	}
+
+	switch tkn := token.(type) {
+	case *StartToken:
+		kind := recordStartElement
+		if tkn.allowBoolAttrs {
+			kind = recordStartElementBoolAttrs
+		}
+
+		s.w.WriteByte(kind)
+		s.writeName(tkn.Name)
+		s.writeBytes(tkn.attrBuf)
+	case *EndElement:
+		s.w.WriteByte(recordEndElement)
+		s.writeName(tkn.Name.Local)
+	case *CharData:
+		s.w.WriteByte(recordCharData)
+		s.writeBytes(*tkn)
+	case *Comment:
+		s.w.WriteByte(recordComment)
+		s.writeBytes(*tkn)
+	case *Directive:
+		s.w.WriteByte(recordDirective)
+		s.writeBytes(*tkn)
+	case *ProcInst:
+		s.w.WriteByte(recordProcInst)
+		s.writeName(tkn.Target)
+		s.writeBytes(tkn.Inst)
+	default:
+		return fmt.Errorf("%w: %T", ErrUnsupportedToken, token)
+	}
+
+	_, err := s.w.Write(nil)
+
+	return err
+}
+
+// Flush will write buffered data to the underlying writer.
+func (s *TokenStreamWriter) Flush() error {
+	if !s.headerWritten {
+		s.w.Write(tokenStreamMagic)
+		s.headerWritten = true
+	}
+
+	return s.w.Flush()
+}
+
+// writeName writes index of already written name, or 0 followed by the name itself.
+func (s *TokenStreamWriter) writeName(name string) {
+	if idx, ok := s.names[name]; ok {
+		s.writeUvarint(idx + 1)
+
+		return
+	}
+
+	s.names[name] = uint64(len(s.names))
+
+	s.writeUvarint(0)
+	s.writeUvarint(uint64(len(name)))
+	s.w.WriteString(name)
+}
+
+func (s *TokenStreamWriter) writeBytes(b []byte) {
+	s.writeUvarint(uint64(len(b)))
+	s.w.Write(b)
+}
+
+func (s *TokenStreamWriter) writeUvarint(v uint64) {
+	n := binary.PutUvarint(s.scratch[:], v)
+	s.w.Write(s.scratch[:n])
+}
+
+// TokenStreamReader reads tokens written by TokenStreamWriter.
+//
+// Same as for Parser, returned tokens are reused between calls to TokenStreamReader.Next
+// and their data points into the buffer, see CopyToken.
+type TokenStreamReader struct {
+	buf   []byte
+	names []string
+	// innerData holds tokens that are returned to the caller.
+	innerData struct {
+		charData     CharData
+		comment      Comment
+		directive    Directive
+		startElement StartToken
+		endElement   EndElement
+		procInst     ProcInst
+	}
+}
+
+// NewTokenStreamReader will create a reader of binary token stream from buf.
+func NewTokenStreamReader(buf []byte) (*TokenStreamReader, error) {
+	if !bytes.HasPrefix(buf, tokenStreamMagic) {
+		return nil, fmt.Errorf("%w: unknown header", ErrInvalidTokenStream)
+	}
+
+	return &TokenStreamReader{buf: buf[len(tokenStreamMagic):]}, nil
+}
+
+// Next returns next token of the stream, or io.EOF if there are no more tokens.
+func (r *TokenStreamReader) Next() (xml.Token, error) {
+	if len(r.buf) == 0 {
+		return nil, io.EOF
+	}
+
+	kind := r.buf[0]
+	r.buf = r.buf[1:]
+
+	var err error
+
+	switch kind {
+	case recordStartElement, recordStartElementBoolAttrs:
+		tkn := &r.innerData.startElement
+		tkn.allowBoolAttrs = kind == recordStartElementBoolAttrs
+
+		if tkn.Name, err = r.readName(); err != nil {
+			return nil, err
+		}
+
+		if tkn.attrBuf, err = r.readBytes(); err != nil {
+			return nil, err
+		}
+
+		if len(tkn.attrBuf) == 0 {
+			tkn.attrBuf = nil
+		}
+
+		return tkn, nil
+	case recordEndElement:
+		tkn := &r.innerData.endElement
+
+		if tkn.Name.Local, err = r.readName(); err != nil {
+			return nil, err
+		}
+
+		return tkn, nil
+	case recordCharData:
+		r.innerData.charData, err = r.readBytes()
+
+		return &r.innerData.charData, err
+	case recordComment:
+		r.innerData.comment, err = r.readBytes()
+
+		return &r.innerData.comment, err
+	case recordDirective:
+		r.innerData.directive, err = r.readBytes()
+
+		return &r.innerData.directive, err
+	case recordProcInst:
+		tkn := &r.innerData.procInst
+
+		if tkn.Target, err = r.readName(); err != nil {
+			return nil, err
+		}
+
+		tkn.Inst, err = r.readBytes()
+
+		return tkn, err
+	default:
+		return nil, fmt.Errorf("%w: unknown record kind %d", ErrInvalidTokenStream, kind)
+	}
+}
+
+func (r *TokenStreamReader) readName() (string, error) {
+	idx, err := r.readUvarint()
+	if err != nil {
+		return "", err
+	}
+
+	if idx != 0 {
+		if idx > uint64(len(r.names)) {
+			return "", fmt.Errorf("%w: unknown name index %d", ErrInvalidTokenStream, idx)
+		}
+
+		return r.names[idx-1], nil
+	}
+
+	name, err := r.readBytes()
+	if err != nil {
+		return "", err
+	}
+
+	r.names = append(r.names, unsafeByteToString(name))
+
+	return r.names[len(r.names)-1], nil
+}
+
+func (r *TokenStreamReader) readBytes() ([]byte, error) {
+	n, err := r.readUvarint()
+	if err != nil {
+		return nil, err
+	}
+
+	if n > uint64(len(r.buf)) {
+		return nil, fmt.Errorf("%w: unexpected end of data", ErrInvalidTokenStream)
+	}
+
+	b := r.buf[:n:n]
+	r.buf = r.buf[n:]
+
+	return b, nil
+}
+
+func (r *TokenStreamReader) readUvarint() (uint64, error) {
+	v, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		return 0, fmt.Errorf("%w: malformed length", ErrInvalidTokenStream)
+	}
+
+	r.buf = r.buf[n:]
+
+	return v, nil
+}
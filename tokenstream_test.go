@@ -0,0 +1,76 @@
+package fastxml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenStream(t *testing.T) {
+	input := []byte(`<?xml version="1.0"?><!DOCTYPE a><a x="1&amp;2"><!--c--><b>text</b><b/><![CDATA[<c>]]></a>`)
+
+	var buf bytes.Buffer
+
+	w := NewTokenStreamWriter(&buf)
+	n, err := Copy(w, NewParser(input, false))
+	require.NoError(t, err)
+	require.NoError(t, w.Flush())
+
+	mustTokens, err := Tokens(input)
+	require.NoError(t, err)
+	require.Len(t, mustTokens, n)
+
+	r, err := NewTokenStreamReader(buf.Bytes())
+	require.NoError(t, err)
+
+	var tokens []xml.Token
+
+	for {
+		token, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+
+		tokens = append(tokens, CopyToken(token))
+	}
+
+	assert.Equal(t, mustTokens, tokens)
+
+	name, val, err := tokens[0].(*StartToken).NextAttribute()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"x", "1&amp;2"}, []string{name, val})
+
+	assert.Equal(t, 1, bytes.Count(buf.Bytes(), []byte("b")), "names must be written once")
+}
+
+func TestTokenStream_Invalid(t *testing.T) {
+	_, err := NewTokenStreamReader([]byte("<a/>"))
+	assert.ErrorIs(t, err, ErrInvalidTokenStream)
+
+	var buf bytes.Buffer
+
+	w := NewTokenStreamWriter(&buf)
+	require.NoError(t, w.WriteToken(&StartToken{Name: "a"}))
+	require.NoError(t, w.Flush())
+
+	assert.ErrorIs(t, w.WriteToken(xml.StartElement{}), ErrUnsupportedToken)
+
+	for _, data := range [][]byte{
+		buf.Bytes()[:buf.Len()-1],
+		append(append([]byte{}, tokenStreamMagic...), 0xff),
+		append(append([]byte{}, tokenStreamMagic...), recordEndElement, 5),
+	} {
+		r, err := NewTokenStreamReader(data)
+		require.NoError(t, err)
+
+		_, err = r.Next()
+		assert.ErrorIs(t, err, ErrInvalidTokenStream)
+	}
+}
@@ -0,0 +1,62 @@
+package fastxml
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+// AttributeWarning describes malformed attribute, which was skipped by StartToken.NextAttribute.
+type AttributeWarning struct {
+	// Raw is the skipped part of the tag, up to the next whitespace.
+	// It points to the parser's memory, same as attribute values.
+	Raw string
+	// Err is the error that the attribute was skipped for.
+	Err error
+}
+
+// WithTolerantAttributes makes StartToken.NextAttribute skip malformed attributes, like `a=1`,
+// instead of returning error for them, so one broken attribute does not hide the rest of the tag's data.
+//
+// Malformed attribute is skipped up to the next whitespace, and reading continues from there.
+// Skipped attributes are recorded, see StartToken.AttributeWarnings.
+func WithTolerantAttributes() ParserOption {
+	return func(p *Parser) {
+		p.tolerantAttrs = true
+	}
+}
+
+// AttributeWarnings returns malformed attributes that were skipped by StartToken.NextAttribute so far,
+// if parser was created WithTolerantAttributes.
+//
+// Returned slice is owned by the token: it is only valid until the next call to Parser.Next.
+func (s *StartToken) AttributeWarnings() []AttributeWarning {
+	return s.attrWarnings
+}
+
+// nextTolerantAttribute returns next attribute, skipping malformed ones.
+func (s *StartToken) nextTolerantAttribute() (attrName, attrVal string, err error) {
+	for {
+		attrName, attrVal, err = s.nextAttribute()
+		if err == nil || errors.Is(err, io.EOF) {
+			return attrName, attrVal, err
+		}
+
+		s.skipAttribute(err)
+	}
+}
+
+// skipAttribute skips malformed attribute at the start of attributes buffer up to the next whitespace,
+// and records it with err.
+func (s *StartToken) skipAttribute(err error) {
+	start := NextNonSpaceIndex(s.attrBuf)
+	raw := s.attrBuf[start : start+NextSpaceIndex(s.attrBuf[start:])]
+
+	// End of the tag is kept, so that following calls return io.EOF.
+	if trimmed := bytes.TrimRight(raw, "/>"); len(trimmed) != 0 {
+		raw = trimmed
+	}
+
+	s.attrWarnings = append(s.attrWarnings, AttributeWarning{Raw: unsafeByteToString(raw), Err: err})
+	s.attrBuf = s.attrBuf[start+len(raw):]
+}
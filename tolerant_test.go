@@ -0,0 +1,108 @@
+package fastxml
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTolerantAttributes(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     []ParserOption
+		attrs    []string
+		warnings []string
+	}{
+		{
+			name:     "unquoted value",
+			input:    `<a id="1" size=10 name='x'>`,
+			attrs:    []string{"id=1", "name=x"},
+			warnings: []string{"size=10"},
+		},
+		{
+			name:     "invalid name",
+			input:    `<a -x="1" y="2"/>`,
+			attrs:    []string{"y=2"},
+			warnings: []string{`-x="1"`},
+		},
+		{
+			name:     "last attribute",
+			input:    `<a x="1" y=2/>`,
+			attrs:    []string{"x=1"},
+			warnings: []string{"y=2"},
+		},
+		{
+			name:     "typographic quotes",
+			input:    `<a x="1" y=“2” z="3">`,
+			attrs:    []string{"x=1", "z=3"},
+			warnings: []string{`y=“2”`},
+		},
+		{
+			name:     "html mode",
+			input:    `<input disabled -x value=a type="text">`,
+			opts:     []ParserOption{WithHTMLMode()},
+			attrs:    []string{"disabled=", "type=text"},
+			warnings: []string{"-x", "value=a"},
+		},
+		{
+			name:  "well-formed",
+			input: `<a x="1" y='2'>`,
+			attrs: []string{"x=1", "y=2"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			p := NewParser([]byte(test.input), false, append(test.opts, WithTolerantAttributes())...)
+
+			token, err := p.Next()
+			require.NoError(t, err)
+
+			start := token.(*StartToken)
+
+			var attrs []string
+
+			for {
+				name, val, err := start.NextAttribute()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+
+				require.NoError(t, err)
+
+				attrs = append(attrs, name+"="+val)
+			}
+
+			assert.Equal(t, test.attrs, attrs)
+
+			var warnings []string
+
+			for _, warning := range start.AttributeWarnings() {
+				assert.Error(t, warning.Err)
+
+				warnings = append(warnings, warning.Raw)
+			}
+
+			assert.Equal(t, test.warnings, warnings)
+		})
+	}
+
+	p := NewParser([]byte(`<a x=1><b y=2></b></a>`), false, WithTolerantAttributes())
+
+	token, err := p.Next()
+	require.NoError(t, err)
+
+	_, _, err = token.(*StartToken).NextAttribute()
+	require.ErrorIs(t, err, io.EOF)
+	assert.Len(t, token.(*StartToken).AttributeWarnings(), 1)
+
+	token, err = p.Next()
+	require.NoError(t, err)
+	assert.Empty(t, token.(*StartToken).AttributeWarnings(), "warnings are reset for the next token")
+}
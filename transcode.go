@@ -0,0 +1,190 @@
+package fastxml
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+var ErrUnsupportedEncoding = errors.New("unsupported encoding")
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+
+	declarationPrefix = []byte("<?xml")
+)
+
+// Transcode will re-emit src document as UTF-8 XML to dst.
+//
+// Encoding of src is detected from the byte order mark or the XML declaration.
+// Supported encodings are UTF-8, US-ASCII, ISO-8859-1 and UTF-16 (both byte orders).
+//
+// Output always starts with XML declaration that specifies UTF-8 encoding,
+// version and standalone values of the source declaration are kept.
+// Byte order mark is not written.
+// Processing instructions and DOCTYPE declaration are copied as is,
+// other tokens are written with Encoder.
+func Transcode(dst io.Writer, src []byte) error {
+	buf, err := decodeToUTF8(src)
+	if err != nil {
+		return err
+	}
+
+	enc := NewEncoder(dst)
+	p := NewParser(buf, false)
+
+	writeDeclaration(enc, buf)
+
+	for {
+		start := p.InputOffset()
+
+		token, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if token != nil {
+			if err := enc.WriteToken(token); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		// Not decoded tokens are copied as is, except for the declaration, which was already written.
+		if raw := buf[start:p.InputOffset()]; !isDeclaration(raw) {
+			enc.w.Write(raw)
+		}
+	}
+
+	return enc.Flush()
+}
+
+// writeDeclaration writes UTF-8 declaration with version and standalone values of the declaration in buf.
+func writeDeclaration(enc *Encoder, buf []byte) {
+	version, standalone := "1.0", ""
+
+	if isDeclaration(buf) {
+		if endIdx := bytes.Index(buf, []byte("?>")); endIdx != -1 {
+			if val := declarationValue(buf[:endIdx], "version"); val != "" {
+				version = val
+			}
+
+			standalone = declarationValue(buf[:endIdx], "standalone")
+		}
+	}
+
+	enc.w.WriteString(`<?xml version="`)
+	enc.w.WriteString(version)
+	enc.w.WriteString(`" encoding="UTF-8"`)
+
+	if standalone != "" {
+		enc.w.WriteString(` standalone="`)
+		enc.w.WriteString(standalone)
+		enc.w.WriteByte('"')
+	}
+
+	enc.w.WriteString("?>")
+}
+
+// isDeclaration reports if buf starts with XML declaration.
+func isDeclaration(buf []byte) bool {
+	return bytes.HasPrefix(buf, declarationPrefix) &&
+		len(buf) > len(declarationPrefix) && IsHTMLSpaceChar(rune(buf[len(declarationPrefix)]))
+}
+
+// declarationValue returns value of the pseudo-attribute from the XML declaration.
+func declarationValue(decl []byte, name string) string {
+	idx := bytes.Index(decl, []byte(name))
+	if idx == -1 {
+		return ""
+	}
+
+	rest := decl[idx+len(name):]
+	rest = rest[NextNonSpaceIndex(rest):]
+
+	if len(rest) == 0 || rest[0] != '=' {
+		return ""
+	}
+
+	val, _, err := NextQuotedWord(rest[1:])
+	if err != nil {
+		return ""
+	}
+
+	return val
+}
+
+// decodeToUTF8 converts buf to UTF-8, removing byte order mark.
+//
+// If buf is already UTF-8 - it is returned without copying.
+func decodeToUTF8(buf []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(buf, bomUTF8):
+		return buf[len(bomUTF8):], nil
+	case bytes.HasPrefix(buf, bomUTF16BE):
+		return decodeUTF16(buf[len(bomUTF16BE):], binary.BigEndian)
+	case bytes.HasPrefix(buf, bomUTF16LE):
+		return decodeUTF16(buf[len(bomUTF16LE):], binary.LittleEndian)
+	case bytes.HasPrefix(buf, []byte{0, '<', 0, '?'}):
+		return decodeUTF16(buf, binary.BigEndian)
+	case bytes.HasPrefix(buf, []byte{'<', 0, '?', 0}):
+		return decodeUTF16(buf, binary.LittleEndian)
+	}
+
+	var encoding string
+
+	if isDeclaration(buf) {
+		if endIdx := bytes.Index(buf, []byte("?>")); endIdx != -1 {
+			encoding = strings.ToLower(declarationValue(buf[:endIdx], "encoding"))
+		}
+	}
+
+	switch encoding {
+	case "", "utf-8", "utf8", "us-ascii", "ascii":
+		return buf, nil
+	case "iso-8859-1", "iso8859-1", "latin1", "latin-1", "l1":
+		return decodeLatin1(buf), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedEncoding, encoding)
+	}
+}
+
+func decodeUTF16(buf []byte, order binary.ByteOrder) ([]byte, error) {
+	if len(buf)%2 != 0 {
+		return nil, fmt.Errorf("decode UTF-16: odd number of bytes: %w", io.ErrUnexpectedEOF)
+	}
+
+	units := make([]uint16, len(buf)/2)
+	for i := range units {
+		units[i] = order.Uint16(buf[i*2:])
+	}
+
+	runes := utf16.Decode(units)
+
+	out := make([]byte, 0, len(runes))
+	for _, rn := range runes {
+		out = append(out, string(rn)...)
+	}
+
+	return out, nil
+}
+
+func decodeLatin1(buf []byte) []byte {
+	out := make([]byte, 0, len(buf))
+	for _, b := range buf {
+		out = append(out, string(rune(b))...)
+	}
+
+	return out
+}
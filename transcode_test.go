@@ -0,0 +1,74 @@
+package fastxml
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranscode(t *testing.T) {
+	mustResult := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><!DOCTYPE a><?pi data?><a x="é">café</a>`
+
+	source := `<?xml version="1.0" encoding="%s" standalone="yes"?><!DOCTYPE a><?pi data?><a x="é">café</a>`
+
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{"utf-8", []byte(fmt.Sprintf(source, "UTF-8"))},
+		{"utf-8 bom", append(append([]byte(nil), bomUTF8...), fmt.Sprintf(source, "utf-8")...)},
+		{"latin1", latin1(fmt.Sprintf(source, "ISO-8859-1"))},
+		{"utf-16le bom", append(append([]byte(nil), bomUTF16LE...), utf16Bytes(fmt.Sprintf(source, "UTF-16"), binary.LittleEndian)...)},
+		{"utf-16be", utf16Bytes(fmt.Sprintf(source, "UTF-16"), binary.BigEndian)},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			require.NoError(t, Transcode(&buf, test.input))
+			assert.Equal(t, mustResult, buf.String())
+		})
+	}
+}
+
+func TestTranscode_NoDeclaration(t *testing.T) {
+	var buf bytes.Buffer
+
+	require.NoError(t, Transcode(&buf, []byte(`<a/>`)))
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?><a></a>`, buf.String())
+}
+
+func TestTranscode_UnsupportedEncoding(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := Transcode(&buf, []byte(`<?xml version="1.0" encoding="Shift_JIS"?><a/>`))
+	assert.ErrorIs(t, err, ErrUnsupportedEncoding)
+}
+
+func latin1(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, rn := range s {
+		out = append(out, byte(rn))
+	}
+
+	return out
+}
+
+func utf16Bytes(s string, order binary.ByteOrder) []byte {
+	units := utf16.Encode([]rune(s))
+
+	out := make([]byte, len(units)*2)
+	for i, unit := range units {
+		order.PutUint16(out[i*2:], unit)
+	}
+
+	return out
+}
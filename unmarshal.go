@@ -0,0 +1,1383 @@
+package fastxml
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrUnsupportedType is returned when element or attribute is mapped to a field of type that can not be decoded.
+	ErrUnsupportedType = errors.New("unsupported type")
+	// ErrInvalidValue is returned when text of element or attribute can not be parsed as value of the field.
+	ErrInvalidValue = errors.New("invalid value")
+	// ErrUnexpectedElement is returned when name of decoded element does not match the name from XMLName field.
+	ErrUnexpectedElement = errors.New("unexpected element")
+	// ErrUnknownField is returned when element or attribute is not mapped to any field,
+	// if decoder was created with WithDisallowUnknownFields.
+	ErrUnknownField = errors.New("unknown field")
+	// ErrMissingField is returned when element or attribute of the field with `required` tag option is missing.
+	ErrMissingField = errors.New("missing required field")
+	// ErrPathDecoderEnd is returned when decoder registered with WithPathDecoder
+	// did not advance parser exactly past the end of decoded element.
+	ErrPathDecoderEnd = errors.New("path decoder did not read element until its end")
+)
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	xmlNameType         = reflect.TypeOf(xml.Name{})
+	xmlAttrType         = reflect.TypeOf(xml.Attr{})
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+)
+
+// structInfoCache holds *structInfo by reflect.Type of the struct.
+var structInfoCache sync.Map
+
+// DecodeError is returned when element or attribute can not be decoded.
+type DecodeError struct {
+	// Path is the path of local names of elements from decoded element, like "/order/items/item".
+	// Attributes are appended with '@' prefix, like "/order/@id".
+	Path string
+	Err  error
+}
+
+func (e *DecodeError) Error() string {
+	return "decode " + e.Path + ": " + e.Err.Error()
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeOption configures ElementDecoder.
+type DecodeOption func(d *ElementDecoder)
+
+// ElementDecoder decodes elements read by the parser into Go values.
+//
+// Struct fields are mapped with the same `xml` struct tags as in encoding/xml,
+// with names matched by their local part, and by namespace if it is set in the tag, like `xml:"urn:x name"`:
+//   - `xml:"name"` - child element, untagged exported fields are child elements named after the field;
+//   - `xml:"name,attr"` - attribute;
+//   - `xml:",chardata"` - text of the element;
+//   - `xml:",innerxml"` - raw content of the element;
+//   - `xml:",comment"` - text of all comments of the element, which are direct children of it;
+//   - `xml:",any"` - child elements that are not mapped to other fields;
+//   - `xml:",anyattr"` or `xml:",any,attr"` - attributes that are not mapped to other fields,
+//     into map[string]string keyed by qualified names, or into []xml.Attr with prefixes as namespaces;
+//   - `xml:"-"` - field is ignored;
+//   - `base64` or `hex` option, like `xml:"data,base64"` - text of []byte field is decoded, ignoring whitespace;
+//   - `required` option, like `xml:"name,required"` - element or attribute must be present,
+//     otherwise ErrMissingField listing all missing ones is returned;
+//   - XMLName field of xml.Name type receives name of the element, and verifies it if tag has a name.
+//
+// Namespaces are resolved with declarations of the decoded element and of elements decoded around it,
+// declarations of ancestors of the element passed to ElementDecoder.DecodeElement are not known.
+// Same as in encoding/xml, unprefixed attributes have no namespace, and unknown prefix is used as namespace.
+//
+// Maps with string keys receive child elements by their local names.
+// Values implementing encoding.TextUnmarshaler are decoded from text.
+//
+// time.Time values are parsed as xs:dateTime, or with layout from `layout` struct tag,
+// like `xml:"date" layout:"2006-01-02"`, or with layouts set by WithTimeLayouts.
+// time.Duration values are parsed as xs:duration, like "PT1H30M", or in Go format, like "1h30m".
+//
+// Decoding into existing value reuses it: slices are truncated and refilled, keeping their
+// capacity and elements, maps are cleared and other fields are reset before decoding.
+// So decoding of many records into the same value allocates only for strings and for growth of slices.
+//
+// Parser must hold the whole decoded element, stream parsers that need more data are not supported.
+type ElementDecoder struct {
+	p *Parser
+	// path holds local names of elements that are being decoded.
+	path []string
+	// starts hold start elements that are being decoded, to resolve namespaces declared by them.
+	starts []StartToken
+	// pathBuf holds path of the element that is being decoded, like "/order/items/item".
+	pathBuf []byte
+	// pathDecoders holds decoders registered for paths of elements.
+	pathDecoders map[string]PathDecoderFunc
+	// text holds text of the element that is being decoded.
+	text []byte
+	// seen holds presence of fields of decoded structs that have required fields.
+	seen []bool
+	// timeLayouts are layouts of time.Time values for fields without `layout` tag.
+	timeLayouts []string
+	// aliases hold names of elements and attributes by their aliases.
+	aliases map[string]string
+	// disallowUnknownFields enables errors for elements and attributes that are not mapped to fields.
+	disallowUnknownFields bool
+}
+
+// WithDisallowUnknownFields makes decoder return ErrUnknownField for elements and attributes
+// of the document that are not mapped to any field of decoded struct,
+// as well as for child elements of values decoded from text.
+//
+// Namespace declarations and attributes with `xml` prefix are always allowed.
+func WithDisallowUnknownFields() DecodeOption {
+	return func(d *ElementDecoder) {
+		d.disallowUnknownFields = true
+	}
+}
+
+// WithTimeLayouts sets layouts that time.Time values are parsed with, for fields without `layout` tag.
+// Layouts are tried in order, until one matches.
+//
+// By default values are parsed as xs:dateTime, which includes RFC 3339 values.
+func WithTimeLayouts(layouts ...string) DecodeOption {
+	return func(d *ElementDecoder) {
+		d.timeLayouts = layouts
+	}
+}
+
+// WithAlias makes decoder read elements and attributes with alias name as if they had the name,
+// like `WithAlias("customer_id", "customerId")`. This allows to accept documents of multiple
+// versions of the schema, where names were changed, with one struct definition.
+//
+// Names are local ones, without namespace prefixes. Alias applies to elements and attributes
+// on any level of the document, including keys of maps, XMLName fields and paths of path decoders,
+// which all see the name instead of the alias. Elements with the name itself are decoded as usual.
+func WithAlias(alias, name string) DecodeOption {
+	return func(d *ElementDecoder) {
+		if d.aliases == nil {
+			d.aliases = make(map[string]string)
+		}
+
+		d.aliases[alias] = name
+	}
+}
+
+// PathDecoderFunc decodes element, which start was just read by p, and returns the value of the element.
+//
+// Function MUST advance parser past the end of the element.
+// Returned value, or value it points to, is stored in the field the element is mapped to,
+// or appended to it if field is a slice. Nil value stores zero value.
+type PathDecoderFunc func(p *Parser, start *StartToken) (interface{}, error)
+
+// WithPathDecoder registers fn to decode elements with given path instead of automatic decoding.
+//
+// Path consists of local names of elements starting from the decoded element, like "/order/items/item".
+// This allows to use hand-written decoding for hot paths of the document,
+// while the rest of it is decoded automatically.
+func WithPathDecoder(path string, fn PathDecoderFunc) DecodeOption {
+	return func(d *ElementDecoder) {
+		if d.pathDecoders == nil {
+			d.pathDecoders = make(map[string]PathDecoderFunc)
+		}
+
+		d.pathDecoders[path] = fn
+	}
+}
+
+// NewElementDecoder will create a decoder of elements read by p.
+func NewElementDecoder(p *Parser, opts ...DecodeOption) *ElementDecoder {
+	d := &ElementDecoder{p: p}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
+}
+
+// Unmarshal will decode the root element of the document into v, which must be a non-nil pointer.
+func Unmarshal(buf []byte, v interface{}, opts ...DecodeOption) error {
+	return NewElementDecoder(NewParser(buf, false), opts...).DecodeElement(v, nil)
+}
+
+// DecodeElement will decode the element into v, which must be a non-nil pointer.
+//
+// start must be the start element just returned by the parser.
+// If start is nil - next start element of the parser is decoded.
+// Parser is advanced past the end of decoded element.
+func (d *ElementDecoder) DecodeElement(v interface{}, start *StartToken) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("%w: non-pointer or nil %T", ErrUnsupportedType, v)
+	}
+
+	for start == nil {
+		token, err := d.p.Next()
+		if err != nil {
+			return err
+		}
+
+		start, _ = token.(*StartToken)
+	}
+
+	d.path = d.path[:0]
+	d.pathBuf = d.pathBuf[:0]
+	d.starts = d.starts[:0]
+
+	return d.element(rv.Elem(), start, nil)
+}
+
+// element decodes element, which start was just read, into v.
+// field is the struct field v belongs to, or nil if v is not a field.
+func (d *ElementDecoder) element(v reflect.Value, start *StartToken, field *fieldInfo) error {
+	v = indirect(v)
+
+	if v.Kind() == reflect.Slice && !isTextType(v.Type()) {
+		v = indirect(appendElem(v))
+	}
+
+	d.pushPath(d.localName(start.Name))
+	d.starts = append(d.starts, *start)
+
+	var err error
+
+	switch {
+	case d.pathDecoders != nil && d.pathDecoders[string(d.pathBuf)] != nil:
+		err = d.pathElement(v, start, d.pathDecoders[string(d.pathBuf)])
+	case isTextType(v.Type()):
+		err = d.textElement(v, field)
+	case v.Kind() == reflect.Struct:
+		err = d.structElement(v, start)
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		err = d.mapElement(v)
+	default:
+		err = d.fail(fmt.Errorf("%w: %s", ErrUnsupportedType, v.Type()))
+	}
+
+	d.popPath()
+	d.starts = d.starts[:len(d.starts)-1]
+
+	return err
+}
+
+// pathElement decodes element with decoder registered for its path.
+func (d *ElementDecoder) pathElement(v reflect.Value, start *StartToken, fn PathDecoderFunc) error {
+	depth := d.p.Depth()
+
+	value, err := fn(d.p, start)
+	if err != nil {
+		return d.fail(err)
+	}
+
+	if d.p.Depth() != depth-1 {
+		return d.fail(ErrPathDecoderEnd)
+	}
+
+	rv := reflect.ValueOf(value)
+
+	switch {
+	case !rv.IsValid():
+		v.Set(reflect.Zero(v.Type()))
+	case rv.Type().AssignableTo(v.Type()):
+		v.Set(rv)
+	case rv.Kind() == reflect.Ptr && rv.Type().Elem().AssignableTo(v.Type()) && !rv.IsNil():
+		v.Set(rv.Elem())
+	default:
+		return d.fail(fmt.Errorf("%w: path decoder returned %s for %s", ErrUnsupportedType, rv.Type(), v.Type()))
+	}
+
+	return nil
+}
+
+func (d *ElementDecoder) textElement(v reflect.Value, field *fieldInfo) error {
+	text, err := d.readText()
+	if err != nil {
+		return err
+	}
+
+	if err := d.setText(v, text, field); err != nil {
+		return d.fail(err)
+	}
+
+	return nil
+}
+
+func (d *ElementDecoder) structElement(v reflect.Value, start *StartToken) error {
+	info, err := getStructInfo(v.Type())
+	if err != nil {
+		return d.fail(err)
+	}
+
+	local := d.path[len(d.path)-1]
+
+	if xmlName := info.xmlName; xmlName != nil && xmlName.name != "" {
+		if xmlName.name != local {
+			return d.fail(fmt.Errorf("%w: expected <%s>", ErrUnexpectedElement, xmlName.name))
+		}
+
+		if xmlName.space != "" && xmlName.space != d.namespace(start.Name, start, false) {
+			return d.fail(fmt.Errorf("%w: expected <%s> in namespace %s",
+				ErrUnexpectedElement, xmlName.name, xmlName.space))
+		}
+	}
+
+	resetStruct(v, info)
+
+	if info.xmlName != nil {
+		fieldByIndex(v, info.xmlName.index).Set(reflect.ValueOf(xml.Name{Local: local}))
+	}
+
+	// seen marks fields that were present in the element, if there are required fields.
+	var seen []bool
+
+	if info.required != 0 {
+		base := len(d.seen)
+
+		for range info.fields {
+			d.seen = append(d.seen, false)
+		}
+
+		seen = d.seen[base:len(d.seen):len(d.seen)]
+
+		defer func() { d.seen = d.seen[:base] }()
+	}
+
+	if err := d.attributes(v, info, start, seen); err != nil {
+		return err
+	}
+
+	if err := d.structContent(v, info, seen); err != nil {
+		return err
+	}
+
+	return d.checkRequired(info, seen)
+}
+
+// structContent decodes content of the element into fields of v and advances parser past its end.
+func (d *ElementDecoder) structContent(v reflect.Value, info *structInfo, seen []bool) error {
+	var (
+		charData   []byte
+		comment    []byte
+		innerStart = d.p.currentPointer
+	)
+
+	for {
+		cdata := d.atCDATA()
+		innerEnd := d.p.currentPointer
+
+		token, err := d.p.Next()
+		if err != nil {
+			return d.fail(err)
+		}
+
+		switch tkn := token.(type) {
+		case *StartToken:
+			field := d.field(info.elements, tkn, tkn.Name, false)
+			if field == nil {
+				field = info.any
+			}
+
+			if field == nil {
+				if err := d.unknownElement(tkn); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			if seen != nil {
+				seen[field.pos] = true
+			}
+
+			if err := d.element(fieldByIndex(v, field.index), tkn, field); err != nil {
+				return err
+			}
+		case *CharData:
+			if info.charData != nil {
+				charData = appendText(charData, *tkn, cdata)
+			}
+		case *Comment:
+			if info.comment != nil {
+				comment = append(comment, *tkn...)
+			}
+		case *EndElement:
+			if info.charData != nil {
+				field := info.charData
+
+				if err := d.setText(indirect(fieldByIndex(v, field.index)), charData, field); err != nil {
+					return d.fail(err)
+				}
+			}
+
+			if info.comment != nil {
+				if err := setText(indirect(fieldByIndex(v, info.comment.index)), comment); err != nil {
+					return d.fail(err)
+				}
+			}
+
+			if info.innerXML != nil {
+				innerXML := d.p.buf[innerStart:innerEnd]
+
+				if err := setText(indirect(fieldByIndex(v, info.innerXML.index)), innerXML); err != nil {
+					return d.fail(err)
+				}
+			}
+
+			return nil
+		}
+	}
+}
+
+// attributes decodes attributes of start element into fields of v.
+func (d *ElementDecoder) attributes(v reflect.Value, info *structInfo, start *StartToken, seen []bool) error {
+	for {
+		name, value, err := start.NextAttribute()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return d.fail(err)
+		}
+
+		local := d.localName(name)
+
+		field := d.field(info.attrs, start, name, true)
+		if field == nil && info.anyAttr != nil {
+			addAnyAttr(fieldByIndex(v, info.anyAttr.index), name, value)
+
+			continue
+		}
+
+		if field == nil {
+			if d.disallowUnknownFields && !isReservedAttribute(name) {
+				return d.failAttr(local, ErrUnknownField)
+			}
+
+			continue
+		}
+
+		if seen != nil {
+			seen[field.pos] = true
+		}
+
+		text := unsafeStringToByte(Unescape(value))
+
+		if err := d.setText(indirect(fieldByIndex(v, field.index)), text, field); err != nil {
+			return d.failAttr(local, err)
+		}
+	}
+}
+
+// addAnyAttr adds attribute to the map or []xml.Attr field.
+//
+// Map keys are qualified names of attributes, like "xlink:href".
+// Prefix of the name is set as the namespace of xml.Attr, as namespaces are not resolved.
+func addAnyAttr(v reflect.Value, name, value string) {
+	value = CopyString(Unescape(value))
+
+	if v.Kind() == reflect.Map {
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+
+		key := reflect.ValueOf(CopyString(name)).Convert(v.Type().Key())
+		v.SetMapIndex(key, reflect.ValueOf(value).Convert(v.Type().Elem()))
+
+		return
+	}
+
+	attr := xml.Attr{Name: xml.Name{Local: CopyString(localName(name))}, Value: value}
+	if idx := strings.IndexByte(name, ':'); idx != -1 {
+		attr.Name.Space = CopyString(name[:idx])
+	}
+
+	v.Set(reflect.Append(v, reflect.ValueOf(attr)))
+}
+
+// checkRequired returns error listing required fields that were not seen.
+func (d *ElementDecoder) checkRequired(info *structInfo, seen []bool) error {
+	var missing []string
+
+	for _, field := range info.fields {
+		if !field.required || seen[field.pos] {
+			continue
+		}
+
+		if field.kind == fieldAttr {
+			missing = append(missing, "@"+field.name)
+		} else {
+			missing = append(missing, field.name)
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	return d.fail(fmt.Errorf("%w: %s", ErrMissingField, strings.Join(missing, ", ")))
+}
+
+// mapElement decodes child elements into map entries by their local names.
+func (d *ElementDecoder) mapElement(v reflect.Value) error {
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(v.Type()))
+	}
+
+	for {
+		token, err := d.p.Next()
+		if err != nil {
+			return d.fail(err)
+		}
+
+		switch tkn := token.(type) {
+		case *StartToken:
+			key := reflect.ValueOf(d.localName(tkn.Name)).Convert(v.Type().Key())
+			elem := reflect.New(v.Type().Elem()).Elem()
+
+			if err := d.element(elem, tkn, nil); err != nil {
+				return err
+			}
+
+			v.SetMapIndex(key, elem)
+		case *EndElement:
+			return nil
+		}
+	}
+}
+
+// readText returns text of the element, which start was just read, and advances parser past its end.
+// Nested elements are skipped.
+//
+// Returned text is only valid until next read.
+func (d *ElementDecoder) readText() ([]byte, error) {
+	d.text = d.text[:0]
+
+	for {
+		cdata := d.atCDATA()
+
+		token, err := d.p.Next()
+		if err != nil {
+			return nil, d.fail(err)
+		}
+
+		switch tkn := token.(type) {
+		case *CharData:
+			d.text = appendText(d.text, *tkn, cdata)
+		case *StartToken:
+			if err := d.unknownElement(tkn); err != nil {
+				return nil, err
+			}
+		case *EndElement:
+			return d.text, nil
+		}
+	}
+}
+
+// unknownElement skips element that is not mapped to any field,
+// or returns error if unknown fields are not allowed.
+func (d *ElementDecoder) unknownElement(start *StartToken) error {
+	if d.disallowUnknownFields {
+		d.pushPath(d.localName(start.Name))
+		err := d.fail(ErrUnknownField)
+		d.popPath()
+
+		return err
+	}
+
+	return d.skipElement()
+}
+
+// skipElement will advance parser past the end of the element, which start was just read.
+func (d *ElementDecoder) skipElement() error {
+	for depth := 1; depth > 0; {
+		token, err := d.p.Next()
+		if err != nil {
+			return d.fail(err)
+		}
+
+		switch token.(type) {
+		case *StartToken:
+			depth++
+		case *EndElement:
+			depth--
+		}
+	}
+
+	return nil
+}
+
+// atCDATA reports if next token of the parser is CDATA section.
+func (d *ElementDecoder) atCDATA() bool {
+	return d.p.currentPointer < len(d.p.buf) && bytes.HasPrefix(d.p.buf[d.p.currentPointer:], cdataPrefix)
+}
+
+// setText sets value of v from text, parsing time values with layouts of the field or of the decoder.
+func (d *ElementDecoder) setText(v reflect.Value, text []byte, field *fieldInfo) error {
+	if field != nil && field.encoding != binaryRaw && v.Kind() == reflect.Slice {
+		return setBinary(v, text, field.encoding)
+	}
+
+	switch v.Type() {
+	case timeType:
+		layouts := d.timeLayouts
+		if field != nil && field.layout != "" {
+			layouts = []string{field.layout}
+		}
+
+		return setTime(v, text, layouts)
+	case durationType:
+		return setDuration(v, text)
+	default:
+		return setText(v, text)
+	}
+}
+
+// field returns the first of the fields mapped to the local part of the name,
+// which namespace is not set or is the namespace of the name.
+func (d *ElementDecoder) field(fields map[string]*fieldInfo, start *StartToken, name string, isAttr bool) *fieldInfo {
+	for field := fields[d.localName(name)]; field != nil; field = field.next {
+		if field.space == "" || field.space == d.namespace(name, start, isAttr) {
+			return field
+		}
+	}
+
+	return nil
+}
+
+// namespace returns namespace of the element or attribute name of start,
+// resolved with declarations of start and of the elements that are being decoded.
+func (d *ElementDecoder) namespace(name string, start *StartToken, isAttr bool) string {
+	var prefix string
+
+	switch idx := strings.IndexByte(name, ':'); {
+	case idx != -1:
+		prefix = name[:idx]
+	case isAttr:
+		return ""
+	}
+
+	if prefix == xmlPrefix {
+		return xmlURL
+	}
+
+	if uri, ok := declaredNamespace(start, prefix); ok {
+		return uri
+	}
+
+	for i := len(d.starts) - 1; i >= 0; i-- {
+		if uri, ok := declaredNamespace(&d.starts[i], prefix); ok {
+			return uri
+		}
+	}
+
+	return prefix
+}
+
+// declaredNamespace returns namespace that is bound to prefix by the start element, if it declares it.
+func declaredNamespace(start *StartToken, prefix string) (string, bool) {
+	// Attributes are read from the copy to not consume them from the caller's token.
+	attrs := *start
+
+	for {
+		name, val, err := attrs.NextAttribute()
+		if err != nil {
+			return "", false
+		}
+
+		if prefix == "" && name == xmlnsPrefix ||
+			prefix != "" && strings.HasPrefix(name, xmlnsPrefix+":") && name[len(xmlnsPrefix)+1:] == prefix {
+			return Unescape(val), true
+		}
+	}
+}
+
+// localName returns local part of the element or attribute name, with alias replaced by the name.
+func (d *ElementDecoder) localName(name string) string {
+	local := localName(name)
+
+	if name, ok := d.aliases[local]; ok {
+		return name
+	}
+
+	return local
+}
+
+func (d *ElementDecoder) pushPath(name string) {
+	d.path = append(d.path, name)
+	d.pathBuf = append(append(d.pathBuf, '/'), name...)
+}
+
+func (d *ElementDecoder) popPath() {
+	name := d.path[len(d.path)-1]
+
+	d.path = d.path[:len(d.path)-1]
+	d.pathBuf = d.pathBuf[:len(d.pathBuf)-len(name)-1]
+}
+
+func (d *ElementDecoder) fail(err error) error {
+	return &DecodeError{Path: string(d.pathBuf), Err: err}
+}
+
+func (d *ElementDecoder) failAttr(name string, err error) error {
+	return &DecodeError{Path: string(d.pathBuf) + "/@" + name, Err: err}
+}
+
+// isReservedAttribute reports if attribute is a namespace declaration or has `xml` prefix.
+func isReservedAttribute(name string) bool {
+	return name == xmlnsPrefix || strings.HasPrefix(name, xmlnsPrefix+":") || strings.HasPrefix(name, xmlPrefix+":")
+}
+
+// appendText appends char data to text, replacing references if data is not CDATA section.
+func appendText(text, data []byte, cdata bool) []byte {
+	if cdata || bytes.IndexByte(data, '&') == -1 {
+		return append(text, data...)
+	}
+
+	return append(text, Unescape(string(data))...)
+}
+
+// setText sets value of v parsed from text.
+func setText(v reflect.Value, text []byte) error { //nolint:gocyclo,cyclop // Flat switch over kinds.
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText(text)
+	}
+
+	invalid := func() error {
+		return fmt.Errorf("%w: %q as %s", ErrInvalidValue, text, v.Type())
+	}
+
+	// Trimmed text is only parsed, so it does not need to be copied.
+	trimmed := unsafeByteToString(bytes.TrimSpace(text))
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(string(text))
+	case reflect.Bool:
+		if trimmed == "" {
+			v.SetBool(false)
+
+			return nil
+		}
+
+		b, err := ParseXSBoolean(trimmed)
+		if err != nil {
+			return invalid()
+		}
+
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if trimmed == "" {
+			v.SetInt(0)
+
+			return nil
+		}
+
+		i, err := strconv.ParseInt(trimmed, 10, v.Type().Bits())
+		if err != nil {
+			return invalid()
+		}
+
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if trimmed == "" {
+			v.SetUint(0)
+
+			return nil
+		}
+
+		u, err := strconv.ParseUint(trimmed, 10, v.Type().Bits())
+		if err != nil {
+			return invalid()
+		}
+
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		if trimmed == "" {
+			v.SetFloat(0)
+
+			return nil
+		}
+
+		f, err := strconv.ParseFloat(trimmed, v.Type().Bits())
+		if err != nil {
+			return invalid()
+		}
+
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("%w: %s", ErrUnsupportedType, v.Type())
+		}
+
+		v.SetBytes(append(v.Bytes()[:0], text...))
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedType, v.Type())
+	}
+
+	return nil
+}
+
+// setTime parses text with the first matching layout, or as xs:dateTime if no layouts are given.
+// Empty text sets zero time.
+func setTime(v reflect.Value, text []byte, layouts []string) error {
+	trimmed := string(bytes.TrimSpace(text))
+	if trimmed == "" {
+		v.Set(reflect.Zero(v.Type()))
+
+		return nil
+	}
+
+	if len(layouts) == 0 {
+		t, err := ParseXSDateTime(trimmed)
+		if err != nil {
+			return fmt.Errorf("%w: %q as %s", ErrInvalidValue, text, v.Type())
+		}
+
+		v.Set(reflect.ValueOf(t))
+
+		return nil
+	}
+
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, trimmed); err == nil {
+			v.Set(reflect.ValueOf(t))
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %q does not match layouts %q", ErrInvalidValue, text, layouts)
+}
+
+// setDuration parses text as xs:duration, like "PT1M30S", or as Go duration, like "1m30s".
+// Empty text sets zero duration.
+func setDuration(v reflect.Value, text []byte) error {
+	trimmed := unsafeByteToString(bytes.TrimSpace(text))
+	if trimmed == "" {
+		v.SetInt(0)
+
+		return nil
+	}
+
+	var (
+		d   time.Duration
+		err error
+	)
+
+	if strings.HasPrefix(trimmed, "P") || strings.HasPrefix(trimmed, "-P") {
+		d, err = ParseXSDuration(trimmed)
+	} else {
+		d, err = time.ParseDuration(trimmed)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: %q as %s", ErrInvalidValue, text, v.Type())
+	}
+
+	v.SetInt(int64(d))
+
+	return nil
+}
+
+// setBinary decodes base64 or hex text into []byte value, ignoring whitespace.
+//
+// Text is decoded in chunks directly into the value, reusing its capacity,
+// so whitespace is skipped without copying the whole text.
+func setBinary(v reflect.Value, text []byte, enc binaryEncoding) error {
+	var (
+		// Size of the chunk is a multiple of both base64 quantum and hex pair.
+		chunk [1024]byte
+		n     int
+		dst   = v.Bytes()[:0]
+	)
+
+	if maxLen := enc.decodedLen(len(text)); cap(dst) < maxLen {
+		dst = make([]byte, 0, maxLen)
+	}
+
+	flush := func() error {
+		var (
+			written int
+			err     error
+		)
+
+		free := dst[len(dst):cap(dst)]
+
+		if enc == binaryBase64 {
+			written, err = base64.StdEncoding.Decode(free, chunk[:n])
+		} else {
+			written, err = hex.Decode(free, chunk[:n])
+		}
+
+		// Offsets reported by decoders are relative to the chunk, so they are not exposed.
+		if err != nil {
+			return fmt.Errorf("%w: malformed %s text", ErrInvalidValue, enc)
+		}
+
+		dst, n = dst[:len(dst)+written], 0
+
+		return nil
+	}
+
+	for _, c := range text {
+		if IsHTMLSpaceChar(rune(c)) {
+			continue
+		}
+
+		if n == len(chunk) {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+
+		chunk[n] = c
+		n++
+	}
+
+	if n != 0 {
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+
+	v.SetBytes(dst)
+
+	return nil
+}
+
+// isTextType reports if value of type t is decoded from text.
+func isTextType(t reflect.Type) bool {
+	if reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8
+	default:
+		return false
+	}
+}
+
+// indirect returns value that v points to, allocating nil pointers.
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+
+		v = v.Elem()
+	}
+
+	return v
+}
+
+// appendElem extends slice by one element and returns it.
+// Element from the capacity of the slice is reused after reset.
+func appendElem(v reflect.Value) reflect.Value {
+	n := v.Len()
+
+	if n < v.Cap() {
+		v.SetLen(n + 1)
+
+		elem := v.Index(n)
+		resetValue(elem)
+
+		return elem
+	}
+
+	v.Set(reflect.Append(v, reflect.Zero(v.Type().Elem())))
+
+	return v.Index(n)
+}
+
+// resetValue resets v to zero value, keeping memory of slices, maps and structs for reuse.
+func resetValue(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Slice:
+		if !v.IsNil() {
+			v.SetLen(0)
+		}
+	case reflect.Map:
+		if !v.IsNil() {
+			for iter := v.MapRange(); iter.Next(); {
+				v.SetMapIndex(iter.Key(), reflect.Value{})
+			}
+		}
+	case reflect.Struct:
+		if isTextType(v.Type()) {
+			v.Set(reflect.Zero(v.Type()))
+
+			return
+		}
+
+		if info, err := getStructInfo(v.Type()); err == nil {
+			resetStruct(v, info)
+		}
+	default:
+		v.Set(reflect.Zero(v.Type()))
+	}
+}
+
+// resetStruct resets mapped fields of the struct.
+func resetStruct(v reflect.Value, info *structInfo) {
+	for _, field := range info.fields {
+		if fv, ok := existingFieldByIndex(v, field.index); ok {
+			resetValue(fv)
+		}
+	}
+}
+
+// fieldByIndex returns nested field of v, allocating nil embedded pointers.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 {
+			v = indirect(v)
+		}
+
+		v = v.Field(x)
+	}
+
+	return v
+}
+
+// existingFieldByIndex returns nested field of v, if it is not inside of nil embedded pointer.
+func existingFieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+
+			v = v.Elem()
+		}
+
+		v = v.Field(x)
+	}
+
+	return v, true
+}
+
+type fieldKind uint8
+
+const (
+	fieldElement fieldKind = iota
+	fieldAttr
+	fieldCharData
+	fieldInnerXML
+	fieldAny
+	fieldAnyAttr
+	fieldComment
+	fieldXMLName
+)
+
+// binaryEncoding is the encoding of text of []byte fields, set by tag options.
+type binaryEncoding uint8
+
+const (
+	binaryRaw binaryEncoding = iota
+	binaryBase64
+	binaryHex
+)
+
+func (e binaryEncoding) String() string {
+	if e == binaryBase64 {
+		return "base64"
+	}
+
+	return "hex"
+}
+
+// decodedLen returns maximum length of data decoded from text of n bytes.
+func (e binaryEncoding) decodedLen(n int) int {
+	if e == binaryBase64 {
+		return base64.StdEncoding.DecodedLen(n + 3)
+	}
+
+	return hex.DecodedLen(n)
+}
+
+// fieldInfo describes mapping of struct field.
+type fieldInfo struct {
+	index []int
+	name  string
+	// space is the namespace from the tag, like "urn:x" for `xml:"urn:x name"`.
+	space string
+	kind  fieldKind
+	// pos is the position of the field in structInfo.fields.
+	pos int
+	// required is set for elements and attributes that must be present.
+	required bool
+	// layout is the layout of time.Time values, set from `layout` struct tag.
+	layout string
+	// encoding is the encoding of text of []byte values.
+	encoding binaryEncoding
+	// omitEmpty is set for elements and attributes that are not encoded if their value is empty.
+	omitEmpty bool
+	// cdata is set for char data that is encoded as CDATA section.
+	cdata bool
+	// next is the next field mapped to the same local name in another namespace.
+	next *fieldInfo
+}
+
+// structInfo describes mapping of struct fields to elements and attributes.
+type structInfo struct {
+	fields   []*fieldInfo
+	elements map[string]*fieldInfo
+	attrs    map[string]*fieldInfo
+	charData *fieldInfo
+	innerXML *fieldInfo
+	comment  *fieldInfo
+	any      *fieldInfo
+	anyAttr  *fieldInfo
+	xmlName  *fieldInfo
+	// required is the number of required fields.
+	required int
+}
+
+func getStructInfo(t reflect.Type) (*structInfo, error) {
+	if info, ok := structInfoCache.Load(t); ok {
+		return info.(*structInfo), nil
+	}
+
+	info := &structInfo{
+		elements: map[string]*fieldInfo{},
+		attrs:    map[string]*fieldInfo{},
+	}
+
+	if err := info.addFields(t, nil); err != nil {
+		return nil, err
+	}
+
+	structInfoCache.Store(t, info)
+
+	return info, nil
+}
+
+// addFields adds fields of struct type t, which is embedded at index.
+// Fields of embedded structs are added after direct fields, so direct fields take precedence.
+func (info *structInfo) addFields(t reflect.Type, index []int) error {
+	var embedded []reflect.StructField
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("xml")
+
+		if tag == "-" {
+			continue
+		}
+
+		if field.Anonymous && tag == "" && isStructType(field.Type) {
+			embedded = append(embedded, field)
+
+			continue
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		fi, err := newFieldInfo(field, tag)
+		if err != nil {
+			return fmt.Errorf("field %s of %s: %w", field.Name, t, err)
+		}
+
+		fi.index = append(append([]int(nil), index...), i)
+		info.add(fi)
+	}
+
+	for _, field := range embedded {
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if err := info.addFields(ft, append(append([]int(nil), index...), field.Index...)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isStructType reports if t is a struct or a pointer to struct.
+func isStructType(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct || t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct
+}
+
+// add adds field, unless another field is already mapped to the same name.
+func (info *structInfo) add(fi *fieldInfo) {
+	var target **fieldInfo
+
+	switch fi.kind {
+	case fieldElement:
+		if !addNamed(info.elements, fi) {
+			return
+		}
+	case fieldAttr:
+		if !addNamed(info.attrs, fi) {
+			return
+		}
+	case fieldCharData:
+		target = &info.charData
+	case fieldInnerXML:
+		target = &info.innerXML
+	case fieldComment:
+		target = &info.comment
+	case fieldAny:
+		target = &info.any
+	case fieldAnyAttr:
+		target = &info.anyAttr
+	case fieldXMLName:
+		target = &info.xmlName
+	}
+
+	if target != nil {
+		if *target != nil {
+			return
+		}
+
+		*target = fi
+	}
+
+	if fi.required {
+		info.required++
+	}
+
+	fi.pos = len(info.fields)
+	info.fields = append(info.fields, fi)
+}
+
+// addNamed adds field by its local name, after fields with the same local name in other namespaces.
+// It returns false if field with the same name and namespace is already added.
+func addNamed(fields map[string]*fieldInfo, fi *fieldInfo) bool {
+	last, ok := fields[fi.name]
+	if !ok {
+		fields[fi.name] = fi
+
+		return true
+	}
+
+	for ; last.space != fi.space; last = last.next {
+		if last.next == nil {
+			last.next = fi
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// newFieldInfo returns mapping of the field with given tag, or nil if field is not decoded.
+// isAnyAttrType reports if field of type t can receive unmapped attributes.
+func isAnyAttrType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String && t.Elem().Kind() == reflect.String
+	case reflect.Slice:
+		return t.Elem() == xmlAttrType
+	default:
+		return false
+	}
+}
+
+// isBytesField reports if field of type t is decoded into []byte values,
+// possibly through pointers or slices of them.
+func isBytesField(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 {
+		t = t.Elem()
+
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+	}
+
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+func newFieldInfo(field reflect.StructField, tag string) (*fieldInfo, error) {
+	fi := &fieldInfo{name: field.Name, layout: field.Tag.Get("layout")}
+
+	opts := strings.Split(tag, ",")
+
+	if name := opts[0]; name != "" {
+		if strings.Contains(name, ">") {
+			return nil, fmt.Errorf("%w: nested path %q", ErrUnsupportedType, name)
+		}
+
+		if idx := strings.LastIndexByte(name, ' '); idx != -1 {
+			fi.space, name = name[:idx], name[idx+1:]
+		}
+
+		fi.name = name
+	}
+
+	var isAttr, isAny bool
+
+	for _, opt := range opts[1:] {
+		switch opt {
+		case "attr":
+			isAttr = true
+		case "any":
+			isAny = true
+		case "anyattr":
+			isAttr, isAny = true, true
+		case "chardata":
+			fi.kind = fieldCharData
+		case "cdata":
+			fi.kind, fi.cdata = fieldCharData, true
+		case "innerxml":
+			fi.kind = fieldInnerXML
+		case "comment":
+			fi.kind = fieldComment
+		case "required":
+			fi.required = true
+		case "omitempty":
+			fi.omitEmpty = true
+		case "base64":
+			fi.encoding = binaryBase64
+		case "hex":
+			fi.encoding = binaryHex
+		}
+	}
+
+	switch {
+	case isAttr && isAny:
+		if !isAnyAttrType(field.Type) {
+			return nil, fmt.Errorf("%w: anyattr option for %s", ErrUnsupportedType, field.Type)
+		}
+
+		fi.kind = fieldAnyAttr
+	case isAttr:
+		fi.kind = fieldAttr
+	case isAny:
+		fi.kind = fieldAny
+	}
+
+	if fi.encoding != binaryRaw && !isBytesField(field.Type) {
+		return nil, fmt.Errorf("%w: %s option for %s", ErrUnsupportedType, fi.encoding, field.Type)
+	}
+
+	// Only presence of elements and attributes is verified.
+	fi.required = fi.required && (fi.kind == fieldElement || fi.kind == fieldAttr)
+
+	if field.Name == "XMLName" {
+		if field.Type != xmlNameType {
+			return nil, fmt.Errorf("%w: XMLName must be xml.Name", ErrUnsupportedType)
+		}
+
+		fi.kind = fieldXMLName
+
+		if opts[0] == "" {
+			fi.name = ""
+		}
+	}
+
+	return fi, nil
+}
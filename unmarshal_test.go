@@ -0,0 +1,824 @@
+package fastxml
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testUnmarshalBase struct {
+	ID      int    `xml:"id,attr"`
+	Comment string `xml:"comment"`
+}
+
+type testUnmarshalItem struct {
+	SKU   string  `xml:"sku,attr"`
+	Price float64 `xml:"price"`
+	Tags  []string
+}
+
+type testUnmarshalOrder struct {
+	XMLName xml.Name `xml:"order"`
+	testUnmarshalBase
+	Customer string              `xml:"urn:c customer"`
+	Paid     bool                `xml:"paid,attr"`
+	Created  time.Time           `xml:"created"`
+	Items    []testUnmarshalItem `xml:"item"`
+	Extra    map[string]string   `xml:"extra"`
+	Note     *string             `xml:"note"`
+	Ignored  string              `xml:"-"`
+	private  string
+}
+
+func TestUnmarshal(t *testing.T) {
+	input := `<?xml version="1.0"?>
+<order id="7" paid="true" xmlns:c="urn:c">
+	<c:customer>Tom &amp; Jerry</c:customer>
+	<created>2021-02-03T04:05:06Z</created>
+	<item sku="a"><price> 1.5 </price><Tags>x</Tags><Tags><![CDATA[<y>]]></Tags></item>
+	<unknown><item sku="nested"/></unknown>
+	<item sku="b"/>
+	<extra><color>red</color><size>L</size></extra>
+	<comment>fast</comment>
+	<note/>
+	<Ignored>no</Ignored>
+</order>`
+
+	var order testUnmarshalOrder
+
+	require.NoError(t, Unmarshal([]byte(input), &order))
+
+	note := ""
+
+	assert.Equal(t, testUnmarshalOrder{
+		XMLName:           xml.Name{Local: "order"},
+		testUnmarshalBase: testUnmarshalBase{ID: 7, Comment: "fast"},
+		Customer:          "Tom & Jerry",
+		Paid:              true,
+		Created:           time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC),
+		Items: []testUnmarshalItem{
+			{SKU: "a", Price: 1.5, Tags: []string{"x", "<y>"}},
+			{SKU: "b"},
+		},
+		Extra: map[string]string{"color": "red", "size": "L"},
+		Note:  &note,
+	}, order)
+}
+
+func TestUnmarshal_CharDataAndInnerXML(t *testing.T) {
+	type text struct {
+		Lang  string `xml:"lang,attr"`
+		Value string `xml:",chardata"`
+		Inner []byte `xml:",innerxml"`
+		Bold  string `xml:"b"`
+	}
+
+	var v text
+
+	require.NoError(t, Unmarshal([]byte(`<t lang="en">a &lt; <b>bold</b> <![CDATA[&c]]></t>`), &v))
+	assert.Equal(t, text{
+		Lang:  "en",
+		Value: "a <  &c",
+		Inner: []byte(`a &lt; <b>bold</b> <![CDATA[&c]]>`),
+		Bold:  "bold",
+	}, v)
+}
+
+func TestUnmarshal_Comment(t *testing.T) {
+	type doc struct {
+		Comment string `xml:",comment"`
+		Child   string `xml:"child"`
+	}
+
+	input := `<doc><!-- first --><child><!-- nested -->c</child><!--second--></doc>`
+
+	var v, std doc
+
+	require.NoError(t, Unmarshal([]byte(input), &v))
+	require.NoError(t, xml.Unmarshal([]byte(input), &std))
+
+	assert.Equal(t, doc{Comment: " first second", Child: "c"}, v)
+	assert.Equal(t, std, v)
+}
+
+func TestUnmarshal_Namespaces(t *testing.T) {
+	type doc struct {
+		XMLName xml.Name `xml:"urn:doc doc"`
+		A       []string `xml:"urn:a item"`
+		B       []string `xml:"urn:b item"`
+		Any     []string `xml:"item"`
+		AttrA   string   `xml:"urn:a v,attr"`
+		Attr    string   `xml:"v,attr"`
+		Lang    string   `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	}
+
+	input := `<doc xmlns="urn:doc" xmlns:a="urn:a" a:v="1" xml:lang="en">
+		<a:item>a1</a:item>
+		<item xmlns="urn:b">b1</item>
+		<item>doc</item>
+		<x:item xmlns:x="urn:a">a2</x:item>
+		<c:item xmlns:c="urn:c">c</c:item>
+	</doc>`
+
+	var v, std doc
+
+	require.NoError(t, Unmarshal([]byte(input), &v))
+	require.NoError(t, xml.Unmarshal([]byte(input), &std))
+
+	assert.Equal(t, []string{"a1", "a2"}, v.A)
+	assert.Equal(t, []string{"b1"}, v.B)
+	assert.Equal(t, []string{"doc", "c"}, v.Any)
+	assert.Equal(t, "1", v.AttrA)
+	assert.Equal(t, "", v.Attr)
+	assert.Equal(t, "en", v.Lang)
+
+	assert.Equal(t, std.A, v.A)
+	assert.Equal(t, std.B, v.B)
+	assert.Equal(t, std.Any, v.Any)
+	assert.Equal(t, std.AttrA, v.AttrA)
+
+	assert.ErrorIs(t, Unmarshal([]byte(`<doc xmlns="urn:other"/>`), &v), ErrUnexpectedElement)
+	assert.Error(t, xml.Unmarshal([]byte(`<doc xmlns="urn:other"/>`), &std))
+}
+
+func TestUnmarshal_Any(t *testing.T) {
+	type node struct {
+		XMLName xml.Name
+		Value   string `xml:",chardata"`
+	}
+
+	type doc struct {
+		Known string `xml:"known"`
+		Other []node `xml:",any"`
+	}
+
+	var v doc
+
+	require.NoError(t, Unmarshal([]byte(`<doc><a>1</a><known>k</known><b>2</b></doc>`), &v))
+	assert.Equal(t, doc{
+		Known: "k",
+		Other: []node{
+			{XMLName: xml.Name{Local: "a"}, Value: "1"},
+			{XMLName: xml.Name{Local: "b"}, Value: "2"},
+		},
+	}, v)
+}
+
+func TestUnmarshal_Scalars(t *testing.T) {
+	var i int8
+
+	require.NoError(t, Unmarshal([]byte(`<v> -12 </v>`), &i))
+	assert.Equal(t, int8(-12), i)
+
+	var items []uint
+
+	require.NoError(t, Unmarshal([]byte(`<v>3</v>`), &items))
+	assert.Equal(t, []uint{3}, items)
+
+	var empty float32 = 5
+
+	require.NoError(t, Unmarshal([]byte(`<v/>`), &empty))
+	assert.Equal(t, float32(0), empty)
+}
+
+func TestUnmarshal_Errors(t *testing.T) {
+	type nested struct {
+		Count int  `xml:"count"`
+		Flag  bool `xml:"flag,attr"`
+	}
+
+	type doc struct {
+		XMLName xml.Name `xml:"doc"`
+		Nested  []nested `xml:"nested"`
+		Chan    chan int `xml:"chan"`
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		dst      interface{}
+		path     string
+		expected error
+	}{
+		{
+			name:     "invalid element value",
+			input:    `<doc><nested><count>1</count></nested><nested><count>x</count></nested></doc>`,
+			dst:      &doc{},
+			path:     "/doc/nested/count",
+			expected: ErrInvalidValue,
+		},
+		{
+			name:     "invalid attribute value",
+			input:    `<doc><nested flag="maybe"/></doc>`,
+			dst:      &doc{},
+			path:     "/doc/nested/@flag",
+			expected: ErrInvalidValue,
+		},
+		{
+			name:     "overflow",
+			input:    `<v>300</v>`,
+			dst:      new(int8),
+			path:     "/v",
+			expected: ErrInvalidValue,
+		},
+		{
+			name:     "unsupported type",
+			input:    `<doc><chan>1</chan></doc>`,
+			dst:      &doc{},
+			path:     "/doc/chan",
+			expected: ErrUnsupportedType,
+		},
+		{
+			name:     "unexpected element",
+			input:    `<other/>`,
+			dst:      &doc{},
+			path:     "/other",
+			expected: ErrUnexpectedElement,
+		},
+		{
+			name:  "unsupported tag",
+			input: `<doc/>`,
+			dst: &struct {
+				A string `xml:"a>b"`
+			}{},
+			path:     "/doc",
+			expected: ErrUnsupportedType,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			err := Unmarshal([]byte(test.input), test.dst)
+			require.ErrorIs(t, err, test.expected)
+
+			var decodeErr *DecodeError
+
+			require.True(t, errors.As(err, &decodeErr))
+			assert.Equal(t, test.path, decodeErr.Path)
+		})
+	}
+
+	require.ErrorIs(t, Unmarshal([]byte(`<v/>`), doc{}), ErrUnsupportedType)
+	require.ErrorIs(t, Unmarshal([]byte(``), &doc{}), io.EOF)
+}
+
+func TestElementDecoder_Reuse(t *testing.T) {
+	input := `<orders>
+		<order id="1"><item sku="a"><Tags>x</Tags><Tags>y</Tags></item><item sku="b"/><extra><k>v</k></extra><note>n</note></order>
+		<order><item sku="c"><Tags>z</Tags></item></order>
+	</orders>`
+
+	p := NewParser([]byte(input), false)
+	d := NewElementDecoder(p)
+
+	var (
+		order   testUnmarshalOrder
+		decoded []testUnmarshalOrder
+	)
+
+	for {
+		token, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+
+		start, ok := token.(*StartToken)
+		if !ok || start.Name != "order" {
+			continue
+		}
+
+		items, extra := order.Items, order.Extra
+
+		require.NoError(t, d.DecodeElement(&order, start))
+
+		if items != nil {
+			// Backing array of the slice and the map are reused.
+			assert.Same(t, &items[0], &order.Items[0])
+			assert.Equal(t, 0, len(extra))
+		}
+
+		decoded = append(decoded, testUnmarshalOrder{
+			testUnmarshalBase: testUnmarshalBase{ID: order.ID},
+			Items:             append([]testUnmarshalItem(nil), order.Items...),
+			Note:              order.Note,
+		})
+	}
+
+	require.Len(t, decoded, 2)
+
+	assert.Equal(t, 1, decoded[0].ID)
+	require.NotNil(t, decoded[0].Note)
+
+	// Values from the previous record are reset.
+	assert.Equal(t, 0, decoded[1].ID)
+	assert.Nil(t, decoded[1].Note)
+	assert.Equal(t, []testUnmarshalItem{{SKU: "c", Tags: []string{"z"}}}, decoded[1].Items)
+	assert.Empty(t, order.Extra)
+}
+
+func TestWithDisallowUnknownFields(t *testing.T) {
+	type item struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:"value"`
+	}
+
+	type doc struct {
+		Items []item            `xml:"item"`
+		Meta  map[string]string `xml:"meta"`
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		path  string
+	}{
+		{
+			name: "known fields",
+			input: `<doc xmlns="urn:d" xmlns:x="urn:x" xml:lang="en">` +
+				`<item name="a" x:name="b"><value>1</value></item><meta><any>m</any></meta></doc>`,
+		},
+		{
+			name:  "unknown element",
+			input: `<doc><item name="a"><value>1</value><other/></item></doc>`,
+			path:  "/doc/item/other",
+		},
+		{
+			name:  "unknown attribute",
+			input: `<doc><item name="a" id="1"/></doc>`,
+			path:  "/doc/item/@id",
+		},
+		{
+			name:  "element in text",
+			input: `<doc><item><value>1<b>2</b></value></item></doc>`,
+			path:  "/doc/item/value/b",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			var v doc
+
+			require.NoError(t, Unmarshal([]byte(test.input), &v), "unknown fields are allowed by default")
+
+			err := Unmarshal([]byte(test.input), &v, WithDisallowUnknownFields())
+			if test.path == "" {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.ErrorIs(t, err, ErrUnknownField)
+
+			var decodeErr *DecodeError
+
+			require.True(t, errors.As(err, &decodeErr))
+			assert.Equal(t, test.path, decodeErr.Path)
+		})
+	}
+}
+
+func TestUnmarshal_Required(t *testing.T) {
+	type item struct {
+		SKU   string  `xml:"sku,attr,required"`
+		Price float64 `xml:"price,required"`
+		Note  string  `xml:"note"`
+	}
+
+	type doc struct {
+		ID    int    `xml:"id,attr,required"`
+		Items []item `xml:"item,required"`
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		path    string
+		message string
+	}{
+		{
+			name:  "all present",
+			input: `<doc id="1"><item sku="a"><price/></item></doc>`,
+		},
+		{
+			name:    "missing in root",
+			input:   `<doc/>`,
+			path:    "/doc",
+			message: "decode /doc: missing required field: @id, item",
+		},
+		{
+			name:    "missing in second item",
+			input:   `<doc id="1"><item sku="a"><price>1</price></item><item><note/></item></doc>`,
+			path:    "/doc/item",
+			message: "decode /doc/item: missing required field: @sku, price",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			var v doc
+
+			err := Unmarshal([]byte(test.input), &v)
+			if test.path == "" {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.ErrorIs(t, err, ErrMissingField)
+			assert.EqualError(t, err, test.message)
+
+			var decodeErr *DecodeError
+
+			require.True(t, errors.As(err, &decodeErr))
+			assert.Equal(t, test.path, decodeErr.Path)
+		})
+	}
+}
+
+func TestWithPathDecoder(t *testing.T) {
+	type item struct {
+		SKU   string `xml:"sku,attr"`
+		Price int    `xml:"price"`
+	}
+
+	type order struct {
+		ID    string `xml:"id,attr"`
+		Items struct {
+			Item []item `xml:"item"`
+		} `xml:"items"`
+	}
+
+	type flatOrder struct {
+		ID    string `xml:"id,attr"`
+		Items []item `xml:"item"`
+		Note  string `xml:"note"`
+	}
+
+	skip := func(p *Parser) error {
+		for depth := 1; depth > 0; {
+			token, err := p.Next()
+			if err != nil {
+				return err
+			}
+
+			switch token.(type) {
+			case *StartToken:
+				depth++
+			case *EndElement:
+				depth--
+			}
+		}
+
+		return nil
+	}
+
+	itemDecoder := func(p *Parser, start *StartToken) (interface{}, error) {
+		_, value, err := start.NextAttribute()
+		if err != nil {
+			return nil, err
+		}
+
+		return &item{SKU: "custom-" + value}, skip(p)
+	}
+
+	t.Run("slice items", func(t *testing.T) {
+		var v flatOrder
+
+		err := Unmarshal(
+			[]byte(`<order id="1"><item sku="a"><price>1</price></item><item sku="b"/><note>n</note></order>`),
+			&v,
+			WithPathDecoder("/order/item", itemDecoder),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, flatOrder{
+			ID:    "1",
+			Items: []item{{SKU: "custom-a"}, {SKU: "custom-b"}},
+			Note:  "n",
+		}, v)
+	})
+
+	t.Run("root", func(t *testing.T) {
+		var v item
+
+		err := Unmarshal([]byte(`<item sku="a"><price>1</price></item>`), &v, WithPathDecoder("/item", itemDecoder))
+		require.NoError(t, err)
+		assert.Equal(t, item{SKU: "custom-a"}, v)
+	})
+
+	t.Run("nil value", func(t *testing.T) {
+		v := flatOrder{Note: "old"}
+
+		err := Unmarshal([]byte(`<order><note>n</note></order>`), &v,
+			WithPathDecoder("/order/note", func(p *Parser, start *StartToken) (interface{}, error) {
+				return nil, skip(p)
+			}))
+		require.NoError(t, err)
+		assert.Equal(t, "", v.Note)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		errCustom := errors.New("custom")
+
+		err := Unmarshal([]byte(`<order><item sku="a"/></order>`), &flatOrder{},
+			WithPathDecoder("/order/item", func(p *Parser, start *StartToken) (interface{}, error) {
+				return nil, errCustom
+			}))
+		require.ErrorIs(t, err, errCustom)
+		assert.EqualError(t, err, "decode /order/item: custom")
+	})
+
+	t.Run("element not consumed", func(t *testing.T) {
+		err := Unmarshal([]byte(`<order><item sku="a"/></order>`), &flatOrder{},
+			WithPathDecoder("/order/item", func(p *Parser, start *StartToken) (interface{}, error) {
+				return item{}, nil
+			}))
+		require.ErrorIs(t, err, ErrPathDecoderEnd)
+	})
+
+	t.Run("type mismatch", func(t *testing.T) {
+		err := Unmarshal([]byte(`<order><item sku="a"/></order>`), &flatOrder{},
+			WithPathDecoder("/order/item", func(p *Parser, start *StartToken) (interface{}, error) {
+				return 1, skip(p)
+			}))
+		require.ErrorIs(t, err, ErrUnsupportedType)
+	})
+
+	t.Run("nested path", func(t *testing.T) {
+		var v order
+
+		err := Unmarshal([]byte(`<order id="1"><items><item sku="a"/></items></order>`), &v,
+			WithPathDecoder("/order/items/item", itemDecoder))
+		require.NoError(t, err)
+		assert.Equal(t, "1", v.ID)
+		assert.Equal(t, []item{{SKU: "custom-a"}}, v.Items.Item)
+	})
+}
+
+func TestUnmarshal_Time(t *testing.T) {
+	type event struct {
+		At       time.Time     `xml:"at,attr"`
+		Day      time.Time     `xml:"day" layout:"2006-01-02"`
+		Times    []time.Time   `xml:"time"`
+		Timeout  time.Duration `xml:"timeout"`
+		Interval time.Duration `xml:"interval,attr"`
+		Empty    *time.Time    `xml:"empty"`
+	}
+
+	input := `<event at="2002-05-30T09:30:10+06:00" interval="1m30s">
+		<day>2020-01-02</day>
+		<time>2002-05-30T09:00:00</time>
+		<time> 2002-05-30T09:00:00.5Z </time>
+		<timeout>PT1H</timeout>
+		<empty/>
+	</event>`
+
+	var v event
+
+	require.NoError(t, Unmarshal([]byte(input), &v))
+
+	assert.True(t, v.At.Equal(time.Date(2002, 5, 30, 3, 30, 10, 0, time.UTC)), v.At)
+	assert.Equal(t, time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), v.Day)
+	assert.Equal(t, []time.Time{
+		time.Date(2002, 5, 30, 9, 0, 0, 0, time.UTC),
+		time.Date(2002, 5, 30, 9, 0, 0, 500000000, time.UTC),
+	}, v.Times)
+	assert.Equal(t, time.Hour, v.Timeout)
+	assert.Equal(t, 90*time.Second, v.Interval)
+	require.NotNil(t, v.Empty)
+	assert.True(t, v.Empty.IsZero())
+
+	t.Run("WithTimeLayouts", func(t *testing.T) {
+		var v event
+
+		err := Unmarshal([]byte(`<event at="30.05.2002"><day>2020-01-02</day><time>05/30/02</time></event>`), &v,
+			WithTimeLayouts("02.01.2006", "01/02/06"))
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2002, 5, 30, 0, 0, 0, 0, time.UTC), v.At)
+		assert.Equal(t, time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), v.Day)
+		assert.Equal(t, []time.Time{time.Date(2002, 5, 30, 0, 0, 0, 0, time.UTC)}, v.Times)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		tests := []struct {
+			input   string
+			message string
+		}{
+			{`<event at="2002-05-30"/>`, `decode /event/@at: invalid value: "2002-05-30" as time.Time`},
+			{
+				`<event><day>2020/01/02</day></event>`,
+				`decode /event/day: invalid value: "2020/01/02" does not match layouts ["2006-01-02"]`,
+			},
+			{`<event><timeout>P1M</timeout></event>`, `decode /event/timeout: invalid value: "P1M" as time.Duration`},
+			{`<event interval="90"/>`, `decode /event/@interval: invalid value: "90" as time.Duration`},
+		}
+
+		for _, test := range tests {
+			err := Unmarshal([]byte(test.input), &event{})
+			require.ErrorIs(t, err, ErrInvalidValue)
+			assert.EqualError(t, err, test.message)
+		}
+	})
+}
+
+func TestUnmarshal_Binary(t *testing.T) {
+	type doc struct {
+		Data   []byte    `xml:"data,base64"`
+		Hash   []byte    `xml:"hash,attr,hex"`
+		Chunks [][]byte  `xml:"chunk,base64"`
+		Ptr    *[]byte   `xml:"ptr,hex"`
+		Raw    []byte    `xml:"raw"`
+		Ptrs   []*[]byte `xml:"ptrs,hex"`
+	}
+
+	large := bytes.Repeat([]byte("0123456789"), 200)
+	encoded := base64.StdEncoding.EncodeToString(large)
+
+	// Wrap encoded text into lines, as it is usually written.
+	var wrapped strings.Builder
+
+	for i := 0; i < len(encoded); i += 76 {
+		end := i + 76
+		if end > len(encoded) {
+			end = len(encoded)
+		}
+
+		wrapped.WriteString("\n\t\t" + encoded[i:end])
+	}
+
+	input := `<doc hash="DEAD beef">
+		<data>` + wrapped.String() + `
+		</data>
+		<chunk>YQ==</chunk>
+		<chunk><![CDATA[Yg==]]></chunk>
+		<chunk/>
+		<ptr>0a0B</ptr>
+		<raw>YQ==</raw>
+		<ptrs>ff</ptrs>
+	</doc>`
+
+	var v doc
+
+	require.NoError(t, Unmarshal([]byte(input), &v))
+	assert.Equal(t, large, v.Data)
+	assert.Equal(t, []byte{0xde, 0xad, 0xbe, 0xef}, v.Hash)
+	assert.Equal(t, [][]byte{[]byte("a"), []byte("b"), nil}, v.Chunks)
+	require.NotNil(t, v.Ptr)
+	assert.Equal(t, []byte{0x0a, 0x0b}, *v.Ptr)
+	assert.Equal(t, []byte("YQ=="), v.Raw)
+	require.Len(t, v.Ptrs, 1)
+	assert.Equal(t, []byte{0xff}, *v.Ptrs[0])
+
+	t.Run("reuse", func(t *testing.T) {
+		data := v.Data
+
+		require.NoError(t, Unmarshal([]byte(`<doc><data>YWJj</data></doc>`), &v))
+		assert.Equal(t, []byte("abc"), v.Data)
+		assert.Equal(t, &data[0], &v.Data[0], "buffer is reused")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		err := Unmarshal([]byte(`<doc><data>YQ=</data></doc>`), &doc{})
+		require.ErrorIs(t, err, ErrInvalidValue)
+		assert.EqualError(t, err, "decode /doc/data: invalid value: malformed base64 text")
+
+		err = Unmarshal([]byte(`<doc hash="0g"/>`), &doc{})
+		require.ErrorIs(t, err, ErrInvalidValue)
+		assert.EqualError(t, err, "decode /doc/@hash: invalid value: malformed hex text")
+	})
+
+	t.Run("unsupported field", func(t *testing.T) {
+		var v struct {
+			Data string `xml:"data,base64"`
+		}
+
+		err := Unmarshal([]byte(`<doc><data>YQ==</data></doc>`), &v)
+		require.ErrorIs(t, err, ErrUnsupportedType)
+		assert.Contains(t, err.Error(), "field Data of struct")
+		assert.Contains(t, err.Error(), "unsupported type: base64 option for string")
+	})
+}
+
+func TestUnmarshal_AnyAttr(t *testing.T) {
+	type link struct {
+		Href  string            `xml:"href,attr"`
+		Attrs map[string]string `xml:",anyattr"`
+	}
+
+	type image struct {
+		Attrs []xml.Attr `xml:",any,attr"`
+	}
+
+	type doc struct {
+		Links  []link `xml:"link"`
+		Image  image  `xml:"image"`
+		Folded struct {
+			Attrs map[string]string `xml:",anyattr"`
+		} `xml:"folded"`
+	}
+
+	input := `<doc>
+		<link href="a" rel="next" xlink:title="A &amp; B"/>
+		<link href="b"/>
+		<image xmlns:xlink="urn:xlink" xlink:href="img.png" width="10"/>
+	</doc>`
+
+	var v doc
+
+	require.NoError(t, NewElementDecoder(NewParser([]byte(input), false), WithDisallowUnknownFields()).DecodeElement(&v, nil))
+	assert.Equal(t, []link{
+		{Href: "a", Attrs: map[string]string{"rel": "next", "xlink:title": "A & B"}},
+		{Href: "b"},
+	}, v.Links)
+	assert.Equal(t, []xml.Attr{
+		{Name: xml.Name{Space: "xmlns", Local: "xlink"}, Value: "urn:xlink"},
+		{Name: xml.Name{Space: "xlink", Local: "href"}, Value: "img.png"},
+		{Name: xml.Name{Local: "width"}, Value: "10"},
+	}, v.Image.Attrs)
+	assert.Nil(t, v.Folded.Attrs)
+
+	t.Run("reuse", func(t *testing.T) {
+		attrs := v.Links[0].Attrs
+
+		require.NoError(t, Unmarshal([]byte(`<doc><link href="c" type="text"/><image/></doc>`), &v))
+		assert.Equal(t, []link{{Href: "c", Attrs: map[string]string{"type": "text"}}}, v.Links)
+		assert.Empty(t, v.Image.Attrs)
+		assert.Equal(t, map[string]string{"type": "text"}, attrs, "map is reused")
+	})
+
+	t.Run("unsupported type", func(t *testing.T) {
+		var v struct {
+			Attrs map[string]int `xml:",anyattr"`
+		}
+
+		err := Unmarshal([]byte(`<doc a="1"/>`), &v)
+		require.ErrorIs(t, err, ErrUnsupportedType)
+		assert.Contains(t, err.Error(), "anyattr option for map[string]int")
+	})
+}
+
+func TestWithAlias(t *testing.T) {
+	type customer struct {
+		XMLName xml.Name          `xml:"customer"`
+		ID      int               `xml:"customerId,attr"`
+		Name    string            `xml:"fullName"`
+		Tags    map[string]string `xml:"tags"`
+	}
+
+	opts := []DecodeOption{
+		WithAlias("client", "customer"),
+		WithAlias("customer_id", "customerId"),
+		WithAlias("name", "fullName"),
+		WithDisallowUnknownFields(),
+	}
+
+	expected := customer{
+		XMLName: xml.Name{Local: "customer"},
+		ID:      42,
+		Name:    "Bob",
+		Tags:    map[string]string{"fullName": "tag"},
+	}
+
+	for _, input := range []string{
+		`<customer customerId="42"><fullName>Bob</fullName><tags><fullName>tag</fullName></tags></customer>`,
+		`<client customer_id="42"><name>Bob</name><tags><name>tag</name></tags></client>`,
+		`<v1:client xmlns:v1="urn:v1" v1:customer_id="42"><v1:name>Bob</v1:name><tags><name>tag</name></tags></v1:client>`,
+	} {
+		var v customer
+
+		require.NoError(t, Unmarshal([]byte(input), &v, opts...), input)
+		assert.Equal(t, expected, v, input)
+	}
+
+	var name string
+
+	pathDecoder := WithPathDecoder("/customer/fullName", func(p *Parser, start *StartToken) (interface{}, error) {
+		name = "path decoder"
+
+		return nil, NewElementDecoder(p).DecodeElement(new(string), start)
+	})
+
+	var v customer
+
+	require.NoError(t, Unmarshal([]byte(`<client><name>Bob</name></client>`), &v, append(opts, pathDecoder)...))
+	assert.Equal(t, "path decoder", name)
+
+	err := Unmarshal([]byte(`<client><id/></client>`), &v, opts...)
+	require.ErrorIs(t, err, ErrUnknownField)
+	assert.Contains(t, err.Error(), "/customer/id")
+}
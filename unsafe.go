@@ -0,0 +1,17 @@
+//go:build !fastxml_safe
+// +build !fastxml_safe
+
+package fastxml
+
+import "unsafe"
+
+func unsafeByteToString(b []byte) string {
+	return *(*string)(unsafe.Pointer(&b)) // nolint:gosec // This is valid and simple conversion.
+}
+
+func unsafeStringToByte(s string) []byte {
+	return *(*[]byte)(unsafe.Pointer(&struct { // nolint:gosec // Slice shares memory of the string, with capacity of its length.
+		string
+		int
+	}{s, len(s)}))
+}
@@ -0,0 +1,483 @@
+/*
+Package wbxml decodes WAP Binary XML (https://www.w3.org/TR/wbxml) documents,
+used by protocols like Exchange ActiveSync and OMA DM, into tokens of fastxml package.
+
+Tag and attribute names are encoded as tokens, that are resolved with code page tables
+specific to the document type, see Tables.
+
+Returned tokens are the same as the ones returned by fastxml.Parser: values are raw
+(escaped as they would be in XML document), so they can be passed to any fastxml machinery,
+like fastxml.Encoder or fastxml.Copy.
+*/
+package wbxml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+
+	"fastxml"
+)
+
+var (
+	ErrUnknownToken       = errors.New("unknown token")
+	ErrUnsupportedCharset = errors.New("unsupported charset")
+	ErrInvalidDocument    = errors.New("invalid document")
+)
+
+// Global tokens, which have the same meaning in all code pages.
+const (
+	tokenSwitchPage = 0x00
+	tokenEnd        = 0x01
+	tokenEntity     = 0x02
+	tokenStrI       = 0x03
+	tokenLiteral    = 0x04
+	tokenExtI0      = 0x40
+	tokenExtI2      = 0x42
+	tokenPI         = 0x43
+	tokenLiteralC   = 0x44
+	tokenExtT0      = 0x80
+	tokenExtT2      = 0x82
+	tokenStrT       = 0x83
+	tokenLiteralA   = 0x84
+	tokenExt0       = 0xC0
+	tokenExt2       = 0xC2
+	tokenOpaque     = 0xC3
+	tokenLiteralAC  = 0xC4
+)
+
+// Tag token bits.
+const (
+	tagHasAttributes = 0x80
+	tagHasContent    = 0x40
+	tagIDMask        = 0x3F
+)
+
+// Character sets, as MIBenum values assigned by IANA.
+const (
+	CharsetUnknown = 0
+	CharsetASCII   = 3
+	CharsetUTF8    = 106
+)
+
+// AttrStart is a name of the attribute, optionally with a prefix of its value.
+type AttrStart struct {
+	Name        string
+	ValuePrefix string
+}
+
+// Tables hold code pages of the document type, indexed by code page number and then by token.
+//
+// Tag names can contain namespace prefixes, to tell elements of different code pages apart.
+type Tables struct {
+	Tags map[byte]map[byte]string
+	// AttrStarts are tokens below 0x80 in attribute code pages.
+	AttrStarts map[byte]map[byte]AttrStart
+	// AttrValues are tokens from 0x80 in attribute code pages.
+	AttrValues map[byte]map[byte]string
+}
+
+// Header is a header of WBXML document.
+type Header struct {
+	Version byte
+	// PublicID is a well-known identifier of the document type, 0 if it is set with PublicIDString.
+	PublicID       uint32
+	PublicIDString string
+	Charset        uint32
+}
+
+// Decoder returns tokens of WBXML document.
+//
+// Same as for fastxml.Parser, returned tokens are reused between calls to Decoder.Next,
+// see fastxml.CopyToken.
+type Decoder struct {
+	buf    []byte
+	offset int
+	tables *Tables
+	header Header
+	// stringTable holds strings referenced by offset.
+	stringTable []byte
+	// tagPage and attrPage are current code pages of tags and attributes.
+	tagPage, attrPage byte
+	// stack holds names of open elements.
+	stack []string
+	// selfClosing is set when start token of element without content was returned,
+	// so end element must be returned next.
+	selfClosing bool
+
+	charData   fastxml.CharData
+	endElement fastxml.EndElement
+	procInst   fastxml.ProcInst
+}
+
+// NewDecoder will create a decoder of the document in buf, reading its header.
+//
+// If tables are nil - only tags and attributes with literal names can be decoded.
+func NewDecoder(buf []byte, tables *Tables) (*Decoder, error) {
+	if tables == nil {
+		tables = &Tables{}
+	}
+
+	d := &Decoder{buf: buf, tables: tables}
+
+	if err := d.readHeader(); err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+
+	return d, nil
+}
+
+func (d *Decoder) readHeader() error {
+	version, err := d.readByte()
+	if err != nil {
+		return err
+	}
+
+	d.header.Version = version
+
+	if d.header.PublicID, err = d.readUint32(); err != nil {
+		return err
+	}
+
+	// Public identifier of 0 is followed by the index of the identifier in the string table.
+	var publicIDIndex uint32
+
+	hasPublicIDString := d.header.PublicID == 0
+	if hasPublicIDString {
+		if publicIDIndex, err = d.readUint32(); err != nil {
+			return err
+		}
+	}
+
+	if d.header.Charset, err = d.readUint32(); err != nil {
+		return err
+	}
+
+	switch d.header.Charset {
+	case CharsetUnknown, CharsetASCII, CharsetUTF8:
+	default:
+		return fmt.Errorf("%w: %d", ErrUnsupportedCharset, d.header.Charset)
+	}
+
+	tableLen, err := d.readUint32()
+	if err != nil {
+		return err
+	}
+
+	if d.stringTable, err = d.readN(int(tableLen)); err != nil {
+		return err
+	}
+
+	if hasPublicIDString {
+		if d.header.PublicIDString, err = d.tableString(publicIDIndex); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Header returns header of the document.
+func (d *Decoder) Header() Header {
+	return d.header
+}
+
+// Next returns next token of the document, or io.EOF when the root element was closed.
+func (d *Decoder) Next() (xml.Token, error) {
+	if d.selfClosing {
+		d.selfClosing = false
+
+		return d.popElement(), nil
+	}
+
+	for {
+		if d.offset >= len(d.buf) {
+			if len(d.stack) != 0 {
+				return nil, fmt.Errorf("%w: unexpected end of data", ErrInvalidDocument)
+			}
+
+			return nil, io.EOF
+		}
+
+		token, err := d.readByte()
+		if err != nil {
+			return nil, err
+		}
+
+		switch token {
+		case tokenSwitchPage:
+			if d.tagPage, err = d.readByte(); err != nil {
+				return nil, err
+			}
+		case tokenEnd:
+			if len(d.stack) == 0 {
+				return nil, fmt.Errorf("%w: end token outside of element", ErrInvalidDocument)
+			}
+
+			return d.popElement(), nil
+		case tokenPI:
+			return d.readProcInst()
+		case tokenEntity, tokenStrI, tokenStrT, tokenOpaque:
+			text, err := d.readValue(token)
+			if err != nil {
+				return nil, err
+			}
+
+			d.charData = d.charData[:0]
+			d.charData = appendEscaped(d.charData, text)
+
+			return &d.charData, nil
+		case tokenExtI0, tokenExtI0 + 1, tokenExtI2, tokenExtT0, tokenExtT0 + 1, tokenExtT2, tokenExt0, tokenExt0 + 1, tokenExt2:
+			return nil, fmt.Errorf("%w: extension 0x%02X", ErrUnknownToken, token)
+		default:
+			return d.readElement(token)
+		}
+	}
+}
+
+func (d *Decoder) readElement(token byte) (xml.Token, error) {
+	var (
+		name string
+		err  error
+	)
+
+	switch token {
+	case tokenLiteral, tokenLiteralC, tokenLiteralA, tokenLiteralAC:
+		idx, err := d.readUint32()
+		if err != nil {
+			return nil, err
+		}
+
+		if name, err = d.tableString(idx); err != nil {
+			return nil, err
+		}
+	default:
+		var ok bool
+
+		name, ok = d.tables.Tags[d.tagPage][token&tagIDMask]
+		if !ok {
+			return nil, fmt.Errorf("%w: tag 0x%02X in code page %d", ErrUnknownToken, token&tagIDMask, d.tagPage)
+		}
+	}
+
+	var attrs []xml.Attr
+
+	if token&tagHasAttributes != 0 {
+		if attrs, err = d.readAttributes(); err != nil {
+			return nil, err
+		}
+	}
+
+	d.stack = append(d.stack, name)
+	d.selfClosing = token&tagHasContent == 0
+
+	return fastxml.NewStartToken(name, attrs...), nil
+}
+
+// readAttributes reads attributes of the element till the end token.
+func (d *Decoder) readAttributes() ([]xml.Attr, error) {
+	var attrs []xml.Attr
+
+	for {
+		token, err := d.readByte()
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case token == tokenEnd:
+			return attrs, nil
+		case token == tokenSwitchPage:
+			if d.attrPage, err = d.readByte(); err != nil {
+				return nil, err
+			}
+		case token == tokenLiteral:
+			idx, err := d.readUint32()
+			if err != nil {
+				return nil, err
+			}
+
+			name, err := d.tableString(idx)
+			if err != nil {
+				return nil, err
+			}
+
+			attrs = append(attrs, xml.Attr{Name: xml.Name{Local: name}})
+		case token == tokenEntity || token == tokenStrI || token == tokenStrT || token == tokenOpaque:
+			if len(attrs) == 0 {
+				return nil, fmt.Errorf("%w: attribute value without attribute", ErrInvalidDocument)
+			}
+
+			text, err := d.readValue(token)
+			if err != nil {
+				return nil, err
+			}
+
+			attrs[len(attrs)-1].Value += string(text)
+		case token < 0x80:
+			start, ok := d.tables.AttrStarts[d.attrPage][token]
+			if !ok {
+				return nil, fmt.Errorf("%w: attribute start 0x%02X in code page %d", ErrUnknownToken, token, d.attrPage)
+			}
+
+			attrs = append(attrs, xml.Attr{Name: xml.Name{Local: start.Name}, Value: start.ValuePrefix})
+		default:
+			value, ok := d.tables.AttrValues[d.attrPage][token]
+			if !ok {
+				return nil, fmt.Errorf("%w: attribute value 0x%02X in code page %d", ErrUnknownToken, token, d.attrPage)
+			}
+
+			if len(attrs) == 0 {
+				return nil, fmt.Errorf("%w: attribute value without attribute", ErrInvalidDocument)
+			}
+
+			attrs[len(attrs)-1].Value += value
+		}
+	}
+}
+
+// readValue reads text of inline string, string table reference, entity or opaque data.
+//
+// Opaque data is returned as is, so it must be text to be used as XML.
+func (d *Decoder) readValue(token byte) ([]byte, error) {
+	switch token {
+	case tokenEntity:
+		code, err := d.readUint32()
+		if err != nil {
+			return nil, err
+		}
+
+		return []byte(string(rune(code))), nil
+	case tokenStrI:
+		return d.readTermString()
+	case tokenStrT:
+		idx, err := d.readUint32()
+		if err != nil {
+			return nil, err
+		}
+
+		text, err := d.tableString(idx)
+
+		return []byte(text), err
+	default: // Opaque.
+		n, err := d.readUint32()
+		if err != nil {
+			return nil, err
+		}
+
+		return d.readN(int(n))
+	}
+}
+
+// readProcInst reads processing instruction, which is encoded as attributes.
+func (d *Decoder) readProcInst() (xml.Token, error) {
+	attrs, err := d.readAttributes()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(attrs) == 0 {
+		return nil, fmt.Errorf("%w: processing instruction without target", ErrInvalidDocument)
+	}
+
+	d.procInst = fastxml.ProcInst{Target: attrs[0].Name.Local, Inst: []byte(attrs[0].Value)}
+
+	return &d.procInst, nil
+}
+
+func (d *Decoder) popElement() xml.Token {
+	d.endElement.Name.Local = d.stack[len(d.stack)-1]
+	d.stack = d.stack[:len(d.stack)-1]
+
+	return &d.endElement
+}
+
+func (d *Decoder) readByte() (byte, error) {
+	if d.offset >= len(d.buf) {
+		return 0, fmt.Errorf("%w: unexpected end of data", ErrInvalidDocument)
+	}
+
+	d.offset++
+
+	return d.buf[d.offset-1], nil
+}
+
+func (d *Decoder) readN(n int) ([]byte, error) {
+	if n < 0 || n > len(d.buf)-d.offset {
+		return nil, fmt.Errorf("%w: unexpected end of data", ErrInvalidDocument)
+	}
+
+	d.offset += n
+
+	return d.buf[d.offset-n : d.offset], nil
+}
+
+// readUint32 reads multi-byte integer: 7 bits per byte, most significant first,
+// with the highest bit set on all bytes except the last one.
+func (d *Decoder) readUint32() (uint32, error) {
+	var v uint32
+
+	for i := 0; i < 5; i++ {
+		b, err := d.readByte()
+		if err != nil {
+			return 0, err
+		}
+
+		v = v<<7 | uint32(b&0x7F)
+
+		if b&0x80 == 0 {
+			return v, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: integer is too long", ErrInvalidDocument)
+}
+
+// readTermString reads null-terminated inline string.
+func (d *Decoder) readTermString() ([]byte, error) {
+	end := bytes.IndexByte(d.buf[d.offset:], 0)
+	if end == -1 {
+		return nil, fmt.Errorf("%w: string is not terminated", ErrInvalidDocument)
+	}
+
+	text := d.buf[d.offset : d.offset+end]
+	d.offset += end + 1
+
+	return text, nil
+}
+
+// tableString returns null-terminated string from the string table.
+func (d *Decoder) tableString(idx uint32) (string, error) {
+	if int(idx) >= len(d.stringTable) {
+		return "", fmt.Errorf("%w: string table index %d is out of range", ErrInvalidDocument, idx)
+	}
+
+	text := d.stringTable[idx:]
+	if end := bytes.IndexByte(text, 0); end != -1 {
+		text = text[:end]
+	}
+
+	return string(text), nil
+}
+
+// appendEscaped appends text escaped as XML char data.
+func appendEscaped(dst, text []byte) []byte {
+	for _, b := range text {
+		switch b {
+		case '&':
+			dst = append(dst, "&amp;"...)
+		case '<':
+			dst = append(dst, "&lt;"...)
+		case '>':
+			dst = append(dst, "&gt;"...)
+		case '\r':
+			dst = append(dst, "&#xD;"...)
+		default:
+			dst = append(dst, b)
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,151 @@
+package wbxml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"fastxml"
+)
+
+var testTables = &Tables{
+	Tags: map[byte]map[byte]string{
+		0: {0x05: "root", 0x06: "item"},
+		1: {0x05: "x:other"},
+	},
+	AttrStarts: map[byte]map[byte]AttrStart{
+		0: {0x05: {Name: "TYPE"}, 0x06: {Name: "URL", ValuePrefix: "http://"}},
+	},
+	AttrValues: map[byte]map[byte]string{
+		0: {0x85: ".org"},
+	},
+}
+
+func decodeAll(t *testing.T, doc []byte, tables *Tables) ([]xml.Token, *Decoder) {
+	t.Helper()
+
+	d, err := NewDecoder(doc, tables)
+	require.NoError(t, err)
+
+	var tokens []xml.Token
+
+	for {
+		token, err := d.Next()
+		if errors.Is(err, io.EOF) {
+			return tokens, d
+		}
+
+		require.NoError(t, err)
+
+		tokens = append(tokens, fastxml.ToStd(token))
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	doc := []byte{
+		0x03, 0x00, 0x00, 0x6A, 0x11, // Version 1.3, public id in string table, UTF-8, string table of 17 bytes.
+	}
+	doc = append(doc, "-//TEST//DTD\x00abc\x00"...)
+	doc = append(doc,
+		0x45,                            // <root>
+		0xC6,                            // <item with attributes and content.
+		0x05, 0x03, 'a', '<', 'b', 0x00, // TYPE="a<b"
+		0x06, 0x03, 'x', 0x00, 0x85, // URL="http://x.org"
+		0x04, 0x0D, 0x83, 0x0D, // abc="abc"
+		0x01,                      // >
+		0x03, 't', '&', 'x', 0x00, // t&x
+		0x02, 0x81, 0x20, // &nbsp;
+		0x01,       // </item>
+		0x00, 0x01, // Switch to code page 1.
+		0x05,                                                   // <x:other/>
+		0x43, 0x04, 0x0D, 0x03, 'd', 'a', 't', 'a', 0x00, 0x01, // <?abc data?>
+		0x01, // </root>
+	)
+
+	tokens, d := decodeAll(t, doc, testTables)
+
+	assert.Equal(t, Header{Version: 3, PublicIDString: "-//TEST//DTD", Charset: CharsetUTF8}, d.Header())
+	assert.Equal(t, []xml.Token{
+		xml.StartElement{Name: xml.Name{Local: "root"}},
+		xml.StartElement{Name: xml.Name{Local: "item"}, Attr: []xml.Attr{
+			{Name: xml.Name{Local: "TYPE"}, Value: "a<b"},
+			{Name: xml.Name{Local: "URL"}, Value: "http://x.org"},
+			{Name: xml.Name{Local: "abc"}, Value: "abc"},
+		}},
+		xml.CharData("t&x"),
+		xml.CharData("\u00a0"),
+		xml.EndElement{Name: xml.Name{Local: "item"}},
+		xml.StartElement{Name: xml.Name{Space: "x", Local: "other"}},
+		xml.EndElement{Name: xml.Name{Space: "x", Local: "other"}},
+		xml.ProcInst{Target: "abc", Inst: []byte("data")},
+		xml.EndElement{Name: xml.Name{Local: "root"}},
+	}, tokens)
+}
+
+// Example is from the specification, section 8.1.
+func TestDecoder_Encode(t *testing.T) {
+	doc := []byte{
+		0x01, 0x01, 0x03, 0x00, 0x47, 0x46,
+		0x03, ' ', 'X', ' ', '&', ' ', 'Y', 0x00,
+		0x05,
+		0x03, ' ', 'X', 0x00,
+		0x02, 0x81, 0x20,
+		0x03, '=', 0x00,
+		0x02, 0x81, 0x20,
+		0x03, '1', ' ', 0x00,
+		0x01, 0x01,
+	}
+	tables := &Tables{Tags: map[byte]map[byte]string{0: {0x05: "BR", 0x06: "CARD", 0x07: "XYZ"}}}
+
+	d, err := NewDecoder(doc, tables)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+
+	enc := fastxml.NewEncoder(&buf)
+
+	for {
+		token, err := d.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+		require.NoError(t, enc.WriteToken(token))
+	}
+
+	require.NoError(t, enc.Flush())
+	assert.Equal(t, "<XYZ><CARD> X &amp; Y<BR></BR> X\u00a0=\u00a01 </CARD></XYZ>", buf.String())
+}
+
+func TestDecoder_Errors(t *testing.T) {
+	tests := map[string]struct {
+		doc []byte
+		err error
+	}{
+		"unknown tag":         {doc: []byte{0x03, 0x01, 0x6A, 0x00, 0x07}, err: ErrUnknownToken},
+		"unknown attribute":   {doc: []byte{0x03, 0x01, 0x6A, 0x00, 0x85, 0x07, 0x01}, err: ErrUnknownToken},
+		"unclosed element":    {doc: []byte{0x03, 0x01, 0x6A, 0x00, 0x45}, err: ErrInvalidDocument},
+		"unterminated string": {doc: []byte{0x03, 0x01, 0x6A, 0x00, 0x45, 0x03, 'a'}, err: ErrInvalidDocument},
+		"string table index":  {doc: []byte{0x03, 0x01, 0x6A, 0x00, 0x04, 0x05}, err: ErrInvalidDocument},
+	}
+
+	for name, test := range tests {
+		d, err := NewDecoder(test.doc, testTables)
+		require.NoError(t, err, name)
+
+		for err == nil {
+			_, err = d.Next()
+		}
+
+		assert.ErrorIs(t, err, test.err, name)
+	}
+
+	_, err := NewDecoder([]byte{0x03, 0x01, 0x04}, nil)
+	assert.ErrorIs(t, err, ErrUnsupportedCharset)
+}
@@ -0,0 +1,78 @@
+package fastxml
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+)
+
+var _ io.WriteCloser = (*ParserWriter)(nil)
+
+// TokenHandlerFunc is called for each token that was parsed.
+//
+// Same as with Parser.Next, handler MUST NOT hold onto received token.
+type TokenHandlerFunc func(xml.Token) error
+
+// ParserWriter is an io.Writer that parses data as it is written to it.
+//
+// This allows to use parser in pipelines like `io.Copy(parserWriter, conn)`.
+type ParserWriter struct {
+	p       *Parser
+	handler TokenHandlerFunc
+}
+
+// NewParserWriter will create a writer that calls handler for each parsed token.
+func NewParserWriter(handler TokenHandlerFunc, opts ...ParserOption) *ParserWriter {
+	return &ParserWriter{
+		p:       NewStreamParser(opts...),
+		handler: handler,
+	}
+}
+
+// Write will parse all tokens that are fully available after data is written.
+//
+// Error returned from the handler or from the parser stops parsing
+// and is returned to the caller.
+func (w *ParserWriter) Write(data []byte) (int, error) {
+	if !w.p.streaming {
+		return 0, io.ErrClosedPipe
+	}
+
+	w.p.Feed(data)
+
+	if err := w.handleTokens(); err != nil && !errors.Is(err, ErrNeedMoreData) {
+		return len(data), err
+	}
+
+	return len(data), nil
+}
+
+// Close will parse the rest of written data, as no more data will be available.
+//
+// If written data ends in the middle of the tag - error is returned.
+func (w *ParserWriter) Close() error {
+	w.p.streaming = false
+
+	if err := w.handleTokens(); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	return nil
+}
+
+func (w *ParserWriter) handleTokens() error {
+	for {
+		token, err := w.p.Next()
+		if err != nil {
+			return err
+		}
+
+		if token == nil {
+			continue
+		}
+
+		if err := w.handler(token); err != nil {
+			return err
+		}
+	}
+}
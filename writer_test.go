@@ -0,0 +1,67 @@
+package fastxml
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserWriter(t *testing.T) {
+	data := `<?xml version="1.0"?><root><item id="1">text</item><item/></root>` + "\n"
+
+	mustResult := []string{
+		`*fastxml.StartToken: &{"root" ""}`,
+		`*fastxml.StartToken: &{"item" "id=\"1\">"}`,
+		`*fastxml.CharData: &"text"`,
+		`*fastxml.EndElement: &{{"" "item"}}`,
+		`*fastxml.StartToken: &{"item" ""}`,
+		`*fastxml.EndElement: &{{"" "item"}}`,
+		`*fastxml.EndElement: &{{"" "root"}}`,
+		`*fastxml.CharData: &"\n"`,
+	}
+
+	var results []string
+
+	w := NewParserWriter(func(token xml.Token) error {
+		results = append(results, tokenString(token))
+
+		return nil
+	})
+
+	_, err := io.Copy(w, iotest.OneByteReader(strings.NewReader(data)))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, mustResult, results)
+}
+
+func TestParserWriter_Errors(t *testing.T) {
+	handlerErr := errors.New("handler error")
+
+	w := NewParserWriter(func(token xml.Token) error {
+		return handlerErr
+	})
+
+	_, err := w.Write([]byte("<a"))
+	require.NoError(t, err)
+
+	_, err = w.Write([]byte(">"))
+	require.ErrorIs(t, err, handlerErr)
+
+	w = NewParserWriter(func(token xml.Token) error {
+		return nil
+	})
+
+	_, err = w.Write([]byte("<a><b"))
+	require.NoError(t, err)
+	require.ErrorIs(t, w.Close(), io.ErrUnexpectedEOF)
+
+	_, err = w.Write([]byte("</b></a>"))
+	require.ErrorIs(t, err, io.ErrClosedPipe)
+}
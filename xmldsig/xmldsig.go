@@ -0,0 +1,624 @@
+/*
+Package xmldsig extracts and verifies enveloped XML signatures (https://www.w3.org/TR/xmldsig-core1).
+
+FindSignatures returns all ds:Signature elements of the document.
+Each reference of the signature is resolved within the same document, canonicalized
+and compared with its digest value, after which signature value over canonical ds:SignedInfo
+is checked by a Verifier supplied by the caller.
+
+Only same-document references (empty URI and "#id") are supported, with enveloped signature
+and canonicalization transforms. Elements are referenced by "ID", "Id" or "id" attribute.
+
+Signature elements are only read from their places in ds:Signature, so elements injected into ds:Object
+or other unsigned parts of the signature do not change its references.
+
+Successful verification only tells that referenced elements are signed,
+callers must check that they use exactly the referenced elements, for example
+by comparing offsets returned by Signature.Resolve with offsets of the elements they read.
+*/
+package xmldsig
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1" //nolint:gosec // SHA-1 is still used by signatures in the wild.
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+
+	"fastxml"
+)
+
+// Namespace is the namespace of XML signature elements.
+const Namespace = "http://www.w3.org/2000/09/xmldsig#"
+
+// Algorithm identifiers.
+const (
+	AlgC14N                = "http://www.w3.org/TR/2001/REC-xml-c14n-20010315"
+	AlgC14NWithComments    = "http://www.w3.org/TR/2001/REC-xml-c14n-20010315#WithComments"
+	AlgExcC14N             = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	AlgExcC14NWithComments = "http://www.w3.org/2001/10/xml-exc-c14n#WithComments"
+	AlgEnvelopedSignature  = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+
+	AlgSHA1   = "http://www.w3.org/2000/09/xmldsig#sha1"
+	AlgSHA256 = "http://www.w3.org/2001/04/xmlenc#sha256"
+	AlgSHA384 = "http://www.w3.org/2001/04/xmldsig-more#sha384"
+	AlgSHA512 = "http://www.w3.org/2001/04/xmlenc#sha512"
+
+	AlgRSASHA1   = "http://www.w3.org/2000/09/xmldsig#rsa-sha1"
+	AlgRSASHA256 = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	AlgRSASHA384 = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha384"
+	AlgRSASHA512 = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha512"
+)
+
+var (
+	ErrUnsupportedAlgorithm = errors.New("unsupported algorithm")
+	ErrReferenceNotFound    = errors.New("referenced element not found")
+	ErrDuplicateID          = errors.New("duplicate element id")
+	ErrDigestMismatch       = errors.New("digest value does not match")
+	ErrInvalidSignature     = errors.New("invalid signature element")
+)
+
+// excC14NNamespace is the namespace of InclusiveNamespaces element.
+const excC14NNamespace = "http://www.w3.org/2001/10/xml-exc-c14n#"
+
+// HashFunc returns a new hash for digest algorithm identifier.
+type HashFunc func(algorithm string) (hash.Hash, error)
+
+// Verifier checks signature value over canonical ds:SignedInfo.
+type Verifier interface {
+	VerifySignature(algorithm string, signedInfo, signature []byte) error
+}
+
+// VerifierFunc is a function that implements Verifier.
+type VerifierFunc func(algorithm string, signedInfo, signature []byte) error
+
+// VerifySignature calls the function.
+func (f VerifierFunc) VerifySignature(algorithm string, signedInfo, signature []byte) error {
+	return f(algorithm, signedInfo, signature)
+}
+
+// Signature is a ds:Signature element of the document.
+type Signature struct {
+	CanonicalizationMethod string
+	// InclusivePrefixes are set for exclusive canonicalization of ds:SignedInfo.
+	InclusivePrefixes []string
+	SignatureMethod   string
+	References        []Reference
+	SignatureValue    []byte
+	// X509Certificates hold DER encoded certificates from ds:KeyInfo.
+	X509Certificates [][]byte
+
+	doc *document
+	// element and signedInfo are positions of ds:Signature and ds:SignedInfo elements.
+	element, signedInfo span
+	// seen holds paths of read elements that can appear only once in the signature or in its last reference.
+	seen map[string]bool
+}
+
+// Reference is a ds:Reference element of the signature.
+type Reference struct {
+	URI          string
+	Transforms   []Transform
+	DigestMethod string
+	DigestValue  []byte
+}
+
+// Transform is a ds:Transform element of the reference.
+type Transform struct {
+	Algorithm string
+	// InclusivePrefixes are set for exclusive canonicalization.
+	InclusivePrefixes []string
+}
+
+// span is a position of the element in the document.
+type span struct {
+	start, end int
+	// ns holds namespaces declared by ancestors of the element.
+	ns map[string]string
+}
+
+type document struct {
+	buf []byte
+	// ids hold elements by their id, duplicated ids have negative start.
+	ids map[string]span
+}
+
+// element is an open element while the document is scanned.
+type element struct {
+	span
+	space, local string
+	// scope holds namespaces in scope of the element, including its own declarations.
+	scope map[string]string
+	sig   *Signature
+	id    string
+	// path is the path of the element from ds:Signature, empty for elements outside of known signature elements.
+	path string
+}
+
+// FindSignatures returns all signatures of the document.
+//
+// doc must not be modified while returned signatures are used.
+func FindSignatures(doc []byte) ([]*Signature, error) {
+	d := &document{buf: doc, ids: make(map[string]span)}
+
+	var (
+		sigs  []*Signature
+		stack []*element
+		text  []byte
+	)
+
+	topScope := map[string]string{}
+
+	p := fastxml.NewParser(doc, false)
+
+	for {
+		start := p.InputOffset()
+
+		token, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			return sigs, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("find signatures: %w", err)
+		}
+
+		switch tkn := token.(type) {
+		case *fastxml.StartToken:
+			parent := &element{scope: topScope}
+			if len(stack) != 0 {
+				parent = stack[len(stack)-1]
+			}
+
+			el, err := newElement(tkn, parent, start)
+			if err != nil {
+				return nil, fmt.Errorf("find signatures: %w", err)
+			}
+
+			if el.space == Namespace && el.local == "Signature" {
+				el.sig = &Signature{doc: d, element: el.span, seen: map[string]bool{}}
+				el.path = "Signature"
+				sigs = append(sigs, el.sig)
+			} else if el.sig != nil {
+				el.path = signaturePath(el, parent)
+			}
+
+			if el.sig != nil {
+				if err := el.sig.addElement(el, tkn); err != nil {
+					return nil, fmt.Errorf("find signatures: %w", err)
+				}
+			}
+
+			stack = append(stack, el)
+			text = text[:0]
+		case *fastxml.CharData:
+			text = append(text, *tkn...)
+		case *fastxml.EndElement:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("find signatures: unexpected end element %s", tkn.Name.Local)
+			}
+
+			el := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			el.end = p.InputOffset()
+
+			if el.sig != nil {
+				if err := el.sig.closeElement(el, text); err != nil {
+					return nil, fmt.Errorf("find signatures: %w", err)
+				}
+			}
+
+			if el.id != "" {
+				if _, ok := d.ids[el.id]; ok {
+					el.start = -1
+				}
+
+				d.ids[el.id] = el.span
+			}
+		}
+	}
+}
+
+func newElement(tkn *fastxml.StartToken, parent *element, start int) (*element, error) {
+	el := &element{span: span{start: start, ns: parent.scope}, scope: parent.scope, sig: parent.sig}
+	attrs := *tkn
+
+	copied := false
+
+	for {
+		name, val, err := attrs.NextAttribute()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case name == "xmlns" || strings.HasPrefix(name, "xmlns:"):
+			if !copied {
+				el.scope, copied = copyScope(parent.scope), true
+			}
+
+			el.scope[strings.TrimPrefix(strings.TrimPrefix(name, "xmlns"), ":")] = fastxml.Unescape(val)
+		case name == "ID" || name == "Id" || name == "id":
+			el.id = fastxml.Unescape(val)
+		}
+	}
+
+	prefix, local := "", tkn.Name
+	if idx := strings.IndexByte(tkn.Name, ':'); idx != -1 {
+		prefix, local = tkn.Name[:idx], tkn.Name[idx+1:]
+	}
+
+	uri, ok := el.scope[prefix]
+	if !ok && prefix != "" && prefix != "xml" {
+		return nil, fmt.Errorf("undeclared namespace prefix %q", prefix)
+	}
+
+	el.space, el.local = uri, local
+
+	return el, nil
+}
+
+// signaturePath returns path of the signature descendant el from ds:Signature.
+//
+// Elements of other namespaces, except for exclusive canonicalization parameters, break the path,
+// so their descendants are never read as parts of the signature.
+func signaturePath(el, parent *element) string {
+	if parent.path == "" {
+		return ""
+	}
+
+	switch el.space {
+	case Namespace:
+		return parent.path + "/" + el.local
+	case excC14NNamespace:
+		return parent.path + "/ec:" + el.local
+	default:
+		return ""
+	}
+}
+
+// Paths of signature elements.
+const (
+	pathSignedInfo      = "Signature/SignedInfo"
+	pathCanonicalMethod = pathSignedInfo + "/CanonicalizationMethod"
+	pathReference       = pathSignedInfo + "/Reference"
+	pathTransform       = pathReference + "/Transforms/Transform"
+	pathDigestMethod    = pathReference + "/DigestMethod"
+	pathDigestValue     = pathReference + "/DigestValue"
+)
+
+// addElement fills signature from the start of its descendant element.
+func (s *Signature) addElement(el *element, tkn *fastxml.StartToken) error {
+	switch el.path {
+	case pathSignedInfo:
+		if err := s.once(el.path); err != nil {
+			return err
+		}
+
+		s.signedInfo = el.span
+	case pathCanonicalMethod:
+		if err := s.once(el.path); err != nil {
+			return err
+		}
+
+		s.CanonicalizationMethod = attribute(tkn, "Algorithm")
+	case pathCanonicalMethod + "/ec:InclusiveNamespaces":
+		s.InclusivePrefixes = strings.Fields(attribute(tkn, "PrefixList"))
+	case pathSignedInfo + "/SignatureMethod":
+		if err := s.once(el.path); err != nil {
+			return err
+		}
+
+		s.SignatureMethod = attribute(tkn, "Algorithm")
+	case pathReference:
+		delete(s.seen, pathDigestMethod)
+		delete(s.seen, pathDigestValue)
+
+		s.References = append(s.References, Reference{URI: attribute(tkn, "URI")})
+	case pathTransform:
+		ref := &s.References[len(s.References)-1]
+		ref.Transforms = append(ref.Transforms, Transform{Algorithm: attribute(tkn, "Algorithm")})
+	case pathTransform + "/ec:InclusiveNamespaces":
+		ref := &s.References[len(s.References)-1]
+		ref.Transforms[len(ref.Transforms)-1].InclusivePrefixes = strings.Fields(attribute(tkn, "PrefixList"))
+	case pathDigestMethod:
+		if err := s.once(el.path); err != nil {
+			return err
+		}
+
+		s.References[len(s.References)-1].DigestMethod = attribute(tkn, "Algorithm")
+	case pathDigestValue, "Signature/SignatureValue":
+		return s.once(el.path)
+	}
+
+	return nil
+}
+
+// closeElement fills signature from the end of its descendant element with its text.
+func (s *Signature) closeElement(el *element, text []byte) error {
+	switch el.path {
+	case "Signature":
+		if el.sig == s {
+			s.element.end = el.end
+		}
+	case pathSignedInfo:
+		s.signedInfo.end = el.end
+	case pathDigestValue:
+		val, err := decodeBase64(text)
+		if err != nil {
+			return fmt.Errorf("digest value: %w", err)
+		}
+
+		s.References[len(s.References)-1].DigestValue = val
+	case "Signature/SignatureValue":
+		val, err := decodeBase64(text)
+		if err != nil {
+			return fmt.Errorf("signature value: %w", err)
+		}
+
+		s.SignatureValue = val
+	case "Signature/KeyInfo/X509Data/X509Certificate":
+		val, err := decodeBase64(text)
+		if err != nil {
+			return fmt.Errorf("certificate: %w", err)
+		}
+
+		s.X509Certificates = append(s.X509Certificates, val)
+	}
+
+	return nil
+}
+
+// once returns error if the element at path was already read.
+func (s *Signature) once(path string) error {
+	if s.seen[path] {
+		return fmt.Errorf("%w: duplicate %s element", ErrInvalidSignature, path[strings.LastIndexByte(path, '/')+1:])
+	}
+
+	s.seen[path] = true
+
+	return nil
+}
+
+// Raw returns ds:Signature element as it is in the document.
+func (s *Signature) Raw() []byte {
+	return s.doc.buf[s.element.start:s.element.end]
+}
+
+// SignedInfo returns canonical form of ds:SignedInfo element, over which signature value is calculated.
+func (s *Signature) SignedInfo() ([]byte, error) {
+	if s.signedInfo.end == 0 {
+		return nil, fmt.Errorf("%w: no SignedInfo element", ErrInvalidSignature)
+	}
+
+	opts, err := canonicalizerOptions(s.CanonicalizationMethod, s.InclusivePrefixes, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	opts = append(opts, fastxml.WithNamespaces(s.signedInfo.ns))
+
+	if err := fastxml.Canonicalize(&buf, s.doc.buf[s.signedInfo.start:s.signedInfo.end], opts...); err != nil {
+		return nil, fmt.Errorf("canonicalize signed info: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// VerifyReferences checks digest values of all references of the signature.
+func (s *Signature) VerifyReferences(newHash HashFunc) error {
+	if len(s.References) == 0 {
+		return fmt.Errorf("%w: no references", ErrInvalidSignature)
+	}
+
+	for _, ref := range s.References {
+		h, err := newHash(ref.DigestMethod)
+		if err != nil {
+			return fmt.Errorf("reference %q: %w", ref.URI, err)
+		}
+
+		if err := s.digest(ref, h); err != nil {
+			return fmt.Errorf("reference %q: %w", ref.URI, err)
+		}
+
+		if !bytes.Equal(h.Sum(nil), ref.DigestValue) {
+			return fmt.Errorf("reference %q: %w", ref.URI, ErrDigestMismatch)
+		}
+	}
+
+	return nil
+}
+
+// Verify checks digest values of all references, and then signature value with v.
+func (s *Signature) Verify(newHash HashFunc, v Verifier) error {
+	if err := s.VerifyReferences(newHash); err != nil {
+		return err
+	}
+
+	signedInfo, err := s.SignedInfo()
+	if err != nil {
+		return err
+	}
+
+	return v.VerifySignature(s.SignatureMethod, signedInfo, s.SignatureValue)
+}
+
+// Resolve returns offsets of the content referenced by ref in the document,
+// which is the whole document for empty URI.
+//
+// Offsets can be compared with offsets of the elements that the caller reads,
+// to make sure that they are the signed ones.
+func (s *Signature) Resolve(ref Reference) (start, end int, err error) {
+	target, err := s.resolve(ref)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return target.start, target.end, nil
+}
+
+func (s *Signature) resolve(ref Reference) (span, error) {
+	if ref.URI == "" {
+		return span{end: len(s.doc.buf)}, nil
+	}
+
+	if !strings.HasPrefix(ref.URI, "#") {
+		return span{}, fmt.Errorf("%w: reference URI %s", ErrUnsupportedAlgorithm, ref.URI)
+	}
+
+	target, ok := s.doc.ids[ref.URI[1:]]
+	if !ok {
+		return span{}, ErrReferenceNotFound
+	}
+
+	if target.start < 0 {
+		return span{}, ErrDuplicateID
+	}
+
+	return target, nil
+}
+
+// digest writes canonical form of referenced content into h, applying transforms.
+func (s *Signature) digest(ref Reference, h hash.Hash) error {
+	target, err := s.resolve(ref)
+	if err != nil {
+		return err
+	}
+
+	content := s.doc.buf[target.start:target.end]
+	// Canonical XML is used if there is no canonicalization transform.
+	var opts []fastxml.CanonicalizerOption
+
+	for _, transform := range ref.Transforms {
+		if transform.Algorithm == AlgEnvelopedSignature {
+			if s.element.start >= target.start && s.element.end <= target.end {
+				content = make([]byte, 0, len(content)-(s.element.end-s.element.start))
+				content = append(content, s.doc.buf[target.start:s.element.start]...)
+				content = append(content, s.doc.buf[s.element.end:target.end]...)
+			}
+
+			continue
+		}
+
+		// Comments are removed from same-document references, even if algorithm keeps them.
+		opts, err = canonicalizerOptions(transform.Algorithm, transform.InclusivePrefixes, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	opts = append(opts, fastxml.WithNamespaces(target.ns))
+
+	return fastxml.Canonicalize(h, content, opts...)
+}
+
+func canonicalizerOptions(
+	algorithm string, prefixes []string, withComments bool,
+) ([]fastxml.CanonicalizerOption, error) {
+	var opts []fastxml.CanonicalizerOption
+
+	switch algorithm {
+	case AlgC14N:
+	case AlgC14NWithComments:
+		if withComments {
+			opts = append(opts, fastxml.WithComments())
+		}
+	case AlgExcC14N:
+		opts = append(opts, fastxml.WithExclusive(prefixes...))
+	case AlgExcC14NWithComments:
+		opts = append(opts, fastxml.WithExclusive(prefixes...))
+
+		if withComments {
+			opts = append(opts, fastxml.WithComments())
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, algorithm)
+	}
+
+	return opts, nil
+}
+
+// DefaultHash returns hash for SHA-1 and SHA-2 digest algorithms.
+func DefaultHash(algorithm string) (hash.Hash, error) {
+	switch algorithm {
+	case AlgSHA1:
+		return sha1.New(), nil //nolint:gosec // SHA-1 is still used by signatures in the wild.
+	case AlgSHA256:
+		return sha256.New(), nil
+	case AlgSHA384:
+		return sha512.New384(), nil
+	case AlgSHA512:
+		return sha512.New(), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, algorithm)
+	}
+}
+
+// RSAVerifier returns verifier of RSA PKCS #1 v1.5 signatures with SHA-1 and SHA-2 hashes.
+func RSAVerifier(key *rsa.PublicKey) Verifier {
+	return VerifierFunc(func(algorithm string, signedInfo, signature []byte) error {
+		var hashType crypto.Hash
+
+		switch algorithm {
+		case AlgRSASHA1:
+			hashType = crypto.SHA1
+		case AlgRSASHA256:
+			hashType = crypto.SHA256
+		case AlgRSASHA384:
+			hashType = crypto.SHA384
+		case AlgRSASHA512:
+			hashType = crypto.SHA512
+		default:
+			return fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, algorithm)
+		}
+
+		h := hashType.New()
+		h.Write(signedInfo)
+
+		return rsa.VerifyPKCS1v15(key, hashType, h.Sum(nil), signature)
+	})
+}
+
+func attribute(tkn *fastxml.StartToken, name string) string {
+	attrs := *tkn
+
+	for {
+		attrName, val, err := attrs.NextAttribute()
+		if err != nil {
+			return ""
+		}
+
+		if attrName == name {
+			return fastxml.Unescape(val)
+		}
+	}
+}
+
+func decodeBase64(text []byte) ([]byte, error) {
+	encoded := strings.Join(strings.Fields(fastxml.Unescape(string(text))), "")
+
+	return base64.StdEncoding.DecodeString(encoded)
+}
+
+func copyScope(scope map[string]string) map[string]string {
+	scopeCopy := make(map[string]string, len(scope)+1)
+	for prefix, uri := range scope {
+		scopeCopy[prefix] = uri
+	}
+
+	return scopeCopy
+}
@@ -0,0 +1,256 @@
+package xmldsig
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const signedTemplate = `<?xml version="1.0"?>
+<root xmlns="urn:root" xmlns:ds="http://www.w3.org/2000/09/xmldsig#" ID="doc">
+  <item ID="i1" note="a&amp;b"><v>1</v></item>
+  <ds:Signature>
+    <ds:SignedInfo>
+      <ds:CanonicalizationMethod Algorithm="http://www.w3.org/2001/10/xml-exc-c14n#"/>
+      <ds:SignatureMethod Algorithm="http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"/>
+      <ds:Reference URI="#i1">
+        <ds:Transforms>
+          <ds:Transform Algorithm="http://www.w3.org/2000/09/xmldsig#enveloped-signature"/>
+          <ds:Transform Algorithm="http://www.w3.org/2001/10/xml-exc-c14n#"/>
+        </ds:Transforms>
+        <ds:DigestMethod Algorithm="http://www.w3.org/2001/04/xmlenc#sha256"/>
+        <ds:DigestValue>%s</ds:DigestValue>
+      </ds:Reference>
+      <ds:Reference URI="">
+        <ds:Transforms>
+          <ds:Transform Algorithm="http://www.w3.org/2000/09/xmldsig#enveloped-signature"/>
+        </ds:Transforms>
+        <ds:DigestMethod Algorithm="http://www.w3.org/2001/04/xmlenc#sha256"/>
+        <ds:DigestValue>%s</ds:DigestValue>
+      </ds:Reference>
+    </ds:SignedInfo>
+    <ds:SignatureValue>%s</ds:SignatureValue>
+    <ds:KeyInfo><ds:X509Data><ds:X509Certificate>AQID</ds:X509Certificate></ds:X509Data></ds:KeyInfo>
+  </ds:Signature>
+</root>`
+
+// signDocument fills digest and signature values of the template.
+func signDocument(t *testing.T, key *rsa.PrivateKey) string {
+	t.Helper()
+
+	itemDigest := sha256.Sum256([]byte(`<item xmlns="urn:root" ID="i1" note="a&amp;b"><v>1</v></item>`))
+	docDigest := sha256.Sum256([]byte("<root xmlns=\"urn:root\" xmlns:ds=\"http://www.w3.org/2000/09/xmldsig#\" ID=\"doc\">\n" +
+		"  <item ID=\"i1\" note=\"a&amp;b\"><v>1</v></item>\n  \n</root>"))
+
+	doc := fmt.Sprintf(signedTemplate,
+		base64.StdEncoding.EncodeToString(itemDigest[:]), base64.StdEncoding.EncodeToString(docDigest[:]), "")
+
+	sigs, err := FindSignatures([]byte(doc))
+	require.NoError(t, err)
+	require.Len(t, sigs, 1)
+
+	signedInfo, err := sigs[0].SignedInfo()
+	require.NoError(t, err)
+
+	hashed := sha256.Sum256(signedInfo)
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+	require.NoError(t, err)
+
+	return fmt.Sprintf(signedTemplate,
+		base64.StdEncoding.EncodeToString(itemDigest[:]), base64.StdEncoding.EncodeToString(docDigest[:]),
+		base64.StdEncoding.EncodeToString(signature))
+}
+
+func TestFindSignatures(t *testing.T) {
+	doc := fmt.Sprintf(signedTemplate, "AQ==", "Ag==", "\n  Aw==\n")
+
+	sigs, err := FindSignatures([]byte(doc))
+	require.NoError(t, err)
+	require.Len(t, sigs, 1)
+
+	sig := sigs[0]
+	assert.Equal(t, AlgExcC14N, sig.CanonicalizationMethod)
+	assert.Equal(t, AlgRSASHA256, sig.SignatureMethod)
+	assert.Equal(t, []byte{3}, sig.SignatureValue)
+	assert.Equal(t, [][]byte{{1, 2, 3}}, sig.X509Certificates)
+	assert.Equal(t, []Reference{
+		{
+			URI:          "#i1",
+			Transforms:   []Transform{{Algorithm: AlgEnvelopedSignature}, {Algorithm: AlgExcC14N}},
+			DigestMethod: AlgSHA256,
+			DigestValue:  []byte{1},
+		},
+		{
+			URI:          "",
+			Transforms:   []Transform{{Algorithm: AlgEnvelopedSignature}},
+			DigestMethod: AlgSHA256,
+			DigestValue:  []byte{2},
+		},
+	}, sig.References)
+	assert.True(t, strings.HasPrefix(string(sig.Raw()), "<ds:Signature>"))
+	assert.True(t, strings.HasSuffix(string(sig.Raw()), "</ds:Signature>"))
+
+	signedInfo, err := sig.SignedInfo()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(signedInfo),
+		`<ds:SignedInfo xmlns:ds="http://www.w3.org/2000/09/xmldsig#">`+"\n      "+
+			`<ds:CanonicalizationMethod Algorithm="http://www.w3.org/2001/10/xml-exc-c14n#"></ds:CanonicalizationMethod>`),
+		string(signedInfo))
+}
+
+func TestSignature_Verify(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	doc := signDocument(t, key)
+
+	tests := []struct {
+		name    string
+		doc     string
+		wantErr error
+	}{
+		{name: "valid", doc: doc},
+		{name: "tampered item", doc: strings.Replace(doc, "<v>1</v>", "<v>2</v>", 1), wantErr: ErrDigestMismatch},
+		{name: "tampered document", doc: strings.Replace(doc, "</root>", "<x/></root>", 1), wantErr: ErrDigestMismatch},
+		{name: "tampered signed info", doc: strings.Replace(doc, `URI=""`, `URI="#doc"`, 1), wantErr: rsa.ErrVerification},
+		{name: "duplicate id", doc: strings.Replace(doc, "</root>", `<item ID="i1"/></root>`, 1), wantErr: ErrDuplicateID},
+		{name: "missing reference", doc: strings.Replace(doc, `ID="i1"`, `ID="i2"`, 1), wantErr: ErrReferenceNotFound},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			sigs, err := FindSignatures([]byte(test.doc))
+			require.NoError(t, err)
+			require.Len(t, sigs, 1)
+
+			err = sigs[0].Verify(DefaultHash, RSAVerifier(&key.PublicKey))
+			if test.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestSignature_UnsupportedAlgorithm(t *testing.T) {
+	doc := strings.Replace(fmt.Sprintf(signedTemplate, "AQ==", "Ag==", "Aw=="), AlgSHA256, "urn:unknown", 1)
+
+	sigs, err := FindSignatures([]byte(doc))
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, sigs[0].VerifyReferences(DefaultHash), ErrUnsupportedAlgorithm)
+}
+
+func TestSignature_Verify_InjectedElements(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	doc := strings.Replace(signDocument(t, key), `<v>1</v></item>`, `<v>1</v></item><admin>true</admin>`, 1)
+
+	// Digest of the tampered document, placed where only the signed ds:SignedInfo must be read from.
+	tamperedDigest := sha256.Sum256([]byte("<root xmlns=\"urn:root\" xmlns:ds=\"http://www.w3.org/2000/09/xmldsig#\" ID=\"doc\">\n" +
+		"  <item ID=\"i1\" note=\"a&amp;b\"><v>1</v></item><admin>true</admin>\n  \n</root>"))
+	injected := base64.StdEncoding.EncodeToString(tamperedDigest[:])
+
+	tests := []struct {
+		name      string
+		injection string
+		wantErr   error
+	}{
+		{
+			name:      "digest value in object",
+			injection: `<ds:Object><ds:DigestValue>` + injected + `</ds:DigestValue></ds:Object>`,
+			wantErr:   ErrDigestMismatch,
+		},
+		{
+			name: "reference in object",
+			injection: `<ds:Object><ds:Reference URI=""><ds:DigestMethod Algorithm="` + AlgSHA256 + `"/>` +
+				`<ds:DigestValue>` + injected + `</ds:DigestValue></ds:Reference></ds:Object>`,
+			wantErr: ErrDigestMismatch,
+		},
+		{
+			name:      "signed info in object",
+			injection: `<ds:Object><ds:SignedInfo/></ds:Object>`,
+			wantErr:   ErrDigestMismatch,
+		},
+		{
+			name:      "digest value in foreign element",
+			injection: `<x:ext xmlns:x="urn:x"><ds:DigestValue>` + injected + `</ds:DigestValue></x:ext>`,
+			wantErr:   ErrDigestMismatch,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			sigs, err := FindSignatures([]byte(strings.Replace(doc, "</ds:KeyInfo>", "</ds:KeyInfo>"+test.injection, 1)))
+			require.NoError(t, err)
+			require.Len(t, sigs, 1)
+
+			assert.ErrorIs(t, sigs[0].Verify(DefaultHash, RSAVerifier(&key.PublicKey)), test.wantErr)
+			assert.Len(t, sigs[0].References, 2)
+		})
+	}
+}
+
+func TestFindSignatures_DuplicateElements(t *testing.T) {
+	doc := fmt.Sprintf(signedTemplate, "AQ==", "Ag==", "Aw==")
+
+	tests := []struct {
+		name string
+		doc  string
+	}{
+		{
+			name: "signed info",
+			doc:  strings.Replace(doc, "<ds:SignatureValue>", "<ds:SignedInfo/><ds:SignatureValue>", 1),
+		},
+		{
+			name: "digest value",
+			doc:  strings.Replace(doc, "</ds:Reference>", "<ds:DigestValue>AQ==</ds:DigestValue></ds:Reference>", 1),
+		},
+		{
+			name: "digest method",
+			doc:  strings.Replace(doc, "</ds:Reference>", `<ds:DigestMethod Algorithm="`+AlgSHA1+`"/></ds:Reference>`, 1),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			_, err := FindSignatures([]byte(test.doc))
+			assert.ErrorIs(t, err, ErrInvalidSignature)
+		})
+	}
+}
+
+func TestSignature_Resolve(t *testing.T) {
+	doc := fmt.Sprintf(signedTemplate, "AQ==", "Ag==", "Aw==")
+
+	sigs, err := FindSignatures([]byte(doc))
+	require.NoError(t, err)
+
+	start, end, err := sigs[0].Resolve(sigs[0].References[0])
+	require.NoError(t, err)
+	assert.Equal(t, `<item ID="i1" note="a&amp;b"><v>1</v></item>`, doc[start:end])
+
+	start, end, err = sigs[0].Resolve(sigs[0].References[1])
+	require.NoError(t, err)
+	assert.Equal(t, doc, doc[start:end])
+
+	_, _, err = sigs[0].Resolve(Reference{URI: "#missing"})
+	assert.ErrorIs(t, err, ErrReferenceNotFound)
+}
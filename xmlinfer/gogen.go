@@ -0,0 +1,195 @@
+package xmlinfer
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// GenerateGo writes Go source of the package pkg with struct types for the inferred structure.
+//
+// Generated types can be used with encoding/xml:
+//   - root type has XMLName field;
+//   - attributes and leaf child elements are fields of simple types;
+//   - child elements that occurred more than once in the same parent are slices;
+//   - optional complex child elements are pointers;
+//   - text of elements with attributes or children is held in `Value` field.
+//
+// xs:dateTime values are generated as strings, as values without timezone cannot be decoded into time.Time.
+func GenerateGo(w io.Writer, root *Element, pkg string) error {
+	g := goGenerator{
+		typeNames: make(map[*Element]string),
+		usedNames: make(map[string]bool),
+	}
+
+	g.buf.WriteString("// Code generated by xmltogo. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&g.buf, "package %s\n\nimport \"encoding/xml\"\n", pkg)
+
+	g.writeType(root, "", true)
+
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("format generated source: %w", err)
+	}
+
+	_, err = w.Write(src)
+
+	return err
+}
+
+type goGenerator struct {
+	buf       bytes.Buffer
+	typeNames map[*Element]string
+	usedNames map[string]bool
+}
+
+// typeName returns unique name of the struct type for the element.
+func (g *goGenerator) typeName(elem *Element, parentType string) string {
+	if name, ok := g.typeNames[elem]; ok {
+		return name
+	}
+
+	name := goIdentifier(elem.Name)
+	if g.usedNames[name] {
+		name = parentType + name
+	}
+
+	name = uniqueName(g.usedNames, name)
+
+	g.typeNames[elem] = name
+
+	return name
+}
+
+func (g *goGenerator) writeType(elem *Element, parentType string, isRoot bool) {
+	name := g.typeName(elem, parentType)
+
+	fmt.Fprintf(&g.buf, "\ntype %s struct {\n", name)
+
+	fieldNames := map[string]bool{"XMLName": true}
+
+	if isRoot {
+		fmt.Fprintf(&g.buf, "XMLName xml.Name `xml:%q`\n", localName(elem.Name))
+	}
+
+	for _, attr := range elem.Attrs {
+		tag := localName(attr.Name) + ",attr"
+		if attr.Optional {
+			tag += ",omitempty"
+		}
+
+		g.writeField(fieldNames, goIdentifier(attr.Name), goType(attr.Type), tag)
+	}
+
+	var complexChildren []*Element
+
+	for _, child := range elem.Children {
+		var typ string
+
+		if child.IsLeaf() {
+			typ = goType(child.Text)
+		} else {
+			typ = g.typeName(child.Element, name)
+			complexChildren = append(complexChildren, child.Element)
+
+			if child.MinOccurs == 0 && child.MaxOccurs <= 1 {
+				typ = "*" + typ
+			}
+		}
+
+		if child.MaxOccurs > 1 {
+			typ = "[]" + typ
+		}
+
+		tag := localName(child.Name)
+		if child.MinOccurs == 0 {
+			tag += ",omitempty"
+		}
+
+		g.writeField(fieldNames, goIdentifier(child.Name), typ, tag)
+	}
+
+	if elem.Text != TypeUnknown && !elem.IsLeaf() {
+		g.writeField(fieldNames, "Value", goType(elem.Text), ",chardata")
+	}
+
+	g.buf.WriteString("}\n")
+
+	for _, child := range complexChildren {
+		g.writeType(child, name, false)
+	}
+}
+
+func (g *goGenerator) writeField(fieldNames map[string]bool, name, typ, tag string) {
+	fmt.Fprintf(&g.buf, "%s %s `xml:%q`\n", uniqueName(fieldNames, name), typ, tag)
+}
+
+// uniqueName returns name that is not in used, adding number to it if needed, and marks it as used.
+func uniqueName(used map[string]bool, name string) string {
+	unique := name
+
+	for i := 2; used[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+
+	used[unique] = true
+
+	return unique
+}
+
+func goType(t Type) string {
+	switch t {
+	case TypeBoolean:
+		return "bool"
+	case TypeInteger:
+		return "int64"
+	case TypeDouble:
+		return "float64"
+	default:
+		return "string"
+	}
+}
+
+// goIdentifier converts XML name to exported Go identifier, like `order-id` to `OrderID`.
+func goIdentifier(name string) string {
+	var sb strings.Builder
+
+	for _, part := range strings.FieldsFunc(localName(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if upper := strings.ToUpper(part); commonInitialisms[upper] {
+			sb.WriteString(upper)
+
+			continue
+		}
+
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		sb.WriteString(string(runes))
+	}
+
+	ident := sb.String()
+	if ident == "" || unicode.IsDigit([]rune(ident)[0]) {
+		ident = "X" + ident
+	}
+
+	return ident
+}
+
+// commonInitialisms are written in upper case in Go identifiers.
+var commonInitialisms = map[string]bool{
+	"ID": true, "URL": true, "URI": true, "HTTP": true, "XML": true, "JSON": true, "UUID": true,
+}
+
+// localName returns name without namespace prefix.
+func localName(name string) string {
+	if idx := strings.IndexByte(name, ':'); idx != -1 {
+		return name[idx+1:]
+	}
+
+	return name
+}
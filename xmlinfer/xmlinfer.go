@@ -0,0 +1,313 @@
+/*
+Package xmlinfer infers structure of documents from samples.
+
+Inferred structure describes element hierarchy, attributes, number of occurrences of child elements
+and guessed simple types of values. It can be used to generate code or schema for the documents,
+see GenerateGo and GenerateXSD.
+
+Elements are identified by their path from the root, so elements with the same name
+under different parents are inferred separately.
+Namespace declarations(`xmlns` attributes) are not included in the structure.
+*/
+package xmlinfer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"fastxml"
+)
+
+var ErrDifferentRoots = errors.New("documents have different root elements")
+
+// Type is a guessed simple type of the value.
+type Type uint8
+
+const (
+	// TypeUnknown is the type of elements that never had text.
+	TypeUnknown Type = iota
+	TypeBoolean
+	TypeInteger
+	TypeDouble
+	TypeDateTime
+	TypeString
+)
+
+func (t Type) String() string {
+	switch t {
+	case TypeBoolean:
+		return "boolean"
+	case TypeInteger:
+		return "integer"
+	case TypeDouble:
+		return "double"
+	case TypeDateTime:
+		return "dateTime"
+	case TypeString:
+		return "string"
+	default:
+		return "unknown"
+	}
+}
+
+// GuessType returns the most specific type of the value.
+//
+// "1" and "0" are guessed as integers, even though they are valid booleans as well.
+func GuessType(val string) Type {
+	if _, err := fastxml.ParseXSInteger(val); err == nil {
+		return TypeInteger
+	}
+
+	if _, err := fastxml.ParseXSDouble(val); err == nil {
+		return TypeDouble
+	}
+
+	if _, err := fastxml.ParseXSBoolean(val); err == nil {
+		return TypeBoolean
+	}
+
+	if _, err := fastxml.ParseXSDateTime(val); err == nil {
+		return TypeDateTime
+	}
+
+	return TypeString
+}
+
+// Merge returns the most specific type that describes values of both types.
+func (t Type) Merge(other Type) Type {
+	switch {
+	case t == other || other == TypeUnknown:
+		return t
+	case t == TypeUnknown:
+		return other
+	case (t == TypeInteger && other == TypeDouble) || (t == TypeDouble && other == TypeInteger):
+		return TypeDouble
+	default:
+		return TypeString
+	}
+}
+
+// Element is the inferred structure of the element.
+type Element struct {
+	Name string
+	// Occurrences is the total number of occurrences of the element in all samples.
+	Occurrences int
+	// Attrs are attributes of the element in order of first appearance.
+	Attrs []*Attr
+	// Children are child elements in order of first appearance.
+	Children []*Child
+	// Text is the type of the element text, TypeUnknown if element never had text.
+	Text Type
+}
+
+// IsLeaf reports if element has neither attributes nor child elements.
+func (e *Element) IsLeaf() bool {
+	return len(e.Attrs) == 0 && len(e.Children) == 0
+}
+
+// Attr is the inferred attribute of the element.
+type Attr struct {
+	Name string
+	Type Type
+	// Optional is set if attribute was missing in some occurrences of the element.
+	Optional bool
+
+	occurrences int
+}
+
+// Child is the child element with number of its occurrences in the parent element.
+type Child struct {
+	*Element
+	// MinOccurs is the minimal number of occurrences in a single parent element.
+	MinOccurs int
+	// MaxOccurs is the maximal number of occurrences in a single parent element.
+	MaxOccurs int
+}
+
+// frame is an open element.
+type frame struct {
+	elem   *Element
+	counts map[*Element]int
+	text   strings.Builder
+}
+
+// Inferrer infers structure from samples that are added to it.
+type Inferrer struct {
+	root *Element
+}
+
+// Infer returns structure inferred from the samples.
+func Infer(samples ...[]byte) (*Element, error) {
+	var in Inferrer
+
+	for i, sample := range samples {
+		if err := in.Add(sample); err != nil {
+			return nil, fmt.Errorf("sample %d: %w", i, err)
+		}
+	}
+
+	return in.Root(), nil
+}
+
+// Add will add structure of the sample to the inferred one.
+func (in *Inferrer) Add(sample []byte) error {
+	p := fastxml.NewParser(sample, false, fastxml.WithSkipComments(), fastxml.WithSkipProcInst(), fastxml.WithSkipDirectives())
+
+	var stack []*frame
+
+	for {
+		token, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		switch tkn := token.(type) {
+		case *fastxml.StartToken:
+			elem, err := in.element(stack, tkn.Name)
+			if err != nil {
+				return err
+			}
+
+			elem.Occurrences++
+
+			if err := addAttributes(elem, tkn); err != nil {
+				return err
+			}
+
+			stack = append(stack, &frame{elem: elem, counts: make(map[*Element]int)})
+		case *fastxml.CharData:
+			if len(stack) != 0 {
+				stack[len(stack)-1].text.Write(*tkn)
+			}
+		case *fastxml.EndElement:
+			if len(stack) == 0 {
+				continue
+			}
+
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			top.finish()
+		}
+	}
+}
+
+// element returns element for the start element with the name.
+func (in *Inferrer) element(stack []*frame, name string) (*Element, error) {
+	if len(stack) == 0 {
+		if in.root == nil {
+			in.root = &Element{Name: fastxml.CopyString(name)}
+		}
+
+		if in.root.Name != name {
+			return nil, fmt.Errorf("%w: %q and %q", ErrDifferentRoots, in.root.Name, name)
+		}
+
+		return in.root, nil
+	}
+
+	parent := stack[len(stack)-1]
+
+	for _, child := range parent.elem.Children {
+		if child.Name == name {
+			parent.counts[child.Element]++
+
+			return child.Element, nil
+		}
+	}
+
+	child := &Child{
+		Element:   &Element{Name: fastxml.CopyString(name)},
+		MinOccurs: -1,
+	}
+
+	// Child was not present in previous occurrences of the parent.
+	if parent.elem.Occurrences > 1 {
+		child.MinOccurs = 0
+	}
+
+	parent.elem.Children = append(parent.elem.Children, child)
+	parent.counts[child.Element]++
+
+	return child.Element, nil
+}
+
+func addAttributes(elem *Element, start *fastxml.StartToken) error {
+	for {
+		name, val, err := start.NextAttribute()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if name == "xmlns" || strings.HasPrefix(name, "xmlns:") {
+			continue
+		}
+
+		attr := elem.attr(name)
+		attr.occurrences++
+		attr.Type = attr.Type.Merge(GuessType(fastxml.Unescape(val)))
+	}
+}
+
+func (e *Element) attr(name string) *Attr {
+	for _, attr := range e.Attrs {
+		if attr.Name == name {
+			return attr
+		}
+	}
+
+	attr := &Attr{Name: fastxml.CopyString(name)}
+	e.Attrs = append(e.Attrs, attr)
+
+	return attr
+}
+
+// finish updates element with data of its closed occurrence.
+func (f *frame) finish() {
+	if text := strings.TrimSpace(f.text.String()); text != "" {
+		f.elem.Text = f.elem.Text.Merge(GuessType(fastxml.Unescape(text)))
+	}
+
+	for _, child := range f.elem.Children {
+		count := f.counts[child.Element]
+
+		if child.MinOccurs == -1 || count < child.MinOccurs {
+			child.MinOccurs = count
+		}
+
+		if count > child.MaxOccurs {
+			child.MaxOccurs = count
+		}
+	}
+}
+
+// Root returns inferred structure of the root element, or nil if no elements were found.
+//
+// Returned structure must not be modified while samples are still added.
+func (in *Inferrer) Root() *Element {
+	if in.root != nil {
+		markOptional(in.root)
+	}
+
+	return in.root
+}
+
+func markOptional(elem *Element) {
+	for _, attr := range elem.Attrs {
+		attr.Optional = attr.occurrences < elem.Occurrences
+	}
+
+	for _, child := range elem.Children {
+		markOptional(child.Element)
+	}
+}
@@ -0,0 +1,158 @@
+package xmlinfer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var samples = [][]byte{
+	[]byte(`<?xml version="1.0"?>
+<catalog xmlns:x="urn:x" version="1">
+	<book id="1" lang="en">
+		<title>Go</title>
+		<price>10.5</price>
+		<x:tag>a</x:tag>
+		<x:tag>b</x:tag>
+	</book>
+	<book id="2">
+		<title>XML &amp; you</title>
+		<price>7</price>
+		<available>true</available>
+	</book>
+</catalog>`),
+	[]byte(`<catalog version="2"><book id="3"><title>Empty</title><price>1</price><note kind="x">text</note></book></catalog>`),
+}
+
+func TestInfer(t *testing.T) {
+	root, err := Infer(samples...)
+	require.NoError(t, err)
+
+	assert.Equal(t, "catalog", root.Name)
+	assert.Equal(t, 2, root.Occurrences)
+	require.Len(t, root.Attrs, 1)
+	assert.Equal(t, Attr{Name: "version", Type: TypeInteger, occurrences: 2}, *root.Attrs[0])
+
+	require.Len(t, root.Children, 1)
+
+	book := root.Children[0]
+	assert.Equal(t, "book", book.Name)
+	assert.Equal(t, 1, book.MinOccurs)
+	assert.Equal(t, 2, book.MaxOccurs)
+	assert.Equal(t, 3, book.Occurrences)
+
+	require.Len(t, book.Attrs, 2)
+	assert.False(t, book.Attrs[0].Optional)
+	assert.True(t, book.Attrs[1].Optional)
+
+	var children []string
+	for _, child := range book.Children {
+		children = append(children, child.Name)
+	}
+
+	assert.Equal(t, []string{"title", "price", "x:tag", "available", "note"}, children)
+
+	price, tag, available := book.Children[1], book.Children[2], book.Children[3]
+	assert.Equal(t, TypeDouble, price.Text)
+	assert.Equal(t, []int{1, 1}, []int{price.MinOccurs, price.MaxOccurs})
+	assert.Equal(t, []int{0, 2}, []int{tag.MinOccurs, tag.MaxOccurs})
+	assert.Equal(t, TypeBoolean, available.Text)
+	assert.Equal(t, 0, available.MinOccurs)
+}
+
+func TestInfer_DifferentRoots(t *testing.T) {
+	_, err := Infer([]byte(`<a/>`), []byte(`<b/>`))
+	assert.ErrorIs(t, err, ErrDifferentRoots)
+}
+
+func TestGuessType(t *testing.T) {
+	tests := map[string]Type{
+		"1":                    TypeInteger,
+		"-1.5e3":               TypeDouble,
+		"false":                TypeBoolean,
+		"2004-12-23T18:00:15Z": TypeDateTime,
+		"text":                 TypeString,
+	}
+
+	for val, typ := range tests {
+		assert.Equal(t, typ, GuessType(val), val)
+	}
+
+	assert.Equal(t, TypeDouble, TypeInteger.Merge(TypeDouble))
+	assert.Equal(t, TypeString, TypeInteger.Merge(TypeBoolean))
+	assert.Equal(t, TypeBoolean, TypeUnknown.Merge(TypeBoolean))
+}
+
+func TestGenerateGo(t *testing.T) {
+	root, err := Infer(samples...)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+
+	require.NoError(t, GenerateGo(&buf, root, "model"))
+
+	assert.Equal(t, "// Code generated by xmltogo. DO NOT EDIT.\n\n"+
+		"package model\n\n"+
+		"import \"encoding/xml\"\n\n"+
+		"type Catalog struct {\n"+
+		"\tXMLName xml.Name `xml:\"catalog\"`\n"+
+		"\tVersion int64    `xml:\"version,attr\"`\n"+
+		"\tBook    []Book   `xml:\"book\"`\n"+
+		"}\n\n"+
+		"type Book struct {\n"+
+		"\tID        int64    `xml:\"id,attr\"`\n"+
+		"\tLang      string   `xml:\"lang,attr,omitempty\"`\n"+
+		"\tTitle     string   `xml:\"title\"`\n"+
+		"\tPrice     float64  `xml:\"price\"`\n"+
+		"\tTag       []string `xml:\"tag,omitempty\"`\n"+
+		"\tAvailable bool     `xml:\"available,omitempty\"`\n"+
+		"\tNote      *Note    `xml:\"note,omitempty\"`\n"+
+		"}\n\n"+
+		"type Note struct {\n"+
+		"\tKind  string `xml:\"kind,attr\"`\n"+
+		"\tValue string `xml:\",chardata\"`\n"+
+		"}\n", buf.String())
+}
+
+func TestGenerateXSD(t *testing.T) {
+	root, err := Infer(
+		[]byte(`<feed><entry id="1"><title>a</title><tag>x</tag><tag>y</tag></entry><note lang="en">n</note></feed>`),
+		[]byte(`<feed>text<entry id="2"><title>b</title></entry></feed>`),
+	)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+
+	require.NoError(t, GenerateXSD(&buf, root))
+
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" elementFormDefault="qualified">
+  <xs:element name="feed">
+    <xs:complexType mixed="true">
+      <xs:sequence>
+        <xs:element name="entry">
+          <xs:complexType>
+            <xs:sequence>
+              <xs:element name="title" type="xs:string"/>
+              <xs:element name="tag" minOccurs="0" maxOccurs="unbounded" type="xs:string"/>
+            </xs:sequence>
+            <xs:attribute name="id" type="xs:integer" use="required"/>
+          </xs:complexType>
+        </xs:element>
+        <xs:element name="note" minOccurs="0">
+          <xs:complexType>
+            <xs:simpleContent>
+              <xs:extension base="xs:string">
+                <xs:attribute name="lang" type="xs:string" use="required"/>
+              </xs:extension>
+            </xs:simpleContent>
+          </xs:complexType>
+        </xs:element>
+      </xs:sequence>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>
+`, buf.String())
+}
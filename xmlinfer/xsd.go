@@ -0,0 +1,117 @@
+package xmlinfer
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// GenerateXSD writes a rough XML Schema for the inferred structure.
+//
+// Schema is written in a single nested definition, without named types:
+//   - child elements are described with xs:sequence in order of first appearance,
+//     so documents with different order of child elements will not be valid;
+//   - minOccurs and maxOccurs are taken from observed number of child elements;
+//   - attributes that were always present are required;
+//   - types of text and attribute values are guessed simple types.
+//
+// Namespace prefixes are removed from names, target namespace is not set.
+func GenerateXSD(w io.Writer, root *Element) error {
+	g := xsdGenerator{w: bufio.NewWriter(w)}
+
+	g.w.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	g.w.WriteString(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" elementFormDefault="qualified">` + "\n")
+	g.writeElement(root, "", 1)
+	g.w.WriteString("</xs:schema>\n")
+
+	return g.w.Flush()
+}
+
+type xsdGenerator struct {
+	w *bufio.Writer
+}
+
+// writeElement writes element declaration, occurs holds occurrence attributes for child elements.
+func (g *xsdGenerator) writeElement(elem *Element, occurs string, depth int) {
+	g.line(depth, `<xs:element name="`+localName(elem.Name)+`"`+occurs)
+
+	if elem.IsLeaf() {
+		g.w.WriteString(` type="` + xsdType(elem.Text) + `"/>` + "\n")
+
+		return
+	}
+
+	g.w.WriteString(">\n")
+
+	switch {
+	case len(elem.Children) == 0 && elem.Text != TypeUnknown:
+		// Only attributes and text.
+		g.line(depth+1, "<xs:complexType>\n")
+		g.line(depth+2, "<xs:simpleContent>\n")
+		g.line(depth+3, `<xs:extension base="`+xsdType(elem.Text)+`">`+"\n")
+		g.writeAttributes(elem, depth+4)
+		g.line(depth+3, "</xs:extension>\n")
+		g.line(depth+2, "</xs:simpleContent>\n")
+	default:
+		if elem.Text != TypeUnknown {
+			g.line(depth+1, `<xs:complexType mixed="true">`+"\n")
+		} else {
+			g.line(depth+1, "<xs:complexType>\n")
+		}
+
+		if len(elem.Children) != 0 {
+			g.line(depth+2, "<xs:sequence>\n")
+
+			for _, child := range elem.Children {
+				g.writeElement(child.Element, childOccurs(child), depth+3)
+			}
+
+			g.line(depth+2, "</xs:sequence>\n")
+		}
+
+		g.writeAttributes(elem, depth+2)
+	}
+
+	g.line(depth+1, "</xs:complexType>\n")
+	g.line(depth, "</xs:element>\n")
+}
+
+func (g *xsdGenerator) writeAttributes(elem *Element, depth int) {
+	for _, attr := range elem.Attrs {
+		use := "required"
+		if attr.Optional {
+			use = "optional"
+		}
+
+		g.line(depth, `<xs:attribute name="`+localName(attr.Name)+`" type="`+xsdType(attr.Type)+`" use="`+use+`"/>`+"\n")
+	}
+}
+
+// line writes indentation for the depth and the data.
+func (g *xsdGenerator) line(depth int, data string) {
+	g.w.WriteString(strings.Repeat("  ", depth))
+	g.w.WriteString(data)
+}
+
+func childOccurs(child *Child) string {
+	var occurs string
+
+	if child.MinOccurs != 1 {
+		occurs += ` minOccurs="` + strconv.Itoa(child.MinOccurs) + `"`
+	}
+
+	if child.MaxOccurs > 1 {
+		occurs += ` maxOccurs="unbounded"`
+	}
+
+	return occurs
+}
+
+func xsdType(t Type) string {
+	if t == TypeUnknown {
+		return "xs:string"
+	}
+
+	return "xs:" + t.String()
+}
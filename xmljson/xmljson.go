@@ -0,0 +1,499 @@
+/*
+Package xmljson converts XML documents to JSON.
+
+Input is parsed as it is read, so document does not need to be loaded into memory
+before conversion. Each root element is written as a separate JSON object
+as soon as it is closed.
+
+Conversion follows widely used conventions:
+  - element is converted to the object with the single key - element name;
+  - attributes are written as keys with AttrPrefix prepended to their names;
+  - element without attributes and child elements is converted to its text;
+  - text of the element with attributes or child elements is written with TextKey;
+  - repeated child elements are converted to arrays.
+
+With Options.Records only matching elements are converted, each one to a separate line (NDJSON),
+which is the common format for feeding large XML dumps into data pipelines.
+
+Objects do not keep order of their keys, so with Options.PreserveOrder content of elements with attributes
+or child elements is converted to the array of {"name": ..., "value": ...} objects in document order instead.
+*/
+package xmljson
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"fastxml"
+	"fastxml/xmlpath"
+)
+
+// DefaultTextKey is the key for element text if Options.TextKey is not set.
+const DefaultTextKey = "#text"
+
+var (
+	ErrUnexpectedEndElement = errors.New("unexpected end element")
+	ErrUnclosedElement      = errors.New("unclosed element")
+)
+
+// Options configures conversion.
+type Options struct {
+	// AttrPrefix is prepended to attribute names, for example `@`.
+	AttrPrefix string
+	// TextKey is the key for element text, DefaultTextKey is used if empty.
+	TextKey string
+	// ArrayPaths are slash separated paths of elements that are always converted to arrays,
+	// even if element is not repeated. Path starts with the root element name, like `catalog/book`.
+	ArrayPaths []string
+	// ArrayRules are path expressions of elements that are always converted to arrays,
+	// like `//item` or `/catalog/*/book`, in addition to ArrayPaths.
+	ArrayRules []*xmlpath.Path
+	// Indent enables pretty printing, each level is indented with this value.
+	Indent string
+	// PreserveOrder converts content of elements with attributes or child elements
+	// to the array of {"name": ..., "value": ...} objects, one for each attribute, child element
+	// and text between child elements, in document order. Repeated child elements are not grouped.
+	PreserveOrder bool
+	// Records enables newline-delimited output: instead of root elements, each element matching the path
+	// is written as a single line with its converted value, as soon as it is closed.
+	// Content outside of records is skipped, and Indent is ignored.
+	Records *xmlpath.Path
+}
+
+// element holds converted data of the element until it is closed.
+type element struct {
+	path     string
+	attrs    []attribute
+	text     strings.Builder
+	children []*group
+	// items holds child elements and text in document order, if Options.PreserveOrder is set.
+	items []item
+}
+
+// item is a child element or, if elem is nil, text of the element.
+type item struct {
+	elem *element
+	name string
+	text string
+}
+
+// flushText moves collected text to items.
+func (e *element) flushText() {
+	if text := strings.TrimSpace(e.text.String()); text != "" {
+		e.items = append(e.items, item{text: text})
+	}
+
+	e.text.Reset()
+}
+
+type attribute struct {
+	name, value string
+}
+
+// group holds child elements with the same name, in order of first appearance.
+type group struct {
+	name     string
+	path     string
+	elements []*element
+}
+
+func (e *element) group(name string) *group {
+	for _, g := range e.children {
+		if g.name == name {
+			return g
+		}
+	}
+
+	g := &group{name: name, path: e.path + "/" + name}
+	e.children = append(e.children, g)
+
+	return g
+}
+
+var _ fastxml.TokenWriter = (*Converter)(nil)
+
+// Converter converts tokens it receives to JSON.
+//
+// Converter implements fastxml.TokenWriter, so it can be used with fastxml.Copy.
+type Converter struct {
+	w          *bufio.Writer
+	opts       Options
+	arrayPaths map[string]struct{}
+	stack      []*element
+	// names holds names of open elements when Options.Records is set.
+	names []string
+	// recordDepth is the depth of the current record, or 0 if it is not started.
+	recordDepth int
+}
+
+// NewConverter will create a converter that writes JSON to w.
+//
+// Converter.Close must be called after the last token was handled.
+func NewConverter(w io.Writer, opts Options) *Converter {
+	if opts.TextKey == "" {
+		opts.TextKey = DefaultTextKey
+	}
+
+	if opts.Records != nil {
+		opts.Indent = ""
+	}
+
+	c := &Converter{
+		w:          bufio.NewWriter(w),
+		opts:       opts,
+		arrayPaths: make(map[string]struct{}, len(opts.ArrayPaths)),
+	}
+
+	for _, path := range opts.ArrayPaths {
+		c.arrayPaths[strings.Trim(path, "/")] = struct{}{}
+	}
+
+	return c
+}
+
+// Convert reads XML from r and writes JSON to w.
+func Convert(w io.Writer, r io.Reader, opts Options, parserOpts ...fastxml.ParserOption) error {
+	c := NewConverter(w, opts)
+	pw := fastxml.NewParserWriter(c.WriteToken, parserOpts...)
+
+	if _, err := io.Copy(pw, r); err != nil {
+		return err
+	}
+
+	if err := pw.Close(); err != nil {
+		return err
+	}
+
+	return c.Close()
+}
+
+// WriteToken will add token to the converted document.
+//
+// Tokens other than start and end elements and char data are ignored.
+func (c *Converter) WriteToken(token xml.Token) error {
+	switch tkn := token.(type) {
+	case *fastxml.StartToken:
+		return c.startElement(tkn)
+	case *fastxml.EndElement:
+		return c.endElement()
+	case *fastxml.CharData:
+		if len(c.stack) != 0 && c.inRecord() {
+			c.stack[len(c.stack)-1].text.Write(*tkn)
+		}
+	}
+
+	return nil
+}
+
+// Close will write buffered data to the underlying writer.
+//
+// Error is returned if some elements were not closed, but data of already closed roots is still written.
+func (c *Converter) Close() error {
+	if err := c.w.Flush(); err != nil {
+		return err
+	}
+
+	if len(c.stack) != 0 {
+		return fmt.Errorf("%w: %q", ErrUnclosedElement, c.stack[len(c.stack)-1].path)
+	}
+
+	return nil
+}
+
+func (c *Converter) startElement(tkn *fastxml.StartToken) error {
+	// Token data is only valid until next token, so everything that is kept is copied.
+	name := fastxml.CopyString(tkn.Name)
+
+	var elem *element
+
+	if c.opts.Records != nil && c.recordDepth == 0 {
+		c.names = append(c.names, name)
+		elem = &element{path: strings.Join(c.names, "/")}
+
+		if !c.opts.Records.Match(c.names) {
+			// Elements outside of records are only tracked to match paths.
+			c.stack = append(c.stack, elem)
+
+			return nil
+		}
+
+		c.recordDepth = len(c.names)
+	} else if len(c.stack) == 0 {
+		elem = &element{path: name}
+	} else {
+		parent := c.stack[len(c.stack)-1]
+		g := parent.group(name)
+		elem = &element{path: g.path}
+		g.elements = append(g.elements, elem)
+
+		if c.opts.PreserveOrder {
+			parent.flushText()
+			parent.items = append(parent.items, item{elem: elem, name: name})
+		}
+	}
+
+	for {
+		attrName, attrVal, err := tkn.NextAttribute()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		elem.attrs = append(elem.attrs, attribute{
+			name:  fastxml.CopyString(attrName),
+			value: fastxml.CopyString(fastxml.Unescape(attrVal)),
+		})
+	}
+
+	c.stack = append(c.stack, elem)
+
+	return nil
+}
+
+func (c *Converter) endElement() error {
+	if len(c.stack) == 0 {
+		return ErrUnexpectedEndElement
+	}
+
+	elem := c.stack[len(c.stack)-1]
+	c.stack = c.stack[:len(c.stack)-1]
+
+	if c.opts.PreserveOrder {
+		elem.flushText()
+	}
+
+	if c.opts.Records != nil {
+		return c.endRecordElement(elem)
+	}
+
+	if len(c.stack) != 0 {
+		return nil
+	}
+
+	return c.writeRoot(elem)
+}
+
+// inRecord reports if content of the current element is converted.
+func (c *Converter) inRecord() bool {
+	return c.opts.Records == nil || c.recordDepth != 0
+}
+
+// endRecordElement writes the record, if elem is the record element.
+func (c *Converter) endRecordElement(elem *element) error {
+	if c.recordDepth == 0 {
+		c.names = c.names[:len(c.names)-1]
+
+		return nil
+	}
+
+	if len(c.stack)+1 != c.recordDepth {
+		return nil
+	}
+
+	c.recordDepth = 0
+	c.names = c.names[:len(c.names)-1]
+
+	c.writeElement(elem, 0)
+
+	return c.w.WriteByte('\n')
+}
+
+func (c *Converter) writeRoot(root *element) error {
+	name := root.path
+
+	c.w.WriteByte('{')
+	c.newLine(1)
+	c.writeString(name)
+	c.writeColon()
+
+	if c.isArrayPath(name) {
+		c.writeArray([]*element{root}, 1)
+	} else {
+		c.writeElement(root, 1)
+	}
+
+	c.newLine(0)
+	c.w.WriteByte('}')
+
+	return c.w.WriteByte('\n')
+}
+
+func (c *Converter) writeElement(elem *element, depth int) {
+	text := strings.TrimSpace(elem.text.String())
+
+	if len(elem.attrs) == 0 && len(elem.children) == 0 {
+		if c.opts.PreserveOrder && len(elem.items) != 0 {
+			text = elem.items[0].text
+		}
+
+		c.writeString(fastxml.Unescape(text))
+
+		return
+	}
+
+	if c.opts.PreserveOrder {
+		c.writeOrdered(elem, depth)
+
+		return
+	}
+
+	c.w.WriteByte('{')
+
+	first := true
+	field := func(key string) {
+		if !first {
+			c.w.WriteByte(',')
+		}
+
+		first = false
+
+		c.newLine(depth + 1)
+		c.writeString(key)
+		c.writeColon()
+	}
+
+	for _, attr := range elem.attrs {
+		field(c.opts.AttrPrefix + attr.name)
+		c.writeString(attr.value)
+	}
+
+	for _, g := range elem.children {
+		field(g.name)
+
+		if c.isArrayPath(g.path) || len(g.elements) > 1 {
+			c.writeArray(g.elements, depth+1)
+		} else {
+			c.writeElement(g.elements[0], depth+1)
+		}
+	}
+
+	if text != "" {
+		field(c.opts.TextKey)
+		c.writeString(fastxml.Unescape(text))
+	}
+
+	c.newLine(depth)
+	c.w.WriteByte('}')
+}
+
+// writeOrdered writes attributes, child elements and text of the element as the array of name-value objects.
+func (c *Converter) writeOrdered(elem *element, depth int) {
+	c.w.WriteByte('[')
+
+	for i, attr := range elem.attrs {
+		c.writePair(i != 0, c.opts.AttrPrefix+attr.name, depth+1, func() { c.writeString(attr.value) })
+	}
+
+	for i, it := range elem.items {
+		next := i != 0 || len(elem.attrs) != 0
+
+		switch {
+		case it.elem == nil:
+			c.writePair(next, c.opts.TextKey, depth+1, func() { c.writeString(fastxml.Unescape(it.text)) })
+		case c.isArrayPath(it.elem.path):
+			c.writePair(next, it.name, depth+1, func() { c.writeArray([]*element{it.elem}, depth+2) })
+		default:
+			c.writePair(next, it.name, depth+1, func() { c.writeElement(it.elem, depth+2) })
+		}
+	}
+
+	c.newLine(depth)
+	c.w.WriteByte(']')
+}
+
+// writePair writes name-value object, separated from the previous one if next is set.
+func (c *Converter) writePair(next bool, name string, depth int, value func()) {
+	if next {
+		c.w.WriteByte(',')
+	}
+
+	c.newLine(depth)
+	c.w.WriteByte('{')
+	c.newLine(depth + 1)
+	c.writeString("name")
+	c.writeColon()
+	c.writeString(name)
+	c.w.WriteByte(',')
+	c.newLine(depth + 1)
+	c.writeString("value")
+	c.writeColon()
+	value()
+	c.newLine(depth)
+	c.w.WriteByte('}')
+}
+
+// isArrayPath reports if element with the path is always converted to array.
+func (c *Converter) isArrayPath(path string) bool {
+	if _, ok := c.arrayPaths[path]; ok {
+		return true
+	}
+
+	if len(c.opts.ArrayRules) == 0 {
+		return false
+	}
+
+	stack := strings.Split(path, "/")
+
+	for _, rule := range c.opts.ArrayRules {
+		if rule.Match(stack) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Converter) writeArray(elems []*element, depth int) {
+	c.w.WriteByte('[')
+
+	for i, elem := range elems {
+		if i != 0 {
+			c.w.WriteByte(',')
+		}
+
+		c.newLine(depth + 1)
+		c.writeElement(elem, depth+1)
+	}
+
+	c.newLine(depth)
+	c.w.WriteByte(']')
+}
+
+func (c *Converter) writeString(s string) {
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s) // Encoding of the string cannot fail.
+
+	c.w.Write(bytes.TrimSuffix(buf.Bytes(), []byte{'\n'}))
+}
+
+func (c *Converter) writeColon() {
+	c.w.WriteByte(':')
+
+	if c.opts.Indent != "" {
+		c.w.WriteByte(' ')
+	}
+}
+
+func (c *Converter) newLine(depth int) {
+	if c.opts.Indent == "" {
+		return
+	}
+
+	c.w.WriteByte('\n')
+
+	for i := 0; i < depth; i++ {
+		c.w.WriteString(c.opts.Indent)
+	}
+}
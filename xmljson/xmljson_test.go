@@ -0,0 +1,159 @@
+package xmljson
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"fastxml/xmlpath"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		opts   Options
+		result string
+	}{
+		{
+			name:   "text",
+			input:  `<root>a &amp; b</root>`,
+			result: `{"root":"a & b"}` + "\n",
+		},
+		{
+			name:   "attributes and text",
+			input:  `<root id="1" name='&lt;x&gt;'> text </root>`,
+			opts:   Options{AttrPrefix: "@"},
+			result: `{"root":{"@id":"1","@name":"<x>","#text":"text"}}` + "\n",
+		},
+		{
+			name:   "repeated children",
+			input:  `<root><a>1</a><b/><a>2</a></root>`,
+			result: `{"root":{"a":["1","2"],"b":""}}` + "\n",
+		},
+		{
+			name:   "array paths",
+			input:  `<root><a><b>1</b></a></root>`,
+			opts:   Options{ArrayPaths: []string{"root/a", "/root/a/b/"}},
+			result: `{"root":{"a":[{"b":["1"]}]}}` + "\n",
+		},
+		{
+			name:  "array rules",
+			input: `<root><a><item>1</item></a><b><item>2</item><c>3</c></b></root>`,
+			opts: Options{
+				ArrayPaths: []string{"root/b/c"},
+				ArrayRules: []*xmlpath.Path{xmlpath.MustCompile("//item"), xmlpath.MustCompile("/root/*")},
+			},
+			result: `{"root":{"a":[{"item":["1"]}],"b":[{"item":["2"],"c":["3"]}]}}` + "\n",
+		},
+		{
+			name:   "text key",
+			input:  `<root a="1">t</root>`,
+			opts:   Options{TextKey: "_"},
+			result: `{"root":{"a":"1","_":"t"}}` + "\n",
+		},
+		{
+			name:  "indent",
+			input: `<?xml version="1.0"?><root><a>1</a><a>2</a></root>`,
+			opts:  Options{Indent: "  "},
+			result: `{
+  "root": {
+    "a": [
+      "1",
+      "2"
+    ]
+  }
+}
+`,
+		},
+		{
+			name:  "preserve order",
+			input: `<root id="1"><a>1</a>text<b x="2"/><a>2</a><c/></root>`,
+			opts:  Options{AttrPrefix: "@", ArrayPaths: []string{"root/c"}, PreserveOrder: true},
+			result: `{"root":[{"name":"@id","value":"1"},{"name":"a","value":"1"},{"name":"#text","value":"text"},` +
+				`{"name":"b","value":[{"name":"@x","value":"2"}]},{"name":"a","value":"2"},{"name":"c","value":[""]}]}` + "\n",
+		},
+		{
+			name:  "preserve order indent",
+			input: "<root>\n  <a>1</a>\n  <b>x</b>\n</root>",
+			opts:  Options{Indent: " ", PreserveOrder: true},
+			result: `{
+ "root": [
+  {
+   "name": "a",
+   "value": "1"
+  },
+  {
+   "name": "b",
+   "value": "x"
+  }
+ ]
+}
+`,
+		},
+		{
+			name:  "records",
+			input: `<dump><meta>x</meta><item id="1"><v>a</v></item><group><item>2</item></group><item/></dump>`,
+			opts:  Options{Records: xmlpath.MustCompile("item"), Indent: "  ", ArrayPaths: []string{"dump/item/v"}},
+			result: `{"id":"1","v":["a"]}` + "\n" +
+				`"2"` + "\n" +
+				`""` + "\n",
+		},
+		{
+			name:   "nested records",
+			input:  `<r><a><a>1</a></a></r>`,
+			opts:   Options{Records: xmlpath.MustCompile("a")},
+			result: `{"a":"1"}` + "\n",
+		},
+		{
+			name:   "multiple roots",
+			input:  "<a>1</a>\n<b>2</b>",
+			result: "{\"a\":\"1\"}\n{\"b\":\"2\"}\n",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			require.NoError(t, Convert(&buf, strings.NewReader(test.input), test.opts))
+			assert.Equal(t, test.result, buf.String())
+		})
+	}
+}
+
+func TestConvert_SmallReads(t *testing.T) {
+	input := `<root><item id="1">first</item><item id="2">second</item></root>`
+
+	var buf bytes.Buffer
+
+	require.NoError(t, Convert(&buf, &oneByteReader{data: []byte(input)}, Options{AttrPrefix: "-"}))
+	assert.Equal(t, `{"root":{"item":[{"-id":"1","#text":"first"},{"-id":"2","#text":"second"}]}}`+"\n", buf.String())
+}
+
+func TestConvert_Error(t *testing.T) {
+	var buf bytes.Buffer
+
+	assert.ErrorIs(t, Convert(&buf, strings.NewReader(`<root><a>`), Options{}), ErrUnclosedElement)
+}
+
+type oneByteReader struct {
+	data []byte
+}
+
+func (r *oneByteReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+
+	n := copy(p[:1], r.data)
+	r.data = r.data[n:]
+
+	return n, nil
+}
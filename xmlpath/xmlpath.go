@@ -0,0 +1,125 @@
+/*
+Package xmlpath matches open elements of the streaming parser against simple path expressions.
+
+Supported expression syntax is a small subset of XPath:
+  - `/a/b` - path is anchored at the root element;
+  - `a/b` - path may start at any depth, same as `//a/b`;
+  - `a//b` - `b` is any descendant of `a`, not only a child;
+  - `*` - any element name.
+
+Names are compared as written in the document, including namespace prefixes.
+Paths are matched against fastxml.Parser.Stack, so no tree is built to match them.
+*/
+package xmlpath
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidPath = errors.New("invalid path")
+
+// step is a single element name in the path.
+type step struct {
+	name string
+	// descendant is set if there can be any number of elements before this step.
+	descendant bool
+}
+
+// Path is a compiled path expression.
+type Path struct {
+	expr  string
+	steps []step
+}
+
+// Compile will parse path expression.
+func Compile(expr string) (*Path, error) {
+	rest := expr
+	descendant := true
+
+	if strings.HasPrefix(rest, "/") && !strings.HasPrefix(rest, "//") {
+		rest = rest[1:]
+		descendant = false
+	}
+
+	rest = strings.TrimPrefix(rest, "//")
+
+	path := &Path{expr: expr}
+
+	for _, name := range strings.Split(rest, "/") {
+		if name == "" {
+			if descendant || len(path.steps) == 0 {
+				return nil, fmt.Errorf("%w: %q: empty element name", ErrInvalidPath, expr)
+			}
+
+			// Two slashes in a row.
+			descendant = true
+
+			continue
+		}
+
+		path.steps = append(path.steps, step{name: name, descendant: descendant})
+		descendant = false
+	}
+
+	if descendant {
+		return nil, fmt.Errorf("%w: %q: path must end with element name", ErrInvalidPath, expr)
+	}
+
+	return path, nil
+}
+
+// MustCompile is like Compile but panics if expression cannot be parsed.
+func MustCompile(expr string) *Path {
+	path, err := Compile(expr)
+	if err != nil {
+		panic(err)
+	}
+
+	return path
+}
+
+// String returns source expression of the path.
+func (p *Path) String() string {
+	return p.expr
+}
+
+// Match reports if innermost element of the stack matches the path.
+//
+// stack holds names of open elements from the root to the innermost one, as returned by fastxml.Parser.Stack.
+func (p *Path) Match(stack []string) bool {
+	return matchSteps(p.steps, stack)
+}
+
+func matchSteps(steps []step, stack []string) bool {
+	if len(steps) == 0 {
+		return len(stack) == 0
+	}
+
+	if len(stack) == 0 {
+		return false
+	}
+
+	// Match from the end, as the last step must always be the innermost element.
+	last := steps[len(steps)-1]
+	if last.name != "*" && last.name != stack[len(stack)-1] {
+		return false
+	}
+
+	if !last.descendant {
+		return matchSteps(steps[:len(steps)-1], stack[:len(stack)-1])
+	}
+
+	if len(steps) == 1 {
+		return true
+	}
+
+	for i := len(stack) - 1; i > 0; i-- {
+		if matchSteps(steps[:len(steps)-1], stack[:i]) {
+			return true
+		}
+	}
+
+	return false
+}
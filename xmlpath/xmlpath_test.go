@@ -0,0 +1,52 @@
+package xmlpath
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPath_Match(t *testing.T) {
+	tests := []struct {
+		expr  string
+		stack string
+		match bool
+	}{
+		{"/a", "a", true},
+		{"/a", "b/a", false},
+		{"/a/b", "a/b", true},
+		{"/a/b", "a/b/c", false},
+		{"b", "a/b", true},
+		{"b", "b", true},
+		{"//b", "a/x/b", true},
+		{"a/b", "x/a/b", true},
+		{"a/b", "a/x/b", false},
+		{"/a//c", "a/b/c", true},
+		{"/a//c", "a/c", true},
+		{"/a//c", "x/a/c", false},
+		{"/a/*/c", "a/b/c", true},
+		{"/a/*/c", "a/c", false},
+		{"x:item", "root/x:item", true},
+		{"item", "root/x:item", false},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.expr+" "+test.stack, func(t *testing.T) {
+			path, err := Compile(test.expr)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.match, path.Match(strings.Split(test.stack, "/")))
+		})
+	}
+}
+
+func TestCompile_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "/", "//", "a/", "a///b"} {
+		_, err := Compile(expr)
+		assert.ErrorIs(t, err, ErrInvalidPath, expr)
+	}
+}
@@ -0,0 +1,393 @@
+package xmlrpc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fastxml"
+)
+
+var cdataPrefix = []byte("<![CDATA[")
+
+// DecodeRequest returns method name and params of the method call.
+func DecodeRequest(buf []byte) (method string, params []interface{}, err error) {
+	d := newDecoder(buf)
+
+	if err := d.expectStart("methodCall"); err != nil {
+		return "", nil, err
+	}
+
+	if err := d.expectStart("methodName"); err != nil {
+		return "", nil, err
+	}
+
+	if method, err = d.readText(); err != nil {
+		return "", nil, err
+	}
+
+	name, err := d.nextStart()
+	if err != nil {
+		return "", nil, err
+	}
+
+	switch name {
+	case "":
+		return method, nil, nil
+	case "params":
+		params, err = d.readParams()
+
+		return method, params, err
+	default:
+		return "", nil, fmt.Errorf("%w: unexpected <%s> in methodCall", ErrInvalidMessage, name)
+	}
+}
+
+// DecodeResponse returns result of the method call.
+//
+// Fault responses are returned as *Fault error.
+func DecodeResponse(buf []byte) (interface{}, error) {
+	d := newDecoder(buf)
+
+	if err := d.expectStart("methodResponse"); err != nil {
+		return nil, err
+	}
+
+	name, err := d.nextStart()
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case "params":
+		params, err := d.readParams()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(params) != 1 {
+			return nil, fmt.Errorf("%w: response must have exactly one param, got %d", ErrInvalidMessage, len(params))
+		}
+
+		return params[0], nil
+	case "fault":
+		return nil, d.readFault()
+	default:
+		return nil, fmt.Errorf("%w: unexpected <%s> in methodResponse", ErrInvalidMessage, name)
+	}
+}
+
+type decoder struct {
+	buf []byte
+	p   *fastxml.Parser
+}
+
+func newDecoder(buf []byte) *decoder {
+	return &decoder{
+		buf: buf,
+		p:   fastxml.NewParser(buf, false),
+	}
+}
+
+func (d *decoder) readParams() ([]interface{}, error) {
+	params := []interface{}{}
+
+	for {
+		name, err := d.nextStart()
+		if err != nil {
+			return nil, err
+		}
+
+		switch name {
+		case "":
+			return params, nil
+		case "param":
+		default:
+			return nil, fmt.Errorf("%w: unexpected <%s> in params", ErrInvalidMessage, name)
+		}
+
+		if err := d.expectStart("value"); err != nil {
+			return nil, err
+		}
+
+		value, err := d.readValue()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := d.expectEnd(); err != nil {
+			return nil, err
+		}
+
+		params = append(params, value)
+	}
+}
+
+func (d *decoder) readFault() error {
+	if err := d.expectStart("value"); err != nil {
+		return err
+	}
+
+	value, err := d.readValue()
+	if err != nil {
+		return err
+	}
+
+	members, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%w: fault value must be a struct", ErrInvalidMessage)
+	}
+
+	code, _ := members["faultCode"].(int64)
+	text, _ := members["faultString"].(string)
+
+	return &Fault{Code: int(code), String: text}
+}
+
+// readValue reads content of `value` element, which start was already read.
+func (d *decoder) readValue() (interface{}, error) {
+	var text strings.Builder
+
+	for {
+		start := d.p.InputOffset()
+
+		token, err := d.p.Next()
+		if err != nil {
+			return nil, fmt.Errorf("read value: %w", err)
+		}
+
+		switch tkn := token.(type) {
+		case *fastxml.CharData:
+			d.writeText(&text, tkn, start)
+		case *fastxml.StartToken:
+			value, err := d.readTyped(localName(tkn.Name))
+			if err != nil {
+				return nil, err
+			}
+
+			return value, d.expectEnd()
+		case *fastxml.EndElement:
+			// Value without type is a string.
+			return text.String(), nil
+		}
+	}
+}
+
+func (d *decoder) readTyped(typ string) (interface{}, error) { //nolint:gocyclo,cyclop // Flat switch over types.
+	switch typ {
+	case "struct":
+		return d.readStruct()
+	case "array":
+		return d.readArray()
+	case "nil":
+		return nil, d.expectEnd()
+	}
+
+	text, err := d.readText()
+	if err != nil {
+		return nil, err
+	}
+
+	switch typ {
+	case "string":
+		return text, nil
+	case "int", "i4", "i8":
+		v, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid %s value %q", ErrInvalidMessage, typ, text)
+		}
+
+		return v, nil
+	case "boolean":
+		switch strings.TrimSpace(text) {
+		case "1":
+			return true, nil
+		case "0":
+			return false, nil
+		default:
+			return nil, fmt.Errorf("%w: invalid boolean value %q", ErrInvalidMessage, text)
+		}
+	case "double":
+		v, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid double value %q", ErrInvalidMessage, text)
+		}
+
+		return v, nil
+	case "dateTime.iso8601":
+		for _, layout := range dateTimeLayouts {
+			if t, err := time.Parse(layout, strings.TrimSpace(text)); err == nil {
+				return t, nil
+			}
+		}
+
+		return nil, fmt.Errorf("%w: invalid dateTime.iso8601 value %q", ErrInvalidMessage, text)
+	case "base64":
+		v, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(text), ""))
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid base64 value: %v", ErrInvalidMessage, err)
+		}
+
+		return v, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown type <%s>", ErrInvalidMessage, typ)
+	}
+}
+
+func (d *decoder) readStruct() (map[string]interface{}, error) {
+	members := map[string]interface{}{}
+
+	for {
+		name, err := d.nextStart()
+		if err != nil {
+			return nil, err
+		}
+
+		switch name {
+		case "":
+			return members, nil
+		case "member":
+		default:
+			return nil, fmt.Errorf("%w: unexpected <%s> in struct", ErrInvalidMessage, name)
+		}
+
+		if err := d.expectStart("name"); err != nil {
+			return nil, err
+		}
+
+		memberName, err := d.readText()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := d.expectStart("value"); err != nil {
+			return nil, err
+		}
+
+		if members[memberName], err = d.readValue(); err != nil {
+			return nil, err
+		}
+
+		if err := d.expectEnd(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (d *decoder) readArray() ([]interface{}, error) {
+	if err := d.expectStart("data"); err != nil {
+		return nil, err
+	}
+
+	values := []interface{}{}
+
+	for {
+		name, err := d.nextStart()
+		if err != nil {
+			return nil, err
+		}
+
+		switch name {
+		case "":
+			return values, d.expectEnd()
+		case "value":
+		default:
+			return nil, fmt.Errorf("%w: unexpected <%s> in array", ErrInvalidMessage, name)
+		}
+
+		value, err := d.readValue()
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+	}
+}
+
+// nextStart returns local name of the next start element, or empty name if end element was read.
+//
+// Char data between elements is ignored.
+func (d *decoder) nextStart() (string, error) {
+	for {
+		token, err := d.p.Next()
+		if err != nil {
+			return "", fmt.Errorf("read element: %w", err)
+		}
+
+		switch tkn := token.(type) {
+		case *fastxml.StartToken:
+			return localName(tkn.Name), nil
+		case *fastxml.EndElement:
+			return "", nil
+		}
+	}
+}
+
+func (d *decoder) expectStart(name string) error {
+	got, err := d.nextStart()
+	if err != nil {
+		return err
+	}
+
+	if got != name {
+		return fmt.Errorf("%w: expected <%s>, got %q", ErrInvalidMessage, name, got)
+	}
+
+	return nil
+}
+
+func (d *decoder) expectEnd() error {
+	name, err := d.nextStart()
+	if err != nil {
+		return err
+	}
+
+	if name != "" {
+		return fmt.Errorf("%w: unexpected <%s>", ErrInvalidMessage, name)
+	}
+
+	return nil
+}
+
+// readText returns text of the element, which start was already read.
+func (d *decoder) readText() (string, error) {
+	var text strings.Builder
+
+	for {
+		start := d.p.InputOffset()
+
+		token, err := d.p.Next()
+		if err != nil {
+			return "", fmt.Errorf("read text: %w", err)
+		}
+
+		switch tkn := token.(type) {
+		case *fastxml.CharData:
+			d.writeText(&text, tkn, start)
+		case *fastxml.StartToken:
+			return "", fmt.Errorf("%w: unexpected <%s> in text", ErrInvalidMessage, tkn.Name)
+		case *fastxml.EndElement:
+			return text.String(), nil
+		}
+	}
+}
+
+// writeText writes char data that started at offset start, replacing references outside of CDATA sections.
+func (d *decoder) writeText(text *strings.Builder, charData *fastxml.CharData, start int) {
+	if bytes.HasPrefix(d.buf[start:], cdataPrefix) {
+		text.Write(*charData)
+	} else {
+		text.WriteString(fastxml.Unescape(string(*charData)))
+	}
+}
+
+func localName(name string) string {
+	if idx := strings.IndexByte(name, ':'); idx != -1 {
+		return name[idx+1:]
+	}
+
+	return name
+}
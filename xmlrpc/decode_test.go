@@ -0,0 +1,124 @@
+package xmlrpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeRequest(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          string
+		expectedMethod string
+		expectedParams []interface{}
+	}{
+		{
+			name:           "without params",
+			input:          `<methodCall><methodName>system.listMethods</methodName></methodCall>`,
+			expectedMethod: "system.listMethods",
+		},
+		{
+			name:           "empty params",
+			input:          `<methodCall><methodName>m</methodName><params/></methodCall>`,
+			expectedMethod: "m",
+			expectedParams: []interface{}{},
+		},
+		{
+			name: "formatted",
+			input: `<?xml version="1.0"?>
+<methodCall>
+  <methodName>examples.getStateName</methodName>
+  <!-- state number -->
+  <params>
+    <param>
+      <value><i4>41</i4></value>
+    </param>
+  </params>
+</methodCall>`,
+			expectedMethod: "examples.getStateName",
+			expectedParams: []interface{}{int64(41)},
+		},
+		{
+			name: "untyped and escaped values",
+			input: `<methodCall><methodName>m</methodName><params>` +
+				`<param><value>plain &amp; simple</value></param>` +
+				`<param><value><string><![CDATA[<raw> &amp;]]></string></value></param>` +
+				`<param><value></value></param>` +
+				`</params></methodCall>`,
+			expectedMethod: "m",
+			expectedParams: []interface{}{"plain & simple", "<raw> &amp;", ""},
+		},
+		{
+			name: "typed values",
+			input: `<methodCall><methodName>m</methodName><params>` +
+				`<param><value><i8> 9007199254740993 </i8></value></param>` +
+				`<param><value><boolean>0</boolean></value></param>` +
+				`<param><value><double>-1.5e3</double></value></param>` +
+				`<param><value><dateTime.iso8601>2020-01-02T03:04:05</dateTime.iso8601></value></param>` +
+				`<param><value><base64>aG Vs
+bG8=</base64></value></param>` +
+				`<param><value><ex:nil xmlns:ex="http://ws.apache.org/xmlrpc/namespaces/extensions"/></value></param>` +
+				`</params></methodCall>`,
+			expectedMethod: "m",
+			expectedParams: []interface{}{
+				int64(9007199254740993),
+				false,
+				-1500.0,
+				time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+				[]byte("hello"),
+				nil,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			method, params, err := DecodeRequest([]byte(test.input))
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedMethod, method)
+			assert.Equal(t, test.expectedParams, params)
+		})
+	}
+}
+
+func TestDecodeResponse_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "wrong root", input: `<methodCall><methodName>m</methodName></methodCall>`},
+		{name: "no params", input: `<methodResponse></methodResponse>`},
+		{name: "two params", input: `<methodResponse><params>` +
+			`<param><value>a</value></param><param><value>b</value></param></params></methodResponse>`},
+		{name: "unknown type", input: `<methodResponse><params><param><value><float>1</float></value></param></params></methodResponse>`},
+		{name: "invalid int", input: `<methodResponse><params><param><value><int>1.5</int></value></param></params></methodResponse>`},
+		{name: "invalid boolean", input: `<methodResponse><params><param><value><boolean>true</boolean></value></param></params></methodResponse>`},
+		{name: "invalid date", input: `<methodResponse><params><param><value><dateTime.iso8601>yesterday</dateTime.iso8601></value></param></params></methodResponse>`},
+		{name: "invalid base64", input: `<methodResponse><params><param><value><base64>!</base64></value></param></params></methodResponse>`},
+		{name: "two types", input: `<methodResponse><params><param><value><int>1</int><int>2</int></value></param></params></methodResponse>`},
+		{name: "member without name", input: `<methodResponse><params><param><value><struct><member><value>a</value></member></struct></value></param></params></methodResponse>`},
+		{name: "array without data", input: `<methodResponse><params><param><value><array><value>a</value></array></value></param></params></methodResponse>`},
+		{name: "element in string", input: `<methodResponse><params><param><value><string>a<b/></string></value></param></params></methodResponse>`},
+		{name: "fault without struct", input: `<methodResponse><fault><value>oops</value></fault></methodResponse>`},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			_, err := DecodeResponse([]byte(test.input))
+			require.ErrorIs(t, err, ErrInvalidMessage)
+		})
+	}
+}
+
+func TestDecodeResponse_Truncated(t *testing.T) {
+	_, err := DecodeResponse([]byte(`<methodResponse><params><param><value><int>1`))
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrInvalidMessage)
+}
@@ -0,0 +1,294 @@
+/*
+Package xmlrpc encodes and decodes XML-RPC (http://xmlrpc.com/spec.md) requests and responses.
+
+Values are decoded into Go types as follows:
+  - int, i4 and i8 - int64;
+  - boolean - bool;
+  - string, and value without type - string;
+  - double - float64;
+  - dateTime.iso8601 - time.Time;
+  - base64 - []byte;
+  - struct - map[string]interface{};
+  - array - []interface{};
+  - nil - nil.
+
+Same types are accepted for encoding, along with other integer and float types,
+slices, maps with string keys and structs. Fields of structs are named after `xmlrpc` tag,
+or field name if tag is not set. Fields with "-" tag are skipped.
+*/
+package xmlrpc
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"fastxml"
+)
+
+// dateTimeLayout is the layout of dateTime.iso8601 values written by the encoder.
+const dateTimeLayout = "20060102T15:04:05"
+
+// dateTimeLayouts are layouts of dateTime.iso8601 values accepted by the decoder.
+var dateTimeLayouts = []string{
+	dateTimeLayout,
+	"20060102T15:04:05Z07:00",
+	"2006-01-02T15:04:05",
+	time.RFC3339Nano,
+	"20060102T15:04:05.999999999",
+}
+
+var (
+	ErrUnsupportedType = errors.New("unsupported type")
+	ErrInvalidMessage  = errors.New("invalid message")
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Fault is the fault response of the method call.
+type Fault struct {
+	Code   int
+	String string
+}
+
+func (f *Fault) Error() string {
+	return fmt.Sprintf("xmlrpc fault %d: %s", f.Code, f.String)
+}
+
+// EncodeRequest writes method call with params to w.
+func EncodeRequest(w io.Writer, method string, params ...interface{}) error {
+	enc := newEncoder(w)
+
+	enc.start("methodCall")
+	enc.element("methodName", method)
+	enc.params(params)
+	enc.end("methodCall")
+
+	return enc.flush()
+}
+
+// EncodeResponse writes successful response with the result to w.
+func EncodeResponse(w io.Writer, result interface{}) error {
+	enc := newEncoder(w)
+
+	enc.start("methodResponse")
+	enc.params([]interface{}{result})
+	enc.end("methodResponse")
+
+	return enc.flush()
+}
+
+// EncodeFault writes fault response to w.
+func EncodeFault(w io.Writer, fault *Fault) error {
+	enc := newEncoder(w)
+
+	enc.start("methodResponse")
+	enc.start("fault")
+	enc.value(map[string]interface{}{"faultCode": fault.Code, "faultString": fault.String})
+	enc.end("fault")
+	enc.end("methodResponse")
+
+	return enc.flush()
+}
+
+// encoder writes values with fastxml.Encoder, remembering the first error.
+type encoder struct {
+	enc *fastxml.Encoder
+	err error
+}
+
+func newEncoder(w io.Writer) *encoder {
+	enc := &encoder{enc: fastxml.NewEncoder(w)}
+	enc.write(xml.ProcInst{Target: "xml", Inst: []byte(`version="1.0"`)})
+
+	return enc
+}
+
+func (e *encoder) params(params []interface{}) {
+	e.start("params")
+
+	for _, param := range params {
+		e.start("param")
+		e.value(param)
+		e.end("param")
+	}
+
+	e.end("params")
+}
+
+func (e *encoder) value(v interface{}) {
+	e.start("value")
+	e.reflectValue(reflect.ValueOf(v))
+	e.end("value")
+}
+
+func (e *encoder) reflectValue(v reflect.Value) { //nolint:gocyclo,cyclop // Flat switch over kinds.
+	if !v.IsValid() {
+		e.start("nil")
+		e.end("nil")
+
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			e.reflectValue(reflect.Value{})
+		} else {
+			e.reflectValue(v.Elem())
+		}
+	case reflect.Bool:
+		if v.Bool() {
+			e.element("boolean", "1")
+		} else {
+			e.element("boolean", "0")
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.integer(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt64 {
+			e.fail(fmt.Errorf("%w: %d overflows i8", ErrUnsupportedType, v.Uint()))
+
+			return
+		}
+
+		e.integer(int64(v.Uint()))
+	case reflect.Float32, reflect.Float64:
+		e.element("double", strconv.FormatFloat(v.Float(), 'f', -1, 64))
+	case reflect.String:
+		e.element("string", v.String())
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			e.element("base64", base64.StdEncoding.EncodeToString(bytesOf(v)))
+
+			return
+		}
+
+		e.start("array")
+		e.start("data")
+
+		for i := 0; i < v.Len(); i++ {
+			e.start("value")
+			e.reflectValue(v.Index(i))
+			e.end("value")
+		}
+
+		e.end("data")
+		e.end("array")
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			e.fail(fmt.Errorf("%w: %s", ErrUnsupportedType, v.Type()))
+
+			return
+		}
+
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+		e.start("struct")
+
+		for _, key := range keys {
+			e.member(key.String(), v.MapIndex(key))
+		}
+
+		e.end("struct")
+	case reflect.Struct:
+		if v.Type() == timeType {
+			e.element("dateTime.iso8601", v.Interface().(time.Time).Format(dateTimeLayout))
+
+			return
+		}
+
+		e.start("struct")
+
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+
+			name := field.Name
+			if tag, ok := field.Tag.Lookup("xmlrpc"); ok {
+				name = tag
+			}
+
+			if name != "-" {
+				e.member(name, v.Field(i))
+			}
+		}
+
+		e.end("struct")
+	default:
+		e.fail(fmt.Errorf("%w: %s", ErrUnsupportedType, v.Type()))
+	}
+}
+
+func (e *encoder) integer(i int64) {
+	if i < math.MinInt32 || i > math.MaxInt32 {
+		e.element("i8", strconv.FormatInt(i, 10))
+	} else {
+		e.element("int", strconv.FormatInt(i, 10))
+	}
+}
+
+func (e *encoder) member(name string, v reflect.Value) {
+	e.start("member")
+	e.element("name", name)
+	e.start("value")
+	e.reflectValue(v)
+	e.end("value")
+	e.end("member")
+}
+
+func (e *encoder) element(name, text string) {
+	e.start(name)
+	e.write(xml.CharData(text))
+	e.end(name)
+}
+
+func (e *encoder) start(name string) {
+	e.write(xml.StartElement{Name: xml.Name{Local: name}})
+}
+
+func (e *encoder) end(name string) {
+	e.write(xml.EndElement{Name: xml.Name{Local: name}})
+}
+
+func (e *encoder) write(token xml.Token) {
+	if e.err == nil {
+		e.err = e.enc.WriteToken(token)
+	}
+}
+
+func (e *encoder) fail(err error) {
+	if e.err == nil {
+		e.err = err
+	}
+}
+
+func (e *encoder) flush() error {
+	if e.err != nil {
+		return e.err
+	}
+
+	return e.enc.Flush()
+}
+
+// bytesOf returns bytes of byte slice or array.
+func bytesOf(v reflect.Value) []byte {
+	if v.Kind() == reflect.Slice {
+		return v.Bytes()
+	}
+
+	b := make([]byte, v.Len())
+	reflect.Copy(reflect.ValueOf(b), v)
+
+	return b
+}
@@ -0,0 +1,149 @@
+package xmlrpc
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeRequest(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := EncodeRequest(&buf, "examples.getStateName", 41, "a<b", true)
+	require.NoError(t, err)
+
+	assert.Equal(t, `<?xml version="1.0"?>`+
+		`<methodCall><methodName>examples.getStateName</methodName><params>`+
+		`<param><value><int>41</int></value></param>`+
+		`<param><value><string>a&lt;b</string></value></param>`+
+		`<param><value><boolean>1</boolean></value></param>`+
+		`</params></methodCall>`, buf.String())
+}
+
+func TestEncodeRequest_Types(t *testing.T) {
+	type point struct {
+		X       int     `xmlrpc:"x"`
+		Y       float64 `xmlrpc:"y"`
+		Ignored string  `xmlrpc:"-"`
+		private int
+	}
+
+	tests := []struct {
+		name     string
+		value    interface{}
+		expected string
+	}{
+		{name: "nil", value: nil, expected: `<nil></nil>`},
+		{name: "nil pointer", value: (*int)(nil), expected: `<nil></nil>`},
+		{name: "big int", value: int64(1) << 40, expected: `<i8>1099511627776</i8>`},
+		{name: "uint", value: uint8(7), expected: `<int>7</int>`},
+		{name: "double", value: 1.5, expected: `<double>1.5</double>`},
+		{name: "base64", value: []byte("hi"), expected: `<base64>aGk=</base64>`},
+		{
+			name:     "time",
+			value:    time.Date(1998, 7, 17, 14, 8, 55, 0, time.UTC),
+			expected: `<dateTime.iso8601>19980717T14:08:55</dateTime.iso8601>`,
+		},
+		{
+			name:  "array",
+			value: []interface{}{1, "a"},
+			expected: `<array><data><value><int>1</int></value>` +
+				`<value><string>a</string></value></data></array>`,
+		},
+		{
+			name:  "map",
+			value: map[string]int{"b": 2, "a": 1},
+			expected: `<struct><member><name>a</name><value><int>1</int></value></member>` +
+				`<member><name>b</name><value><int>2</int></value></member></struct>`,
+		},
+		{
+			name:  "struct",
+			value: point{X: 1, Y: 2.5, Ignored: "no", private: 3},
+			expected: `<struct><member><name>x</name><value><int>1</int></value></member>` +
+				`<member><name>y</name><value><double>2.5</double></value></member></struct>`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			require.NoError(t, EncodeResponse(&buf, test.value))
+			assert.Equal(t, `<?xml version="1.0"?><methodResponse><params><param><value>`+
+				test.expected+`</value></param></params></methodResponse>`, buf.String())
+		})
+	}
+}
+
+func TestEncode_Unsupported(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+	}{
+		{name: "map with int keys", value: map[int]string{1: "a"}},
+		{name: "channel", value: make(chan int)},
+		{name: "nested", value: []interface{}{func() {}}},
+		{name: "overflow", value: uint64(1) << 63},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			err := EncodeResponse(&buf, test.value)
+			require.ErrorIs(t, err, ErrUnsupportedType)
+		})
+	}
+}
+
+func TestEncodeFault(t *testing.T) {
+	var buf bytes.Buffer
+
+	require.NoError(t, EncodeFault(&buf, &Fault{Code: 4, String: "Too many parameters."}))
+
+	_, err := DecodeResponse(buf.Bytes())
+
+	var fault *Fault
+
+	require.True(t, errors.As(err, &fault))
+	assert.Equal(t, &Fault{Code: 4, String: "Too many parameters."}, fault)
+	assert.Equal(t, "xmlrpc fault 4: Too many parameters.", fault.Error())
+}
+
+func TestRoundTrip(t *testing.T) {
+	params := []interface{}{
+		int64(-3),
+		"text & more",
+		false,
+		0.25,
+		time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		[]byte{0, 1, 2},
+		map[string]interface{}{"list": []interface{}{int64(1), nil}, "empty": map[string]interface{}{}},
+		[]interface{}{},
+		nil,
+	}
+
+	var buf bytes.Buffer
+
+	require.NoError(t, EncodeRequest(&buf, "test.roundTrip", params...))
+
+	method, decoded, err := DecodeRequest(buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, "test.roundTrip", method)
+	assert.Equal(t, params, decoded)
+
+	buf.Reset()
+	require.NoError(t, EncodeResponse(&buf, params))
+
+	result, err := DecodeResponse(buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, params, result)
+}
@@ -0,0 +1,278 @@
+/*
+Package xmlzip opens zip containers of XML documents, like Office Open XML(docx, xlsx, pptx)
+and OpenDocument(odt, ods, odp) files, and returns parsers for their XML parts.
+
+Well-known parts are located by the metadata of the package: main document of Office Open XML
+is found by the relationships of the package, and parts of specific type by content types of the package.
+*/
+package xmlzip
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"fastxml"
+)
+
+// Format is the format of the package.
+type Format uint8
+
+const (
+	// FormatUnknown is a zip archive which format was not recognized.
+	FormatUnknown Format = iota
+	// FormatOOXML is an Office Open XML package (docx, xlsx, pptx).
+	FormatOOXML
+	// FormatODF is an OpenDocument package (odt, ods, odp).
+	FormatODF
+)
+
+// maxPreallocSize is the maximum declared size of the part, for which memory is allocated upfront.
+const maxPreallocSize = 64 << 20
+
+// Well-known parts of the packages.
+const (
+	ContentTypesPart  = "[Content_Types].xml"
+	RelationshipsPart = "_rels/.rels"
+	ODFMimeTypePart   = "mimetype"
+	ODFContentPart    = "content.xml"
+	ODFStylesPart     = "styles.xml"
+	ODFMetaPart       = "meta.xml"
+	ODFManifestPart   = "META-INF/manifest.xml"
+)
+
+// officeDocumentRelType is the suffix of relationship type of the main part of Office Open XML package.
+// Suffix is used, as transitional and strict variants of the format have different prefixes.
+const officeDocumentRelType = "/relationships/officeDocument"
+
+var (
+	ErrPartNotFound     = errors.New("part not found")
+	ErrMainPartNotFound = errors.New("main part not found")
+)
+
+// Archive is an opened zip container.
+type Archive struct {
+	zr     *zip.Reader
+	closer io.Closer
+	parts  map[string]*zip.File
+}
+
+// OpenFile will open zip container from the file.
+//
+// Archive.Close must be called when archive is not needed anymore.
+func OpenFile(name string) (*Archive, error) {
+	rc, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, fmt.Errorf("open zip: %w", err)
+	}
+
+	a := newArchive(&rc.Reader)
+	a.closer = rc
+
+	return a, nil
+}
+
+// NewArchive will open zip container of given size from r.
+func NewArchive(r io.ReaderAt, size int64) (*Archive, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("open zip: %w", err)
+	}
+
+	return newArchive(zr), nil
+}
+
+func newArchive(zr *zip.Reader) *Archive {
+	a := &Archive{
+		zr:    zr,
+		parts: make(map[string]*zip.File, len(zr.File)),
+	}
+
+	for _, f := range zr.File {
+		a.parts[f.Name] = f
+	}
+
+	return a
+}
+
+// Close will close the underlying file, if archive was opened with OpenFile.
+func (a *Archive) Close() error {
+	if a.closer == nil {
+		return nil
+	}
+
+	return a.closer.Close()
+}
+
+// Format returns format of the package, based on its metadata parts.
+func (a *Archive) Format() Format {
+	switch {
+	case a.parts[ContentTypesPart] != nil:
+		return FormatOOXML
+	case a.parts[ODFMimeTypePart] != nil && a.parts[ODFManifestPart] != nil:
+		return FormatODF
+	default:
+		return FormatUnknown
+	}
+}
+
+// XMLParts returns names of all XML parts in the order they are stored in the archive.
+func (a *Archive) XMLParts() []string {
+	var names []string
+
+	for _, f := range a.zr.File {
+		if ext := path.Ext(f.Name); ext == ".xml" || ext == ".rels" {
+			names = append(names, f.Name)
+		}
+	}
+
+	return names
+}
+
+// ReadPart returns uncompressed content of the part.
+func (a *Archive) ReadPart(name string) ([]byte, error) {
+	f, ok := a.parts[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrPartNotFound, name)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open part %s: %w", name, err)
+	}
+
+	defer rc.Close()
+
+	var buf bytes.Buffer
+
+	// Declared size is only a hint, as it can not be trusted.
+	if f.UncompressedSize64 < maxPreallocSize {
+		buf.Grow(int(f.UncompressedSize64) + bytes.MinRead)
+	}
+
+	if _, err := buf.ReadFrom(rc); err != nil {
+		return nil, fmt.Errorf("read part %s: %w", name, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Parser returns parser over uncompressed content of the part.
+//
+// Content is read into memory at once, and parser owns it.
+func (a *Archive) Parser(name string, opts ...fastxml.ParserOption) (*fastxml.Parser, error) {
+	buf, err := a.ReadPart(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return fastxml.NewParser(buf, false, opts...), nil
+}
+
+// MainPart returns name of the main part of the package:
+// target of the office document relationship for Office Open XML(like "word/document.xml"),
+// or "content.xml" for OpenDocument.
+func (a *Archive) MainPart() (string, error) {
+	switch a.Format() {
+	case FormatODF:
+		return ODFContentPart, nil
+	case FormatOOXML:
+		var target string
+
+		err := a.eachElement(RelationshipsPart, "Relationship", func(attrs map[string]string) bool {
+			if strings.HasSuffix(attrs["Type"], officeDocumentRelType) {
+				target = strings.TrimPrefix(attrs["Target"], "/")
+
+				return false
+			}
+
+			return true
+		})
+		if err != nil {
+			return "", err
+		}
+
+		if target == "" {
+			return "", ErrMainPartNotFound
+		}
+
+		return target, nil
+	default:
+		return "", ErrMainPartNotFound
+	}
+}
+
+// PartsByContentType returns names of Office Open XML parts with the content type,
+// as declared in "[Content_Types].xml" of the package.
+//
+// For example worksheets of xlsx package have
+// "application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml" content type.
+func (a *Archive) PartsByContentType(contentType string) ([]string, error) {
+	var names []string
+
+	err := a.eachElement(ContentTypesPart, "Override", func(attrs map[string]string) bool {
+		if attrs["ContentType"] == contentType {
+			names = append(names, strings.TrimPrefix(attrs["PartName"], "/"))
+		}
+
+		return true
+	})
+
+	return names, err
+}
+
+// eachElement calls fn with attributes of each element of the part with given local name,
+// until fn returns false.
+func (a *Archive) eachElement(part, local string, fn func(attrs map[string]string) bool) error {
+	p, err := a.Parser(part)
+	if err != nil {
+		return err
+	}
+
+	for {
+		token, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("parse %s: %w", part, err)
+		}
+
+		start, ok := token.(*fastxml.StartToken)
+		if !ok || localName(start.Name) != local {
+			continue
+		}
+
+		attrs := make(map[string]string)
+
+		for {
+			name, value, err := start.NextAttribute()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			if err != nil {
+				return fmt.Errorf("parse %s: %w", part, err)
+			}
+
+			attrs[localName(name)] = fastxml.Unescape(value)
+		}
+
+		if !fn(attrs) {
+			return nil
+		}
+	}
+}
+
+func localName(name string) string {
+	if idx := strings.IndexByte(name, ':'); idx != -1 {
+		return name[idx+1:]
+	}
+
+	return name
+}
@@ -0,0 +1,136 @@
+package xmlzip
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"fastxml"
+)
+
+const worksheetType = "application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"
+
+// buildZip returns zip archive with given files, in the order of names.
+func buildZip(t *testing.T, names []string, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	zw := zip.NewWriter(&buf)
+
+	for _, name := range names {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+
+		_, err = w.Write([]byte(files[name]))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, zw.Close())
+
+	return buf.Bytes()
+}
+
+func xlsxPackage(t *testing.T) []byte {
+	t.Helper()
+
+	files := map[string]string{
+		ContentTypesPart: `<?xml version="1.0"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+  <Default Extension="xml" ContentType="application/xml"/>
+  <Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+  <Override PartName="/xl/worksheets/sheet1.xml" ContentType="` + worksheetType + `"/>
+  <Override PartName="/xl/worksheets/sheet2.xml" ContentType="` + worksheetType + `"/>
+</Types>`,
+		RelationshipsPart: `<?xml version="1.0"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`,
+		"xl/workbook.xml":          `<workbook><sheets><sheet name="A"/><sheet name="B"/></sheets></workbook>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData><row r="1"/></sheetData></worksheet>`,
+		"xl/worksheets/sheet2.xml": `<worksheet/>`,
+		"xl/media/image.png":       "\x89PNG",
+	}
+
+	return buildZip(t, []string{
+		ContentTypesPart, RelationshipsPart, "xl/workbook.xml",
+		"xl/worksheets/sheet1.xml", "xl/worksheets/sheet2.xml", "xl/media/image.png",
+	}, files)
+}
+
+func TestArchive_OOXML(t *testing.T) {
+	data := xlsxPackage(t)
+
+	a, err := NewArchive(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	assert.Equal(t, FormatOOXML, a.Format())
+	assert.Equal(t, []string{
+		ContentTypesPart, RelationshipsPart, "xl/workbook.xml", "xl/worksheets/sheet1.xml", "xl/worksheets/sheet2.xml",
+	}, a.XMLParts())
+
+	main, err := a.MainPart()
+	require.NoError(t, err)
+	assert.Equal(t, "xl/workbook.xml", main)
+
+	sheets, err := a.PartsByContentType(worksheetType)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"xl/worksheets/sheet1.xml", "xl/worksheets/sheet2.xml"}, sheets)
+
+	p, err := a.Parser(sheets[0])
+	require.NoError(t, err)
+
+	token, err := p.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "worksheet", token.(*fastxml.StartToken).Name)
+
+	_, err = a.Parser("xl/missing.xml")
+	assert.ErrorIs(t, err, ErrPartNotFound)
+}
+
+func TestArchive_ODF(t *testing.T) {
+	data := buildZip(t, []string{ODFMimeTypePart, ODFManifestPart, ODFContentPart}, map[string]string{
+		ODFMimeTypePart: "application/vnd.oasis.opendocument.text",
+		ODFManifestPart: `<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0"/>`,
+		ODFContentPart:  `<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0"/>`,
+	})
+
+	path := filepath.Join(t.TempDir(), "doc.odt")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	a, err := OpenFile(path)
+	require.NoError(t, err)
+
+	defer a.Close()
+
+	assert.Equal(t, FormatODF, a.Format())
+
+	main, err := a.MainPart()
+	require.NoError(t, err)
+	assert.Equal(t, ODFContentPart, main)
+
+	content, err := a.ReadPart(main)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "office:document-content")
+}
+
+func TestArchive_Unknown(t *testing.T) {
+	data := buildZip(t, []string{"a.xml"}, map[string]string{"a.xml": "<a/>"})
+
+	a, err := NewArchive(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	assert.Equal(t, FormatUnknown, a.Format())
+
+	_, err = a.MainPart()
+	assert.ErrorIs(t, err, ErrMainPartNotFound)
+
+	_, err = NewArchive(bytes.NewReader([]byte("not a zip")), 9)
+	assert.Error(t, err)
+}
@@ -0,0 +1,232 @@
+package fastxml
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Parsers of lexical representations of XML Schema simple types (https://www.w3.org/TR/xmlschema-2/).
+//
+// As schema validation is not implemented - caller decides which type the value has.
+// All functions collapse surrounding whitespace before parsing, as required by the schema
+// for these types.
+
+// xsDateTimeLayouts are layouts of xs:dateTime with and without timezone.
+var xsDateTimeLayouts = []string{
+	"2006-01-02T15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999",
+}
+
+// ParseXSBoolean parses value of xs:boolean type: "true", "false", "1" or "0".
+func ParseXSBoolean(s string) (bool, error) {
+	switch strings.TrimSpace(s) {
+	case "true", "1":
+		return true, nil
+	case "false", "0":
+		return false, nil
+	default:
+		return false, fmt.Errorf("invalid xs:boolean value: %q", s)
+	}
+}
+
+// ParseXSInteger parses value of xs:int, xs:long and other integer types that fit into int64.
+func ParseXSInteger(s string) (int64, error) {
+	// xs:integer allows leading '+', which is also accepted by strconv.
+	val, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid xs:integer value: %q", s)
+	}
+
+	return val, nil
+}
+
+// ParseXSDouble parses value of xs:double and xs:float types, including "INF", "-INF" and "NaN".
+func ParseXSDouble(s string) (float64, error) {
+	trimmed := strings.TrimSpace(s)
+
+	switch trimmed {
+	case "INF":
+		return math.Inf(1), nil
+	case "-INF":
+		return math.Inf(-1), nil
+	case "NaN":
+		return math.NaN(), nil
+	}
+
+	// strconv accepts spellings of special values that are not valid in the schema.
+	if strings.ContainsAny(trimmed, "iInN") {
+		return 0, fmt.Errorf("invalid xs:double value: %q", s)
+	}
+
+	val, err := strconv.ParseFloat(trimmed, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid xs:double value: %q", s)
+	}
+
+	return val, nil
+}
+
+// ParseXSDateTime parses value of xs:dateTime type.
+//
+// Values without timezone are returned in UTC.
+func ParseXSDateTime(s string) (time.Time, error) {
+	trimmed := strings.TrimSpace(s)
+
+	for _, layout := range xsDateTimeLayouts {
+		if t, err := time.Parse(layout, trimmed); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid xs:dateTime value: %q", s)
+}
+
+// ParseXSDuration parses value of xs:duration type, like "PT1H30M" or "-P1DT0.5S".
+//
+// Years and months do not have fixed length, so values with them are not supported.
+// Days are 24 hours long.
+func ParseXSDuration(s string) (time.Duration, error) {
+	d, ok := parseXSDuration(strings.TrimSpace(s))
+	if !ok {
+		return 0, fmt.Errorf("invalid xs:duration value: %q", s)
+	}
+
+	return d, nil
+}
+
+func parseXSDuration(s string) (time.Duration, bool) { //nolint:gocyclo,cyclop // Flat parsing of components.
+	negative := strings.HasPrefix(s, "-")
+	if negative {
+		s = s[1:]
+	}
+
+	if !strings.HasPrefix(s, "P") || len(s) == 1 || strings.HasSuffix(s, "T") {
+		return 0, false
+	}
+
+	var (
+		total  time.Duration
+		inTime bool
+		// units holds units allowed after previous component, in their order.
+		units = "DHMS"
+	)
+
+	for s = s[1:]; s != ""; {
+		if s[0] == 'T' {
+			if inTime {
+				return 0, false
+			}
+
+			inTime, units, s = true, "HMS", s[1:]
+
+			continue
+		}
+
+		end := strings.IndexAny(s, "DHMS")
+		if end <= 0 {
+			return 0, false
+		}
+
+		unit := s[end]
+		if !inTime && unit != 'D' || inTime && unit == 'D' {
+			return 0, false
+		}
+
+		idx := strings.IndexByte(units, unit)
+		if idx == -1 {
+			return 0, false
+		}
+
+		units = units[idx+1:]
+
+		value, ok := parseXSDurationComponent(s[:end], unit)
+		if !ok || total > math.MaxInt64-value {
+			return 0, false
+		}
+
+		total += value
+		s = s[end+1:]
+	}
+
+	if negative {
+		total = -total
+	}
+
+	return total, true
+}
+
+// parseXSDurationComponent parses number of units, only seconds can have fraction.
+func parseXSDurationComponent(num string, unit byte) (time.Duration, bool) {
+	var scale time.Duration
+
+	switch unit {
+	case 'D':
+		scale = 24 * time.Hour
+	case 'H':
+		scale = time.Hour
+	case 'M':
+		scale = time.Minute
+	default:
+		scale = time.Second
+	}
+
+	whole, frac := num, ""
+	if idx := strings.IndexByte(num, '.'); idx != -1 && unit == 'S' {
+		whole, frac = num[:idx], num[idx+1:]
+	}
+
+	if whole == "" || strings.IndexFunc(whole+frac, func(r rune) bool { return r < '0' || r > '9' }) != -1 {
+		return 0, false
+	}
+
+	n, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || n > int64(math.MaxInt64/scale) {
+		return 0, false
+	}
+
+	value := time.Duration(n) * scale
+
+	if frac != "" {
+		// Nanoseconds are the smallest unit, rest of the fraction is truncated.
+		if len(frac) > 9 {
+			frac = frac[:9]
+		}
+
+		nanos, _ := strconv.ParseInt(frac+strings.Repeat("0", 9-len(frac)), 10, 64)
+		if value > math.MaxInt64-time.Duration(nanos) {
+			return 0, false
+		}
+
+		value += time.Duration(nanos)
+	}
+
+	return value, true
+}
+
+// Bool returns char data as xs:boolean value.
+func (c CharData) Bool() (bool, error) {
+	return ParseXSBoolean(unsafeByteToString(c))
+}
+
+// Int64 returns char data as xs:integer value.
+func (c CharData) Int64() (int64, error) {
+	return ParseXSInteger(unsafeByteToString(c))
+}
+
+// Float64 returns char data as xs:double value.
+func (c CharData) Float64() (float64, error) {
+	return ParseXSDouble(unsafeByteToString(c))
+}
+
+// Duration returns char data as xs:duration value.
+func (c CharData) Duration() (time.Duration, error) {
+	return ParseXSDuration(unsafeByteToString(c))
+}
+
+// Time returns char data as xs:dateTime value.
+func (c CharData) Time() (time.Time, error) {
+	return ParseXSDateTime(unsafeByteToString(c))
+}
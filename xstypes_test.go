@@ -0,0 +1,152 @@
+package fastxml
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseXSBoolean(t *testing.T) {
+	tests := []struct {
+		input  string
+		result bool
+		err    string
+	}{
+		{"true", true, ""},
+		{" 1\n", true, ""},
+		{"false", false, ""},
+		{"0", false, ""},
+		{"TRUE", false, `invalid xs:boolean value: "TRUE"`},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.input, func(t *testing.T) {
+			result, err := CharData(test.input).Bool()
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.result, result)
+		})
+	}
+}
+
+func TestParseXSInteger(t *testing.T) {
+	result, err := CharData(" +42 ").Int64()
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), result)
+
+	_, err = ParseXSInteger("4.2")
+	require.EqualError(t, err, `invalid xs:integer value: "4.2"`)
+}
+
+func TestParseXSDouble(t *testing.T) {
+	tests := []struct {
+		input  string
+		result float64
+		err    string
+	}{
+		{"1.5", 1.5, ""},
+		{"-1E4", -1e4, ""},
+		{"INF", math.Inf(1), ""},
+		{"-INF", math.Inf(-1), ""},
+		{"Infinity", 0, `invalid xs:double value: "Infinity"`},
+		{"abc", 0, `invalid xs:double value: "abc"`},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.input, func(t *testing.T) {
+			result, err := CharData(test.input).Float64()
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.result, result)
+		})
+	}
+
+	nan, err := ParseXSDouble("NaN")
+	require.NoError(t, err)
+	assert.True(t, math.IsNaN(nan))
+}
+
+func TestParseXSDateTime(t *testing.T) {
+	tests := []struct {
+		input  string
+		result time.Time
+	}{
+		{"2002-05-30T09:00:00", time.Date(2002, 5, 30, 9, 0, 0, 0, time.UTC)},
+		{"2002-05-30T09:30:10.5Z", time.Date(2002, 5, 30, 9, 30, 10, 500000000, time.UTC)},
+		{"2002-05-30T09:30:10+06:00", time.Date(2002, 5, 30, 3, 30, 10, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.input, func(t *testing.T) {
+			result, err := CharData(test.input).Time()
+			require.NoError(t, err)
+			assert.True(t, test.result.Equal(result), result)
+		})
+	}
+
+	_, err := ParseXSDateTime("2002-05-30")
+	require.EqualError(t, err, `invalid xs:dateTime value: "2002-05-30"`)
+}
+
+func TestParseXSDuration(t *testing.T) {
+	tests := []struct {
+		input  string
+		result time.Duration
+		err    bool
+	}{
+		{input: "PT1H30M", result: 90 * time.Minute},
+		{input: " P1DT2S ", result: 24*time.Hour + 2*time.Second},
+		{input: "P2D", result: 48 * time.Hour},
+		{input: "-PT0.5S", result: -500 * time.Millisecond},
+		{input: "PT1.0000000019S", result: time.Second + time.Nanosecond},
+		{input: "PT0S", result: 0},
+		{input: "P1Y", err: true},
+		{input: "P1M", err: true},
+		{input: "P", err: true},
+		{input: "PT", err: true},
+		{input: "P1DT", err: true},
+		{input: "PT1S1M", err: true},
+		{input: "PT1D", err: true},
+		{input: "P1H", err: true},
+		{input: "P1.5D", err: true},
+		{input: "PT-1S", err: true},
+		{input: "PTS", err: true},
+		{input: "PT9999999999H", err: true},
+		{input: "1h", err: true},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.input, func(t *testing.T) {
+			result, err := CharData(test.input).Duration()
+			if test.err {
+				require.EqualError(t, err, `invalid xs:duration value: "`+test.input+`"`)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.result, result)
+		})
+	}
+}